@@ -0,0 +1,44 @@
+// Command loadbot plays simulated games against a running server and reports latencies.
+//
+//	go run ./cmd/loadbot -url ws://localhost:8080/ws -rooms 50 -players 8 -think 2s
+//
+// All bots connect from one address, so raise or disable the server's per-IP limit
+// (WS_IP_RATE=0) unless that limit is what you want to test.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/oblongtable/beanbag-backend/internal/loadbot"
+)
+
+func main() {
+	var cfg loadbot.Config
+	var quizID int
+	flag.StringVar(&cfg.URL, "url", "ws://localhost:8080/ws", "websocket endpoint of the server")
+	flag.IntVar(&cfg.Rooms, "rooms", 10, "rooms to play at the same time")
+	flag.IntVar(&cfg.Players, "players", 5, "bot players per room besides the creator")
+	flag.IntVar(&quizID, "quiz", 0, "stored quiz to play, 0 for the built-in quiz")
+	flag.DurationVar(&cfg.ThinkTime, "think", time.Second, "longest time a bot waits before answering or moving on")
+	flag.DurationVar(&cfg.Ramp, "ramp", 50*time.Millisecond, "delay between starting rooms")
+	flag.DurationVar(&cfg.Timeout, "timeout", time.Minute, "how long a bot waits for a callback or its next message")
+	flag.Parse()
+	cfg.QuizID = int32(quizID)
+
+	// Ctrl-C stops the run and still prints the report
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	log.Printf("loadbot: %d rooms of %d players against %s", cfg.Rooms, cfg.Players, cfg.URL)
+	report, err := loadbot.Run(ctx, cfg)
+	if err != nil {
+		log.Fatal("? Load test failed: ", err)
+	}
+	fmt.Println(report)
+}
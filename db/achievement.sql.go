@@ -0,0 +1,114 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: achievement.sql
+
+package db
+
+import (
+	"context"
+)
+
+const awardAchievement = `-- name: AwardAchievement :execrows
+INSERT INTO user_achievements (
+    user_id, achievement_id
+) VALUES (
+    $1, $2
+)
+ON CONFLICT (user_id, achievement_id) DO NOTHING
+`
+
+type AwardAchievementParams struct {
+	UserID        int32
+	AchievementID string
+}
+
+// Affects no rows when the user already has the achievement.
+func (q *Queries) AwardAchievement(ctx context.Context, arg AwardAchievementParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, awardAchievement, arg.UserID, arg.AchievementID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const incrementAchievementCounter = `-- name: IncrementAchievementCounter :one
+INSERT INTO user_achievement_counters (
+    user_id, counter, value
+) VALUES (
+    $1, $2, 1
+)
+ON CONFLICT (user_id, counter) DO UPDATE
+SET value = user_achievement_counters.value + 1
+RETURNING value
+`
+
+type IncrementAchievementCounterParams struct {
+	UserID  int32
+	Counter string
+}
+
+func (q *Queries) IncrementAchievementCounter(ctx context.Context, arg IncrementAchievementCounterParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, incrementAchievementCounter, arg.UserID, arg.Counter)
+	var value int64
+	err := row.Scan(&value)
+	return value, err
+}
+
+const listUserAchievementCounters = `-- name: ListUserAchievementCounters :many
+SELECT user_id, counter, value FROM user_achievement_counters
+WHERE user_id = $1
+ORDER BY counter
+`
+
+func (q *Queries) ListUserAchievementCounters(ctx context.Context, userID int32) ([]UserAchievementCounter, error) {
+	rows, err := q.db.QueryContext(ctx, listUserAchievementCounters, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []UserAchievementCounter
+	for rows.Next() {
+		var i UserAchievementCounter
+		if err := rows.Scan(&i.UserID, &i.Counter, &i.Value); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUserAchievements = `-- name: ListUserAchievements :many
+SELECT user_id, achievement_id, awarded_at FROM user_achievements
+WHERE user_id = $1
+ORDER BY awarded_at, achievement_id
+`
+
+func (q *Queries) ListUserAchievements(ctx context.Context, userID int32) ([]UserAchievement, error) {
+	rows, err := q.db.QueryContext(ctx, listUserAchievements, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []UserAchievement
+	for rows.Next() {
+		var i UserAchievement
+		if err := rows.Scan(&i.UserID, &i.AchievementID, &i.AwardedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -22,7 +22,7 @@ INSERT INTO answers (
     updated_at
 ) VALUES (
     $1, $2, $3, $4, $5
-) RETURNING ans_id, ques_id, description, is_correct, created_at, updated_at
+) RETURNING ans_id, ques_id, description, is_correct, created_at, updated_at, image_id
 `
 
 type CreateAnswerParams struct {
@@ -49,6 +49,7 @@ func (q *Queries) CreateAnswer(ctx context.Context, arg CreateAnswerParams) (Ans
 		&i.IsCorrect,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.ImageID,
 	)
 	return i, err
 }
@@ -58,7 +59,7 @@ INSERT INTO answers (
     ques_id, description, is_correct
 ) VALUES (
     $1, $2, $3
-) returning ans_id, ques_id, description, is_correct, created_at, updated_at
+) returning ans_id, ques_id, description, is_correct, created_at, updated_at, image_id
 `
 
 type CreateAnswerMinimalParams struct {
@@ -77,6 +78,7 @@ func (q *Queries) CreateAnswerMinimal(ctx context.Context, arg CreateAnswerMinim
 		&i.IsCorrect,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.ImageID,
 	)
 	return i, err
 }
@@ -92,7 +94,7 @@ func (q *Queries) DeleteAnswer(ctx context.Context, ansID int32) error {
 }
 
 const getAnswer = `-- name: GetAnswer :one
-SELECT ans_id, ques_id, description, is_correct, created_at, updated_at FROM answers
+SELECT ans_id, ques_id, description, is_correct, created_at, updated_at, image_id FROM answers
 WHERE ans_id = $1 LIMIT 1
 `
 
@@ -106,12 +108,13 @@ func (q *Queries) GetAnswer(ctx context.Context, ansID int32) (Answer, error) {
 		&i.IsCorrect,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.ImageID,
 	)
 	return i, err
 }
 
 const listAnswersByQuestionIDs = `-- name: ListAnswersByQuestionIDs :many
-SELECT ans_id, ques_id, description, is_correct, created_at, updated_at FROM answers
+SELECT ans_id, ques_id, description, is_correct, created_at, updated_at, image_id FROM answers
 WHERE ques_id = ANY($1::int[])
 ORDER BY ques_id, ans_id
 `
@@ -132,6 +135,7 @@ func (q *Queries) ListAnswersByQuestionIDs(ctx context.Context, dollar_1 []int32
 			&i.IsCorrect,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.ImageID,
 		); err != nil {
 			return nil, err
 		}
@@ -154,7 +158,7 @@ SET
     is_correct = COALESCE($4, is_correct),
     updated_at = NOW()
 WHERE ans_id = $1
-RETURNING ans_id, ques_id, description, is_correct, created_at, updated_at
+RETURNING ans_id, ques_id, description, is_correct, created_at, updated_at, image_id
 `
 
 type UpdateAnswerParams struct {
@@ -179,6 +183,7 @@ func (q *Queries) UpdateAnswer(ctx context.Context, arg UpdateAnswerParams) (Ans
 		&i.IsCorrect,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.ImageID,
 	)
 	return i, err
 }
@@ -0,0 +1,106 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: assignment.sql
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+const createAssignment = `-- name: CreateAssignment :one
+INSERT INTO assignments (
+    code, quiz_id, creator_id, opens_at, closes_at
+) VALUES (
+    $1, $2, $3, $4, $5
+) RETURNING assignment_id, code, quiz_id, creator_id, opens_at, closes_at, created_at
+`
+
+type CreateAssignmentParams struct {
+	Code      string
+	QuizID    int32
+	CreatorID sql.NullInt32
+	OpensAt   time.Time
+	ClosesAt  time.Time
+}
+
+func (q *Queries) CreateAssignment(ctx context.Context, arg CreateAssignmentParams) (Assignment, error) {
+	row := q.db.QueryRowContext(ctx, createAssignment,
+		arg.Code,
+		arg.QuizID,
+		arg.CreatorID,
+		arg.OpensAt,
+		arg.ClosesAt,
+	)
+	var i Assignment
+	err := row.Scan(
+		&i.AssignmentID,
+		&i.Code,
+		&i.QuizID,
+		&i.CreatorID,
+		&i.OpensAt,
+		&i.ClosesAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getAssignmentByCode = `-- name: GetAssignmentByCode :one
+SELECT assignment_id, code, quiz_id, creator_id, opens_at, closes_at, created_at FROM assignments
+WHERE code = $1 LIMIT 1
+`
+
+func (q *Queries) GetAssignmentByCode(ctx context.Context, code string) (Assignment, error) {
+	row := q.db.QueryRowContext(ctx, getAssignmentByCode, code)
+	var i Assignment
+	err := row.Scan(
+		&i.AssignmentID,
+		&i.Code,
+		&i.QuizID,
+		&i.CreatorID,
+		&i.OpensAt,
+		&i.ClosesAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const listAssignmentsByCreator = `-- name: ListAssignmentsByCreator :many
+SELECT assignment_id, code, quiz_id, creator_id, opens_at, closes_at, created_at FROM assignments
+WHERE creator_id = $1
+ORDER BY created_at, assignment_id
+`
+
+func (q *Queries) ListAssignmentsByCreator(ctx context.Context, creatorID sql.NullInt32) ([]Assignment, error) {
+	rows, err := q.db.QueryContext(ctx, listAssignmentsByCreator, creatorID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Assignment
+	for rows.Next() {
+		var i Assignment
+		if err := rows.Scan(
+			&i.AssignmentID,
+			&i.Code,
+			&i.QuizID,
+			&i.CreatorID,
+			&i.OpensAt,
+			&i.ClosesAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -0,0 +1,116 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: leaderboard.sql
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+const listLeaderboard = `-- name: ListLeaderboard :many
+WITH entries AS (
+    SELECT
+        sp.session_player_id,
+        sp.user_id,
+        COALESCE(u.name, sp.display_name)::text AS name,
+        sp.score,
+        gs.quiz_id,
+        sp.finished_at,
+        ROW_NUMBER() OVER (
+            PARTITION BY sp.user_id
+            ORDER BY sp.score DESC, sp.finished_at
+        ) AS user_best
+    FROM session_players sp
+    JOIN game_sessions gs ON gs.session_id = sp.session_id
+    LEFT JOIN users u ON u.user_id = sp.user_id
+    WHERE sp.finished_at IS NOT NULL
+        AND sp.finished_at >= $3::timestamptz
+        AND ($4::int IS NULL OR gs.quiz_id = $4::int)
+        AND ($5::bool OR sp.user_id IS NOT NULL)
+),
+ranked AS (
+    SELECT
+        session_player_id, user_id, name, score, quiz_id, finished_at, user_best,
+        RANK() OVER (ORDER BY score DESC) AS rank,
+        ROW_NUMBER() OVER (ORDER BY score DESC, finished_at) AS position
+    FROM entries
+    WHERE user_id IS NULL OR user_best = 1
+)
+SELECT
+    session_player_id,
+    user_id,
+    name,
+    score,
+    quiz_id,
+    finished_at,
+    rank,
+    position
+FROM ranked
+WHERE position <= $1::int OR user_id = $2::int
+ORDER BY position
+`
+
+type ListLeaderboardParams struct {
+	MaxEntries    int32
+	ViewerID      sql.NullInt32
+	Since         time.Time
+	QuizID        sql.NullInt32
+	IncludeGuests bool
+}
+
+type ListLeaderboardRow struct {
+	SessionPlayerID int32
+	UserID          sql.NullInt32
+	Name            string
+	Score           int32
+	QuizID          sql.NullInt32
+	FinishedAt      sql.NullTime
+	Rank            int64
+	Position        int64
+}
+
+// High scores of finished games, best first. Signed-in users appear once with
+// their best score, guests can't be told apart across games so each of their
+// games is its own entry. Leave quiz_id NULL for the global leaderboard.
+// The row of viewer_id is returned as well even when it is below the limit.
+func (q *Queries) ListLeaderboard(ctx context.Context, arg ListLeaderboardParams) ([]ListLeaderboardRow, error) {
+	rows, err := q.db.QueryContext(ctx, listLeaderboard,
+		arg.MaxEntries,
+		arg.ViewerID,
+		arg.Since,
+		arg.QuizID,
+		arg.IncludeGuests,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListLeaderboardRow
+	for rows.Next() {
+		var i ListLeaderboardRow
+		if err := rows.Scan(
+			&i.SessionPlayerID,
+			&i.UserID,
+			&i.Name,
+			&i.Score,
+			&i.QuizID,
+			&i.FinishedAt,
+			&i.Rank,
+			&i.Position,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
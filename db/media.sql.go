@@ -0,0 +1,183 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: media.sql
+
+package db
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
+)
+
+const createMediaAsset = `-- name: CreateMediaAsset :one
+INSERT INTO media_assets (
+    kind, content_type, size_bytes, storage_key
+) VALUES (
+    $1, $2, $3, $4
+) RETURNING media_id, kind, content_type, size_bytes, storage_key, created_at
+`
+
+type CreateMediaAssetParams struct {
+	Kind        string
+	ContentType string
+	SizeBytes   int64
+	StorageKey  string
+}
+
+func (q *Queries) CreateMediaAsset(ctx context.Context, arg CreateMediaAssetParams) (MediaAsset, error) {
+	row := q.db.QueryRowContext(ctx, createMediaAsset,
+		arg.Kind,
+		arg.ContentType,
+		arg.SizeBytes,
+		arg.StorageKey,
+	)
+	var i MediaAsset
+	err := row.Scan(
+		&i.MediaID,
+		&i.Kind,
+		&i.ContentType,
+		&i.SizeBytes,
+		&i.StorageKey,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const deleteMediaAsset = `-- name: DeleteMediaAsset :one
+DELETE FROM media_assets
+WHERE media_id = $1
+RETURNING storage_key
+`
+
+func (q *Queries) DeleteMediaAsset(ctx context.Context, mediaID int32) (string, error) {
+	row := q.db.QueryRowContext(ctx, deleteMediaAsset, mediaID)
+	var storage_key string
+	err := row.Scan(&storage_key)
+	return storage_key, err
+}
+
+const getMediaAsset = `-- name: GetMediaAsset :one
+SELECT media_id, kind, content_type, size_bytes, storage_key, created_at FROM media_assets
+WHERE media_id = $1 LIMIT 1
+`
+
+func (q *Queries) GetMediaAsset(ctx context.Context, mediaID int32) (MediaAsset, error) {
+	row := q.db.QueryRowContext(ctx, getMediaAsset, mediaID)
+	var i MediaAsset
+	err := row.Scan(
+		&i.MediaID,
+		&i.Kind,
+		&i.ContentType,
+		&i.SizeBytes,
+		&i.StorageKey,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const listMediaAssetsByIDs = `-- name: ListMediaAssetsByIDs :many
+SELECT media_id, kind, content_type, size_bytes, storage_key, created_at FROM media_assets
+WHERE media_id = ANY($1::int[])
+`
+
+func (q *Queries) ListMediaAssetsByIDs(ctx context.Context, dollar_1 []int32) ([]MediaAsset, error) {
+	rows, err := q.db.QueryContext(ctx, listMediaAssetsByIDs, pq.Array(dollar_1))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []MediaAsset
+	for rows.Next() {
+		var i MediaAsset
+		if err := rows.Scan(
+			&i.MediaID,
+			&i.Kind,
+			&i.ContentType,
+			&i.SizeBytes,
+			&i.StorageKey,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setAnswerImage = `-- name: SetAnswerImage :one
+UPDATE answers a
+SET
+    image_id = $1,
+    updated_at = NOW()
+FROM (SELECT p.ans_id, p.image_id FROM answers p WHERE p.ans_id = $2 FOR UPDATE) old
+WHERE a.ans_id = old.ans_id
+RETURNING old.image_id AS replaced_id
+`
+
+type SetAnswerImageParams struct {
+	ImageID sql.NullInt32
+	AnsID   int32
+}
+
+// Returns the image it replaced, see SetQuestionImage.
+func (q *Queries) SetAnswerImage(ctx context.Context, arg SetAnswerImageParams) (sql.NullInt32, error) {
+	row := q.db.QueryRowContext(ctx, setAnswerImage, arg.ImageID, arg.AnsID)
+	var replaced_id sql.NullInt32
+	err := row.Scan(&replaced_id)
+	return replaced_id, err
+}
+
+const setQuestionAudio = `-- name: SetQuestionAudio :one
+UPDATE questions q
+SET
+    audio_id = $1,
+    updated_at = NOW()
+FROM (SELECT p.ques_id, p.audio_id FROM questions p WHERE p.ques_id = $2 FOR UPDATE) old
+WHERE q.ques_id = old.ques_id
+RETURNING old.audio_id AS replaced_id
+`
+
+type SetQuestionAudioParams struct {
+	AudioID sql.NullInt32
+	QuesID  int32
+}
+
+// Returns the audio clip it replaced, see SetQuestionImage.
+func (q *Queries) SetQuestionAudio(ctx context.Context, arg SetQuestionAudioParams) (sql.NullInt32, error) {
+	row := q.db.QueryRowContext(ctx, setQuestionAudio, arg.AudioID, arg.QuesID)
+	var replaced_id sql.NullInt32
+	err := row.Scan(&replaced_id)
+	return replaced_id, err
+}
+
+const setQuestionImage = `-- name: SetQuestionImage :one
+UPDATE questions q
+SET
+    image_id = $1,
+    updated_at = NOW()
+FROM (SELECT p.ques_id, p.image_id FROM questions p WHERE p.ques_id = $2 FOR UPDATE) old
+WHERE q.ques_id = old.ques_id
+RETURNING old.image_id AS replaced_id
+`
+
+type SetQuestionImageParams struct {
+	ImageID sql.NullInt32
+	QuesID  int32
+}
+
+// Returns the image it replaced. The row is locked, so concurrent uploads each get the one they replaced.
+func (q *Queries) SetQuestionImage(ctx context.Context, arg SetQuestionImageParams) (sql.NullInt32, error) {
+	row := q.db.QueryRowContext(ctx, setQuestionImage, arg.ImageID, arg.QuesID)
+	var replaced_id sql.NullInt32
+	err := row.Scan(&replaced_id)
+	return replaced_id, err
+}
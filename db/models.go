@@ -6,6 +6,7 @@ package db
 
 import (
 	"database/sql"
+	"encoding/json"
 	"time"
 )
 
@@ -16,6 +17,37 @@ type Answer struct {
 	IsCorrect   bool
 	CreatedAt   time.Time
 	UpdatedAt   time.Time
+	ImageID     sql.NullInt32
+}
+
+type Assignment struct {
+	AssignmentID int32
+	Code         string
+	QuizID       int32
+	CreatorID    sql.NullInt32
+	OpensAt      time.Time
+	ClosesAt     time.Time
+	CreatedAt    time.Time
+}
+
+type GameSession struct {
+	SessionID    int32
+	GameCode     string
+	QuizID       sql.NullInt32
+	AssignmentID sql.NullInt32
+	Mode         string
+	StartedAt    time.Time
+	FinishedAt   sql.NullTime
+	CreatedAt    time.Time
+}
+
+type MediaAsset struct {
+	MediaID     int32
+	Kind        string
+	ContentType string
+	SizeBytes   int64
+	StorageKey  string
+	CreatedAt   time.Time
 }
 
 type Question struct {
@@ -26,6 +58,8 @@ type Question struct {
 	Timer       int32
 	CreatedAt   time.Time
 	UpdatedAt   time.Time
+	ImageID     sql.NullInt32
+	AudioID     sql.NullInt32
 }
 
 type Quiz struct {
@@ -39,11 +73,78 @@ type Quiz struct {
 	UpdatedAt   time.Time
 }
 
+type RoomCode struct {
+	Code        string
+	InstanceID  string
+	ClaimedAt   time.Time
+	HeartbeatAt time.Time
+}
+
+type RoomCodeReservation struct {
+	Code       string
+	Label      string
+	ReservedBy int32
+	StartsAt   time.Time
+	EndsAt     time.Time
+	CreatedAt  time.Time
+}
+
+type SessionAnswer struct {
+	SessionAnswerID int32
+	SessionID       int32
+	SessionPlayerID int32
+	QuestionIndex   int32
+	QuesID          sql.NullInt32
+	AnswerIndex     sql.NullInt32
+	IsCorrect       bool
+	TimeTakenMs     sql.NullInt32
+	Points          int32
+	AnsweredAt      time.Time
+}
+
+type SessionPlayer struct {
+	SessionPlayerID int32
+	SessionID       int32
+	PlayerRef       string
+	DisplayName     string
+	Score           int32
+	Rank            sql.NullInt32
+	StartedAt       time.Time
+	FinishedAt      sql.NullTime
+	UserID          sql.NullInt32
+}
+
 type User struct {
-	UserID    int32
-	Name      string
-	Email     string
-	CreatedAt time.Time
-	UpdatedAt time.Time
-	DeletedAt sql.NullTime
+	UserID      int32
+	Name        string
+	Email       string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	DeletedAt   sql.NullTime
+	Role        string
+	AvatarUrl   sql.NullString
+	Preferences json.RawMessage
+	CustomName  bool
+	AuthSubject sql.NullString
+}
+
+type UserAchievement struct {
+	UserID        int32
+	AchievementID string
+	AwardedAt     time.Time
+}
+
+type UserAchievementCounter struct {
+	UserID  int32
+	Counter string
+	Value   int64
+}
+
+type UserIdentity struct {
+	IdentityID  int32
+	UserID      int32
+	AuthSubject string
+	Email       string
+	CreatedAt   time.Time
+	LastSeenAt  time.Time
 }
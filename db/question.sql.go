@@ -21,7 +21,7 @@ INSERT INTO questions (
     updated_at
 ) VALUES (
     $1, $2, $3, $4, $5, $6
-) RETURNING ques_id, quiz_id, description, timer_option, timer, created_at, updated_at
+) RETURNING ques_id, quiz_id, description, timer_option, timer, created_at, updated_at, image_id, audio_id
 `
 
 type CreateQuestionParams struct {
@@ -51,6 +51,8 @@ func (q *Queries) CreateQuestion(ctx context.Context, arg CreateQuestionParams)
 		&i.Timer,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.ImageID,
+		&i.AudioID,
 	)
 	return i, err
 }
@@ -58,7 +60,7 @@ func (q *Queries) CreateQuestion(ctx context.Context, arg CreateQuestionParams)
 const createQuestionMinimal = `-- name: CreateQuestionMinimal :one
 INSERT INTO questions (quiz_id, description, timer_option, timer)
 VALUES ($1, $2, $3, $4)
-RETURNING ques_id, quiz_id, description, timer_option, timer, created_at, updated_at
+RETURNING ques_id, quiz_id, description, timer_option, timer, created_at, updated_at, image_id, audio_id
 `
 
 type CreateQuestionMinimalParams struct {
@@ -84,6 +86,8 @@ func (q *Queries) CreateQuestionMinimal(ctx context.Context, arg CreateQuestionM
 		&i.Timer,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.ImageID,
+		&i.AudioID,
 	)
 	return i, err
 }
@@ -99,7 +103,7 @@ func (q *Queries) DeleteQuestion(ctx context.Context, quesID int32) error {
 }
 
 const getQuestion = `-- name: GetQuestion :one
-SELECT ques_id, quiz_id, description, timer_option, timer, created_at, updated_at FROM questions
+SELECT ques_id, quiz_id, description, timer_option, timer, created_at, updated_at, image_id, audio_id FROM questions
 WHERE ques_id = $1 LIMIT 1
 `
 
@@ -114,12 +118,14 @@ func (q *Queries) GetQuestion(ctx context.Context, quesID int32) (Question, erro
 		&i.Timer,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.ImageID,
+		&i.AudioID,
 	)
 	return i, err
 }
 
 const listQuestionsByQuiz = `-- name: ListQuestionsByQuiz :many
-SELECT ques_id, quiz_id, description, timer_option, timer, created_at, updated_at FROM questions
+SELECT ques_id, quiz_id, description, timer_option, timer, created_at, updated_at, image_id, audio_id FROM questions
 WHERE quiz_id = $1
 ORDER BY ques_id
 `
@@ -141,6 +147,8 @@ func (q *Queries) ListQuestionsByQuiz(ctx context.Context, quizID sql.NullInt32)
 			&i.Timer,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.ImageID,
+			&i.AudioID,
 		); err != nil {
 			return nil, err
 		}
@@ -164,7 +172,7 @@ SET
     timer = COALESCE($5, timer),
     updated_at = NOW()
 WHERE ques_id = $1
-RETURNING ques_id, quiz_id, description, timer_option, timer, created_at, updated_at
+RETURNING ques_id, quiz_id, description, timer_option, timer, created_at, updated_at, image_id, audio_id
 `
 
 type UpdateQuestionParams struct {
@@ -192,6 +200,8 @@ func (q *Queries) UpdateQuestion(ctx context.Context, arg UpdateQuestionParams)
 		&i.Timer,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.ImageID,
+		&i.AudioID,
 	)
 	return i, err
 }
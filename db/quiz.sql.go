@@ -117,6 +117,44 @@ func (q *Queries) GetQuiz(ctx context.Context, quizID int32) (Quiz, error) {
 	return i, err
 }
 
+const listQuizzesByCreator = `-- name: ListQuizzesByCreator :many
+SELECT quiz_id, creator_id, quiz_title, description, is_priv, timer, created_at, updated_at FROM quizzes
+WHERE creator_id = $1
+ORDER BY created_at, quiz_id
+`
+
+func (q *Queries) ListQuizzesByCreator(ctx context.Context, creatorID sql.NullInt32) ([]Quiz, error) {
+	rows, err := q.db.QueryContext(ctx, listQuizzesByCreator, creatorID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Quiz
+	for rows.Next() {
+		var i Quiz
+		if err := rows.Scan(
+			&i.QuizID,
+			&i.CreatorID,
+			&i.QuizTitle,
+			&i.Description,
+			&i.IsPriv,
+			&i.Timer,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const updateQuiz = `-- name: UpdateQuiz :one
 UPDATE quizzes
 SET
@@ -0,0 +1,243 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: room_code.sql
+
+package db
+
+import (
+	"context"
+	"time"
+)
+
+const claimReservedRoomCode = `-- name: ClaimReservedRoomCode :one
+INSERT INTO room_codes (code, instance_id)
+SELECT r.code, $1::text
+FROM room_code_reservations r
+WHERE r.code = $2::text AND r.reserved_by = $3::int
+  AND r.starts_at <= NOW() AND r.ends_at > NOW()
+ON CONFLICT (code) DO UPDATE
+SET instance_id = EXCLUDED.instance_id, claimed_at = NOW(), heartbeat_at = NOW()
+WHERE room_codes.heartbeat_at < NOW() - make_interval(secs => $4::float8)
+RETURNING code
+`
+
+type ClaimReservedRoomCodeParams struct {
+	InstanceID   string
+	Code         string
+	UserID       int32
+	StaleSeconds float64
+}
+
+// Takes a code reserved for the user while the reservation lasts, unless a live room has it.
+func (q *Queries) ClaimReservedRoomCode(ctx context.Context, arg ClaimReservedRoomCodeParams) (string, error) {
+	row := q.db.QueryRowContext(ctx, claimReservedRoomCode,
+		arg.InstanceID,
+		arg.Code,
+		arg.UserID,
+		arg.StaleSeconds,
+	)
+	var code string
+	err := row.Scan(&code)
+	return code, err
+}
+
+const claimRoomCode = `-- name: ClaimRoomCode :one
+INSERT INTO room_codes (code, instance_id)
+SELECT $1::text, $2::text
+WHERE NOT EXISTS (
+    SELECT 1 FROM room_code_reservations r
+    WHERE r.code = $1::text AND r.ends_at > NOW()
+)
+ON CONFLICT (code) DO UPDATE
+SET instance_id = EXCLUDED.instance_id, claimed_at = NOW(), heartbeat_at = NOW()
+WHERE room_codes.heartbeat_at < NOW() - make_interval(secs => $3::float8)
+RETURNING code
+`
+
+type ClaimRoomCodeParams struct {
+	Code         string
+	InstanceID   string
+	StaleSeconds float64
+}
+
+// Takes a generated code unless a live room has it or it is reserved.
+func (q *Queries) ClaimRoomCode(ctx context.Context, arg ClaimRoomCodeParams) (string, error) {
+	row := q.db.QueryRowContext(ctx, claimRoomCode, arg.Code, arg.InstanceID, arg.StaleSeconds)
+	var code string
+	err := row.Scan(&code)
+	return code, err
+}
+
+const countRoomCodeReservationsByUser = `-- name: CountRoomCodeReservationsByUser :one
+SELECT COUNT(*) FROM room_code_reservations
+WHERE reserved_by = $1 AND ends_at > NOW()
+`
+
+func (q *Queries) CountRoomCodeReservationsByUser(ctx context.Context, reservedBy int32) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countRoomCodeReservationsByUser, reservedBy)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const createRoomCodeReservation = `-- name: CreateRoomCodeReservation :one
+INSERT INTO room_code_reservations (
+    code, label, reserved_by, starts_at, ends_at
+) VALUES (
+    $1, $2, $3, $4, $5
+) RETURNING code, label, reserved_by, starts_at, ends_at, created_at
+`
+
+type CreateRoomCodeReservationParams struct {
+	Code       string
+	Label      string
+	ReservedBy int32
+	StartsAt   time.Time
+	EndsAt     time.Time
+}
+
+func (q *Queries) CreateRoomCodeReservation(ctx context.Context, arg CreateRoomCodeReservationParams) (RoomCodeReservation, error) {
+	row := q.db.QueryRowContext(ctx, createRoomCodeReservation,
+		arg.Code,
+		arg.Label,
+		arg.ReservedBy,
+		arg.StartsAt,
+		arg.EndsAt,
+	)
+	var i RoomCodeReservation
+	err := row.Scan(
+		&i.Code,
+		&i.Label,
+		&i.ReservedBy,
+		&i.StartsAt,
+		&i.EndsAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const deleteEndedRoomCodeReservation = `-- name: DeleteEndedRoomCodeReservation :exec
+DELETE FROM room_code_reservations
+WHERE code = $1 AND ends_at <= NOW()
+`
+
+func (q *Queries) DeleteEndedRoomCodeReservation(ctx context.Context, code string) error {
+	_, err := q.db.ExecContext(ctx, deleteEndedRoomCodeReservation, code)
+	return err
+}
+
+const deleteRoomCodeReservation = `-- name: DeleteRoomCodeReservation :execrows
+DELETE FROM room_code_reservations
+WHERE code = $1 AND reserved_by = $2
+`
+
+type DeleteRoomCodeReservationParams struct {
+	Code       string
+	ReservedBy int32
+}
+
+func (q *Queries) DeleteRoomCodeReservation(ctx context.Context, arg DeleteRoomCodeReservationParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteRoomCodeReservation, arg.Code, arg.ReservedBy)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteUserRoomCodeReservations = `-- name: DeleteUserRoomCodeReservations :exec
+DELETE FROM room_code_reservations
+WHERE reserved_by = $1
+`
+
+func (q *Queries) DeleteUserRoomCodeReservations(ctx context.Context, reservedBy int32) error {
+	_, err := q.db.ExecContext(ctx, deleteUserRoomCodeReservations, reservedBy)
+	return err
+}
+
+const getActiveRoomCodeReservation = `-- name: GetActiveRoomCodeReservation :one
+SELECT code, label, reserved_by, starts_at, ends_at, created_at FROM room_code_reservations
+WHERE code = $1 AND reserved_by = $2 AND starts_at <= NOW() AND ends_at > NOW()
+LIMIT 1
+`
+
+type GetActiveRoomCodeReservationParams struct {
+	Code       string
+	ReservedBy int32
+}
+
+func (q *Queries) GetActiveRoomCodeReservation(ctx context.Context, arg GetActiveRoomCodeReservationParams) (RoomCodeReservation, error) {
+	row := q.db.QueryRowContext(ctx, getActiveRoomCodeReservation, arg.Code, arg.ReservedBy)
+	var i RoomCodeReservation
+	err := row.Scan(
+		&i.Code,
+		&i.Label,
+		&i.ReservedBy,
+		&i.StartsAt,
+		&i.EndsAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const listRoomCodeReservationsByUser = `-- name: ListRoomCodeReservationsByUser :many
+SELECT code, label, reserved_by, starts_at, ends_at, created_at FROM room_code_reservations
+WHERE reserved_by = $1 AND ends_at > NOW()
+ORDER BY starts_at, code
+`
+
+func (q *Queries) ListRoomCodeReservationsByUser(ctx context.Context, reservedBy int32) ([]RoomCodeReservation, error) {
+	rows, err := q.db.QueryContext(ctx, listRoomCodeReservationsByUser, reservedBy)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []RoomCodeReservation
+	for rows.Next() {
+		var i RoomCodeReservation
+		if err := rows.Scan(
+			&i.Code,
+			&i.Label,
+			&i.ReservedBy,
+			&i.StartsAt,
+			&i.EndsAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const releaseRoomCode = `-- name: ReleaseRoomCode :exec
+DELETE FROM room_codes
+WHERE code = $1 AND instance_id = $2
+`
+
+type ReleaseRoomCodeParams struct {
+	Code       string
+	InstanceID string
+}
+
+func (q *Queries) ReleaseRoomCode(ctx context.Context, arg ReleaseRoomCodeParams) error {
+	_, err := q.db.ExecContext(ctx, releaseRoomCode, arg.Code, arg.InstanceID)
+	return err
+}
+
+const touchRoomCodes = `-- name: TouchRoomCodes :exec
+UPDATE room_codes
+SET heartbeat_at = NOW()
+WHERE instance_id = $1
+`
+
+func (q *Queries) TouchRoomCodes(ctx context.Context, instanceID string) error {
+	_, err := q.db.ExecContext(ctx, touchRoomCodes, instanceID)
+	return err
+}
@@ -0,0 +1,714 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: session.sql
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+const createGameSession = `-- name: CreateGameSession :one
+INSERT INTO game_sessions (
+    game_code, quiz_id, mode, started_at, finished_at
+) VALUES (
+    $1, $2, $3, $4, $5
+) RETURNING session_id, game_code, quiz_id, assignment_id, mode, started_at, finished_at, created_at
+`
+
+type CreateGameSessionParams struct {
+	GameCode   string
+	QuizID     sql.NullInt32
+	Mode       string
+	StartedAt  time.Time
+	FinishedAt sql.NullTime
+}
+
+func (q *Queries) CreateGameSession(ctx context.Context, arg CreateGameSessionParams) (GameSession, error) {
+	row := q.db.QueryRowContext(ctx, createGameSession,
+		arg.GameCode,
+		arg.QuizID,
+		arg.Mode,
+		arg.StartedAt,
+		arg.FinishedAt,
+	)
+	var i GameSession
+	err := row.Scan(
+		&i.SessionID,
+		&i.GameCode,
+		&i.QuizID,
+		&i.AssignmentID,
+		&i.Mode,
+		&i.StartedAt,
+		&i.FinishedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createSessionAnswer = `-- name: CreateSessionAnswer :exec
+INSERT INTO session_answers (
+    session_id, session_player_id, question_index, ques_id, answer_index, is_correct, time_taken_ms, points, answered_at
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9
+)
+`
+
+type CreateSessionAnswerParams struct {
+	SessionID       int32
+	SessionPlayerID int32
+	QuestionIndex   int32
+	QuesID          sql.NullInt32
+	AnswerIndex     sql.NullInt32
+	IsCorrect       bool
+	TimeTakenMs     sql.NullInt32
+	Points          int32
+	AnsweredAt      time.Time
+}
+
+func (q *Queries) CreateSessionAnswer(ctx context.Context, arg CreateSessionAnswerParams) error {
+	_, err := q.db.ExecContext(ctx, createSessionAnswer,
+		arg.SessionID,
+		arg.SessionPlayerID,
+		arg.QuestionIndex,
+		arg.QuesID,
+		arg.AnswerIndex,
+		arg.IsCorrect,
+		arg.TimeTakenMs,
+		arg.Points,
+		arg.AnsweredAt,
+	)
+	return err
+}
+
+const createSessionPlayer = `-- name: CreateSessionPlayer :one
+INSERT INTO session_players (
+    session_id, player_ref, user_id, display_name, score, rank, started_at, finished_at
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8
+) RETURNING session_player_id, session_id, player_ref, display_name, score, rank, started_at, finished_at, user_id
+`
+
+type CreateSessionPlayerParams struct {
+	SessionID   int32
+	PlayerRef   string
+	UserID      sql.NullInt32
+	DisplayName string
+	Score       int32
+	Rank        sql.NullInt32
+	StartedAt   time.Time
+	FinishedAt  sql.NullTime
+}
+
+func (q *Queries) CreateSessionPlayer(ctx context.Context, arg CreateSessionPlayerParams) (SessionPlayer, error) {
+	row := q.db.QueryRowContext(ctx, createSessionPlayer,
+		arg.SessionID,
+		arg.PlayerRef,
+		arg.UserID,
+		arg.DisplayName,
+		arg.Score,
+		arg.Rank,
+		arg.StartedAt,
+		arg.FinishedAt,
+	)
+	var i SessionPlayer
+	err := row.Scan(
+		&i.SessionPlayerID,
+		&i.SessionID,
+		&i.PlayerRef,
+		&i.DisplayName,
+		&i.Score,
+		&i.Rank,
+		&i.StartedAt,
+		&i.FinishedAt,
+		&i.UserID,
+	)
+	return i, err
+}
+
+const getGameSession = `-- name: GetGameSession :one
+SELECT session_id, game_code, quiz_id, assignment_id, mode, started_at, finished_at, created_at FROM game_sessions
+WHERE session_id = $1 LIMIT 1
+`
+
+func (q *Queries) GetGameSession(ctx context.Context, sessionID int32) (GameSession, error) {
+	row := q.db.QueryRowContext(ctx, getGameSession, sessionID)
+	var i GameSession
+	err := row.Scan(
+		&i.SessionID,
+		&i.GameCode,
+		&i.QuizID,
+		&i.AssignmentID,
+		&i.Mode,
+		&i.StartedAt,
+		&i.FinishedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getGameSessionByAssignment = `-- name: GetGameSessionByAssignment :one
+SELECT session_id, game_code, quiz_id, assignment_id, mode, started_at, finished_at, created_at FROM game_sessions
+WHERE assignment_id = $1 LIMIT 1
+`
+
+func (q *Queries) GetGameSessionByAssignment(ctx context.Context, assignmentID sql.NullInt32) (GameSession, error) {
+	row := q.db.QueryRowContext(ctx, getGameSessionByAssignment, assignmentID)
+	var i GameSession
+	err := row.Scan(
+		&i.SessionID,
+		&i.GameCode,
+		&i.QuizID,
+		&i.AssignmentID,
+		&i.Mode,
+		&i.StartedAt,
+		&i.FinishedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getUserBestStreak = `-- name: GetUserBestStreak :one
+WITH ordered AS (
+    SELECT
+        sa.session_player_id,
+        sa.is_correct,
+        ROW_NUMBER() OVER (PARTITION BY sa.session_player_id ORDER BY sa.question_index, sa.session_answer_id)
+          - ROW_NUMBER() OVER (PARTITION BY sa.session_player_id, sa.is_correct ORDER BY sa.question_index, sa.session_answer_id) AS run
+    FROM session_answers sa
+    JOIN session_players sp ON sp.session_player_id = sa.session_player_id
+    WHERE sp.user_id = $1::int
+)
+SELECT COALESCE(MAX(streak), 0)::int AS best_streak
+FROM (
+    SELECT COUNT(*) AS streak
+    FROM ordered
+    WHERE is_correct
+    GROUP BY session_player_id, run
+) runs
+`
+
+// Longest run of consecutive correct answers within one game.
+func (q *Queries) GetUserBestStreak(ctx context.Context, userID int32) (int32, error) {
+	row := q.db.QueryRowContext(ctx, getUserBestStreak, userID)
+	var best_streak int32
+	err := row.Scan(&best_streak)
+	return best_streak, err
+}
+
+const getUserStats = `-- name: GetUserStats :one
+SELECT
+    COUNT(DISTINCT sp.session_id) AS sessions_played,
+    COALESCE(SUM(sp.score), 0)::bigint AS total_points,
+    COALESCE(AVG(sp.rank), 0)::float8 AS average_rank,
+    (SELECT COUNT(*) FROM session_answers sa
+        JOIN session_players p ON p.session_player_id = sa.session_player_id
+        WHERE p.user_id = $1::int) AS questions_seen,
+    (SELECT COUNT(*) FROM session_answers sa
+        JOIN session_players p ON p.session_player_id = sa.session_player_id
+        WHERE p.user_id = $1::int AND sa.is_correct) AS correct_answers
+FROM session_players sp
+WHERE sp.user_id = $1::int
+`
+
+type GetUserStatsRow struct {
+	SessionsPlayed int64
+	TotalPoints    int64
+	AverageRank    float64
+	QuestionsSeen  int64
+	CorrectAnswers int64
+}
+
+func (q *Queries) GetUserStats(ctx context.Context, userID int32) (GetUserStatsRow, error) {
+	row := q.db.QueryRowContext(ctx, getUserStats, userID)
+	var i GetUserStatsRow
+	err := row.Scan(
+		&i.SessionsPlayed,
+		&i.TotalPoints,
+		&i.AverageRank,
+		&i.QuestionsSeen,
+		&i.CorrectAnswers,
+	)
+	return i, err
+}
+
+const listGameSessionsByQuiz = `-- name: ListGameSessionsByQuiz :many
+SELECT session_id, game_code, quiz_id, assignment_id, mode, started_at, finished_at, created_at FROM game_sessions
+WHERE quiz_id = $1
+ORDER BY started_at DESC
+`
+
+func (q *Queries) ListGameSessionsByQuiz(ctx context.Context, quizID sql.NullInt32) ([]GameSession, error) {
+	rows, err := q.db.QueryContext(ctx, listGameSessionsByQuiz, quizID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GameSession
+	for rows.Next() {
+		var i GameSession
+		if err := rows.Scan(
+			&i.SessionID,
+			&i.GameCode,
+			&i.QuizID,
+			&i.AssignmentID,
+			&i.Mode,
+			&i.StartedAt,
+			&i.FinishedAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSessionAnswerCounts = `-- name: ListSessionAnswerCounts :many
+SELECT
+    question_index,
+    answer_index::int AS answer_index,
+    COUNT(*) AS players
+FROM session_answers
+WHERE session_id = $1 AND answer_index IS NOT NULL
+GROUP BY question_index, answer_index
+ORDER BY question_index, answer_index
+`
+
+type ListSessionAnswerCountsRow struct {
+	QuestionIndex int32
+	AnswerIndex   int32
+	Players       int64
+}
+
+func (q *Queries) ListSessionAnswerCounts(ctx context.Context, sessionID int32) ([]ListSessionAnswerCountsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listSessionAnswerCounts, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListSessionAnswerCountsRow
+	for rows.Next() {
+		var i ListSessionAnswerCountsRow
+		if err := rows.Scan(&i.QuestionIndex, &i.AnswerIndex, &i.Players); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSessionFastestCorrect = `-- name: ListSessionFastestCorrect :many
+SELECT DISTINCT ON (sa.question_index)
+    sa.question_index,
+    sp.player_ref,
+    sp.display_name,
+    sa.time_taken_ms::int AS time_taken_ms
+FROM session_answers sa
+JOIN session_players sp ON sp.session_player_id = sa.session_player_id
+WHERE sa.session_id = $1 AND sa.is_correct
+ORDER BY sa.question_index, sa.time_taken_ms ASC
+`
+
+type ListSessionFastestCorrectRow struct {
+	QuestionIndex int32
+	PlayerRef     string
+	DisplayName   string
+	TimeTakenMs   int32
+}
+
+func (q *Queries) ListSessionFastestCorrect(ctx context.Context, sessionID int32) ([]ListSessionFastestCorrectRow, error) {
+	rows, err := q.db.QueryContext(ctx, listSessionFastestCorrect, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListSessionFastestCorrectRow
+	for rows.Next() {
+		var i ListSessionFastestCorrectRow
+		if err := rows.Scan(
+			&i.QuestionIndex,
+			&i.PlayerRef,
+			&i.DisplayName,
+			&i.TimeTakenMs,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSessionPlayerSummaries = `-- name: ListSessionPlayerSummaries :many
+SELECT
+    sp.session_player_id,
+    sp.display_name,
+    sp.score,
+    sp.rank,
+    sp.started_at,
+    sp.finished_at,
+    COUNT(sa.session_answer_id) AS questions_seen,
+    COUNT(sa.session_answer_id) FILTER (WHERE sa.is_correct) AS correct_answers
+FROM session_players sp
+LEFT JOIN session_answers sa ON sa.session_player_id = sp.session_player_id
+WHERE sp.session_id = $1
+GROUP BY sp.session_player_id
+ORDER BY sp.score DESC, sp.finished_at ASC NULLS LAST
+`
+
+type ListSessionPlayerSummariesRow struct {
+	SessionPlayerID int32
+	DisplayName     string
+	Score           int32
+	Rank            sql.NullInt32
+	StartedAt       time.Time
+	FinishedAt      sql.NullTime
+	QuestionsSeen   int64
+	CorrectAnswers  int64
+}
+
+func (q *Queries) ListSessionPlayerSummaries(ctx context.Context, sessionID int32) ([]ListSessionPlayerSummariesRow, error) {
+	rows, err := q.db.QueryContext(ctx, listSessionPlayerSummaries, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListSessionPlayerSummariesRow
+	for rows.Next() {
+		var i ListSessionPlayerSummariesRow
+		if err := rows.Scan(
+			&i.SessionPlayerID,
+			&i.DisplayName,
+			&i.Score,
+			&i.Rank,
+			&i.StartedAt,
+			&i.FinishedAt,
+			&i.QuestionsSeen,
+			&i.CorrectAnswers,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSessionQuestionStats = `-- name: ListSessionQuestionStats :many
+SELECT
+    question_index,
+    COALESCE(MAX(ques_id), 0)::int AS ques_id,
+    COUNT(*) AS players,
+    COUNT(answer_index) AS responses,
+    COUNT(*) FILTER (WHERE is_correct) AS correct_answers,
+    COALESCE(AVG(time_taken_ms), 0)::float8 AS average_time_ms,
+    COALESCE(MIN(time_taken_ms), 0)::int AS fastest_time_ms
+FROM session_answers
+WHERE session_id = $1
+GROUP BY question_index
+ORDER BY question_index
+`
+
+type ListSessionQuestionStatsRow struct {
+	QuestionIndex  int32
+	QuesID         int32
+	Players        int64
+	Responses      int64
+	CorrectAnswers int64
+	AverageTimeMs  float64
+	FastestTimeMs  int32
+}
+
+// Rows without an answer_index are players who ran out of time.
+func (q *Queries) ListSessionQuestionStats(ctx context.Context, sessionID int32) ([]ListSessionQuestionStatsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listSessionQuestionStats, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListSessionQuestionStatsRow
+	for rows.Next() {
+		var i ListSessionQuestionStatsRow
+		if err := rows.Scan(
+			&i.QuestionIndex,
+			&i.QuesID,
+			&i.Players,
+			&i.Responses,
+			&i.CorrectAnswers,
+			&i.AverageTimeMs,
+			&i.FastestTimeMs,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUserSessionAnswers = `-- name: ListUserSessionAnswers :many
+SELECT sa.session_answer_id, sa.session_id, sa.session_player_id, sa.question_index, sa.ques_id, sa.answer_index, sa.is_correct, sa.time_taken_ms, sa.points, sa.answered_at
+FROM session_answers sa
+JOIN session_players sp ON sp.session_player_id = sa.session_player_id
+WHERE sp.user_id = $1
+ORDER BY sa.session_player_id, sa.question_index, sa.session_answer_id
+`
+
+func (q *Queries) ListUserSessionAnswers(ctx context.Context, userID sql.NullInt32) ([]SessionAnswer, error) {
+	rows, err := q.db.QueryContext(ctx, listUserSessionAnswers, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SessionAnswer
+	for rows.Next() {
+		var i SessionAnswer
+		if err := rows.Scan(
+			&i.SessionAnswerID,
+			&i.SessionID,
+			&i.SessionPlayerID,
+			&i.QuestionIndex,
+			&i.QuesID,
+			&i.AnswerIndex,
+			&i.IsCorrect,
+			&i.TimeTakenMs,
+			&i.Points,
+			&i.AnsweredAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUserSessionHistory = `-- name: ListUserSessionHistory :many
+SELECT
+    gs.session_id,
+    gs.game_code,
+    gs.mode,
+    gs.quiz_id,
+    COALESCE(qz.quiz_title, '')::text AS quiz_title,
+    sp.display_name,
+    sp.score,
+    sp.rank,
+    sp.started_at,
+    sp.finished_at,
+    (SELECT COUNT(*) FROM session_players o WHERE o.session_id = sp.session_id) AS players,
+    COUNT(sa.session_answer_id) AS questions_seen,
+    COUNT(sa.session_answer_id) FILTER (WHERE sa.is_correct) AS correct_answers
+FROM session_players sp
+JOIN game_sessions gs ON gs.session_id = sp.session_id
+LEFT JOIN quizzes qz ON qz.quiz_id = gs.quiz_id
+LEFT JOIN session_answers sa ON sa.session_player_id = sp.session_player_id
+WHERE sp.user_id = $1::int
+GROUP BY sp.session_player_id, gs.session_id, qz.quiz_title
+ORDER BY sp.started_at DESC
+LIMIT $3 OFFSET $2
+`
+
+type ListUserSessionHistoryParams struct {
+	UserID int32
+	Offset int32
+	Limit  int32
+}
+
+type ListUserSessionHistoryRow struct {
+	SessionID      int32
+	GameCode       string
+	Mode           string
+	QuizID         sql.NullInt32
+	QuizTitle      string
+	DisplayName    string
+	Score          int32
+	Rank           sql.NullInt32
+	StartedAt      time.Time
+	FinishedAt     sql.NullTime
+	Players        int64
+	QuestionsSeen  int64
+	CorrectAnswers int64
+}
+
+func (q *Queries) ListUserSessionHistory(ctx context.Context, arg ListUserSessionHistoryParams) ([]ListUserSessionHistoryRow, error) {
+	rows, err := q.db.QueryContext(ctx, listUserSessionHistory, arg.UserID, arg.Offset, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListUserSessionHistoryRow
+	for rows.Next() {
+		var i ListUserSessionHistoryRow
+		if err := rows.Scan(
+			&i.SessionID,
+			&i.GameCode,
+			&i.Mode,
+			&i.QuizID,
+			&i.QuizTitle,
+			&i.DisplayName,
+			&i.Score,
+			&i.Rank,
+			&i.StartedAt,
+			&i.FinishedAt,
+			&i.Players,
+			&i.QuestionsSeen,
+			&i.CorrectAnswers,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUserSessionPlayers = `-- name: ListUserSessionPlayers :many
+SELECT
+    sp.session_player_id, sp.session_id, sp.player_ref, sp.display_name, sp.score, sp.rank, sp.started_at, sp.finished_at, sp.user_id,
+    gs.game_code,
+    gs.mode,
+    gs.quiz_id,
+    COALESCE(qz.quiz_title, '')::text AS quiz_title
+FROM session_players sp
+JOIN game_sessions gs ON gs.session_id = sp.session_id
+LEFT JOIN quizzes qz ON qz.quiz_id = gs.quiz_id
+WHERE sp.user_id = $1
+ORDER BY sp.started_at, sp.session_player_id
+`
+
+type ListUserSessionPlayersRow struct {
+	SessionPlayerID int32
+	SessionID       int32
+	PlayerRef       string
+	DisplayName     string
+	Score           int32
+	Rank            sql.NullInt32
+	StartedAt       time.Time
+	FinishedAt      sql.NullTime
+	UserID          sql.NullInt32
+	GameCode        string
+	Mode            string
+	QuizID          sql.NullInt32
+	QuizTitle       string
+}
+
+// Every game the user played, for exporting their data.
+func (q *Queries) ListUserSessionPlayers(ctx context.Context, userID sql.NullInt32) ([]ListUserSessionPlayersRow, error) {
+	rows, err := q.db.QueryContext(ctx, listUserSessionPlayers, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListUserSessionPlayersRow
+	for rows.Next() {
+		var i ListUserSessionPlayersRow
+		if err := rows.Scan(
+			&i.SessionPlayerID,
+			&i.SessionID,
+			&i.PlayerRef,
+			&i.DisplayName,
+			&i.Score,
+			&i.Rank,
+			&i.StartedAt,
+			&i.FinishedAt,
+			&i.UserID,
+			&i.GameCode,
+			&i.Mode,
+			&i.QuizID,
+			&i.QuizTitle,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const upsertAssignmentSession = `-- name: UpsertAssignmentSession :one
+INSERT INTO game_sessions (
+    game_code, quiz_id, assignment_id, mode, started_at
+) VALUES (
+    $1, $2, $3, $4, $5
+)
+ON CONFLICT (assignment_id) DO UPDATE
+SET assignment_id = EXCLUDED.assignment_id
+RETURNING session_id, game_code, quiz_id, assignment_id, mode, started_at, finished_at, created_at
+`
+
+type UpsertAssignmentSessionParams struct {
+	GameCode     string
+	QuizID       sql.NullInt32
+	AssignmentID sql.NullInt32
+	Mode         string
+	StartedAt    time.Time
+}
+
+// Every attempt at an assignment is collected into one session.
+func (q *Queries) UpsertAssignmentSession(ctx context.Context, arg UpsertAssignmentSessionParams) (GameSession, error) {
+	row := q.db.QueryRowContext(ctx, upsertAssignmentSession,
+		arg.GameCode,
+		arg.QuizID,
+		arg.AssignmentID,
+		arg.Mode,
+		arg.StartedAt,
+	)
+	var i GameSession
+	err := row.Scan(
+		&i.SessionID,
+		&i.GameCode,
+		&i.QuizID,
+		&i.AssignmentID,
+		&i.Mode,
+		&i.StartedAt,
+		&i.FinishedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
@@ -8,11 +8,30 @@ package db
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"time"
 )
 
+const anonymizeUserSessions = `-- name: AnonymizeUserSessions :execrows
+UPDATE session_players
+SET
+    user_id = NULL,
+    display_name = 'Deleted user'
+WHERE user_id = $1
+`
+
+// The user's past games count as a guest's from now on.
+func (q *Queries) AnonymizeUserSessions(ctx context.Context, userID sql.NullInt32) (int64, error) {
+	result, err := q.db.ExecContext(ctx, anonymizeUserSessions, userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const countUsers = `-- name: CountUsers :one
 SELECT count(*) FROM users
+WHERE deleted_at IS NULL
 `
 
 func (q *Queries) CountUsers(ctx context.Context) (int64, error) {
@@ -22,6 +41,41 @@ func (q *Queries) CountUsers(ctx context.Context) (int64, error) {
 	return count, err
 }
 
+const createLinkedUser = `-- name: CreateLinkedUser :one
+INSERT INTO users (
+    name,
+    email,
+    auth_subject
+) VALUES (
+    $1, $2, $3
+) RETURNING user_id, name, email, created_at, updated_at, deleted_at, role, avatar_url, preferences, custom_name, auth_subject
+`
+
+type CreateLinkedUserParams struct {
+	Name        string
+	Email       string
+	AuthSubject sql.NullString
+}
+
+func (q *Queries) CreateLinkedUser(ctx context.Context, arg CreateLinkedUserParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, createLinkedUser, arg.Name, arg.Email, arg.AuthSubject)
+	var i User
+	err := row.Scan(
+		&i.UserID,
+		&i.Name,
+		&i.Email,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+		&i.Role,
+		&i.AvatarUrl,
+		&i.Preferences,
+		&i.CustomName,
+		&i.AuthSubject,
+	)
+	return i, err
+}
+
 const createUser = `-- name: CreateUser :one
 INSERT INTO users (
     name,
@@ -30,7 +84,7 @@ INSERT INTO users (
     updated_at
 ) VALUES (
     $1, $2, $3, $4
-) RETURNING user_id, name, email, created_at, updated_at, deleted_at
+) RETURNING user_id, name, email, created_at, updated_at, deleted_at, role, avatar_url, preferences, custom_name, auth_subject
 `
 
 type CreateUserParams struct {
@@ -55,6 +109,41 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.DeletedAt,
+		&i.Role,
+		&i.AvatarUrl,
+		&i.Preferences,
+		&i.CustomName,
+		&i.AuthSubject,
+	)
+	return i, err
+}
+
+const createUserIdentity = `-- name: CreateUserIdentity :one
+INSERT INTO user_identities (
+    user_id,
+    auth_subject,
+    email
+) VALUES (
+    $1, $2, $3
+) RETURNING identity_id, user_id, auth_subject, email, created_at, last_seen_at
+`
+
+type CreateUserIdentityParams struct {
+	UserID      int32
+	AuthSubject string
+	Email       string
+}
+
+func (q *Queries) CreateUserIdentity(ctx context.Context, arg CreateUserIdentityParams) (UserIdentity, error) {
+	row := q.db.QueryRowContext(ctx, createUserIdentity, arg.UserID, arg.AuthSubject, arg.Email)
+	var i UserIdentity
+	err := row.Scan(
+		&i.IdentityID,
+		&i.UserID,
+		&i.AuthSubject,
+		&i.Email,
+		&i.CreatedAt,
+		&i.LastSeenAt,
 	)
 	return i, err
 }
@@ -65,7 +154,7 @@ INSERT INTO users (
     email
 ) VALUES (
     $1, $2
-) RETURNING user_id, name, email, created_at, updated_at, deleted_at
+) RETURNING user_id, name, email, created_at, updated_at, deleted_at, role, avatar_url, preferences, custom_name, auth_subject
 `
 
 type CreateUserMinimalParams struct {
@@ -83,6 +172,11 @@ func (q *Queries) CreateUserMinimal(ctx context.Context, arg CreateUserMinimalPa
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.DeletedAt,
+		&i.Role,
+		&i.AvatarUrl,
+		&i.Preferences,
+		&i.CustomName,
+		&i.AuthSubject,
 	)
 	return i, err
 }
@@ -97,9 +191,109 @@ func (q *Queries) DeleteUser(ctx context.Context, userID int32) error {
 	return err
 }
 
+const deleteUserAchievementCounters = `-- name: DeleteUserAchievementCounters :exec
+DELETE FROM user_achievement_counters
+WHERE user_id = $1
+`
+
+func (q *Queries) DeleteUserAchievementCounters(ctx context.Context, userID int32) error {
+	_, err := q.db.ExecContext(ctx, deleteUserAchievementCounters, userID)
+	return err
+}
+
+const deleteUserAchievements = `-- name: DeleteUserAchievements :exec
+DELETE FROM user_achievements
+WHERE user_id = $1
+`
+
+func (q *Queries) DeleteUserAchievements(ctx context.Context, userID int32) error {
+	_, err := q.db.ExecContext(ctx, deleteUserAchievements, userID)
+	return err
+}
+
+const deleteUserIdentities = `-- name: DeleteUserIdentities :exec
+DELETE FROM user_identities
+WHERE user_id = $1
+`
+
+func (q *Queries) DeleteUserIdentities(ctx context.Context, userID int32) error {
+	_, err := q.db.ExecContext(ctx, deleteUserIdentities, userID)
+	return err
+}
+
+const deleteUserIdentity = `-- name: DeleteUserIdentity :execrows
+DELETE FROM user_identities
+WHERE user_id = $1 AND auth_subject = $2
+`
+
+type DeleteUserIdentityParams struct {
+	UserID      int32
+	AuthSubject string
+}
+
+func (q *Queries) DeleteUserIdentity(ctx context.Context, arg DeleteUserIdentityParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteUserIdentity, arg.UserID, arg.AuthSubject)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getUnlinkedUserByEmail = `-- name: GetUnlinkedUserByEmail :one
+SELECT user_id, name, email, created_at, updated_at, deleted_at, role, avatar_url, preferences, custom_name, auth_subject FROM users
+WHERE email = $1 AND auth_subject IS NULL AND deleted_at IS NULL
+LIMIT 1
+`
+
+// Users who haven't signed in since identities were introduced.
+func (q *Queries) GetUnlinkedUserByEmail(ctx context.Context, email string) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUnlinkedUserByEmail, email)
+	var i User
+	err := row.Scan(
+		&i.UserID,
+		&i.Name,
+		&i.Email,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+		&i.Role,
+		&i.AvatarUrl,
+		&i.Preferences,
+		&i.CustomName,
+		&i.AuthSubject,
+	)
+	return i, err
+}
+
+const getUserByAuthSubject = `-- name: GetUserByAuthSubject :one
+SELECT user_id, name, email, created_at, updated_at, deleted_at, role, avatar_url, preferences, custom_name, auth_subject FROM users
+WHERE user_id = (SELECT i.user_id FROM user_identities i WHERE i.auth_subject = $1)
+    AND deleted_at IS NULL
+LIMIT 1
+`
+
+func (q *Queries) GetUserByAuthSubject(ctx context.Context, authSubject string) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByAuthSubject, authSubject)
+	var i User
+	err := row.Scan(
+		&i.UserID,
+		&i.Name,
+		&i.Email,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+		&i.Role,
+		&i.AvatarUrl,
+		&i.Preferences,
+		&i.CustomName,
+		&i.AuthSubject,
+	)
+	return i, err
+}
+
 const getUserByEmail = `-- name: GetUserByEmail :one
-SELECT user_id, name, email, created_at, updated_at, deleted_at FROM users
-WHERE email = $1
+SELECT user_id, name, email, created_at, updated_at, deleted_at, role, avatar_url, preferences, custom_name, auth_subject FROM users
+WHERE email = $1 AND deleted_at IS NULL
 LIMIT 1
 `
 
@@ -113,13 +307,19 @@ func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.DeletedAt,
+		&i.Role,
+		&i.AvatarUrl,
+		&i.Preferences,
+		&i.CustomName,
+		&i.AuthSubject,
 	)
 	return i, err
 }
 
 const getUserById = `-- name: GetUserById :one
-SELECT user_id, name, email, created_at, updated_at, deleted_at FROM users
-WHERE user_id = $1 LIMIT 1
+SELECT user_id, name, email, created_at, updated_at, deleted_at, role, avatar_url, preferences, custom_name, auth_subject FROM users
+WHERE user_id = $1 AND deleted_at IS NULL
+LIMIT 1
 `
 
 func (q *Queries) GetUserById(ctx context.Context, userID int32) (User, error) {
@@ -132,18 +332,182 @@ func (q *Queries) GetUserById(ctx context.Context, userID int32) (User, error) {
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.DeletedAt,
+		&i.Role,
+		&i.AvatarUrl,
+		&i.Preferences,
+		&i.CustomName,
+		&i.AuthSubject,
+	)
+	return i, err
+}
+
+const listUserIdentities = `-- name: ListUserIdentities :many
+SELECT identity_id, user_id, auth_subject, email, created_at, last_seen_at FROM user_identities
+WHERE user_id = $1
+ORDER BY created_at, identity_id
+`
+
+func (q *Queries) ListUserIdentities(ctx context.Context, userID int32) ([]UserIdentity, error) {
+	rows, err := q.db.QueryContext(ctx, listUserIdentities, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []UserIdentity
+	for rows.Next() {
+		var i UserIdentity
+		if err := rows.Scan(
+			&i.IdentityID,
+			&i.UserID,
+			&i.AuthSubject,
+			&i.Email,
+			&i.CreatedAt,
+			&i.LastSeenAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const resetUserAuthSubject = `-- name: ResetUserAuthSubject :exec
+UPDATE users
+SET auth_subject = (
+    SELECT i.auth_subject FROM user_identities i
+    WHERE i.user_id = users.user_id
+    ORDER BY i.created_at, i.identity_id
+    LIMIT 1
+)
+WHERE users.user_id = $1
+`
+
+// Falls back to the oldest identity still linked, or NULL if none is.
+func (q *Queries) ResetUserAuthSubject(ctx context.Context, userID int32) error {
+	_, err := q.db.ExecContext(ctx, resetUserAuthSubject, userID)
+	return err
+}
+
+const setUserAuthSubject = `-- name: SetUserAuthSubject :exec
+UPDATE users
+SET auth_subject = $2
+WHERE user_id = $1 AND auth_subject IS NULL
+`
+
+type SetUserAuthSubjectParams struct {
+	UserID      int32
+	AuthSubject sql.NullString
+}
+
+// Only the first identity linked becomes the user's subject.
+func (q *Queries) SetUserAuthSubject(ctx context.Context, arg SetUserAuthSubjectParams) error {
+	_, err := q.db.ExecContext(ctx, setUserAuthSubject, arg.UserID, arg.AuthSubject)
+	return err
+}
+
+const setUserRole = `-- name: SetUserRole :one
+UPDATE users
+SET
+    role = $2,
+    updated_at = NOW()
+WHERE user_id = $1 AND deleted_at IS NULL
+RETURNING user_id, name, email, created_at, updated_at, deleted_at, role, avatar_url, preferences, custom_name, auth_subject
+`
+
+type SetUserRoleParams struct {
+	UserID int32
+	Role   string
+}
+
+func (q *Queries) SetUserRole(ctx context.Context, arg SetUserRoleParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, setUserRole, arg.UserID, arg.Role)
+	var i User
+	err := row.Scan(
+		&i.UserID,
+		&i.Name,
+		&i.Email,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+		&i.Role,
+		&i.AvatarUrl,
+		&i.Preferences,
+		&i.CustomName,
+		&i.AuthSubject,
 	)
 	return i, err
 }
 
+const softDeleteUser = `-- name: SoftDeleteUser :one
+UPDATE users
+SET
+    name = 'Deleted user',
+    email = 'deleted-' || user_id || '@users.invalid',
+    avatar_url = NULL,
+    preferences = '{}',
+    custom_name = false,
+    role = 'player',
+    auth_subject = NULL,
+    deleted_at = NOW(),
+    updated_at = NOW()
+WHERE user_id = $1 AND deleted_at IS NULL
+RETURNING user_id, name, email, created_at, updated_at, deleted_at, role, avatar_url, preferences, custom_name, auth_subject
+`
+
+// Keeps the row, so quizzes and assignments the user wrote keep their creator,
+// but drops everything that identifies them. The email is freed so signing in
+// again starts a new account.
+func (q *Queries) SoftDeleteUser(ctx context.Context, userID int32) (User, error) {
+	row := q.db.QueryRowContext(ctx, softDeleteUser, userID)
+	var i User
+	err := row.Scan(
+		&i.UserID,
+		&i.Name,
+		&i.Email,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+		&i.Role,
+		&i.AvatarUrl,
+		&i.Preferences,
+		&i.CustomName,
+		&i.AuthSubject,
+	)
+	return i, err
+}
+
+const touchUserIdentity = `-- name: TouchUserIdentity :exec
+UPDATE user_identities
+SET
+    email = $2,
+    last_seen_at = NOW()
+WHERE auth_subject = $1
+`
+
+type TouchUserIdentityParams struct {
+	AuthSubject string
+	Email       string
+}
+
+func (q *Queries) TouchUserIdentity(ctx context.Context, arg TouchUserIdentityParams) error {
+	_, err := q.db.ExecContext(ctx, touchUserIdentity, arg.AuthSubject, arg.Email)
+	return err
+}
+
 const updateUser = `-- name: UpdateUser :one
 UPDATE users
 SET
     name = COALESCE($2, name),
     email = COALESCE($3, email),
     updated_at = NOW()
-WHERE user_id = $1
-RETURNING user_id, name, email, created_at, updated_at, deleted_at
+WHERE user_id = $1 AND deleted_at IS NULL
+RETURNING user_id, name, email, created_at, updated_at, deleted_at, role, avatar_url, preferences, custom_name, auth_subject
 `
 
 type UpdateUserParams struct {
@@ -162,6 +526,56 @@ func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, e
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.DeletedAt,
+		&i.Role,
+		&i.AvatarUrl,
+		&i.Preferences,
+		&i.CustomName,
+		&i.AuthSubject,
+	)
+	return i, err
+}
+
+const updateUserProfile = `-- name: UpdateUserProfile :one
+UPDATE users
+SET
+    name = $2,
+    custom_name = $3,
+    avatar_url = $4,
+    preferences = $5,
+    updated_at = NOW()
+WHERE user_id = $1 AND deleted_at IS NULL
+RETURNING user_id, name, email, created_at, updated_at, deleted_at, role, avatar_url, preferences, custom_name, auth_subject
+`
+
+type UpdateUserProfileParams struct {
+	UserID      int32
+	Name        string
+	CustomName  bool
+	AvatarUrl   sql.NullString
+	Preferences json.RawMessage
+}
+
+func (q *Queries) UpdateUserProfile(ctx context.Context, arg UpdateUserProfileParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateUserProfile,
+		arg.UserID,
+		arg.Name,
+		arg.CustomName,
+		arg.AvatarUrl,
+		arg.Preferences,
+	)
+	var i User
+	err := row.Scan(
+		&i.UserID,
+		&i.Name,
+		&i.Email,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+		&i.Role,
+		&i.AvatarUrl,
+		&i.Preferences,
+		&i.CustomName,
+		&i.AuthSubject,
 	)
 	return i, err
 }
@@ -15,39 +15,76 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/answers": {
-            "post": {
-                "description": "Create a new answer with the given details",
-                "consumes": [
+        "/admin/rooms": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Every open room with its participants, their roles and the state of its game. Requires the admin role and scope.",
+                "produces": [
                     "application/json"
                 ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "List live rooms",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/apimodels.AdminRoomApiModel"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Missing the admin role or scope",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/rooms/{code}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "The room's participants and roles, and its game's state, current section and question and scores. Requires the admin role and scope.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "answers"
+                    "admin"
                 ],
-                "summary": "Create a new answer",
+                "summary": "Inspect a live room",
                 "parameters": [
                     {
-                        "description": "Answer details",
-                        "name": "answer",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/handlers.AnswerApiModel"
-                        }
+                        "type": "string",
+                        "description": "Room code",
+                        "name": "code",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/db.Answer"
+                            "$ref": "#/definitions/apimodels.AdminRoomApiModel"
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "403": {
+                        "description": "Missing the admin role or scope",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -55,8 +92,8 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "404": {
+                        "description": "Room not found",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -65,36 +102,33 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/answers/{id}": {
-            "get": {
-                "description": "Get an answer by its ID",
-                "produces": [
-                    "application/json"
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
                 ],
+                "description": "Ends the room's game and sends everyone in it away, as if its creator had left. Requires the admin role and scope.",
                 "tags": [
-                    "answers"
+                    "admin"
                 ],
-                "summary": "Get an answer by ID",
+                "summary": "Close a room",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "Answer ID",
-                        "name": "id",
+                        "type": "string",
+                        "description": "Room code",
+                        "name": "code",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/db.Answer"
-                        }
+                    "204": {
+                        "description": "No Content"
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "403": {
+                        "description": "Missing the admin role or scope",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -102,8 +136,8 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "404": {
+                        "description": "Room not found",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -114,39 +148,39 @@ const docTemplate = `{
                 }
             }
         },
-        "/questions": {
+        "/admin/rooms/{code}/advance": {
             "post": {
-                "description": "Create a new question with the given details",
-                "consumes": [
-                    "application/json"
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
                 ],
+                "description": "Does what the host's next button would, or closes the open question early. Requires the admin role and scope.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "questions"
+                    "admin"
                 ],
-                "summary": "Create a new question",
+                "summary": "Force a game forward",
                 "parameters": [
                     {
-                        "description": "Question details",
-                        "name": "question",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/handlers.QuestionApiModel"
-                        }
+                        "type": "string",
+                        "description": "Room code",
+                        "name": "code",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/db.Question"
+                            "$ref": "#/definitions/apimodels.AdminRoomApiModel"
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "403": {
+                        "description": "Missing the admin role or scope",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -154,8 +188,17 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "404": {
+                        "description": "Room or game not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "The game can't move on from its state",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -166,21 +209,26 @@ const docTemplate = `{
                 }
             }
         },
-        "/questions/{id}": {
-            "get": {
-                "description": "Get a question by its ID",
+        "/admin/rooms/{code}/end": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Finishes the game now and shows everyone the leaderboard as it stands. Requires the admin role and scope.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "questions"
+                    "admin"
                 ],
-                "summary": "Get a question by ID",
+                "summary": "Force a game to end",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "Question ID",
-                        "name": "id",
+                        "type": "string",
+                        "description": "Room code",
+                        "name": "code",
                         "in": "path",
                         "required": true
                     }
@@ -189,11 +237,11 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/db.Question"
+                            "$ref": "#/definitions/apimodels.AdminRoomApiModel"
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "403": {
+                        "description": "Missing the admin role or scope",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -201,8 +249,17 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "404": {
+                        "description": "Room or game not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "The game is already over",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -213,14 +270,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/quizzes": {
-            "post": {
+        "/admin/users/{id}/role": {
+            "put": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Create a quiz, its questions, and their answers in a single transaction.",
+                "description": "Players can play quizzes, teachers can also write them and set assignments, admins can do everything. Requires the admin role and scope.",
                 "consumes": [
                     "application/json"
                 ],
@@ -228,29 +285,36 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "quizzes"
+                    "admin"
                 ],
-                "summary": "Create a full quiz with questions and answers",
+                "summary": "Change a user's role",
                 "parameters": [
                     {
-                        "description": "Full quiz details including questions and answers",
-                        "name": "quiz",
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "One of admin, teacher or player",
+                        "name": "role",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/apimodels.QuizApiModel"
+                            "$ref": "#/definitions/handlers.SetUserRoleRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "The fully created quiz structure\" // \u003c-- FIX: Use qualified name",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/apimodels.QuizApiModel"
+                            "$ref": "#/definitions/db.User"
                         }
                     },
                     "400": {
-                        "description": "Invalid input",
+                        "description": "Bad Request",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -258,8 +322,8 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "401": {
-                        "description": "Unauthorized",
+                    "403": {
+                        "description": "Missing the admin role or scope",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -267,8 +331,8 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "403": {
-                        "description": "Forbidden (Creator ID mismatch)",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -277,7 +341,7 @@ const docTemplate = `{
                         }
                     },
                     "500": {
-                        "description": "Internal server error",
+                        "description": "Internal Server Error",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -288,14 +352,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/quizzes/basic": {
+        "/answers": {
             "post": {
-                "security": [
-                    {
-                        "BearerAuth": []
-                    }
-                ],
-                "description": "Create only the quiz entry without questions/answers. Consider using POST /quizzes instead.",
+                "description": "Create a new answer with the given details",
                 "consumes": [
                     "application/json"
                 ],
@@ -303,38 +362,29 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "quizzes"
+                    "answers"
                 ],
-                "summary": "Create a new basic quiz entry (DEPRECATED? Use POST /quizzes for full creation)",
+                "summary": "Create a new answer",
                 "parameters": [
                     {
-                        "description": "Basic Quiz details (Title, CreatorID)",
-                        "name": "quiz",
+                        "description": "Answer details",
+                        "name": "answer",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/apimodels.QuizApiModel"
+                            "$ref": "#/definitions/handlers.AnswerApiModel"
                         }
                     }
                 ],
                 "responses": {
                     "201": {
-                        "description": "The created basic quiz object",
+                        "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/db.Quiz"
+                            "$ref": "#/definitions/db.Answer"
                         }
                     },
                     "400": {
-                        "description": "Invalid input",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
-                        }
-                    },
-                    "401": {
-                        "description": "Unauthorized",
+                        "description": "Bad Request",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -343,7 +393,7 @@ const docTemplate = `{
                         }
                     },
                     "500": {
-                        "description": "Internal server error",
+                        "description": "Internal Server Error",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -354,25 +404,20 @@ const docTemplate = `{
                 }
             }
         },
-        "/quizzes/{id}/basic": {
+        "/answers/{id}": {
             "get": {
-                "security": [
-                    {
-                        "BearerAuth": []
-                    }
-                ],
-                "description": "Get only the quiz details without questions/answers. Consider using GET /quizzes/{id}/full instead.",
+                "description": "Get an answer by its ID",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "quizzes"
+                    "answers"
                 ],
-                "summary": "Get basic quiz details by ID (DEPRECATED? Use GET /quizzes/{id}/full)",
+                "summary": "Get an answer by ID",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Quiz ID",
+                        "description": "Answer ID",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -380,22 +425,13 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "Basic quiz object",
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/db.Quiz"
+                            "$ref": "#/definitions/db.Answer"
                         }
                     },
                     "400": {
-                        "description": "Invalid ID",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
-                        }
-                    },
-                    "401": {
-                        "description": "Unauthorized",
+                        "description": "Bad Request",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -403,17 +439,8 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "404": {
-                        "description": "Quiz not found",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
-                        }
-                    },
-                    "500": {
-                        "description": "Internal error",
+                    "500": {
+                        "description": "Internal Server Error",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -424,39 +451,49 @@ const docTemplate = `{
                 }
             }
         },
-        "/quizzes/{id}/full": {
-            "get": {
+        "/answers/{id}/image": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Get quiz details including all questions and their answers",
+                "description": "Upload a PNG, JPEG, GIF or WebP image and attach it to the answer option. Replaces and deletes any existing image. Only the quiz's creator can do this.",
+                "consumes": [
+                    "multipart/form-data"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "quizzes"
+                    "media"
                 ],
-                "summary": "Get full quiz details by ID",
+                "summary": "Attach an image to an answer",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Quiz ID",
+                        "description": "Answer ID",
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "Image file",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "Full quiz structure\" // \u003c-- FIX: Use qualified name apimodels.QuizApiModel",
+                    "201": {
+                        "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/apimodels.QuizApiModel"
+                            "$ref": "#/definitions/handlers.MediaResponse"
                         }
                     },
                     "400": {
-                        "description": "Invalid ID",
+                        "description": "Bad Request",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -464,8 +501,8 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "401": {
-                        "description": "Unauthorized",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -474,7 +511,25 @@ const docTemplate = `{
                         }
                     },
                     "404": {
-                        "description": "Quiz not found",
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -483,7 +538,7 @@ const docTemplate = `{
                         }
                     },
                     "500": {
-                        "description": "Internal error",
+                        "description": "Internal Server Error",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -494,9 +549,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/users": {
+        "/assignments": {
             "post": {
-                "description": "Create a new user with the given details",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Creates a shareable assignment link. Players can start the quiz between opens_at and closes_at and progress through it on their own.",
                 "consumes": [
                     "application/json"
                 ],
@@ -504,17 +564,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "assignments"
                 ],
-                "summary": "Create a new user",
+                "summary": "Assign a quiz for self-paced play",
                 "parameters": [
                     {
-                        "description": "User details",
-                        "name": "user",
+                        "description": "Assignment details",
+                        "name": "assignment",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/handlers.CreateUserRequest"
+                            "$ref": "#/definitions/handlers.CreateAssignmentRequest"
                         }
                     }
                 ],
@@ -522,7 +582,7 @@ const docTemplate = `{
                     "201": {
                         "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/db.User"
+                            "$ref": "#/definitions/handlers.AssignmentResponse"
                         }
                     },
                     "400": {
@@ -534,6 +594,15 @@ const docTemplate = `{
                             }
                         }
                     },
+                    "404": {
+                        "description": "Quiz not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -546,14 +615,145 @@ const docTemplate = `{
                 }
             }
         },
-        "/users/sync": {
-            "post": {
+        "/assignments/{code}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "assignments"
+                ],
+                "summary": "Get an assignment by its share code",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Assignment share code",
+                        "name": "code",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.AssignmentResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/assignments/{code}/results": {
+            "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Creates a user if they don't exist based on email, or updates existing user's name.",
+                "description": "Every recorded attempt, best score first. Attempts abandoned part-way are included with completed=false. Only the teacher who set the assignment or created its quiz can see them.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "assignments"
+                ],
+                "summary": "List the results of an assignment",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Assignment share code",
+                        "name": "code",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/handlers.AssignmentResultEntry"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/asyncapi.json": {
+            "get": {
+                "description": "Returns the AsyncAPI document describing the messages of the /ws endpoint",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "websocket"
+                ],
+                "summary": "Websocket protocol",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/dev/token": {
+            "post": {
+                "description": "Signs an access token for any user and scopes. Only exists when the server runs with AUTH_PROVIDER=dev, never in production.",
                 "consumes": [
                     "application/json"
                 ],
@@ -561,35 +761,29 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "dev"
                 ],
-                "summary": "Sync Auth0 user with backend database via Email",
+                "summary": "Get a dev access token",
                 "parameters": [
                     {
-                        "description": "User details (name, email) from Auth0",
-                        "name": "user",
+                        "description": "Who the token is for",
+                        "name": "claims",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/handlers.SyncUserRequest"
+                            "$ref": "#/definitions/handlers.DevTokenRequest"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "User found and potentially updated",
-                        "schema": {
-                            "$ref": "#/definitions/db.User"
-                        }
-                    },
-                    "201": {
-                        "description": "User created",
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/db.User"
+                            "$ref": "#/definitions/handlers.DevTokenResponse"
                         }
                     },
                     "400": {
-                        "description": "Invalid input",
+                        "description": "Bad Request",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -597,17 +791,31 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "401": {
-                        "description": "Unauthorized (JWT missing/invalid)",
+                    "500": {
+                        "description": "Internal Server Error",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
                                 "type": "string"
                             }
                         }
-                    },
-                    "500": {
-                        "description": "Internal server error",
+                    }
+                }
+            }
+        },
+        "/healthz": {
+            "get": {
+                "description": "Succeeds as long as the server is up and serving requests. It checks no dependencies, restart the server only when this fails.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Liveness probe",
+                "responses": {
+                    "200": {
+                        "description": "OK",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -618,30 +826,44 @@ const docTemplate = `{
                 }
             }
         },
-        "/users/{id}": {
+        "/leaderboards": {
             "get": {
-                "description": "Get a user by their ID",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Best scores across every quiz. Signed-in players appear once with their best score; how guests appear depends on the server's guest name policy. \"you\" is the requesting user's own entry.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "leaderboards"
                 ],
-                "summary": "Get a user by ID",
+                "summary": "Get the global high-score leaderboard",
                 "parameters": [
+                    {
+                        "enum": [
+                            "all",
+                            "week"
+                        ],
+                        "type": "string",
+                        "description": "all (default) or week for the past 7 days",
+                        "name": "period",
+                        "in": "query"
+                    },
                     {
                         "type": "integer",
-                        "description": "User ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Number of entries (default 10, max 100)",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/db.User"
+                            "$ref": "#/definitions/apimodels.LeaderboardApiModel"
                         }
                     },
                     "400": {
@@ -664,69 +886,2513 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "apimodels.AnswerApiModel": {
+        },
+        "/media/{key}": {
+            "get": {
+                "description": "Streams an uploaded media file from the configured storage backend.",
+                "produces": [
+                    "application/octet-stream"
+                ],
+                "tags": [
+                    "media"
+                ],
+                "summary": "Download a media file",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Storage key",
+                        "name": "key",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/questions": {
+            "post": {
+                "description": "Create a new question with the given details",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "questions"
+                ],
+                "summary": "Create a new question",
+                "parameters": [
+                    {
+                        "description": "Question details",
+                        "name": "question",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.QuestionApiModel"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/db.Question"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/questions/{id}": {
+            "get": {
+                "description": "Get a question by its ID",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "questions"
+                ],
+                "summary": "Get a question by ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Question ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/db.Question"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/questions/{id}/audio": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Upload an MP3, WAV or Ogg audio clip and attach it to the question. Replaces and deletes any existing clip. Only the quiz's creator can do this.",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "media"
+                ],
+                "summary": "Attach an audio clip to a question",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Question ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "Audio file",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.MediaResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/questions/{id}/image": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Upload a PNG, JPEG, GIF or WebP image and attach it to the question. Replaces and deletes any existing image. Only the quiz's creator can do this.",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "media"
+                ],
+                "summary": "Attach an image to a question",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Question ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "Image file",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.MediaResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/quizzes": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Create a quiz, its questions, and their answers in a single transaction.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "quizzes"
+                ],
+                "summary": "Create a full quiz with questions and answers",
+                "parameters": [
+                    {
+                        "description": "Full quiz details including questions and answers",
+                        "name": "quiz",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/apimodels.QuizApiModel"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "The fully created quiz structure\" // \u003c-- FIX: Use qualified name",
+                        "schema": {
+                            "$ref": "#/definitions/apimodels.QuizApiModel"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden (Creator ID mismatch)",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/quizzes/basic": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Create only the quiz entry without questions/answers. Consider using POST /quizzes instead.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "quizzes"
+                ],
+                "summary": "Create a new basic quiz entry (DEPRECATED? Use POST /quizzes for full creation)",
+                "parameters": [
+                    {
+                        "description": "Basic Quiz details (Title, CreatorID)",
+                        "name": "quiz",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/apimodels.QuizApiModel"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "The created basic quiz object",
+                        "schema": {
+                            "$ref": "#/definitions/db.Quiz"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden (Creator ID mismatch)",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/quizzes/{id}/analytics": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Per question: correctness rate, average response time, most common wrong option and discrimination index (top 27% vs bottom 27% of everyone who played the quiz, by score). Also the number of plays per day. Only the quiz's creator can see them. With format=csv only the per-question table is returned, as a CSV download.",
+                "produces": [
+                    "application/json",
+                    "text/csv"
+                ],
+                "tags": [
+                    "quizzes"
+                ],
+                "summary": "Get analytics for a quiz across all its sessions",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Quiz ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "json",
+                            "csv"
+                        ],
+                        "type": "string",
+                        "description": "Response format",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/apimodels.QuizAnalyticsApiModel"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid ID",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Not the quiz's creator",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Quiz not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/quizzes/{id}/basic": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get only the quiz details without questions/answers. Consider using GET /quizzes/{id}/full instead.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "quizzes"
+                ],
+                "summary": "Get basic quiz details by ID (DEPRECATED? Use GET /quizzes/{id}/full)",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Quiz ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Basic quiz object",
+                        "schema": {
+                            "$ref": "#/definitions/db.Quiz"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid ID",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Quiz not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/quizzes/{id}/full": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get quiz details including all questions and their answers",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "quizzes"
+                ],
+                "summary": "Get full quiz details by ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Quiz ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Full quiz structure\" // \u003c-- FIX: Use qualified name apimodels.QuizApiModel",
+                        "schema": {
+                            "$ref": "#/definitions/apimodels.QuizApiModel"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid ID",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Quiz not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/quizzes/{id}/leaderboard": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Best scores of games played with the quiz. Signed-in players appear once with their best score; how guests appear depends on the server's guest name policy. \"you\" is the requesting user's own entry.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "leaderboards"
+                ],
+                "summary": "Get the high-score leaderboard of a quiz",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Quiz ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "all",
+                            "week"
+                        ],
+                        "type": "string",
+                        "description": "all (default) or week for the past 7 days",
+                        "name": "period",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of entries (default 10, max 100)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/apimodels.LeaderboardApiModel"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Quiz not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/quizzes/{id}/sessions": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Live games and self-paced assignments played with the quiz, most recent first. Only the quiz's creator can list them.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "sessions"
+                ],
+                "summary": "List the recorded sessions of a quiz",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Quiz ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/apimodels.SessionApiModel"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid ID",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Not the quiz's creator",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Quiz not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/readyz": {
+            "get": {
+                "description": "Checks the database is reachable and fully migrated, every room is responsive and the server isn't running too many games, plus optional components such as the identity provider's keys. Returns 503 if a critical component is failing and \"degraded\" if only optional ones are.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Readiness probe",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/health.Report"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/health.Report"
+                        }
+                    }
+                }
+            }
+        },
+        "/room-codes": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Reservations that haven't ended, soonest first.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "room-codes"
+                ],
+                "summary": "List your room code reservations",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/handlers.RoomCodeReservationResponse"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Sets a code aside between starts_at and ends_at. Only you can open a room with it then, by sending it as room_code in create_room. Codes are 4 to 12 letters or digits, case doesn't matter.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "room-codes"
+                ],
+                "summary": "Reserve a room code for a scheduled event",
+                "parameters": [
+                    {
+                        "description": "Code and when it is reserved",
+                        "name": "reservation",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ReserveRoomCodeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.RoomCodeReservationResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Already reserved, or you hold too many reservations",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/room-codes/{code}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Frees the code for others. A room already open with it stays open.",
+                "tags": [
+                    "room-codes"
+                ],
+                "summary": "Cancel a room code reservation",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Reserved room code",
+                        "name": "code",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/sessions/{id}/results": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Player standings plus, for every question, how many players chose each option, the percentage correct, the average and fastest response time and the fastest correct player. Only the creator of the session's quiz can see them.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "sessions"
+                ],
+                "summary": "Get the results of a recorded session",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Session ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/apimodels.SessionResultsApiModel"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid ID",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Not the creator of the session's quiz",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Session not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/users/me": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "The authenticated user's name, avatar, role and display preferences.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Get your profile",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/apimodels.UserProfileApiModel"
+                        }
+                    },
+                    "403": {
+                        "description": "Not synced yet",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Removes the authenticated user's name, email, avatar, preferences and achievements. Games they played stay in other players' history and quiz analytics, as a guest's. Quizzes and assignments they wrote are kept. Signing in again starts a new account.",
+                "tags": [
+                    "users"
+                ],
+                "summary": "Delete your account",
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "403": {
+                        "description": "Not synced yet",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Changes the authenticated user's name, avatar or display preferences. Fields left out are kept. A name set here is no longer overwritten by /users/sync.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Update your profile",
+                "parameters": [
+                    {
+                        "description": "Fields to change",
+                        "name": "profile",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.UpdateProfileRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/apimodels.UserProfileApiModel"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Not synced yet",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/users/me/export": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Everything stored about the authenticated user: their profile, linked logins, every game they played with their answers, their achievements and the quizzes and assignments they wrote.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Export your data",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/apimodels.UserExportApiModel"
+                        }
+                    },
+                    "403": {
+                        "description": "Not synced yet",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/users/me/identities": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Every login that signs in as the authenticated user, e.g. a password and a Google login with the same email, which are linked automatically by /users/sync.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "List your linked logins",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/apimodels.UserIdentityApiModel"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Not synced yet",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/users/me/identities/{subject}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Stops a login from signing in as the authenticated user. The login making the request can't be unlinked.",
+                "tags": [
+                    "users"
+                ],
+                "summary": "Unlink a login",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Subject of the login, e.g. google-oauth2|1234",
+                        "name": "subject",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "403": {
+                        "description": "Not synced yet",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not linked to the user",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "The login making the request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/users/sync": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Finds the user by the token's subject and updates their name and email. A login not seen before is linked to the user with the same email if the token says it is verified, e.g. a Google login next to a password one, or creates a new user otherwise.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Sync Auth0 user with backend database",
+                "parameters": [
+                    {
+                        "description": "User details (name, email) from Auth0",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SyncUserRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "User found and potentially updated",
+                        "schema": {
+                            "$ref": "#/definitions/db.User"
+                        }
+                    },
+                    "201": {
+                        "description": "User created",
+                        "schema": {
+                            "$ref": "#/definitions/db.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized (JWT missing/invalid)",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "The user's profile. Only available to the user themselves and to admins.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Get a user by ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/apimodels.UserProfileApiModel"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}/achievements": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Badges the user has earned while signed in, oldest first. Only available to the user themselves.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "List a user's achievements",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/apimodels.UserAchievementApiModel"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}/history": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Sessions the user played while signed in, most recent first. Only available to the user themselves.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "List the games a user has played",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of sessions (default 20, max 100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of sessions to skip",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/apimodels.UserHistoryEntryApiModel"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}/stats": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Sessions played, average rank, accuracy, best streak and total points over every game the user played while signed in. Only available to the user themselves.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Get a user's personal stats",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/apimodels.UserStatsApiModel"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "apimodels.AdminGameApiModel": {
+            "type": "object",
+            "properties": {
+                "answered": {
+                    "description": "Answers to the open question",
+                    "type": "integer"
+                },
+                "host_id": {
+                    "type": "string"
+                },
+                "question": {
+                    "description": "1-based within the section, 0 before its first question",
+                    "type": "integer"
+                },
+                "questions": {
+                    "type": "integer"
+                },
+                "quiz_id": {
+                    "description": "0 for the built-in quiz",
+                    "type": "integer"
+                },
+                "quiz_title": {
+                    "type": "string"
+                },
+                "scores": {
+                    "description": "Highest first",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/apimodels.AdminPlayerScoreApiModel"
+                    }
+                },
+                "section": {
+                    "description": "1-based, 0 before the first section",
+                    "type": "integer"
+                },
+                "sections": {
+                    "type": "integer"
+                },
+                "started_at": {
+                    "type": "string"
+                },
+                "state": {
+                    "type": "string"
+                }
+            }
+        },
+        "apimodels.AdminParticipantApiModel": {
+            "type": "object",
+            "properties": {
+                "client_id": {
+                    "type": "string"
+                },
+                "joined_at": {
+                    "type": "string"
+                },
+                "role": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "description": "0 for guests",
+                    "type": "integer"
+                },
+                "user_lobby_id": {
+                    "type": "integer"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "apimodels.AdminPlayerScoreApiModel": {
+            "type": "object",
+            "properties": {
+                "client_id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "score": {
+                    "type": "integer"
+                }
+            }
+        },
+        "apimodels.AdminRoomApiModel": {
+            "type": "object",
+            "properties": {
+                "creator_id": {
+                    "type": "string"
+                },
+                "game": {
+                    "description": "nil until a quiz is started",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/apimodels.AdminGameApiModel"
+                        }
+                    ]
+                },
+                "host_id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "participants": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/apimodels.AdminParticipantApiModel"
+                    }
+                },
+                "room_code": {
+                    "type": "string"
+                },
+                "size": {
+                    "type": "integer"
+                }
+            }
+        },
+        "apimodels.AnswerApiModel": {
+            "type": "object",
+            "required": [
+                "isCorrect",
+                "text"
+            ],
+            "properties": {
+                "imageUrl": {
+                    "type": "string"
+                },
+                "isCorrect": {
+                    "type": "boolean"
+                },
+                "text": {
+                    "type": "string"
+                }
+            }
+        },
+        "apimodels.FastestAnswerApiModel": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "timeMs": {
+                    "type": "integer"
+                }
+            }
+        },
+        "apimodels.LeaderboardApiModel": {
+            "type": "object",
+            "properties": {
+                "entries": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/apimodels.LeaderboardEntryApiModel"
+                    }
+                },
+                "period": {
+                    "type": "string"
+                },
+                "quiz_id": {
+                    "type": "integer"
+                },
+                "you": {
+                    "description": "The requesting user's best entry, also when it is outside the top entries.\nnil if they have no finished games in the period.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/apimodels.LeaderboardEntryApiModel"
+                        }
+                    ]
+                }
+            }
+        },
+        "apimodels.LeaderboardEntryApiModel": {
+            "type": "object",
+            "properties": {
+                "finished_at": {
+                    "type": "string"
+                },
+                "guest": {
+                    "type": "boolean"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "quiz_id": {
+                    "type": "integer"
+                },
+                "rank": {
+                    "type": "integer"
+                },
+                "score": {
+                    "type": "integer"
+                }
+            }
+        },
+        "apimodels.PlaysPerDayApiModel": {
+            "type": "object",
+            "properties": {
+                "day": {
+                    "description": "YYYY-MM-DD in UTC",
+                    "type": "string"
+                },
+                "players": {
+                    "type": "integer"
+                },
+                "sessions": {
+                    "type": "integer"
+                }
+            }
+        },
+        "apimodels.QuestionAnalyticsApiModel": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "description": "Players who were asked the question",
+                    "type": "integer"
+                },
+                "average_time_ms": {
+                    "type": "integer"
+                },
+                "discrimination_index": {
+                    "description": "Correct rate of the top 27% of players minus that of the bottom 27%, from -1 to 1.\nnil until both groups have answered the question.",
+                    "type": "number"
+                },
+                "most_common_wrong_answer": {
+                    "$ref": "#/definitions/apimodels.WrongAnswerApiModel"
+                },
+                "percent_correct": {
+                    "type": "number"
+                },
+                "question_id": {
+                    "type": "integer"
+                },
+                "responses": {
+                    "description": "Players who answered in time",
+                    "type": "integer"
+                },
+                "text": {
+                    "type": "string"
+                }
+            }
+        },
+        "apimodels.QuestionApiModel": {
+            "type": "object",
+            "required": [
+                "text",
+                "timerValue",
+                "useTimer"
+            ],
+            "properties": {
+                "answers": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/apimodels.AnswerApiModel"
+                    }
+                },
+                "audioUrl": {
+                    "type": "string"
+                },
+                "imageUrl": {
+                    "type": "string"
+                },
+                "text": {
+                    "type": "string"
+                },
+                "timerValue": {
+                    "type": "integer"
+                },
+                "useTimer": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "apimodels.QuestionStatsApiModel": {
+            "type": "object",
+            "properties": {
+                "answerCounts": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "averageTimeMs": {
+                    "type": "integer"
+                },
+                "fastestCorrect": {
+                    "$ref": "#/definitions/apimodels.FastestAnswerApiModel"
+                },
+                "fastestTimeMs": {
+                    "type": "integer"
+                },
+                "percentCorrect": {
+                    "type": "number"
+                },
+                "players": {
+                    "type": "integer"
+                },
+                "questionId": {
+                    "type": "integer"
+                },
+                "questionIndex": {
+                    "type": "integer"
+                },
+                "responses": {
+                    "type": "integer"
+                }
+            }
+        },
+        "apimodels.QuizAnalyticsApiModel": {
+            "type": "object",
+            "properties": {
+                "plays": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/apimodels.PlaysPerDayApiModel"
+                    }
+                },
+                "questions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/apimodels.QuestionAnalyticsApiModel"
+                    }
+                },
+                "quiz_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "apimodels.QuizApiModel": {
+            "type": "object",
+            "required": [
+                "title"
+            ],
+            "properties": {
+                "creator_id": {
+                    "description": "The signed-in user when creating a quiz",
+                    "type": "integer"
+                },
+                "questions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/apimodels.QuestionApiModel"
+                    }
+                },
+                "quiz_id": {
+                    "type": "integer"
+                },
+                "title": {
+                    "type": "string"
+                }
+            }
+        },
+        "apimodels.SessionApiModel": {
+            "type": "object",
+            "properties": {
+                "assignment_id": {
+                    "type": "integer"
+                },
+                "finished_at": {
+                    "type": "string"
+                },
+                "game_code": {
+                    "type": "string"
+                },
+                "mode": {
+                    "type": "string"
+                },
+                "quiz_id": {
+                    "type": "integer"
+                },
+                "session_id": {
+                    "type": "integer"
+                },
+                "started_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "apimodels.SessionPlayerApiModel": {
+            "type": "object",
+            "properties": {
+                "correct_answers": {
+                    "type": "integer"
+                },
+                "finished_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "questions_seen": {
+                    "type": "integer"
+                },
+                "rank": {
+                    "type": "integer"
+                },
+                "score": {
+                    "type": "integer"
+                },
+                "started_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "apimodels.SessionResultsApiModel": {
+            "type": "object",
+            "properties": {
+                "players": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/apimodels.SessionPlayerApiModel"
+                    }
+                },
+                "questions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/apimodels.QuestionStatsApiModel"
+                    }
+                },
+                "session": {
+                    "$ref": "#/definitions/apimodels.SessionApiModel"
+                }
+            }
+        },
+        "apimodels.UserAchievementApiModel": {
+            "type": "object",
+            "properties": {
+                "awarded_at": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "apimodels.UserExportAchievementApiModel": {
+            "type": "object",
+            "properties": {
+                "awarded_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                }
+            }
+        },
+        "apimodels.UserExportAnswerApiModel": {
+            "type": "object",
+            "properties": {
+                "answer_index": {
+                    "description": "nil if the time ran out",
+                    "type": "integer"
+                },
+                "answered_at": {
+                    "type": "string"
+                },
+                "correct": {
+                    "type": "boolean"
+                },
+                "points": {
+                    "type": "integer"
+                },
+                "question_id": {
+                    "type": "integer"
+                },
+                "question_index": {
+                    "type": "integer"
+                },
+                "time_taken_ms": {
+                    "type": "integer"
+                }
+            }
+        },
+        "apimodels.UserExportApiModel": {
+            "type": "object",
+            "properties": {
+                "achievement_counters": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                },
+                "achievements": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/apimodels.UserExportAchievementApiModel"
+                    }
+                },
+                "assignments": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/apimodels.UserExportAssignmentApiModel"
+                    }
+                },
+                "exported_at": {
+                    "type": "string"
+                },
+                "identities": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/apimodels.UserIdentityApiModel"
+                    }
+                },
+                "profile": {
+                    "$ref": "#/definitions/apimodels.UserProfileApiModel"
+                },
+                "quizzes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/apimodels.UserExportQuizApiModel"
+                    }
+                },
+                "sessions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/apimodels.UserExportSessionApiModel"
+                    }
+                }
+            }
+        },
+        "apimodels.UserExportAssignmentApiModel": {
+            "type": "object",
+            "properties": {
+                "assignment_id": {
+                    "type": "integer"
+                },
+                "closes_at": {
+                    "type": "string"
+                },
+                "code": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "opens_at": {
+                    "type": "string"
+                },
+                "quiz_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "apimodels.UserExportQuizApiModel": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "private": {
+                    "type": "boolean"
+                },
+                "quiz_id": {
+                    "type": "integer"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "apimodels.UserExportSessionApiModel": {
+            "type": "object",
+            "properties": {
+                "answers": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/apimodels.UserExportAnswerApiModel"
+                    }
+                },
+                "display_name": {
+                    "type": "string"
+                },
+                "finished_at": {
+                    "type": "string"
+                },
+                "game_code": {
+                    "type": "string"
+                },
+                "mode": {
+                    "type": "string"
+                },
+                "player_ref": {
+                    "type": "string"
+                },
+                "quiz_id": {
+                    "type": "integer"
+                },
+                "quiz_title": {
+                    "type": "string"
+                },
+                "rank": {
+                    "type": "integer"
+                },
+                "score": {
+                    "type": "integer"
+                },
+                "session_id": {
+                    "type": "integer"
+                },
+                "started_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "apimodels.UserHistoryEntryApiModel": {
+            "type": "object",
+            "properties": {
+                "correct_answers": {
+                    "type": "integer"
+                },
+                "finished_at": {
+                    "type": "string"
+                },
+                "game_code": {
+                    "type": "string"
+                },
+                "mode": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "players": {
+                    "type": "integer"
+                },
+                "questions_seen": {
+                    "type": "integer"
+                },
+                "quiz_id": {
+                    "type": "integer"
+                },
+                "quiz_title": {
+                    "type": "string"
+                },
+                "rank": {
+                    "type": "integer"
+                },
+                "score": {
+                    "type": "integer"
+                },
+                "session_id": {
+                    "type": "integer"
+                },
+                "started_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "apimodels.UserIdentityApiModel": {
             "type": "object",
-            "required": [
-                "isCorrect",
-                "text"
-            ],
             "properties": {
-                "isCorrect": {
+                "created_at": {
+                    "type": "string"
+                },
+                "current": {
+                    "description": "The login making the request",
                     "type": "boolean"
                 },
-                "text": {
+                "email": {
+                    "type": "string"
+                },
+                "last_seen_at": {
                     "type": "string"
+                },
+                "provider": {
+                    "type": "string",
+                    "example": "google-oauth2"
+                },
+                "subject": {
+                    "type": "string",
+                    "example": "google-oauth2|1234"
                 }
             }
         },
-        "apimodels.QuestionApiModel": {
+        "apimodels.UserPreferencesApiModel": {
             "type": "object",
-            "required": [
-                "text",
-                "timerValue",
-                "useTimer"
-            ],
             "properties": {
-                "answers": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/apimodels.AnswerApiModel"
-                    }
+                "language": {
+                    "description": "BCP 47 tag",
+                    "type": "string",
+                    "example": "en-GB"
                 },
-                "text": {
+                "reduced_motion": {
+                    "type": "boolean"
+                },
+                "theme": {
+                    "description": "system, light or dark",
+                    "type": "string",
+                    "example": "dark"
+                }
+            }
+        },
+        "apimodels.UserProfileApiModel": {
+            "type": "object",
+            "properties": {
+                "avatar_url": {
                     "type": "string"
                 },
-                "timerValue": {
-                    "type": "integer"
+                "created_at": {
+                    "type": "string"
                 },
-                "useTimer": {
-                    "type": "boolean"
+                "email": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "preferences": {
+                    "$ref": "#/definitions/apimodels.UserPreferencesApiModel"
+                },
+                "role": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
                 }
             }
         },
-        "apimodels.QuizApiModel": {
+        "apimodels.UserStatsApiModel": {
             "type": "object",
-            "required": [
-                "creator_id",
-                "title"
-            ],
             "properties": {
-                "creator_id": {
+                "accuracy": {
+                    "description": "Percentage of questions answered correctly",
+                    "type": "number"
+                },
+                "average_rank": {
+                    "description": "nil until the user has finished a ranked game",
+                    "type": "number"
+                },
+                "best_streak": {
+                    "description": "Most consecutive correct answers in one game",
                     "type": "integer"
                 },
-                "questions": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/apimodels.QuestionApiModel"
-                    }
+                "sessions_played": {
+                    "type": "integer"
                 },
-                "quiz_id": {
+                "total_points": {
+                    "type": "integer"
+                }
+            }
+        },
+        "apimodels.WrongAnswerApiModel": {
+            "type": "object",
+            "properties": {
+                "answer_index": {
                     "type": "integer"
                 },
-                "title": {
+                "players": {
+                    "type": "integer"
+                },
+                "text": {
                     "type": "string"
                 }
             }
@@ -743,6 +3409,9 @@ const docTemplate = `{
                 "description": {
                     "type": "string"
                 },
+                "imageID": {
+                    "$ref": "#/definitions/sql.NullInt32"
+                },
                 "isCorrect": {
                     "type": "boolean"
                 },
@@ -757,12 +3426,18 @@ const docTemplate = `{
         "db.Question": {
             "type": "object",
             "properties": {
+                "audioID": {
+                    "$ref": "#/definitions/sql.NullInt32"
+                },
                 "createdAt": {
                     "type": "string"
                 },
                 "description": {
                     "type": "string"
                 },
+                "imageID": {
+                    "$ref": "#/definitions/sql.NullInt32"
+                },
                 "quesID": {
                     "type": "integer"
                 },
@@ -812,9 +3487,18 @@ const docTemplate = `{
         "db.User": {
             "type": "object",
             "properties": {
+                "authSubject": {
+                    "$ref": "#/definitions/sql.NullString"
+                },
+                "avatarUrl": {
+                    "$ref": "#/definitions/sql.NullString"
+                },
                 "createdAt": {
                     "type": "string"
                 },
+                "customName": {
+                    "type": "boolean"
+                },
                 "deletedAt": {
                     "$ref": "#/definitions/sql.NullTime"
                 },
@@ -824,6 +3508,15 @@ const docTemplate = `{
                 "name": {
                     "type": "string"
                 },
+                "preferences": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "role": {
+                    "type": "string"
+                },
                 "updatedAt": {
                     "type": "string"
                 },
@@ -851,18 +3544,139 @@ const docTemplate = `{
                 }
             }
         },
-        "handlers.CreateUserRequest": {
-            "description": "User details",
+        "handlers.AssignmentResponse": {
+            "description": "Assignment details",
+            "type": "object",
+            "properties": {
+                "assignment_id": {
+                    "type": "integer"
+                },
+                "closes_at": {
+                    "type": "string"
+                },
+                "code": {
+                    "type": "string"
+                },
+                "opens_at": {
+                    "type": "string"
+                },
+                "quiz_id": {
+                    "type": "integer"
+                },
+                "share_url": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.AssignmentResultEntry": {
+            "description": "Attempt result",
+            "type": "object",
+            "properties": {
+                "completed": {
+                    "type": "boolean"
+                },
+                "correct_answers": {
+                    "type": "integer"
+                },
+                "finished_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "questions_seen": {
+                    "type": "integer"
+                },
+                "rank": {
+                    "type": "integer"
+                },
+                "score": {
+                    "type": "integer"
+                },
+                "started_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.CreateAssignmentRequest": {
+            "description": "Assignment details. opens_at defaults to now.",
+            "type": "object",
+            "required": [
+                "closes_at",
+                "quiz_id"
+            ],
+            "properties": {
+                "closes_at": {
+                    "type": "string"
+                },
+                "opens_at": {
+                    "type": "string"
+                },
+                "quiz_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "handlers.DevTokenRequest": {
+            "description": "Claims of the dev access token",
             "type": "object",
             "required": [
-                "email",
-                "name"
+                "email"
             ],
             "properties": {
                 "email": {
+                    "type": "string",
+                    "example": "teacher@example.com"
+                },
+                "expires_in": {
+                    "description": "Seconds, defaults to an hour",
+                    "type": "integer",
+                    "example": 3600
+                },
+                "scope": {
+                    "type": "string",
+                    "example": "write:quizzes admin"
+                },
+                "sub": {
+                    "description": "Defaults to dev|\u003cemail\u003e",
+                    "type": "string",
+                    "example": "dev|teacher"
+                }
+            }
+        },
+        "handlers.DevTokenResponse": {
+            "type": "object",
+            "properties": {
+                "access_token": {
                     "type": "string"
                 },
-                "name": {
+                "expires_in": {
+                    "type": "integer",
+                    "example": 3600
+                },
+                "token_type": {
+                    "type": "string",
+                    "example": "Bearer"
+                }
+            }
+        },
+        "handlers.MediaResponse": {
+            "description": "Uploaded media details",
+            "type": "object",
+            "properties": {
+                "content_type": {
+                    "type": "string"
+                },
+                "kind": {
+                    "type": "string"
+                },
+                "media_id": {
+                    "type": "integer"
+                },
+                "size_bytes": {
+                    "type": "integer"
+                },
+                "url": {
                     "type": "string"
                 }
             }
@@ -889,6 +3703,61 @@ const docTemplate = `{
                 }
             }
         },
+        "handlers.ReserveRoomCodeRequest": {
+            "description": "Room code reservation. starts_at defaults to now.",
+            "type": "object",
+            "required": [
+                "code",
+                "ends_at"
+            ],
+            "properties": {
+                "code": {
+                    "type": "string",
+                    "example": "MATHS101"
+                },
+                "ends_at": {
+                    "type": "string"
+                },
+                "label": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "example": "Year 9 maths quiz night"
+                },
+                "starts_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.RoomCodeReservationResponse": {
+            "description": "Room code reservation",
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "ends_at": {
+                    "type": "string"
+                },
+                "label": {
+                    "type": "string"
+                },
+                "starts_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.SetUserRoleRequest": {
+            "type": "object",
+            "required": [
+                "role"
+            ],
+            "properties": {
+                "role": {
+                    "type": "string",
+                    "example": "teacher"
+                }
+            }
+        },
         "handlers.SyncUserRequest": {
             "type": "object",
             "required": [
@@ -904,6 +3773,79 @@ const docTemplate = `{
                 }
             }
         },
+        "handlers.UpdatePreferencesRequest": {
+            "type": "object",
+            "properties": {
+                "language": {
+                    "type": "string"
+                },
+                "reduced_motion": {
+                    "type": "boolean"
+                },
+                "theme": {
+                    "type": "string",
+                    "enum": [
+                        "system",
+                        "light",
+                        "dark"
+                    ]
+                }
+            }
+        },
+        "handlers.UpdateProfileRequest": {
+            "type": "object",
+            "properties": {
+                "avatar_url": {
+                    "description": "\"\" removes the avatar",
+                    "type": "string",
+                    "maxLength": 2048
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 50,
+                    "minLength": 1,
+                    "example": "Ms Smith"
+                },
+                "preferences": {
+                    "$ref": "#/definitions/handlers.UpdatePreferencesRequest"
+                }
+            }
+        },
+        "health.Component": {
+            "type": "object",
+            "properties": {
+                "critical": {
+                    "type": "boolean"
+                },
+                "details": {
+                    "type": "object",
+                    "additionalProperties": true
+                },
+                "duration": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "health.Report": {
+            "type": "object",
+            "properties": {
+                "components": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/health.Component"
+                    }
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
         "sql.NullInt32": {
             "type": "object",
             "properties": {
@@ -3,7 +3,7 @@ package initializers
 import (
 	"database/sql"
 	"fmt"
-	"log"
+	"log/slog"
 
 	_ "github.com/lib/pq" // Import the PostgreSQL driver
 )
@@ -15,7 +15,7 @@ func NewDBConnection(config *Config) (*sql.DB, error) {
 
 	// Log the DSN string (mask password)
     maskedDSN := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=disable TimeZone=Europe/London", config.DBHost, config.DBUserName, "***", config.DBName, config.DBPort)
-    log.Printf("Attempting DB connection with DSN: %s", maskedDSN) // Add this log
+    slog.Info("connecting to the database", "dsn", maskedDSN)
 
 
 	DB, err := sql.Open("postgres", dsn) // Use sql.Open
@@ -32,9 +32,9 @@ func NewDBConnection(config *Config) (*sql.DB, error) {
 	if err := enableUUIDExtension(DB); err != nil {
 		return nil, fmt.Errorf("failed to enable uuid-ossp extension: %w", err)
 	}
-	fmt.Println("? uuid-ossp extension enabled")
+	slog.Info("uuid-ossp extension enabled")
 
-	fmt.Println("? Connected Successfully to the Database")
+	slog.Info("connected to the database")
 	return DB, nil
 }
 
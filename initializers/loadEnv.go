@@ -2,8 +2,9 @@ package initializers
 
 import (
 	"github.com/spf13/viper"
-	"log"
+	"log/slog"
 	"os"
+	"time"
 )
 
 type Config struct {
@@ -18,21 +19,122 @@ type Config struct {
 
 	AuthDomain   string `mapstructure:"AUTH0_DOMAIN"`
 	AuthAudience string `mapstructure:"AUTH0_AUDIENCE"`
+
+	// Where access token keys come from: "auth0", a "static" JWKS or public key file, or "dev" to sign tokens locally.
+	AuthProvider string `mapstructure:"AUTH_PROVIDER"`
+	AuthKeyFile  string `mapstructure:"AUTH_KEY_FILE"`
+	AuthIssuer   string `mapstructure:"AUTH_ISSUER"`
+
+	// Media storage: "local" keeps files in MediaDir, "s3" uses an S3-compatible bucket.
+	StorageBackend      string `mapstructure:"STORAGE_BACKEND"`
+	MediaDir            string `mapstructure:"MEDIA_DIR"`
+	MediaBaseURL        string `mapstructure:"MEDIA_BASE_URL"`
+	MediaMaxUploadBytes int64  `mapstructure:"MEDIA_MAX_UPLOAD_BYTES"`
+	S3Endpoint          string `mapstructure:"S3_ENDPOINT"`
+	S3Bucket            string `mapstructure:"S3_BUCKET"`
+	S3Region            string `mapstructure:"S3_REGION"`
+	S3AccessKey         string `mapstructure:"S3_ACCESS_KEY"`
+	S3SecretKey         string `mapstructure:"S3_SECRET_KEY"`
+
+	// How guests appear on leaderboards: "show" their chosen name, "anonymize" as "Guest", or "hide" them.
+	LeaderboardGuestNames string `mapstructure:"LEADERBOARD_GUEST_NAMES"`
+
+	// Websocket flood protection: events per second with bursts of up to the burst size, 0 disables a limit.
+	// WSEventBudgets adds per-connection budgets for single events as "event=rate/burst" pairs separated by commas.
+	WSClientRate   float64 `mapstructure:"WS_CLIENT_RATE"`
+	WSClientBurst  int     `mapstructure:"WS_CLIENT_BURST"`
+	WSIPRate       float64 `mapstructure:"WS_IP_RATE"`
+	WSIPBurst      int     `mapstructure:"WS_IP_BURST"`
+	WSEventBudgets string  `mapstructure:"WS_EVENT_BUDGETS"`
+
+	// Client addresses, which rate limits go by, are read from X-Forwarded-For only when sent by these proxies,
+	// IPs or CIDRs separated by commas, or from the header the platform's load balancer sets, e.g. CF-Connecting-IP.
+	TrustedProxies  string `mapstructure:"TRUSTED_PROXIES"`
+	TrustedPlatform string `mapstructure:"TRUSTED_PLATFORM"`
+
+	// Messages waiting to be sent to each websocket client, and how long a client may keep more than half of them waiting.
+	WSSendQueueSize     int           `mapstructure:"WS_SEND_QUEUE_SIZE"`
+	WSSlowClientTimeout time.Duration `mapstructure:"WS_SLOW_CLIENT_TIMEOUT"`
+
+	// Logging: level is debug, info, warn or error; format is json (production) or text (local development).
+	LogLevel  string `mapstructure:"LOG_LEVEL"`
+	LogFormat string `mapstructure:"LOG_FORMAT"`
+
+	// Tracing: exporter is otlp (to OTEL_EXPORTER_OTLP_ENDPOINT), stdout or none; the ratio is the share of new traces kept.
+	TracingExporter    string  `mapstructure:"TRACING_EXPORTER"`
+	TracingSampleRatio float64 `mapstructure:"TRACING_SAMPLE_RATIO"`
+
+	// /readyz fails while more games than this are being played, 0 disables the limit.
+	ReadyMaxGames int `mapstructure:"READY_MAX_GAMES"`
+
+	// Room lifecycle: rooms close once idle or after their game ran for the maximum duration, warning everyone first.
+	// Finished games are kept for the retention so late requests still see them. 0 disables each of them.
+	RoomIdleTimeout       time.Duration `mapstructure:"ROOM_IDLE_TIMEOUT"`
+	RoomExpiryWarning     time.Duration `mapstructure:"ROOM_EXPIRY_WARNING"`
+	GameMaxDuration       time.Duration `mapstructure:"GAME_MAX_DURATION"`
+	FinishedGameRetention time.Duration `mapstructure:"FINISHED_GAME_RETENTION"`
+
+	// Generated room codes start this long and get longer, up to the maximum, as codes in use crowd them.
+	// Servers renew their codes in the database every heartbeat, and take over those of servers silent for three.
+	RoomCodeLength    int           `mapstructure:"ROOM_CODE_LENGTH"`
+	RoomCodeMaxLength int           `mapstructure:"ROOM_CODE_MAX_LENGTH"`
+	RoomCodeHeartbeat time.Duration `mapstructure:"ROOM_CODE_HEARTBEAT"`
+
+	// Each user may hold this many reservations that haven't ended, each ending at most the maximum ahead.
+	RoomCodeMaxReservations  int           `mapstructure:"ROOM_CODE_MAX_RESERVATIONS"`
+	RoomCodeReservationAhead time.Duration `mapstructure:"ROOM_CODE_RESERVATION_AHEAD"`
 }
 
 var config Config
 
+// setDefaults registers defaults for optional settings. Registering a key with
+// viper also makes AutomaticEnv pick it up during Unmarshal.
+func setDefaults() {
+	viper.SetDefault("STORAGE_BACKEND", "local")
+	viper.SetDefault("MEDIA_DIR", "./media")
+	viper.SetDefault("MEDIA_BASE_URL", "/media")
+	viper.SetDefault("MEDIA_MAX_UPLOAD_BYTES", 5*1024*1024)
+	viper.SetDefault("S3_ENDPOINT", "")
+	viper.SetDefault("S3_BUCKET", "")
+	viper.SetDefault("S3_REGION", "us-east-1")
+	viper.SetDefault("S3_ACCESS_KEY", "")
+	viper.SetDefault("S3_SECRET_KEY", "")
+	viper.SetDefault("LEADERBOARD_GUEST_NAMES", "anonymize")
+	viper.SetDefault("WS_CLIENT_RATE", 10)
+	viper.SetDefault("WS_CLIENT_BURST", 20)
+	viper.SetDefault("WS_IP_RATE", 50)
+	viper.SetDefault("WS_IP_BURST", 100)
+	viper.SetDefault("WS_EVENT_BUDGETS", "create_room=0.2/3,join_room=0.5/5,start_quiz=0.2/2,start_assignment=0.2/2,submit_answer=2/3,assignment_answer=2/3")
+	viper.SetDefault("TRUSTED_PROXIES", "")
+	viper.SetDefault("TRUSTED_PLATFORM", "")
+	viper.SetDefault("WS_SEND_QUEUE_SIZE", 256)
+	viper.SetDefault("WS_SLOW_CLIENT_TIMEOUT", 10*time.Second)
+	viper.SetDefault("LOG_LEVEL", "info")
+	viper.SetDefault("LOG_FORMAT", "json")
+	viper.SetDefault("TRACING_EXPORTER", "none")
+	viper.SetDefault("TRACING_SAMPLE_RATIO", 1.0)
+	viper.SetDefault("READY_MAX_GAMES", 500)
+	viper.SetDefault("ROOM_IDLE_TIMEOUT", 30*time.Minute)
+	viper.SetDefault("ROOM_EXPIRY_WARNING", time.Minute)
+	viper.SetDefault("GAME_MAX_DURATION", 3*time.Hour)
+	viper.SetDefault("FINISHED_GAME_RETENTION", 10*time.Minute)
+	viper.SetDefault("ROOM_CODE_LENGTH", 4)
+	viper.SetDefault("ROOM_CODE_MAX_LENGTH", 8)
+	viper.SetDefault("ROOM_CODE_HEARTBEAT", 30*time.Second)
+	viper.SetDefault("ROOM_CODE_MAX_RESERVATIONS", 10)
+	viper.SetDefault("ROOM_CODE_RESERVATION_AHEAD", 30*24*time.Hour)
+	viper.SetDefault("AUTH_PROVIDER", "auth0")
+	viper.SetDefault("AUTH_KEY_FILE", "")
+	viper.SetDefault("AUTH_ISSUER", "")
+}
+
 func LoadConfig(path string) (err error) {
 
 	// --- Direct Environment Variable Check ---
-	log.Println("--- Checking ENV VARS directly via os.Getenv ---")
 	pgUser := os.Getenv("POSTGRES_USER")
 	pgHost := os.Getenv("POSTGRES_HOST")
 	serverPort := os.Getenv("PORT")
-	log.Printf("os.Getenv(\"POSTGRES_USER\"): [%s]", pgUser)
-	log.Printf("os.Getenv(\"POSTGRES_HOST\"): [%s]", pgHost)
-	log.Printf("os.Getenv(\"PORT\"): [%s]", serverPort)
-	log.Println("--- End direct ENV VAR check ---")
+	slog.Info("environment variables", "POSTGRES_USER", pgUser, "POSTGRES_HOST", pgHost, "PORT", serverPort)
 	// --- End Direct Check ---
 
 	viper.AddConfigPath(path)
@@ -40,6 +142,7 @@ func LoadConfig(path string) (err error) {
 	viper.SetConfigName("app")
 
 	viper.AutomaticEnv()
+	setDefaults()
 
 	err = viper.ReadInConfig()
 	// Check if the error is specifically "Config File Not Found"
@@ -48,32 +151,32 @@ func LoadConfig(path string) (err error) {
 	if err != nil {
 		if _, ok := err.(viper.ConfigFileNotFoundError); ok {
 			// Config file not found; ignore error if we want to rely on ENV vars
-			log.Println("Config file 'app.env' not found, relying on environment variables.")
+			slog.Info("config file app.env not found, relying on environment variables")
 			
-			log.Println("Explicitly binding ENV VARS to Viper keys sinice AutomaticEnv doesn't work in railway?...")
+			slog.Info("binding environment variables to viper keys explicitly, since AutomaticEnv doesn't work in railway?")
 			bindEnvErr := viper.BindEnv("POSTGRES_HOST")
-			if bindEnvErr != nil { log.Printf("BindEnv POSTGRES_HOST error: %v", bindEnvErr); return }
+			if bindEnvErr != nil { slog.Error("BindEnv failed", "key", "POSTGRES_HOST", "error", bindEnvErr); return }
 			bindEnvErr = viper.BindEnv("POSTGRES_USER")
-			if bindEnvErr != nil { log.Printf("BindEnv POSTGRES_USER error: %v", bindEnvErr); return }
+			if bindEnvErr != nil { slog.Error("BindEnv failed", "key", "POSTGRES_USER", "error", bindEnvErr); return }
 			bindEnvErr = viper.BindEnv("POSTGRES_PASSWORD")
-			if bindEnvErr != nil { log.Printf("BindEnv POSTGRES_PASSWORD error: %v", bindEnvErr); return }
+			if bindEnvErr != nil { slog.Error("BindEnv failed", "key", "POSTGRES_PASSWORD", "error", bindEnvErr); return }
 			bindEnvErr = viper.BindEnv("POSTGRES_DB")
-			if bindEnvErr != nil { log.Printf("BindEnv POSTGRES_DB error: %v", bindEnvErr); return }
+			if bindEnvErr != nil { slog.Error("BindEnv failed", "key", "POSTGRES_DB", "error", bindEnvErr); return }
 			bindEnvErr = viper.BindEnv("POSTGRES_PORT")
-			if bindEnvErr != nil { log.Printf("BindEnv POSTGRES_PORT error: %v", bindEnvErr); return }
+			if bindEnvErr != nil { slog.Error("BindEnv failed", "key", "POSTGRES_PORT", "error", bindEnvErr); return }
 			bindEnvErr = viper.BindEnv("PORT")
-			if bindEnvErr != nil { log.Printf("BindEnv PORT error: %v", bindEnvErr); return }
+			if bindEnvErr != nil { slog.Error("BindEnv failed", "key", "PORT", "error", bindEnvErr); return }
 			bindEnvErr = viper.BindEnv("CLIENT_ORIGIN")
-			if bindEnvErr != nil { log.Printf("BindEnv CLIENT_ORIGIN error: %v", bindEnvErr); return }
+			if bindEnvErr != nil { slog.Error("BindEnv failed", "key", "CLIENT_ORIGIN", "error", bindEnvErr); return }
 			bindEnvErr = viper.BindEnv("AUTH0_DOMAIN")
-			if bindEnvErr != nil { log.Printf("BindEnv AUTH0_DOMAIN error: %v", bindEnvErr); return }
+			if bindEnvErr != nil { slog.Error("BindEnv failed", "key", "AUTH0_DOMAIN", "error", bindEnvErr); return }
 			bindEnvErr = viper.BindEnv("AUTH0_AUDIENCE")
-			if bindEnvErr != nil { log.Printf("BindEnv AUTH0_AUDIENCE error: %v", bindEnvErr); return }
+			if bindEnvErr != nil { slog.Error("BindEnv failed", "key", "AUTH0_AUDIENCE", "error", bindEnvErr); return }
 			
 			err = nil // Clear the error so we don't return it
 		} else {
 			// Config file was found but another error occurred
-			log.Printf("Error reading config file: %s", err)
+			slog.Error("failed to read config file", "error", err)
 			return 
 		}
 	}
@@ -81,24 +184,46 @@ func LoadConfig(path string) (err error) {
 	err = viper.Unmarshal(&config)
 
 	if err != nil {
-		log.Printf("!!! Viper Unmarshal Error: %v", err)
-	} else {
-		log.Printf("--- Config loaded via Viper ---")
-		log.Printf("DBHost: [%s]", config.DBHost)
-		log.Printf("DBUserName: [%s]", config.DBUserName)
-		log.Printf("DBPassword: [%s]", "***")
-		log.Printf("DBName: [%s]", config.DBName)
-		log.Printf("DBPort: [%s]", config.DBPort)
-		log.Printf("ServerPort: [%s]", config.ServerPort)
-		log.Printf("ClientOrigin: [%s]", config.ClientOrigin)
-		log.Printf("AuthDomain: [%s]", config.AuthDomain)
-		log.Printf("AuthAudience: [%s]", config.AuthAudience)
-		log.Printf("--- End Config ---")
+		slog.Error("failed to unmarshal config", "error", err)
 	}
 
 	return
 }
 
+// LogConfig logs the loaded config, leaving out the database password.
+// Call it once logging is set up, so it is logged in the configured format.
+func LogConfig(config *Config) {
+	slog.Info("config loaded",
+		"db_host", config.DBHost,
+		"db_user", config.DBUserName,
+		"db_name", config.DBName,
+		"db_port", config.DBPort,
+		"server_port", config.ServerPort,
+		"client_origin", config.ClientOrigin,
+		"auth_domain", config.AuthDomain,
+		"auth_audience", config.AuthAudience,
+		"auth_provider", config.AuthProvider,
+		"auth_key_file", config.AuthKeyFile,
+		"auth_issuer", config.AuthIssuer,
+		"storage_backend", config.StorageBackend,
+		"leaderboard_guest_names", config.LeaderboardGuestNames,
+		"ws_client_rate", config.WSClientRate, "ws_client_burst", config.WSClientBurst,
+		"ws_ip_rate", config.WSIPRate, "ws_ip_burst", config.WSIPBurst,
+		"ws_event_budgets", config.WSEventBudgets,
+		"trusted_proxies", config.TrustedProxies, "trusted_platform", config.TrustedPlatform,
+		"ws_send_queue_size", config.WSSendQueueSize, "ws_slow_client_timeout", config.WSSlowClientTimeout,
+		"log_level", config.LogLevel, "log_format", config.LogFormat,
+		"tracing_exporter", config.TracingExporter, "tracing_sample_ratio", config.TracingSampleRatio,
+		"ready_max_games", config.ReadyMaxGames,
+		"room_idle_timeout", config.RoomIdleTimeout, "room_expiry_warning", config.RoomExpiryWarning,
+		"game_max_duration", config.GameMaxDuration, "finished_game_retention", config.FinishedGameRetention,
+		"room_code_length", config.RoomCodeLength, "room_code_max_length", config.RoomCodeMaxLength,
+		"room_code_heartbeat", config.RoomCodeHeartbeat,
+		"room_code_max_reservations", config.RoomCodeMaxReservations,
+		"room_code_reservation_ahead", config.RoomCodeReservationAhead,
+	)
+}
+
 func GetConfig() *Config {
 	return &config
 }
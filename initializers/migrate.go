@@ -2,21 +2,23 @@ package initializers
 
 import (
     "database/sql"
-    "fmt"
-    "log"
     "embed"
+    "fmt"
+    "log/slog"
 
     "github.com/pressly/goose/v3"
 )
 
-func MigrateDB(db *sql.DB, migrationsEmbedRoot embed.FS) {
+// MigrateDB runs the migrations that haven't been applied yet.
+func MigrateDB(db *sql.DB, migrationsEmbedRoot embed.FS) error {
     // Set the migration directory
     goose.SetBaseFS(migrationsEmbedRoot) // Use the embedded dir for goose
     goose.SetTableName("goose_db_version") // Set the table name for migration versions
 
     // Run migrations
     if err := goose.Up(db, "migrations"); err != nil {
-        log.Fatalf("goose up failed: %v", err)
+        return fmt.Errorf("goose up failed: %w", err)
     }
-    fmt.Println("? Database migrations completed successfully")
+    slog.Info("database migrations completed")
+    return nil
 }
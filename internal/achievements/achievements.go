@@ -0,0 +1,44 @@
+// Package achievements awards badges to signed-in players for what they do in games.
+// The Engine listens to game events, evaluates rules against them and stores new badges.
+package achievements
+
+import "github.com/oblongtable/beanbag-backend/internal/game"
+
+// MessageUnlocked is pushed to a player when they earn an achievement.
+const MessageUnlocked = "achievement_unlocked"
+
+type Achievement struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// Facts are what rules are evaluated against: a game event plus state the engine
+// keeps across events.
+type Facts struct {
+	Event game.Event
+
+	// EventQuestionFinished: each player's current run of correct answers in the game
+	Streaks map[string]int
+
+	// EventGameFinished of a live game with a signed-in host: games the host has
+	// hosted, including this one
+	HostedGames int64
+}
+
+// Rule awards its achievement to the players that Check returns for an event of type On.
+// Awarding is idempotent, a rule may return players who already have the achievement.
+type Rule struct {
+	Achievement Achievement
+	On          game.EventType
+	Check       func(f Facts) []string // Player IDs
+}
+
+// Catalog maps achievement IDs to their definitions.
+func Catalog(rules []Rule) map[string]Achievement {
+	catalog := make(map[string]Achievement, len(rules))
+	for _, rule := range rules {
+		catalog[rule.Achievement.ID] = rule.Achievement
+	}
+	return catalog
+}
@@ -0,0 +1,133 @@
+package achievements
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/oblongtable/beanbag-backend/internal/game"
+)
+
+const counterGamesHosted = "games_hosted"
+
+// Store persists awarded achievements and the counters rules depend on.
+type Store interface {
+	// Award records the achievement and reports whether the user did not have it yet.
+	Award(ctx context.Context, userID int32, achievementID string) (bool, error)
+	// IncrementCounter adds one to the user's counter and returns the new value.
+	IncrementCounter(ctx context.Context, userID int32, counter string) (int64, error)
+}
+
+// Notifier pushes a message to a connected player.
+type Notifier interface {
+	NotifyPlayer(playerID string, msgType string, payload interface{})
+}
+
+type streakKey struct {
+	gameID   string
+	playerID string
+}
+
+// Engine evaluates rules against game events. Events are queued and processed on
+// a separate goroutine so games never wait on the database.
+type Engine struct {
+	store    Store
+	notifier Notifier
+	rules    []Rule
+	events   chan game.Event
+
+	streaks map[streakKey]int // Only used by the processing goroutine
+}
+
+// NewEngine starts an engine. It implements game.EventListener.
+func NewEngine(store Store, notifier Notifier, rules []Rule) *Engine {
+	e := &Engine{
+		store:    store,
+		notifier: notifier,
+		rules:    rules,
+		events:   make(chan game.Event, 256),
+		streaks:  make(map[streakKey]int),
+	}
+	go e.run()
+	return e
+}
+
+// HandleGameEvent queues the event. It never blocks; events are dropped when the queue is full.
+func (e *Engine) HandleGameEvent(ev game.Event) {
+	if len(ev.UserIDs) == 0 {
+		// Only signed-in players can earn achievements
+		return
+	}
+	select {
+	case e.events <- ev:
+	default:
+		slog.Warn("achievements queue full, dropping event", "event_type", ev.Type, "game_id", ev.GameID)
+	}
+}
+
+func (e *Engine) run() {
+	for ev := range e.events {
+		e.process(context.Background(), ev)
+	}
+}
+
+func (e *Engine) process(ctx context.Context, ev game.Event) {
+	facts := Facts{Event: ev}
+	switch ev.Type {
+	case game.EventQuestionFinished:
+		facts.Streaks = e.updateStreaks(ev)
+	case game.EventGameFinished:
+		e.clearStreaks(ev)
+		if ev.Mode == game.ModeLive && ev.HostUserID != 0 {
+			hosted, err := e.store.IncrementCounter(ctx, ev.HostUserID, counterGamesHosted)
+			if err != nil {
+				slog.Error("achievements: failed to count hosted games", "game_id", ev.GameID, "user_id", ev.HostUserID, "error", err)
+			}
+			facts.HostedGames = hosted
+		}
+	}
+
+	for _, rule := range e.rules {
+		if rule.On != ev.Type {
+			continue
+		}
+		for _, playerID := range rule.Check(facts) {
+			userID, ok := ev.UserIDs[playerID]
+			if !ok {
+				continue
+			}
+			awarded, err := e.store.Award(ctx, userID, rule.Achievement.ID)
+			if err != nil {
+				slog.Error("achievements: failed to award", "game_id", ev.GameID, "achievement", rule.Achievement.ID, "user_id", userID, "error", err)
+				continue
+			}
+			if awarded {
+				slog.Info("achievement unlocked", "game_id", ev.GameID, "achievement", rule.Achievement.ID, "user_id", userID)
+				e.notifier.NotifyPlayer(playerID, MessageUnlocked, rule.Achievement)
+			}
+		}
+	}
+}
+
+// updateStreaks extends or resets the streak of every signed-in player who saw the question.
+func (e *Engine) updateStreaks(ev game.Event) map[string]int {
+	streaks := make(map[string]int, len(ev.Answers))
+	for _, a := range ev.Answers {
+		if _, ok := ev.UserIDs[a.PlayerID]; !ok {
+			continue
+		}
+		key := streakKey{gameID: ev.GameID, playerID: a.PlayerID}
+		if a.Correct {
+			e.streaks[key]++
+		} else {
+			e.streaks[key] = 0
+		}
+		streaks[a.PlayerID] = e.streaks[key]
+	}
+	return streaks
+}
+
+func (e *Engine) clearStreaks(ev game.Event) {
+	for playerID := range ev.UserIDs {
+		delete(e.streaks, streakKey{gameID: ev.GameID, playerID: playerID})
+	}
+}
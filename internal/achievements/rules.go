@@ -0,0 +1,105 @@
+package achievements
+
+import (
+	"time"
+
+	"github.com/oblongtable/beanbag-backend/internal/game"
+)
+
+const (
+	streakLength        = 5
+	fastestMinResponses = 2 // Being fastest only counts when someone else answered too
+)
+
+// DefaultRules are the achievements players can earn.
+func DefaultRules() []Rule {
+	return []Rule{
+		{
+			Achievement: Achievement{ID: "perfect_game", Name: "Perfect Game", Description: "Answer every question of a quiz correctly"},
+			On:          game.EventGameFinished,
+			Check:       perfectGame,
+		},
+		{
+			Achievement: Achievement{ID: "streak_5", Name: "On a Roll", Description: "Answer 5 questions in a row correctly"},
+			On:          game.EventQuestionFinished,
+			Check:       streak(streakLength),
+		},
+		{
+			Achievement: Achievement{ID: "fastest_answer", Name: "Quick Draw", Description: "Be the fastest to answer a question correctly in a live game"},
+			On:          game.EventQuestionFinished,
+			Check:       fastestAnswer,
+		},
+		{
+			Achievement: Achievement{ID: "host_1", Name: "Host", Description: "Host a live game to the end"},
+			On:          game.EventGameFinished,
+			Check:       gamesHosted(1),
+		},
+		{
+			Achievement: Achievement{ID: "host_10", Name: "Seasoned Host", Description: "Host 10 live games to the end"},
+			On:          game.EventGameFinished,
+			Check:       gamesHosted(10),
+		},
+	}
+}
+
+func perfectGame(f Facts) []string {
+	if f.Event.Abandoned || f.Event.TotalQuestions == 0 {
+		return nil
+	}
+	correct := make(map[string]int)
+	for _, a := range f.Event.Answers {
+		if a.Correct {
+			correct[a.PlayerID]++
+		}
+	}
+	var players []string
+	for playerID, n := range correct {
+		if n == f.Event.TotalQuestions {
+			players = append(players, playerID)
+		}
+	}
+	return players
+}
+
+func streak(length int) func(f Facts) []string {
+	return func(f Facts) []string {
+		var players []string
+		for playerID, n := range f.Streaks {
+			if n >= length {
+				players = append(players, playerID)
+			}
+		}
+		return players
+	}
+}
+
+func fastestAnswer(f Facts) []string {
+	if f.Event.Mode != game.ModeLive {
+		return nil
+	}
+	var fastest string
+	var fastestTime time.Duration
+	responses := 0
+	for _, a := range f.Event.Answers {
+		if a.AnswerIndex < 0 {
+			continue
+		}
+		responses++
+		if a.Correct && (fastest == "" || a.TimeTaken < fastestTime) {
+			fastest, fastestTime = a.PlayerID, a.TimeTaken
+		}
+	}
+	if fastest == "" || responses < fastestMinResponses {
+		return nil
+	}
+	return []string{fastest}
+}
+
+func gamesHosted(count int64) func(f Facts) []string {
+	return func(f Facts) []string {
+		if f.HostedGames < count {
+			return nil
+		}
+		return []string{f.Event.HostID}
+	}
+}
@@ -1,8 +1,11 @@
 package apimodels
 
+import "time"
+
 type AnswerApiModel struct {
 	Text      string `json:"text" binding:"required"`
 	IsCorrect bool   `json:"isCorrect" binding:"required"`
+	ImageURL  string `json:"imageUrl,omitempty"`
 }
 
 type QuestionApiModel struct {
@@ -10,11 +13,265 @@ type QuestionApiModel struct {
 	UseTimer   bool             `json:"useTimer" binding:"required"`
 	TimerValue int32            `json:"timerValue" binding:"required"`
 	Answers    []AnswerApiModel `json:"answers"`
+	ImageURL   string           `json:"imageUrl,omitempty"`
+	AudioURL   string           `json:"audioUrl,omitempty"`
 }
 
 type QuizApiModel struct {
 	Title     string             `json:"title" binding:"required"`
 	QuizID    int32              `json:"quiz_id"`
-	CreatorID int32              `json:"creator_id" binding:"required"`
+	CreatorID int32              `json:"creator_id"` // The signed-in user when creating a quiz
 	Questions []QuestionApiModel `json:"questions"`
 }
+
+type SessionApiModel struct {
+	SessionID    int32      `json:"session_id"`
+	GameCode     string     `json:"game_code"`
+	QuizID       int32      `json:"quiz_id,omitempty"`
+	AssignmentID int32      `json:"assignment_id,omitempty"`
+	Mode         string     `json:"mode"`
+	StartedAt    time.Time  `json:"started_at"`
+	FinishedAt   *time.Time `json:"finished_at,omitempty"`
+}
+
+type SessionPlayerApiModel struct {
+	Name           string     `json:"name"`
+	Score          int32      `json:"score"`
+	Rank           int32      `json:"rank,omitempty"`
+	CorrectAnswers int64      `json:"correct_answers"`
+	QuestionsSeen  int64      `json:"questions_seen"`
+	StartedAt      time.Time  `json:"started_at"`
+	FinishedAt     *time.Time `json:"finished_at,omitempty"`
+}
+
+type FastestAnswerApiModel struct {
+	PlayerID string `json:"id"`
+	Name     string `json:"name"`
+	TimeMs   int64  `json:"timeMs"`
+}
+
+// QuestionStatsApiModel carries the same aggregates as the live question_result message.
+type QuestionStatsApiModel struct {
+	QuestionIndex  int32                  `json:"questionIndex"`
+	QuestionID     int32                  `json:"questionId,omitempty"`
+	AnswerCounts   []int64                `json:"answerCounts"`
+	Players        int64                  `json:"players"`
+	Responses      int64                  `json:"responses"`
+	PercentCorrect float64                `json:"percentCorrect"`
+	AverageTimeMs  int64                  `json:"averageTimeMs"`
+	FastestTimeMs  int64                  `json:"fastestTimeMs"`
+	FastestCorrect *FastestAnswerApiModel `json:"fastestCorrect"`
+}
+
+type SessionResultsApiModel struct {
+	Session   SessionApiModel         `json:"session"`
+	Players   []SessionPlayerApiModel `json:"players"`
+	Questions []QuestionStatsApiModel `json:"questions"`
+}
+
+type WrongAnswerApiModel struct {
+	AnswerIndex int32  `json:"answer_index"`
+	Text        string `json:"text"`
+	Players     int64  `json:"players"`
+}
+
+type QuestionAnalyticsApiModel struct {
+	QuestionID     int32                `json:"question_id"`
+	Text           string               `json:"text"`
+	Attempts       int64                `json:"attempts"`  // Players who were asked the question
+	Responses      int64                `json:"responses"` // Players who answered in time
+	PercentCorrect float64              `json:"percent_correct"`
+	AverageTimeMs  int64                `json:"average_time_ms"`
+	CommonWrong    *WrongAnswerApiModel `json:"most_common_wrong_answer"`
+	// Correct rate of the top 27% of players minus that of the bottom 27%, from -1 to 1.
+	// nil until both groups have answered the question.
+	Discrimination *float64 `json:"discrimination_index"`
+}
+
+type PlaysPerDayApiModel struct {
+	Day      string `json:"day"` // YYYY-MM-DD in UTC
+	Sessions int64  `json:"sessions"`
+	Players  int64  `json:"players"`
+}
+
+type QuizAnalyticsApiModel struct {
+	QuizID    int32                       `json:"quiz_id"`
+	Questions []QuestionAnalyticsApiModel `json:"questions"`
+	Plays     []PlaysPerDayApiModel       `json:"plays"`
+}
+
+type UserHistoryEntryApiModel struct {
+	SessionID      int32      `json:"session_id"`
+	GameCode       string     `json:"game_code"`
+	Mode           string     `json:"mode"`
+	QuizID         int32      `json:"quiz_id,omitempty"`
+	QuizTitle      string     `json:"quiz_title,omitempty"`
+	Name           string     `json:"name"`
+	Score          int32      `json:"score"`
+	Rank           int32      `json:"rank,omitempty"`
+	Players        int64      `json:"players"`
+	CorrectAnswers int64      `json:"correct_answers"`
+	QuestionsSeen  int64      `json:"questions_seen"`
+	StartedAt      time.Time  `json:"started_at"`
+	FinishedAt     *time.Time `json:"finished_at,omitempty"`
+}
+
+type UserStatsApiModel struct {
+	SessionsPlayed int64    `json:"sessions_played"`
+	AverageRank    *float64 `json:"average_rank"` // nil until the user has finished a ranked game
+	Accuracy       float64  `json:"accuracy"`     // Percentage of questions answered correctly
+	BestStreak     int32    `json:"best_streak"`  // Most consecutive correct answers in one game
+	TotalPoints    int64    `json:"total_points"`
+}
+
+type LeaderboardEntryApiModel struct {
+	Rank       int64     `json:"rank"`
+	Name       string    `json:"name"`
+	Score      int32     `json:"score"`
+	Guest      bool      `json:"guest"`
+	QuizID     int32     `json:"quiz_id,omitempty"`
+	FinishedAt time.Time `json:"finished_at"`
+}
+
+type LeaderboardApiModel struct {
+	Period  string                     `json:"period"`
+	QuizID  int32                      `json:"quiz_id,omitempty"`
+	Entries []LeaderboardEntryApiModel `json:"entries"`
+	// The requesting user's best entry, also when it is outside the top entries.
+	// nil if they have no finished games in the period.
+	You *LeaderboardEntryApiModel `json:"you"`
+}
+
+type UserAchievementApiModel struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	AwardedAt   time.Time `json:"awarded_at"`
+}
+
+type AdminParticipantApiModel struct {
+	ClientID    string    `json:"client_id"`
+	Username    string    `json:"username"`
+	UserID      int32     `json:"user_id,omitempty"` // 0 for guests
+	Role        string    `json:"role"`
+	UserLobbyID int       `json:"user_lobby_id"`
+	JoinedAt    time.Time `json:"joined_at"`
+}
+
+type AdminPlayerScoreApiModel struct {
+	ClientID string `json:"client_id"`
+	Name     string `json:"name"`
+	Score    int    `json:"score"`
+}
+
+type AdminGameApiModel struct {
+	State     string                     `json:"state"`
+	QuizID    int32                      `json:"quiz_id,omitempty"` // 0 for the built-in quiz
+	QuizTitle string                     `json:"quiz_title"`
+	HostID    string                     `json:"host_id"`
+	Section   int                        `json:"section"` // 1-based, 0 before the first section
+	Sections  int                        `json:"sections"`
+	Question  int                        `json:"question"` // 1-based within the section, 0 before its first question
+	Questions int                        `json:"questions"`
+	Answered  int                        `json:"answered"` // Answers to the open question
+	StartedAt *time.Time                 `json:"started_at,omitempty"`
+	Scores    []AdminPlayerScoreApiModel `json:"scores"` // Highest first
+}
+
+type AdminRoomApiModel struct {
+	RoomCode     string                     `json:"room_code"`
+	Name         string                     `json:"name"`
+	Size         int                        `json:"size"`
+	CreatorID    string                     `json:"creator_id"`
+	HostID       string                     `json:"host_id,omitempty"`
+	Participants []AdminParticipantApiModel `json:"participants"`
+	Game         *AdminGameApiModel         `json:"game,omitempty"` // nil until a quiz is started
+}
+
+// UserPreferencesApiModel holds display settings, applied by the clients.
+type UserPreferencesApiModel struct {
+	Theme         string `json:"theme,omitempty" example:"dark"`     // system, light or dark
+	Language      string `json:"language,omitempty" example:"en-GB"` // BCP 47 tag
+	ReducedMotion bool   `json:"reduced_motion"`
+}
+
+type UserProfileApiModel struct {
+	UserID      int32                   `json:"user_id"`
+	Name        string                  `json:"name"`
+	Email       string                  `json:"email"`
+	Role        string                  `json:"role"`
+	AvatarURL   string                  `json:"avatar_url,omitempty"`
+	Preferences UserPreferencesApiModel `json:"preferences"`
+	CreatedAt   time.Time               `json:"created_at"`
+	UpdatedAt   time.Time               `json:"updated_at"`
+}
+
+// UserIdentityApiModel is a login linked to the user.
+type UserIdentityApiModel struct {
+	Subject    string    `json:"subject" example:"google-oauth2|1234"`
+	Provider   string    `json:"provider" example:"google-oauth2"`
+	Email      string    `json:"email"`
+	Current    bool      `json:"current"` // The login making the request
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+}
+
+type UserExportAnswerApiModel struct {
+	QuestionIndex int32     `json:"question_index"`
+	QuestionID    int32     `json:"question_id,omitempty"`
+	AnswerIndex   *int32    `json:"answer_index"` // nil if the time ran out
+	Correct       bool      `json:"correct"`
+	TimeTakenMs   *int32    `json:"time_taken_ms"`
+	Points        int32     `json:"points"`
+	AnsweredAt    time.Time `json:"answered_at"`
+}
+
+type UserExportSessionApiModel struct {
+	SessionID   int32                      `json:"session_id"`
+	GameCode    string                     `json:"game_code"`
+	Mode        string                     `json:"mode"`
+	QuizID      int32                      `json:"quiz_id,omitempty"`
+	QuizTitle   string                     `json:"quiz_title,omitempty"`
+	PlayerRef   string                     `json:"player_ref"`
+	DisplayName string                     `json:"display_name"`
+	Score       int32                      `json:"score"`
+	Rank        int32                      `json:"rank,omitempty"`
+	StartedAt   time.Time                  `json:"started_at"`
+	FinishedAt  *time.Time                 `json:"finished_at,omitempty"`
+	Answers     []UserExportAnswerApiModel `json:"answers"`
+}
+
+type UserExportAchievementApiModel struct {
+	ID        string    `json:"id"`
+	AwardedAt time.Time `json:"awarded_at"`
+}
+
+type UserExportQuizApiModel struct {
+	QuizID      int32     `json:"quiz_id"`
+	Title       string    `json:"title"`
+	Description string    `json:"description,omitempty"`
+	Private     bool      `json:"private"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type UserExportAssignmentApiModel struct {
+	AssignmentID int32     `json:"assignment_id"`
+	Code         string    `json:"code"`
+	QuizID       int32     `json:"quiz_id"`
+	OpensAt      time.Time `json:"opens_at"`
+	ClosesAt     time.Time `json:"closes_at"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// UserExportApiModel is everything stored about a user.
+type UserExportApiModel struct {
+	ExportedAt          time.Time                       `json:"exported_at"`
+	Profile             UserProfileApiModel             `json:"profile"`
+	Identities          []UserIdentityApiModel          `json:"identities"`
+	Sessions            []UserExportSessionApiModel     `json:"sessions"`
+	Achievements        []UserExportAchievementApiModel `json:"achievements"`
+	AchievementCounters map[string]int64                `json:"achievement_counters"`
+	Quizzes             []UserExportQuizApiModel        `json:"quizzes"`
+	Assignments         []UserExportAssignmentApiModel  `json:"assignments"`
+}
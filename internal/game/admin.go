@@ -0,0 +1,115 @@
+package game
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// Snapshot describes a live game for the admin API.
+type Snapshot struct {
+	ID          string
+	PresenterID string
+	HostID      string
+	State       GameState
+	QuizID      int32
+	QuizTitle   string
+	Section     int // 1-based, 0 before the first section is shown
+	Sections    int
+	Question    int // 1-based within the section, 0 before its first question
+	Questions   int // In the current section
+	Answered    int // Answers to the current question so far
+	StartedAt   time.Time
+	Players     []LeaderboardEntry // Highest score first
+}
+
+// Snapshot returns the state of the game played in the given room.
+func (s *GameService) Snapshot(gameID string) (Snapshot, error) {
+	game, found := s.GetGame(gameID)
+	if !found {
+		return Snapshot{}, ErrGameNotFound
+	}
+	return game.snapshot(), nil
+}
+
+// ForceAdvance moves a game on without the host, e.g. when the host's client is stuck.
+// An open question is closed early, any other screen moves on as if the host had pressed next.
+func (s *GameService) ForceAdvance(ctx context.Context, gameID string) error {
+	game, found := s.GetGame(gameID)
+	if !found {
+		return ErrGameNotFound
+	}
+	return game.forceAdvance(ctx)
+}
+
+// ForceEnd finishes a game straight away, showing the leaderboard as it stands.
+func (s *GameService) ForceEnd(ctx context.Context, gameID string) error {
+	game, found := s.GetGame(gameID)
+	if !found {
+		return ErrGameNotFound
+	}
+	return game.forceEnd(ctx, "ended by an admin")
+}
+
+func (g *Game) snapshot() Snapshot {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	snap := Snapshot{
+		ID:          g.ID,
+		PresenterID: g.PresenterID,
+		HostID:      g.HostID,
+		State:       g.State,
+		QuizID:      g.quiz.ID,
+		QuizTitle:   g.quiz.Title,
+		Sections:    len(g.quiz.Sections),
+		StartedAt:   g.startedAt,
+	}
+	if g.State != StateLobby && g.State != StateTitle && g.currentSection < len(g.quiz.Sections) {
+		snap.Section = g.currentSection + 1
+		snap.Questions = len(g.quiz.Sections[g.currentSection].Questions)
+		snap.Question = g.currentQuestionInSection
+		if g.State == StateQuestion {
+			// The index only moves past a question once it is finished
+			snap.Question++
+			snap.Answered = len(g.questionAnswers)
+		}
+	}
+	for _, player := range g.players {
+		snap.Players = append(snap.Players, LeaderboardEntry{ID: player.ID, Name: player.Name, Score: player.Score})
+	}
+	sort.SliceStable(snap.Players, func(i, j int) bool {
+		return snap.Players[i].Score > snap.Players[j].Score
+	})
+	return snap
+}
+
+func (g *Game) forceAdvance(ctx context.Context) error {
+	g.mu.Lock()
+	if g.State == StateQuestion {
+		g.questionTimer.Stop()
+		g.mu.Unlock()
+		g.logger.Warn("question closed early by an admin")
+		g.finishQuestion(ctx)
+		return nil
+	}
+	g.mu.Unlock()
+
+	g.logger.Warn("game advanced by an admin")
+	return g.nextState(ctx)
+}
+
+func (g *Game) forceEnd(ctx context.Context, reason string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.State == StateFinished {
+		return ErrInvalidState
+	}
+	if g.questionTimer != nil {
+		g.questionTimer.Stop()
+	}
+	g.logger.Warn("game ended early", "reason", reason, "state", g.State)
+	g.finishGameInternal(ctx)
+	return nil
+}
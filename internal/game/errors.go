@@ -0,0 +1,27 @@
+package game
+
+import "errors"
+
+// Errors returned by GameService, so callers can tell failures apart without matching messages.
+var (
+	ErrGameNotFound        = errors.New("game not found")
+	ErrNotHost             = errors.New("only the host can control the game")
+	ErrInvalidState        = errors.New("cannot advance the game from its current state")
+	ErrNotAcceptingAnswers = errors.New("not accepting answers right now")
+	ErrAlreadyAnswered     = errors.New("player has already answered")
+	ErrNotAPlayer          = errors.New("only players of the game can answer")
+	ErrNoSuchOption        = errors.New("the question has no option with that index")
+	ErrTimeUp              = errors.New("time is up for this question")
+	ErrQuizNotFound        = errors.New("quiz not found")
+	ErrQuizUnavailable     = errors.New("loading stored quizzes is not enabled")
+
+	ErrAssignmentsDisabled = errors.New("assignments are not enabled")
+	ErrAssignmentNotFound  = errors.New("assignment not found")
+	ErrAssignmentNotOpen   = errors.New("assignment is not open yet")
+	ErrAssignmentClosed    = errors.New("assignment is closed")
+	ErrAttemptInProgress   = errors.New("player already has an attempt in progress")
+	ErrNoAttempt           = errors.New("no attempt in progress")
+	ErrAttemptStarted      = errors.New("attempt has already started")
+	ErrAttemptFinished     = errors.New("attempt is already finished")
+	ErrQuestionOpen        = errors.New("answer the current question first")
+)
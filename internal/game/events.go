@@ -0,0 +1,35 @@
+// internal/game/events.go
+package game
+
+type EventType string
+
+const (
+	EventQuestionFinished EventType = "question_finished" // A question closed and was scored
+	EventGameFinished     EventType = "game_finished"     // A live game or self-paced attempt ended
+)
+
+// Event describes something that happened in a game, for subsystems such as achievements
+// that react to play without being part of the game loop.
+type Event struct {
+	Type   EventType
+	GameID string // Room code for live games, assignment code for self-paced attempts
+	Mode   SessionMode
+
+	// Player IDs mapped to users.user_id, only signed-in players are included
+	UserIDs    map[string]int32
+	HostID     string // Player ID of the host, empty for self-paced attempts
+	HostUserID int32  // 0 when the host is not signed in or for self-paced attempts
+
+	// EventQuestionFinished: every player's outcome for the question.
+	// EventGameFinished: every player's outcome for every question.
+	Answers []AnswerRecord
+
+	TotalQuestions int  // Questions in the quiz
+	Abandoned      bool // EventGameFinished: the player left a self-paced attempt before the end
+}
+
+// EventListener receives game events. It is called while the game is locked,
+// so implementations must return quickly and not call back into the game.
+type EventListener interface {
+	HandleGameEvent(ev Event)
+}
@@ -1,15 +1,20 @@
 package game
 
 import (
-	"errors"
+	"context"
 	"fmt"
-	"log" // For logging errors
+	"log/slog"
 	"sync"
 	"time"
 
 	"github.com/oblongtable/beanbag-backend/internal/quiz"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
+var tracer = otel.Tracer("github.com/oblongtable/beanbag-backend/internal/game")
+
 type GameState string
 
 const (
@@ -22,9 +27,10 @@ const (
 )
 
 type Player struct {
-	ID    string
-	Name  string
-	Score int
+	ID     string
+	Name   string
+	Score  int
+	UserID int32 // users.user_id of a signed-in player, 0 for guests
 }
 
 type PlayerAnswer struct {
@@ -35,10 +41,12 @@ type PlayerAnswer struct {
 type InitialPlayerInfo struct {
 	ID       string
 	Username string
+	UserID   int32 // 0 for guests
 }
 
 // BroadcastFunc is a function type for broadcasting messages to clients.
-type BroadcastFunc func(msgType string, payload interface{})
+// ctx carries the span of the game transition sending the message.
+type BroadcastFunc func(ctx context.Context, msgType string, payload interface{})
 
 // Game represents a single game instance with its state.
 type Game struct {
@@ -62,73 +70,101 @@ type Game struct {
 
 	// Function to broadcast messages to clients in the associated room
 	broadcastFunc BroadcastFunc
+
+	startedAt  time.Time
+	finishedAt time.Time
+	history    []AnswerRecord  // Every player's outcome for every finished question
+	recorder   SessionRecorder // Optional, persists the session when the game finishes
+	listener   EventListener   // Optional, notified of questions and the game finishing
+	logger     *slog.Logger    // Tags every line with the game and room
+	retention  time.Duration   // How long the finished game stays with the service, 0 forever
+	remove     func(*Game)     // Takes the game off the service once its retention is up
+}
+
+// startSpan starts the span of a game transition. The caller ends it.
+func (g *Game) startSpan(ctx context.Context, name string) (context.Context, trace.Span) {
+	return tracer.Start(ctx, "game."+name, trace.WithAttributes(
+		attribute.String("game.id", g.ID),
+		attribute.String("game.state", string(g.State)),
+	))
 }
 
-func (g *Game) startTitleScreen() {
+func (g *Game) startTitleScreen(ctx context.Context) {
+	ctx, span := g.startSpan(ctx, "title")
+	defer span.End()
+
 	g.mu.Lock()
 	g.State = StateTitle
+	g.startedAt = time.Now()
 	g.mu.Unlock()
 
-	g.broadcastMessage("show_title", map[string]string{
-		"title":       g.quiz.Title,
-		"description": g.quiz.Description,
+	g.broadcastMessage(ctx, MessageShowTitle, ShowTitlePayload{
+		Title:       g.quiz.Title,
+		Description: g.quiz.Description,
 	})
-	log.Printf("Game %s: Showing title screen. Waiting for host.", g.ID)
+	g.logger.Info("showing title screen, waiting for host")
 }
 
 // nextState is the core state machine driver.
-func (g *Game) nextState() error {
+func (g *Game) nextState(ctx context.Context) error {
 	g.mu.Lock()
 	defer g.mu.Unlock()
 
-	log.Printf("Game %s: nextState called. Current State: %s, Current Section: %d/%d, Current Question In Section: %d/%d",
-		g.ID, g.State, g.currentSection+1, len(g.quiz.Sections), g.currentQuestionInSection+1, len(g.quiz.Sections[g.currentSection].Questions))
+	ctx, span := g.startSpan(ctx, "next_state")
+	defer func() {
+		span.SetAttributes(attribute.String("game.next_state", string(g.State)))
+		span.End()
+	}()
+
+	g.logger.Debug("advancing game", "state", g.State,
+		"section", g.currentSection+1, "sections", len(g.quiz.Sections),
+		"question", g.currentQuestionInSection+1, "questions", len(g.quiz.Sections[g.currentSection].Questions))
 
 	switch g.State {
 	case StateTitle:
 		// Logic to show a "section" screen before the questions begin.
 		g.State = StateSection
-		g.broadcastMessage("show_section", map[string]interface{}{
-			"id":    1, // Assuming the first section has ID 1
-			"title": g.quiz.Sections[0].Section,
+		g.broadcastMessage(ctx, MessageShowSection, ShowSectionPayload{
+			ID:    1, // Assuming the first section has ID 1
+			Title: g.quiz.Sections[0].Section,
 		})
-		log.Printf("Game %s: Showing section. Waiting for host.", g.ID)
+		g.logger.Info("showing section, waiting for host", "section", 1)
 
 	case StateSection, StateScores:
 		// Check if there are more questions in the current section
 		if g.currentQuestionInSection < len(g.quiz.Sections[g.currentSection].Questions) {
-			g.startQuestion()
+			g.startQuestion(ctx)
 		} else {
 			// Move to the next section
 			g.currentSection++
 			g.currentQuestionInSection = 0 // Reset question index for the new section
 
-			log.Printf("Game %s: After incrementing section. Current Section: %d, Total Sections: %d", g.ID, g.currentSection, len(g.quiz.Sections))
-
 			// Check if there are more sections
 			if g.currentSection < len(g.quiz.Sections) {
 				// Show the next section title screen
 				g.State = StateSection
-				g.broadcastMessage("show_section", map[string]interface{}{ // Changed value type to interface{}
-					"id":    g.currentSection + 1,
-					"title": g.quiz.Sections[g.currentSection].Section,
+				g.broadcastMessage(ctx, MessageShowSection, ShowSectionPayload{
+					ID:    g.currentSection + 1,
+					Title: g.quiz.Sections[g.currentSection].Section,
 				})
-				log.Printf("Game %s: Showing section '%s'. Waiting for host.", g.ID, g.quiz.Sections[g.currentSection].Section)
+				g.logger.Info("showing section, waiting for host", "section", g.currentSection+1)
 			} else {
 				// No more sections, end the game.
-				log.Printf("Game %s: All sections completed. Calling finishGameInternal.", g.ID)
-				g.finishGameInternal() // Call internal function directly as mutex is already held
+				g.finishGameInternal(ctx) // Call internal function directly as mutex is already held
 			}
 		}
 
 	default:
-		return fmt.Errorf("cannot advance state from current state: %s", g.State)
+		return fmt.Errorf("%w: %s", ErrInvalidState, g.State)
 	}
 	return nil
 }
 
 // startQuestion prepares and broadcasts the current question and starts its timer.
-func (g *Game) startQuestion() {
+func (g *Game) startQuestion(ctx context.Context) {
+	ctx, span := g.startSpan(ctx, "start_question")
+	defer span.End()
+
 	currentSection := &g.quiz.Sections[g.currentSection]
 	q := currentSection.Questions[g.currentQuestionInSection]
 
@@ -136,58 +172,89 @@ func (g *Game) startQuestion() {
 	g.questionAnswers = make(map[string]PlayerAnswer) // Use the new struct
 	g.questionStartTime = time.Now()
 
-	log.Printf("Game %s: Starting question %d in section %d.", g.ID, g.currentQuestionInSection+1, g.currentSection+1)
-	g.broadcastQuestion(q)
+	g.logger.Info("starting question", "section", g.currentSection+1, "question", g.currentQuestionInSection+1)
+	g.broadcastQuestion(ctx, q)
 
 	// This timer will automatically call finishQuestion when the time is up.
-	g.questionTimer = time.AfterFunc(time.Duration(q.TimeLimit)*time.Second, g.finishQuestion)
+	// Its transition is traced as part of the question.
+	g.questionTimer = time.AfterFunc(time.Duration(q.TimeLimit)*time.Second, func() { g.finishQuestion(ctx) })
 }
 
 // finishQuestion is called when the timer runs out OR all players have answered.
-func (g *Game) finishQuestion() {
+func (g *Game) finishQuestion(ctx context.Context) {
 	g.mu.Lock()
 	defer g.mu.Unlock()
 
+	ctx, span := g.startSpan(ctx, "finish_question")
+	defer span.End()
+
 	// Prevent this from running twice (e.g., if timer fires right after last player answers)
 	if g.State != StateQuestion {
-		log.Printf("Game %s: finishQuestion called but state is %s, not StateQuestion. Returning.", g.ID, g.State)
+		g.logger.Debug("question already finished", "state", g.State)
 		return
 	}
 
-	log.Printf("Game %s: Finishing question %d in section %d.", g.ID, g.currentQuestionInSection+1, g.currentSection+1)
+	g.logger.Info("finishing question", "section", g.currentSection+1, "question", g.currentQuestionInSection+1)
 	g.State = StateScores
 	currentSection := &g.quiz.Sections[g.currentSection]
 	q := currentSection.Questions[g.currentQuestionInSection]
 
 	// --- New Scoring Logic ---
-	for playerID, answer := range g.questionAnswers {
-		if answer.AnswerIndex == q.CorrectOptionIndex {
-			if player, ok := g.players[playerID]; ok {
+	questionIndex := g.questionIndex()
+	records := make([]AnswerRecord, 0, len(g.players))
+	for playerID, player := range g.players {
+		record := AnswerRecord{
+			PlayerID:      playerID,
+			QuestionIndex: questionIndex,
+			QuestionID:    q.ID,
+			AnswerIndex:   -1,
+		}
+		if answer, ok := g.questionAnswers[playerID]; ok {
+			record.AnswerIndex = answer.AnswerIndex
+			record.TimeTaken = answer.TimeTaken
+			record.AnsweredAt = g.questionStartTime.Add(answer.TimeTaken)
+			if answer.AnswerIndex == q.CorrectOptionIndex {
 				// Base points + time bonus
-				timeBonus := float64(q.Points) * 0.5 * (1 - (answer.TimeTaken.Seconds() / float64(q.TimeLimit)))
-				player.Score += q.Points + int(timeBonus)
+				record.Correct = true
+				record.Points = scoreAnswer(q.Points, q.TimeLimit, answer.TimeTaken)
+				player.Score += record.Points
 			}
+		} else {
+			record.AnsweredAt = time.Now()
 		}
+		records = append(records, record)
 	}
+	g.history = append(g.history, records...)
+	g.emit(EventQuestionFinished, records)
 
-	g.broadcastScores(q)
+	stats := questionStats(len(q.Options), records, func(playerID string) string {
+		return g.players[playerID].Name
+	})
+	g.broadcastScores(ctx, q, stats)
 	g.currentQuestionInSection++ // Move to the next question index for the next round
 }
 
 // handlePlayerAnswer is called from the service when a player submits an answer.
-func (g *Game) handlePlayerAnswer(playerID string, answerIndex int) error {
+func (g *Game) handlePlayerAnswer(ctx context.Context, playerID string, answerIndex int) error {
 	g.mu.Lock()
 	defer g.mu.Unlock()
 
 	// Ignore answers if not in the question phase or if player has already answered.
 	if g.State != StateQuestion {
-		log.Printf("Game %s: Player %s tried to answer %d, but game state is %s (not StateQuestion).", g.ID, playerID, answerIndex, g.State)
-		return errors.New("not accepting answers right now")
+		g.logger.Debug("answer outside of a question", "client_id", playerID, "state", g.State)
+		return ErrNotAcceptingAnswers
+	}
+	// The presenter and those who joined after the game started only watch
+	if _, ok := g.players[playerID]; !ok {
+		return ErrNotAPlayer
 	}
 	currentQuestion := g.quiz.Sections[g.currentSection].Questions[g.currentQuestionInSection]
-	log.Printf("Game %s: Player %s submitted answer %d. Correct answer is %d. Current question: '%s'", g.ID, playerID, answerIndex, currentQuestion.CorrectOptionIndex, currentQuestion.QuestionText)
+	if answerIndex >= len(currentQuestion.Options) {
+		return ErrNoSuchOption
+	}
+	g.logger.Debug("answer received", "client_id", playerID, "answer_index", answerIndex, "correct_index", currentQuestion.CorrectOptionIndex)
 	if _, alreadyAnswered := g.questionAnswers[playerID]; alreadyAnswered {
-		return errors.New("player has already answered")
+		return ErrAlreadyAnswered
 	}
 
 	// Record the answer and the time it took.
@@ -200,7 +267,7 @@ func (g *Game) handlePlayerAnswer(playerID string, answerIndex int) error {
 	if len(g.questionAnswers) == len(g.players) {
 		// All players have answered, stop the timer and finish the question immediately.
 		if g.questionTimer.Stop() {
-			go g.finishQuestion()
+			go g.finishQuestion(ctx)
 		}
 	}
 	return nil
@@ -215,26 +282,27 @@ type LeaderboardEntry struct {
 
 // finishGame is a public wrapper that ensures the lock is held before calling finishGameInternal.
 // This is used when finishGame is called from a new goroutine (e.g., by a timer or player answer).
-func (g *Game) finishGame() {
+func (g *Game) finishGame(ctx context.Context) {
 	g.mu.Lock()
 	defer g.mu.Unlock()
-	g.finishGameInternal()
+	g.finishGameInternal(ctx)
 }
 
 // finishGameInternal contains the core logic for ending the game.
 // It assumes the mutex is already locked by the caller.
-func (g *Game) finishGameInternal() {
-	log.Printf("Game %s: Entering finishGameInternal. Current State: %s", g.ID, g.State)
+func (g *Game) finishGameInternal(ctx context.Context) {
+	ctx, span := g.startSpan(ctx, "finish")
+	defer span.End()
 
 	// Prevent this from running twice (e.g., if timer fires right after last player answers)
 	// This check is crucial here as finishGameInternal can be called from multiple paths.
 	if g.State == StateFinished {
-		log.Printf("Game %s: finishGameInternal: State is already Finished. Returning.", g.ID)
+		g.logger.Debug("game already finished")
 		return
 	}
 
 	g.State = StateFinished
-	log.Printf("Game %s: State set to StateFinished.", g.ID)
+	g.finishedAt = time.Now()
 
 	// Create a slice to hold leaderboard entries
 	leaderboard := make([]LeaderboardEntry, 0, len(g.players))
@@ -256,45 +324,139 @@ func (g *Game) finishGameInternal() {
 		}
 	}
 
-	payload := map[string]interface{}{
-		"leaderboard": leaderboard,
+	payload := GameOverPayload{
+		Leaderboard: leaderboard,
+	}
+	g.broadcastMessage(ctx, MessageGameOver, payload)
+	g.logger.Info("game finished", "players", len(leaderboard))
+
+	answers := make([]AnswerRecord, len(g.history))
+	copy(answers, g.history)
+	g.emit(EventGameFinished, answers)
+
+	if g.recorder != nil {
+		result := g.sessionResult(leaderboard)
+		go func() {
+			// Traced as part of the game, but not cancelled with whatever ended it
+			if err := g.recorder.RecordSession(context.WithoutCancel(ctx), result); err != nil {
+				g.logger.Error("failed to record session", "error", err)
+			}
+		}()
+	}
+	if g.retention > 0 && g.remove != nil {
+		time.AfterFunc(g.retention, func() { g.remove(g) })
 	}
-	log.Printf("Game %s: Sending 'game_over' message with payload: %+v", g.ID, payload)
-	g.broadcastMessage("game_over", payload)
-	log.Printf("Game %s finished. Leaderboard sent.", g.ID)
+}
+
+// sessionResult snapshots the finished game for the session recorder.
+// It assumes the mutex is already locked by the caller and leaderboard is sorted by score.
+func (g *Game) sessionResult(leaderboard []LeaderboardEntry) SessionResult {
+	finishedAt := time.Now()
+	players := make([]PlayerResult, 0, len(leaderboard))
+	for i, entry := range leaderboard {
+		// Players with the same score share a rank
+		rank := i + 1
+		if i > 0 && entry.Score == leaderboard[i-1].Score {
+			rank = players[i-1].Rank
+		}
+		var userID int32
+		if player, ok := g.players[entry.ID]; ok {
+			userID = player.UserID
+		}
+		players = append(players, PlayerResult{
+			PlayerID:   entry.ID,
+			UserID:     userID,
+			Name:       entry.Name,
+			Score:      entry.Score,
+			Rank:       rank,
+			StartedAt:  g.startedAt,
+			FinishedAt: finishedAt,
+		})
+	}
+
+	answers := make([]AnswerRecord, len(g.history))
+	copy(answers, g.history)
+
+	return SessionResult{
+		Mode:       ModeLive,
+		GameID:     g.ID,
+		QuizID:     g.quiz.ID,
+		StartedAt:  g.startedAt,
+		FinishedAt: finishedAt,
+		Players:    players,
+		Answers:    answers,
+	}
+}
+
+// emit notifies the listener of a game event.
+// It assumes the mutex is already locked by the caller.
+func (g *Game) emit(eventType EventType, answers []AnswerRecord) {
+	if g.listener == nil {
+		return
+	}
+	userIDs := make(map[string]int32)
+	for playerID, player := range g.players {
+		if player.UserID != 0 {
+			userIDs[playerID] = player.UserID
+		}
+	}
+	totalQuestions := 0
+	for _, section := range g.quiz.Sections {
+		totalQuestions += len(section.Questions)
+	}
+	g.listener.HandleGameEvent(Event{
+		Type:           eventType,
+		GameID:         g.ID,
+		Mode:           ModeLive,
+		UserIDs:        userIDs,
+		HostID:         g.HostID,
+		HostUserID:     userIDs[g.HostID],
+		Answers:        answers,
+		TotalQuestions: totalQuestions,
+	})
+}
+
+// questionIndex returns the position of the current question across all sections.
+func (g *Game) questionIndex() int {
+	index := g.currentQuestionInSection
+	for i := 0; i < g.currentSection; i++ {
+		index += len(g.quiz.Sections[i].Questions)
+	}
+	return index
 }
 
 // --- Updated Helper Methods for Broadcasting ---
 
 // broadcastQuestion sends the question to all players, hiding the answer.
-func (g *Game) broadcastQuestion(q quiz.Question) {
-	// We create a new struct for the payload to control what data is sent.
-	// We don't want to send the correctOptionIndex or explanation yet.
-	payload := map[string]interface{}{
-		"questionText":   q.QuestionText,
-		"options":        q.Options,
-		"timeLimit":      q.TimeLimit,
-		"points":         q.Points,
-		"questionNumber": g.currentQuestionInSection + 1,
-		"totalQuestions": len(g.quiz.Sections[g.currentSection].Questions),
-	}
-	g.broadcastMessage("new_question", payload)
+func (g *Game) broadcastQuestion(ctx context.Context, q quiz.Question) {
+	payload := questionPayload(q, g.currentQuestionInSection+1, len(g.quiz.Sections[g.currentSection].Questions))
+	g.broadcastMessage(ctx, MessageNewQuestion, payload)
 }
 
-// broadcastScores sends the results of the question and the current leaderboard.
-func (g *Game) broadcastScores(q quiz.Question) {
-	// Define a struct that matches the frontend's LeaderboardEntry for this specific payload
-	type QuestionLeaderboardEntry struct {
-		ID    string `json:"ID"`
-		Name  string `json:"Name"`
-		Score int    `json:"Score"` // Score for this specific question
+// questionPayload builds the new_question message, hiding the answer.
+func questionPayload(q quiz.Question, questionNumber, totalQuestions int) NewQuestionPayload {
+	// We don't want to send the correctOptionIndex or explanation yet.
+	// Media is optional, empty URLs are left out of the message.
+	return NewQuestionPayload{
+		QuestionText:    q.QuestionText,
+		Options:         q.Options,
+		TimeLimit:       q.TimeLimit,
+		Points:          q.Points,
+		QuestionNumber:  questionNumber,
+		TotalQuestions:  totalQuestions,
+		ImageURL:        q.ImageURL,
+		AudioURL:        q.AudioURL,
+		OptionImageURLs: q.OptionImageURLs,
 	}
+}
 
-	questionLeaderboard := make(map[string]QuestionLeaderboardEntry)
+// broadcastScores sends the results of the question, how the players answered it and the current leaderboard.
+func (g *Game) broadcastScores(ctx context.Context, q quiz.Question, stats QuestionStats) {
+	questionLeaderboard := make(map[string]QuestionScore)
 
 	// Iterate over all players in the game
 	for playerID, player := range g.players {
-		entry := QuestionLeaderboardEntry{
+		entry := QuestionScore{
 			ID:    player.ID,
 			Name:  player.Name,
 			Score: 0, // Default to 0 points for this question
@@ -304,26 +466,26 @@ func (g *Game) broadcastScores(q quiz.Question) {
 		if playerAnswer, ok := g.questionAnswers[playerID]; ok {
 			if playerAnswer.AnswerIndex == q.CorrectOptionIndex {
 				// Calculate points for this question
-				timeBonus := float64(q.Points) * 0.5 * (1 - (playerAnswer.TimeTaken.Seconds() / float64(q.TimeLimit)))
-				entry.Score = q.Points + int(timeBonus)
+				entry.Score = scoreAnswer(q.Points, q.TimeLimit, playerAnswer.TimeTaken)
 			}
 		}
 		questionLeaderboard[playerID] = entry
 	}
 
-	payload := map[string]interface{}{
-		"correctOptionIndex": q.CorrectOptionIndex,
-		"explanation":        q.Explanation,
-		"leaderboard":        questionLeaderboard, // Send the map of player results for this question
+	payload := QuestionResultPayload{
+		CorrectOptionIndex: q.CorrectOptionIndex,
+		Explanation:        q.Explanation,
+		Leaderboard:        questionLeaderboard, // Send the map of player results for this question
+		Stats:              stats,
 	}
-	g.broadcastMessage("question_result", payload)
+	g.broadcastMessage(ctx, MessageQuestionResult, payload)
 }
 
 // A generic helper to create and broadcast messages
-func (g *Game) broadcastMessage(msgType string, payload interface{}) {
+func (g *Game) broadcastMessage(ctx context.Context, msgType string, payload interface{}) {
 	if g.broadcastFunc != nil {
-		g.broadcastFunc(msgType, payload)
+		g.broadcastFunc(ctx, msgType, payload)
 	} else {
-		log.Printf("Game %s: BroadcastFunc is not set. Cannot broadcast message of type %s.", g.ID, msgType)
+		g.logger.Error("no broadcast function, message not sent", "type", msgType)
 	}
 }
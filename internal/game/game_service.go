@@ -3,12 +3,17 @@ package game
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
+	"log/slog"
 	"sync"
+	"time"
 
 	"github.com/oblongtable/beanbag-backend/internal/quiz"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const hardcodedQuiz = `{
@@ -102,32 +107,108 @@ const hardcodedQuiz = `{
   ]
 }`
 
+// QuizLoader loads a stored quiz by its database ID.
+type QuizLoader interface {
+	LoadGameQuiz(ctx context.Context, quizID int32) (*quiz.Quiz, error)
+}
+
 type GameService struct {
-	games map[string]*Game
-	mu    sync.RWMutex
+	games        map[string]*Game
+	attempts     map[string]*Attempt // Self-paced attempts in progress, keyed by player ID
+	userAttempts map[int32]*Attempt  // The same attempts of signed-in players, keyed by user ID
+	mu           sync.RWMutex
+	quizLoader   QuizLoader
+
+	assignments AssignmentLookup
+	recorder    SessionRecorder
+	listener    EventListener
+	retention   time.Duration
 }
 
 func NewService() *GameService {
 	return &GameService{
-		games: make(map[string]*Game),
+		games:        make(map[string]*Game),
+		attempts:     make(map[string]*Attempt),
+		userAttempts: make(map[int32]*Attempt),
 	}
 }
 
+// SetSessionRecorder persists results of finished games and attempts.
+func (s *GameService) SetSessionRecorder(recorder SessionRecorder) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.recorder = recorder
+}
+
+// SetEventListener subscribes a listener to the events of games created afterwards.
+func (s *GameService) SetEventListener(listener EventListener) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.listener = listener
+}
+
+// SetAssignmentLookup enables self-paced attempts at assignments.
+func (s *GameService) SetAssignmentLookup(lookup AssignmentLookup) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.assignments = lookup
+}
+
+// SetQuizLoader enables playing stored quizzes. Without a loader every game uses the built-in quiz.
+func (s *GameService) SetQuizLoader(loader QuizLoader) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.quizLoader = loader
+}
+
+// loadQuiz returns the stored quiz with the given ID, or the built-in quiz when quizID is 0.
+func (s *GameService) loadQuiz(ctx context.Context, quizID int32) (*quiz.Quiz, error) {
+	if quizID == 0 {
+		var q quiz.Quiz
+		err := json.NewDecoder(bytes.NewReader([]byte(hardcodedQuiz))).Decode(&q)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode hardcoded quiz: %w", err)
+		}
+		return &q, nil
+	}
+
+	s.mu.RLock()
+	loader := s.quizLoader
+	s.mu.RUnlock()
+	if loader == nil {
+		return nil, ErrQuizUnavailable
+	}
+	return loader.LoadGameQuiz(ctx, quizID)
+}
+
 // CreateGame loads a quiz, creates a Game, and links it to the room.
 // It now also initializes the players map from the room participants.
-func (s *GameService) CreateGame(roomID, presenterID, hostID string, broadcastFunc BroadcastFunc, initialPlayers []InitialPlayerInfo) (*Game, error) {
-	var q quiz.Quiz
-	err := json.NewDecoder(bytes.NewReader([]byte(hardcodedQuiz))).Decode(&q)
+// A quizID of 0 plays the built-in quiz.
+func (s *GameService) CreateGame(ctx context.Context, roomID, presenterID, hostID string, quizID int32, broadcastFunc BroadcastFunc, initialPlayers []InitialPlayerInfo) (*Game, error) {
+	ctx, span := tracer.Start(ctx, "game.create", trace.WithAttributes(
+		attribute.String("game.id", roomID),
+		attribute.Int("quiz.id", int(quizID)),
+		attribute.Int("game.players", len(initialPlayers)),
+	))
+	defer span.End()
+
+	q, err := s.loadQuiz(ctx, quizID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to decode hardcoded quiz: %w", err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
 	}
 
+	// The presenter shows the quiz to the room rather than playing it
 	playersMap := make(map[string]*Player)
 	for _, pInfo := range initialPlayers {
+		if pInfo.ID == presenterID {
+			continue
+		}
 		playersMap[pInfo.ID] = &Player{
-			ID:    pInfo.ID,
-			Name:  pInfo.Username,
-			Score: 0, // Initialize score to 0
+			ID:     pInfo.ID,
+			Name:   pInfo.Username,
+			Score:  0, // Initialize score to 0
+			UserID: pInfo.UserID,
 		}
 	}
 
@@ -136,13 +217,18 @@ func (s *GameService) CreateGame(roomID, presenterID, hostID string, broadcastFu
 		PresenterID:     presenterID,
 		HostID:          hostID,
 		State:           StateLobby,
-		quiz:            &q,
+		quiz:            q,
 		players:         playersMap, // Use the populated players map
 		questionAnswers: make(map[string]PlayerAnswer),
 		broadcastFunc:   broadcastFunc, // Pass the broadcast function
+		logger:          slog.Default().With("game_id", roomID, "room_id", roomID),
 	}
 
 	s.mu.Lock()
+	game.recorder = s.recorder
+	game.listener = s.listener
+	game.retention = s.retention
+	game.remove = s.removeGame
 	s.games[roomID] = game
 	s.mu.Unlock()
 
@@ -150,39 +236,39 @@ func (s *GameService) CreateGame(roomID, presenterID, hostID string, broadcastFu
 }
 
 // Start will now just begin the title screen, not the whole loop.
-func (s *GameService) StartGame(gameID string, hostID string) error {
+func (s *GameService) StartGame(ctx context.Context, gameID string, hostID string) error {
 	game, found := s.GetGame(gameID)
 	if !found {
-		return errors.New("game not found")
+		return ErrGameNotFound
 	}
 	if game.HostID != hostID {
-		return errors.New("only the host can start the game")
+		return ErrNotHost
 	}
 
-	game.startTitleScreen()
+	game.startTitleScreen(ctx)
 	return nil
 }
 
 // NextAction is the new method called by the host to advance the game.
-func (s *GameService) NextAction(gameID string, hostID string) error {
+func (s *GameService) NextAction(ctx context.Context, gameID string, hostID string) error {
 	game, found := s.GetGame(gameID)
 	if !found {
-		return errors.New("game not found")
+		return ErrGameNotFound
 	}
 	if game.HostID != hostID {
-		return errors.New("only the host can advance the game")
+		return ErrNotHost
 	}
 
-	return game.nextState() // Delegate the action to the game instance
+	return game.nextState(ctx) // Delegate the action to the game instance
 }
 
 // HandleAnswer now needs more complex logic.
-func (s *GameService) HandleAnswer(gameID, playerID string, answerIndex int) error {
+func (s *GameService) HandleAnswer(ctx context.Context, gameID, playerID string, answerIndex int) error {
 	game, found := s.GetGame(gameID)
 	if !found {
-		return errors.New("game not found")
+		return ErrGameNotFound
 	}
-	return game.handlePlayerAnswer(playerID, answerIndex)
+	return game.handlePlayerAnswer(ctx, playerID, answerIndex)
 }
 
 // GetGame retrieves a game instance (needed by the ws_handler).
@@ -192,3 +278,134 @@ func (s *GameService) GetGame(gameID string) (*Game, bool) {
 	game, found := s.games[gameID]
 	return game, found
 }
+
+// CountByState returns how many live games are in each state.
+func (s *GameService) CountByState() map[GameState]int {
+	s.mu.RLock()
+	games := make([]*Game, 0, len(s.games))
+	for _, g := range s.games {
+		games = append(games, g)
+	}
+	s.mu.RUnlock()
+
+	counts := make(map[GameState]int)
+	for _, g := range games {
+		g.mu.RLock()
+		counts[g.State]++
+		g.mu.RUnlock()
+	}
+	return counts
+}
+
+// CreateAttempt prepares a self-paced attempt at the assignment with the given share code.
+// The attempt sends its messages to the player through send once StartAttempt is called.
+// Signed-in players have one attempt at a time however many connections they open.
+func (s *GameService) CreateAttempt(ctx context.Context, code string, player InitialPlayerInfo, send SendFunc) (*Attempt, error) {
+	s.mu.RLock()
+	lookup := s.assignments
+	inProgress := s.hasAttempt(player)
+	s.mu.RUnlock()
+
+	if lookup == nil {
+		return nil, ErrAssignmentsDisabled
+	}
+	if inProgress {
+		return nil, ErrAttemptInProgress
+	}
+
+	assignment, err := lookup.GetAssignment(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if now.Before(assignment.OpensAt) {
+		return nil, fmt.Errorf("%w: opens at %s", ErrAssignmentNotOpen, assignment.OpensAt.Format(time.RFC3339))
+	}
+	if !now.Before(assignment.ClosesAt) {
+		return nil, ErrAssignmentClosed
+	}
+
+	q, err := s.loadQuiz(ctx, assignment.QuizID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	if s.hasAttempt(player) {
+		s.mu.Unlock()
+		return nil, ErrAttemptInProgress
+	}
+	attempt := newAttempt(*assignment, q, player, send, s.recorder, s.listener, s.removeAttempt)
+	s.attempts[player.ID] = attempt
+	if player.UserID != 0 {
+		s.userAttempts[player.UserID] = attempt
+	}
+	s.mu.Unlock()
+
+	return attempt, nil
+}
+
+// StartAttempt shows the title screen of the player's attempt.
+func (s *GameService) StartAttempt(playerID string) error {
+	attempt, found := s.getAttempt(playerID)
+	if !found {
+		return ErrNoAttempt
+	}
+	return attempt.start()
+}
+
+// AttemptNext advances the player's attempt to the next question, or finishes it.
+func (s *GameService) AttemptNext(playerID string) error {
+	attempt, found := s.getAttempt(playerID)
+	if !found {
+		return ErrNoAttempt
+	}
+	return attempt.next()
+}
+
+// AttemptAnswer submits an answer for the current question of the player's attempt.
+func (s *GameService) AttemptAnswer(playerID string, answerIndex int) error {
+	attempt, found := s.getAttempt(playerID)
+	if !found {
+		return ErrNoAttempt
+	}
+	return attempt.answer(answerIndex)
+}
+
+// AbandonAttempt stops the player's attempt if they have one, e.g. on disconnect.
+func (s *GameService) AbandonAttempt(playerID string) {
+	attempt, found := s.getAttempt(playerID)
+	if !found {
+		return
+	}
+	attempt.abandon()
+	s.removeAttempt(attempt)
+}
+
+func (s *GameService) getAttempt(playerID string) (*Attempt, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	attempt, found := s.attempts[playerID]
+	return attempt, found
+}
+
+// hasAttempt reports whether the player, or the user they are signed in as, has an attempt in progress.
+// It assumes the mutex is already locked by the caller.
+func (s *GameService) hasAttempt(player InitialPlayerInfo) bool {
+	if _, exists := s.attempts[player.ID]; exists {
+		return true
+	}
+	_, exists := s.userAttempts[player.UserID]
+	return player.UserID != 0 && exists
+}
+
+func (s *GameService) removeAttempt(attempt *Attempt) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.attempts[attempt.PlayerID] == attempt {
+		delete(s.attempts, attempt.PlayerID)
+	}
+	if s.userAttempts[attempt.UserID] == attempt {
+		delete(s.userAttempts, attempt.UserID)
+	}
+}
@@ -0,0 +1,41 @@
+package game
+
+import (
+	"context"
+	"time"
+)
+
+// SetRetention keeps games that finish afterwards for the given time, so their room and the
+// admin API can still show the final leaderboard, before they are removed. 0 keeps them forever.
+func (s *GameService) SetRetention(retention time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.retention = retention
+}
+
+// EndWithRoom finishes the game played in a room that is closing, as a game without its room
+// could never be advanced again. It returns ErrInvalidState if the game already finished.
+func (s *GameService) EndWithRoom(ctx context.Context, gameID string) error {
+	game, found := s.GetGame(gameID)
+	if !found {
+		return ErrGameNotFound
+	}
+	return game.forceEnd(ctx, "room closed")
+}
+
+// removeGame forgets the game, unless its room has started another one since.
+func (s *GameService) removeGame(game *Game) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.games[game.ID] == game {
+		delete(s.games, game.ID)
+		game.logger.Debug("finished game removed")
+	}
+}
+
+// Progress reports the game's state, when it started and, once it has, when it finished.
+func (g *Game) Progress() (state GameState, startedAt, finishedAt time.Time) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.State, g.startedAt, g.finishedAt
+}
@@ -0,0 +1,77 @@
+package game
+
+import "time"
+
+// Message types sent to players by games and self-paced attempts.
+const (
+	MessageShowTitle      = "show_title"
+	MessageShowSection    = "show_section"
+	MessageNewQuestion    = "new_question"
+	MessageQuestionResult = "question_result"
+	MessageGameOver       = "game_over"
+)
+
+// ShowTitlePayload is sent when a game or attempt starts.
+type ShowTitlePayload struct {
+	Title          string     `json:"title"`
+	Description    string     `json:"description"`
+	TotalQuestions int        `json:"totalQuestions,omitempty"` // Self-paced attempts only
+	ClosesAt       *time.Time `json:"closesAt,omitempty"`       // Self-paced attempts only
+}
+
+// ShowSectionPayload is sent before the first question of each section of a live game.
+type ShowSectionPayload struct {
+	ID    int    `json:"id"` // 1-based position of the section
+	Title string `json:"title"`
+}
+
+// NewQuestionPayload is a question as shown to players, without its answer.
+type NewQuestionPayload struct {
+	QuestionText    string   `json:"questionText"`
+	Options         []string `json:"options"`
+	TimeLimit       int      `json:"timeLimit"`
+	Points          int      `json:"points"`
+	QuestionNumber  int      `json:"questionNumber"`
+	TotalQuestions  int      `json:"totalQuestions"`
+	ImageURL        string   `json:"imageUrl,omitempty"`
+	AudioURL        string   `json:"audioUrl,omitempty"`
+	OptionImageURLs []string `json:"optionImageUrls,omitempty"`
+}
+
+// QuestionScore is the points one player earned for a question.
+type QuestionScore struct {
+	ID    string `json:"ID"`
+	Name  string `json:"Name"`
+	Score int    `json:"Score"`
+}
+
+// QuestionResultPayload closes a question of a live game.
+type QuestionResultPayload struct {
+	CorrectOptionIndex int                      `json:"correctOptionIndex"`
+	Explanation        string                   `json:"explanation"`
+	Leaderboard        map[string]QuestionScore `json:"leaderboard"` // Keyed by player ID
+	Stats              QuestionStats            `json:"stats"`
+}
+
+// AttemptResultPayload closes a question of a self-paced attempt.
+type AttemptResultPayload struct {
+	CorrectOptionIndex int    `json:"correctOptionIndex"`
+	Explanation        string `json:"explanation"`
+	AnswerIndex        int    `json:"answerIndex"` // -1 when the question timed out
+	Points             int    `json:"points"`
+	Score              int    `json:"score"`
+	QuestionNumber     int    `json:"questionNumber"`
+	TotalQuestions     int    `json:"totalQuestions"`
+}
+
+// GameOverPayload ends a live game, with the players sorted by score.
+type GameOverPayload struct {
+	Leaderboard []LeaderboardEntry `json:"leaderboard"`
+}
+
+// AttemptOverPayload ends a self-paced attempt.
+type AttemptOverPayload struct {
+	Score          int `json:"score"`
+	CorrectAnswers int `json:"correctAnswers"`
+	TotalQuestions int `json:"totalQuestions"`
+}
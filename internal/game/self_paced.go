@@ -0,0 +1,350 @@
+// internal/game/self_paced.go
+package game
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/oblongtable/beanbag-backend/internal/quiz"
+)
+
+// Assignment is a quiz shared through a link that players work through at their own pace
+// while it is open.
+type Assignment struct {
+	ID       int32
+	Code     string
+	QuizID   int32
+	OpensAt  time.Time
+	ClosesAt time.Time
+}
+
+// AssignmentLookup finds assignments by their share code.
+type AssignmentLookup interface {
+	GetAssignment(ctx context.Context, code string) (*Assignment, error)
+}
+
+// SendFunc sends a message to a single player.
+type SendFunc func(msgType string, payload interface{})
+
+// Attempt is one player's independent run through an assigned quiz.
+// The player advances whenever they are ready; each question's timer is enforced here,
+// so a player who stops answering is moved on to the results when time runs out.
+// The attempt ends when the assignment closes, wherever the player is.
+type Attempt struct {
+	PlayerID   string
+	PlayerName string
+	UserID     int32 // 0 for guests
+	Assignment Assignment
+	State      GameState
+
+	quiz      *quiz.Quiz
+	questions []quiz.Question // All questions of every section, in order
+	current   int             // Index into questions of the current or next question
+
+	questionStartTime time.Time
+	questionTimer     *time.Timer
+	questionSeq       int // Incremented per question so a stale timer can't finish a later one
+	closeTimer        *time.Timer
+
+	score     int
+	answers   []AnswerRecord
+	startedAt time.Time
+
+	send     SendFunc
+	recorder SessionRecorder
+	listener EventListener
+	onDone   func(*Attempt) // Called once the attempt has finished or been abandoned
+	logger   *slog.Logger   // Tags every line with the assignment and player
+
+	mu sync.Mutex
+}
+
+func newAttempt(assignment Assignment, q *quiz.Quiz, player InitialPlayerInfo, send SendFunc, recorder SessionRecorder, listener EventListener, onDone func(*Attempt)) *Attempt {
+	var questions []quiz.Question
+	for _, section := range q.Sections {
+		questions = append(questions, section.Questions...)
+	}
+	return &Attempt{
+		PlayerID:   player.ID,
+		PlayerName: player.Username,
+		UserID:     player.UserID,
+		Assignment: assignment,
+		State:      StateLobby,
+		quiz:       q,
+		questions:  questions,
+		send:       send,
+		recorder:   recorder,
+		listener:   listener,
+		onDone:     onDone,
+		logger:     slog.Default().With("assignment_code", assignment.Code, "client_id", player.ID),
+	}
+}
+
+// start shows the title screen. The player calls next to see the first question.
+func (a *Attempt) start() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.State != StateLobby {
+		return ErrAttemptStarted
+	}
+	a.State = StateTitle
+	a.startedAt = time.Now()
+	a.closeTimer = time.AfterFunc(time.Until(a.Assignment.ClosesAt), a.close)
+	a.send(MessageShowTitle, ShowTitlePayload{
+		Title:          a.quiz.Title,
+		Description:    a.quiz.Description,
+		TotalQuestions: len(a.questions),
+		ClosesAt:       &a.Assignment.ClosesAt,
+	})
+	a.logger.Info("attempt started", "player_name", a.PlayerName)
+	return nil
+}
+
+// next moves from the title or a question result to the next question, or ends the attempt.
+func (a *Attempt) next() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.State != StateFinished && a.closed() {
+		a.expire()
+		return ErrAssignmentClosed
+	}
+	switch a.State {
+	case StateTitle, StateScores:
+		if a.current < len(a.questions) {
+			a.startQuestion()
+		} else {
+			a.finish()
+		}
+		return nil
+	case StateQuestion:
+		return ErrQuestionOpen
+	default:
+		return ErrAttemptFinished
+	}
+}
+
+// startQuestion sends the current question and starts its timer.
+// It assumes the mutex is already locked by the caller.
+func (a *Attempt) startQuestion() {
+	q := a.questions[a.current]
+	a.State = StateQuestion
+	a.questionStartTime = time.Now()
+	a.questionSeq++
+
+	a.send(MessageNewQuestion, questionPayload(q, a.current+1, len(a.questions)))
+
+	seq := a.questionSeq
+	a.questionTimer = time.AfterFunc(time.Duration(q.TimeLimit)*time.Second, func() {
+		a.timeout(seq)
+	})
+}
+
+// answer records the player's choice for the current question if its time has not run out.
+func (a *Attempt) answer(answerIndex int) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.State != StateQuestion {
+		return ErrNotAcceptingAnswers
+	}
+	if a.closed() {
+		a.expire()
+		return ErrAssignmentClosed
+	}
+	q := a.questions[a.current]
+	if answerIndex >= len(q.Options) {
+		return ErrNoSuchOption
+	}
+	timeTaken := time.Since(a.questionStartTime)
+	if timeTaken > time.Duration(q.TimeLimit)*time.Second {
+		// The timer is about to fire and will close the question
+		return ErrTimeUp
+	}
+
+	a.questionTimer.Stop()
+	a.finishQuestion(answerIndex, timeTaken)
+	return nil
+}
+
+// timeout closes the question when its timer fires without an answer.
+func (a *Attempt) timeout(seq int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.State != StateQuestion || a.questionSeq != seq {
+		return
+	}
+	a.logger.Info("question timed out", "question", a.current+1)
+	a.finishQuestion(-1, time.Duration(a.questions[a.current].TimeLimit)*time.Second)
+}
+
+// closed reports whether the assignment has closed.
+func (a *Attempt) closed() bool {
+	return !time.Now().Before(a.Assignment.ClosesAt)
+}
+
+// close ends the attempt when the assignment closes, even if the player is idle.
+func (a *Attempt) close() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.expire()
+}
+
+// expire ends the attempt because the assignment closed. An open question counts as unanswered.
+// It assumes the mutex is already locked by the caller.
+func (a *Attempt) expire() {
+	if a.State == StateFinished {
+		return
+	}
+	a.logger.Info("assignment closed during the attempt", "question", a.current+1)
+	if a.State == StateQuestion {
+		a.questionTimer.Stop()
+		a.finishQuestion(-1, 0)
+	}
+	a.finish()
+}
+
+// finishQuestion scores the answer and sends the result. An answerIndex of -1 means no answer.
+// It assumes the mutex is already locked by the caller.
+func (a *Attempt) finishQuestion(answerIndex int, timeTaken time.Duration) {
+	q := a.questions[a.current]
+	record := AnswerRecord{
+		PlayerID:      a.PlayerID,
+		QuestionIndex: a.current,
+		QuestionID:    q.ID,
+		AnswerIndex:   answerIndex,
+		AnsweredAt:    time.Now(),
+	}
+	if answerIndex >= 0 {
+		record.TimeTaken = timeTaken
+		if answerIndex == q.CorrectOptionIndex {
+			record.Correct = true
+			record.Points = scoreAnswer(q.Points, q.TimeLimit, timeTaken)
+			a.score += record.Points
+		}
+	}
+	a.answers = append(a.answers, record)
+	a.emit(EventQuestionFinished, []AnswerRecord{record}, false)
+
+	a.State = StateScores
+	a.send(MessageQuestionResult, AttemptResultPayload{
+		CorrectOptionIndex: q.CorrectOptionIndex,
+		Explanation:        q.Explanation,
+		AnswerIndex:        answerIndex,
+		Points:             record.Points,
+		Score:              a.score,
+		QuestionNumber:     a.current + 1,
+		TotalQuestions:     len(a.questions),
+	})
+	a.current++
+}
+
+// finish ends the attempt and records it.
+// It assumes the mutex is already locked by the caller.
+func (a *Attempt) finish() {
+	a.State = StateFinished
+	if a.closeTimer != nil {
+		a.closeTimer.Stop()
+	}
+
+	correct := 0
+	for _, r := range a.answers {
+		if r.Correct {
+			correct++
+		}
+	}
+	a.send(MessageGameOver, AttemptOverPayload{
+		Score:          a.score,
+		CorrectAnswers: correct,
+		TotalQuestions: len(a.questions),
+	})
+	a.logger.Info("attempt finished", "score", a.score)
+
+	a.record(time.Now())
+	a.emit(EventGameFinished, a.answers, false)
+	if a.onDone != nil {
+		go a.onDone(a)
+	}
+}
+
+// abandon stops an unfinished attempt, e.g. when the player disconnects.
+// Questions answered so far are still recorded so the teacher can see partial progress.
+func (a *Attempt) abandon() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.State == StateFinished {
+		return
+	}
+	if a.questionTimer != nil {
+		a.questionTimer.Stop()
+	}
+	if a.closeTimer != nil {
+		a.closeTimer.Stop()
+	}
+	a.State = StateFinished
+	a.logger.Info("attempt abandoned", "answers", len(a.answers))
+
+	if len(a.answers) > 0 {
+		a.record(time.Time{})
+	}
+	a.emit(EventGameFinished, a.answers, true)
+}
+
+// emit notifies the listener of an attempt event.
+// It assumes the mutex is already locked by the caller.
+func (a *Attempt) emit(eventType EventType, answers []AnswerRecord, abandoned bool) {
+	if a.listener == nil {
+		return
+	}
+	userIDs := make(map[string]int32)
+	if a.UserID != 0 {
+		userIDs[a.PlayerID] = a.UserID
+	}
+	a.listener.HandleGameEvent(Event{
+		Type:           eventType,
+		GameID:         a.Assignment.Code,
+		Mode:           ModeSelfPaced,
+		UserIDs:        userIDs,
+		Answers:        append([]AnswerRecord(nil), answers...),
+		TotalQuestions: len(a.questions),
+		Abandoned:      abandoned,
+	})
+}
+
+// record hands the attempt to the session recorder. A zero finishedAt marks an unfinished attempt.
+// It assumes the mutex is already locked by the caller.
+func (a *Attempt) record(finishedAt time.Time) {
+	if a.recorder == nil {
+		return
+	}
+	answers := make([]AnswerRecord, len(a.answers))
+	copy(answers, a.answers)
+
+	result := SessionResult{
+		Mode:         ModeSelfPaced,
+		GameID:       a.Assignment.Code,
+		QuizID:       a.Assignment.QuizID,
+		AssignmentID: a.Assignment.ID,
+		StartedAt:    a.startedAt,
+		FinishedAt:   finishedAt,
+		Players: []PlayerResult{{
+			PlayerID:   a.PlayerID,
+			UserID:     a.UserID,
+			Name:       a.PlayerName,
+			Score:      a.score,
+			StartedAt:  a.startedAt,
+			FinishedAt: finishedAt,
+		}},
+		Answers: answers,
+	}
+	go func() {
+		if err := a.recorder.RecordSession(context.Background(), result); err != nil {
+			a.logger.Error("failed to record attempt", "error", err)
+		}
+	}()
+}
@@ -0,0 +1,122 @@
+// internal/game/session.go
+package game
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+type SessionMode string
+
+const (
+	ModeLive      SessionMode = "live"       // Host-driven through quiz_forward
+	ModeSelfPaced SessionMode = "self_paced" // Each player progresses on their own
+)
+
+// AnswerRecord is one player's outcome for one question.
+type AnswerRecord struct {
+	PlayerID      string
+	QuestionIndex int   // Position of the question across all sections, starting at 0
+	QuestionID    int32 // questions.ques_id, 0 for the built-in quiz
+	AnswerIndex   int   // -1 when the player did not answer in time
+	Correct       bool
+	TimeTaken     time.Duration
+	Points        int
+	AnsweredAt    time.Time
+}
+
+// PlayerResult is a player's final standing in a session.
+type PlayerResult struct {
+	PlayerID   string
+	UserID     int32 // 0 for guests
+	Name       string
+	Score      int
+	Rank       int // 0 when the session is not ranked (self-paced attempts)
+	StartedAt  time.Time
+	FinishedAt time.Time // Zero if the player did not finish
+}
+
+// SessionResult is everything the game engine reports about a finished game or attempt.
+type SessionResult struct {
+	Mode         SessionMode
+	GameID       string // Room code for live games, assignment code for self-paced attempts
+	QuizID       int32  // 0 for the built-in quiz
+	AssignmentID int32  // 0 for live games
+	StartedAt    time.Time
+	FinishedAt   time.Time
+	Players      []PlayerResult
+	Answers      []AnswerRecord
+}
+
+// SessionRecorder persists session results.
+type SessionRecorder interface {
+	RecordSession(ctx context.Context, result SessionResult) error
+}
+
+// scoreAnswer returns the points for an answer: base points plus up to 50% for answering quickly.
+func scoreAnswer(points, timeLimit int, timeTaken time.Duration) int {
+	timeBonus := float64(points) * 0.5 * (1 - (timeTaken.Seconds() / float64(timeLimit)))
+	if timeBonus < 0 {
+		timeBonus = 0
+	}
+	return points + int(timeBonus)
+}
+
+// FastestAnswer identifies the player who answered a question correctly in the least time.
+type FastestAnswer struct {
+	PlayerID string `json:"id"`
+	Name     string `json:"name"`
+	TimeMs   int64  `json:"timeMs"`
+}
+
+// QuestionStats summarises how the players answered a question.
+type QuestionStats struct {
+	AnswerCounts   []int          `json:"answerCounts"`   // Players who chose each option
+	Players        int            `json:"players"`        // Players who were asked the question
+	Responses      int            `json:"responses"`      // Players who answered in time
+	PercentCorrect float64        `json:"percentCorrect"` // Of all players, no answer counts as wrong
+	AverageTimeMs  int64          `json:"averageTimeMs"`  // Of the players who answered
+	FastestTimeMs  int64          `json:"fastestTimeMs"`
+	FastestCorrect *FastestAnswer `json:"fastestCorrect"` // nil when nobody answered correctly
+}
+
+// questionStats aggregates the records of a single question with optionCount options.
+func questionStats(optionCount int, records []AnswerRecord, playerName func(playerID string) string) QuestionStats {
+	stats := QuestionStats{
+		AnswerCounts: make([]int, optionCount),
+		Players:      len(records),
+	}
+	correct := 0
+	var totalTime time.Duration
+	for _, r := range records {
+		if r.AnswerIndex < 0 {
+			continue
+		}
+		if r.AnswerIndex < optionCount {
+			stats.AnswerCounts[r.AnswerIndex]++
+		}
+		stats.Responses++
+		totalTime += r.TimeTaken
+		if ms := r.TimeTaken.Milliseconds(); stats.Responses == 1 || ms < stats.FastestTimeMs {
+			stats.FastestTimeMs = ms
+		}
+		if r.Correct {
+			correct++
+			if stats.FastestCorrect == nil || r.TimeTaken.Milliseconds() < stats.FastestCorrect.TimeMs {
+				stats.FastestCorrect = &FastestAnswer{
+					PlayerID: r.PlayerID,
+					Name:     playerName(r.PlayerID),
+					TimeMs:   r.TimeTaken.Milliseconds(),
+				}
+			}
+		}
+	}
+	if stats.Players > 0 {
+		stats.PercentCorrect = math.Round(float64(correct)*1000/float64(stats.Players)) / 10
+	}
+	if stats.Responses > 0 {
+		stats.AverageTimeMs = (totalTime / time.Duration(stats.Responses)).Milliseconds()
+	}
+	return stats
+}
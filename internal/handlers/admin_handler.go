@@ -0,0 +1,222 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+	"github.com/oblongtable/beanbag-backend/internal/apimodels"
+	"github.com/oblongtable/beanbag-backend/internal/game"
+	"github.com/oblongtable/beanbag-backend/internal/logging"
+	"github.com/oblongtable/beanbag-backend/internal/roomcode"
+	"github.com/oblongtable/beanbag-backend/websocket"
+)
+
+// AdminHandler lets admins see and unstick live rooms and games.
+type AdminHandler struct {
+	wssvr *websocket.WebSocServer
+}
+
+func NewAdminHandler(wssvr *websocket.WebSocServer) *AdminHandler {
+	return &AdminHandler{wssvr: wssvr}
+}
+
+// roomModel describes the room and its game, if one has been started.
+func (h *AdminHandler) roomModel(room *websocket.Room) (apimodels.AdminRoomApiModel, error) {
+	snap, err := room.Snapshot()
+	if err != nil {
+		return apimodels.AdminRoomApiModel{}, err
+	}
+	model := apimodels.AdminRoomApiModel{
+		RoomCode:     snap.ID,
+		Name:         snap.Name,
+		Size:         snap.Size,
+		CreatorID:    snap.CreatorID,
+		HostID:       snap.HostID,
+		Participants: make([]apimodels.AdminParticipantApiModel, 0, len(snap.Participants)),
+	}
+	for _, p := range snap.Participants {
+		model.Participants = append(model.Participants, apimodels.AdminParticipantApiModel{
+			ClientID:    p.ClientID,
+			Username:    p.Username,
+			UserID:      p.UserID,
+			Role:        p.Role.String(),
+			UserLobbyID: p.UserLobbyId,
+			JoinedAt:    p.JoinedAt,
+		})
+	}
+
+	gameSnap, err := h.wssvr.Games.Snapshot(room.ID)
+	if errors.Is(err, game.ErrGameNotFound) {
+		return model, nil
+	}
+	if err != nil {
+		return apimodels.AdminRoomApiModel{}, err
+	}
+	gameModel := &apimodels.AdminGameApiModel{
+		State:     string(gameSnap.State),
+		QuizID:    gameSnap.QuizID,
+		QuizTitle: gameSnap.QuizTitle,
+		HostID:    gameSnap.HostID,
+		Section:   gameSnap.Section,
+		Sections:  gameSnap.Sections,
+		Question:  gameSnap.Question,
+		Questions: gameSnap.Questions,
+		Answered:  gameSnap.Answered,
+		Scores:    make([]apimodels.AdminPlayerScoreApiModel, 0, len(gameSnap.Players)),
+	}
+	if !gameSnap.StartedAt.IsZero() {
+		gameModel.StartedAt = &gameSnap.StartedAt
+	}
+	for _, p := range gameSnap.Players {
+		gameModel.Scores = append(gameModel.Scores, apimodels.AdminPlayerScoreApiModel{ClientID: p.ID, Name: p.Name, Score: p.Score})
+	}
+	model.Game = gameModel
+	return model, nil
+}
+
+// respondError maps room and game errors to responses.
+func (h *AdminHandler) respondError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, websocket.ErrRoomNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Room not found"})
+	case errors.Is(err, game.ErrGameNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "No game has been started in this room"})
+	case errors.Is(err, game.ErrInvalidState):
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		logging.FromGin(ctx).Error("admin room action failed", "room_id", ctx.Param("code"), "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to inspect the room"})
+	}
+}
+
+// ListRooms godoc
+// @Summary List live rooms
+// @Description Every open room with its participants, their roles and the state of its game. Requires the admin role and scope.
+// @Tags admin
+// @Produce json
+// @Success 200 {array} apimodels.AdminRoomApiModel
+// @Failure 403 {object} map[string]string "Missing the admin role or scope"
+// @Router /admin/rooms [get]
+// @Security BearerAuth
+func (h *AdminHandler) ListRooms(ctx *gin.Context) {
+	// Snapshot outside Range, a room takes the list's lock when it shuts down
+	var rooms []*websocket.Room
+	h.wssvr.Rooms.Range(func(r *websocket.Room) bool {
+		rooms = append(rooms, r)
+		return true
+	})
+
+	models := make([]apimodels.AdminRoomApiModel, 0, len(rooms))
+	for _, room := range rooms {
+		model, err := h.roomModel(room)
+		if errors.Is(err, websocket.ErrRoomNotFound) {
+			continue // Closed meanwhile
+		}
+		if err != nil {
+			h.respondError(ctx, err)
+			return
+		}
+		models = append(models, model)
+	}
+	sort.Slice(models, func(i, j int) bool { return models[i].RoomCode < models[j].RoomCode })
+
+	ctx.JSON(http.StatusOK, models)
+}
+
+// GetRoom godoc
+// @Summary Inspect a live room
+// @Description The room's participants and roles, and its game's state, current section and question and scores. Requires the admin role and scope.
+// @Tags admin
+// @Produce json
+// @Param code path string true "Room code"
+// @Success 200 {object} apimodels.AdminRoomApiModel
+// @Failure 403 {object} map[string]string "Missing the admin role or scope"
+// @Failure 404 {object} map[string]string "Room not found"
+// @Router /admin/rooms/{code} [get]
+// @Security BearerAuth
+func (h *AdminHandler) GetRoom(ctx *gin.Context) {
+	room, ok := h.wssvr.Rooms.Get(roomcode.Normalize(ctx.Param("code")))
+	if !ok {
+		h.respondError(ctx, websocket.ErrRoomNotFound)
+		return
+	}
+	model, err := h.roomModel(room)
+	if err != nil {
+		h.respondError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, model)
+}
+
+// AdvanceGame godoc
+// @Summary Force a game forward
+// @Description Does what the host's next button would, or closes the open question early. Requires the admin role and scope.
+// @Tags admin
+// @Produce json
+// @Param code path string true "Room code"
+// @Success 200 {object} apimodels.AdminRoomApiModel
+// @Failure 403 {object} map[string]string "Missing the admin role or scope"
+// @Failure 404 {object} map[string]string "Room or game not found"
+// @Failure 409 {object} map[string]string "The game can't move on from its state"
+// @Router /admin/rooms/{code}/advance [post]
+// @Security BearerAuth
+func (h *AdminHandler) AdvanceGame(ctx *gin.Context) {
+	room, ok := h.wssvr.Rooms.Get(roomcode.Normalize(ctx.Param("code")))
+	if !ok {
+		h.respondError(ctx, websocket.ErrRoomNotFound)
+		return
+	}
+	if err := h.wssvr.Games.ForceAdvance(ctx.Request.Context(), room.ID); err != nil {
+		h.respondError(ctx, err)
+		return
+	}
+	logging.FromGin(ctx).Warn("admin advanced game", "room_id", room.ID)
+	h.GetRoom(ctx)
+}
+
+// EndGame godoc
+// @Summary Force a game to end
+// @Description Finishes the game now and shows everyone the leaderboard as it stands. Requires the admin role and scope.
+// @Tags admin
+// @Produce json
+// @Param code path string true "Room code"
+// @Success 200 {object} apimodels.AdminRoomApiModel
+// @Failure 403 {object} map[string]string "Missing the admin role or scope"
+// @Failure 404 {object} map[string]string "Room or game not found"
+// @Failure 409 {object} map[string]string "The game is already over"
+// @Router /admin/rooms/{code}/end [post]
+// @Security BearerAuth
+func (h *AdminHandler) EndGame(ctx *gin.Context) {
+	room, ok := h.wssvr.Rooms.Get(roomcode.Normalize(ctx.Param("code")))
+	if !ok {
+		h.respondError(ctx, websocket.ErrRoomNotFound)
+		return
+	}
+	if err := h.wssvr.Games.ForceEnd(ctx.Request.Context(), room.ID); err != nil {
+		h.respondError(ctx, err)
+		return
+	}
+	logging.FromGin(ctx).Warn("admin ended game", "room_id", room.ID)
+	h.GetRoom(ctx)
+}
+
+// CloseRoom godoc
+// @Summary Close a room
+// @Description Ends the room's game and sends everyone in it away, as if its creator had left. Requires the admin role and scope.
+// @Tags admin
+// @Param code path string true "Room code"
+// @Success 204
+// @Failure 403 {object} map[string]string "Missing the admin role or scope"
+// @Failure 404 {object} map[string]string "Room not found"
+// @Router /admin/rooms/{code} [delete]
+// @Security BearerAuth
+func (h *AdminHandler) CloseRoom(ctx *gin.Context) {
+	if err := h.wssvr.CloseRoom(ctx.Request.Context(), ctx.Param("code")); err != nil {
+		h.respondError(ctx, err)
+		return
+	}
+	logging.FromGin(ctx).Warn("admin closed room", "room_id", ctx.Param("code"))
+	ctx.Status(http.StatusNoContent)
+}
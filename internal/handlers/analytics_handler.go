@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/oblongtable/beanbag-backend/internal/apimodels"
+	"github.com/oblongtable/beanbag-backend/internal/logging"
+	"github.com/oblongtable/beanbag-backend/internal/services"
+)
+
+type AnalyticsHandler struct {
+	analyticsService *services.AnalyticsService
+	userService      *services.UserService
+}
+
+func NewAnalyticsHandler(analyticsService *services.AnalyticsService, userService *services.UserService) *AnalyticsHandler {
+	return &AnalyticsHandler{analyticsService: analyticsService, userService: userService}
+}
+
+// GetQuizAnalytics godoc
+// @Summary Get analytics for a quiz across all its sessions
+// @Description Per question: correctness rate, average response time, most common wrong option and discrimination index (top 27% vs bottom 27% of everyone who played the quiz, by score). Also the number of plays per day. Only the quiz's creator can see them. With format=csv only the per-question table is returned, as a CSV download.
+// @Tags quizzes
+// @Produce json
+// @Produce text/csv
+// @Param id path int true "Quiz ID"
+// @Param format query string false "Response format" Enums(json, csv)
+// @Success 200 {object} apimodels.QuizAnalyticsApiModel
+// @Failure 400 {object} map[string]string "Invalid ID"
+// @Failure 403 {object} map[string]string "Not the quiz's creator"
+// @Failure 404 {object} map[string]string "Quiz not found"
+// @Failure 500 {object} map[string]string
+// @Router /quizzes/{id}/analytics [get]
+// @Security BearerAuth
+func (h *AnalyticsHandler) GetQuizAnalytics(ctx *gin.Context) {
+	quizID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid quiz ID format"})
+		return
+	}
+	format := ctx.DefaultQuery("format", "json")
+	if format != "json" && format != "csv" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "format must be json or csv"})
+		return
+	}
+
+	user, ok := lookupCurrentUser(ctx, h.userService)
+	if !ok {
+		return
+	}
+
+	analytics, err := h.analyticsService.QuizAnalytics(ctx.Request.Context(), int32(quizID), user.UserID)
+	if err != nil {
+		if respondOwnershipError(ctx, err) {
+			return
+		}
+		logging.FromGin(ctx).Error("QuizAnalytics failed", "quiz_id", quizID, "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve quiz analytics"})
+		return
+	}
+
+	if format == "csv" {
+		ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="quiz-%d-analytics.csv"`, quizID))
+		ctx.Header("Content-Type", "text/csv")
+		ctx.Status(http.StatusOK)
+		if err := writeQuestionAnalyticsCSV(ctx.Writer, analytics.Questions); err != nil {
+			logging.FromGin(ctx).Error("writing analytics CSV failed", "quiz_id", quizID, "error", err)
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, analytics)
+}
+
+func writeQuestionAnalyticsCSV(w http.ResponseWriter, questions []apimodels.QuestionAnalyticsApiModel) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{
+		"question_id", "question", "attempts", "responses", "percent_correct", "average_time_ms",
+		"most_common_wrong_answer", "most_common_wrong_answer_players", "discrimination_index",
+	})
+	for _, q := range questions {
+		var wrongText, wrongPlayers, discrimination string
+		if q.CommonWrong != nil {
+			wrongText = q.CommonWrong.Text
+			wrongPlayers = strconv.FormatInt(q.CommonWrong.Players, 10)
+		}
+		if q.Discrimination != nil {
+			discrimination = strconv.FormatFloat(*q.Discrimination, 'f', 2, 64)
+		}
+		cw.Write([]string{
+			strconv.Itoa(int(q.QuestionID)),
+			q.Text,
+			strconv.FormatInt(q.Attempts, 10),
+			strconv.FormatInt(q.Responses, 10),
+			strconv.FormatFloat(q.PercentCorrect, 'f', 1, 64),
+			strconv.FormatInt(q.AverageTimeMs, 10),
+			wrongText,
+			wrongPlayers,
+			discrimination,
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
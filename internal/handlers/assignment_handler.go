@@ -0,0 +1,198 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/oblongtable/beanbag-backend/db"
+	"github.com/oblongtable/beanbag-backend/internal/logging"
+	"github.com/oblongtable/beanbag-backend/internal/services"
+	"github.com/oblongtable/beanbag-backend/middleware"
+)
+
+type AssignmentHandler struct {
+	assignmentService *services.AssignmentService
+	userService       *services.UserService
+	shareBaseURL      string
+}
+
+// shareBaseURL is the frontend origin the share links point at.
+func NewAssignmentHandler(assignmentService *services.AssignmentService, userService *services.UserService, shareBaseURL string) *AssignmentHandler {
+	return &AssignmentHandler{
+		assignmentService: assignmentService,
+		userService:       userService,
+		shareBaseURL:      strings.TrimRight(shareBaseURL, "/"),
+	}
+}
+
+// CreateAssignmentRequest represents the request body for assigning a quiz.
+// @Description Assignment details. opens_at defaults to now.
+type CreateAssignmentRequest struct {
+	QuizID   int32      `json:"quiz_id" binding:"required"`
+	OpensAt  *time.Time `json:"opens_at"`
+	ClosesAt time.Time  `json:"closes_at" binding:"required"`
+}
+
+// AssignmentResponse describes an assignment and the link to share with players.
+// @Description Assignment details
+type AssignmentResponse struct {
+	AssignmentID int32     `json:"assignment_id"`
+	Code         string    `json:"code"`
+	QuizID       int32     `json:"quiz_id"`
+	OpensAt      time.Time `json:"opens_at"`
+	ClosesAt     time.Time `json:"closes_at"`
+	ShareURL     string    `json:"share_url"`
+}
+
+// AssignmentResultEntry is one player's attempt at an assignment.
+// @Description Attempt result
+type AssignmentResultEntry struct {
+	Rank           int        `json:"rank"`
+	Name           string     `json:"name"`
+	Score          int32      `json:"score"`
+	CorrectAnswers int64      `json:"correct_answers"`
+	QuestionsSeen  int64      `json:"questions_seen"`
+	Completed      bool       `json:"completed"`
+	StartedAt      time.Time  `json:"started_at"`
+	FinishedAt     *time.Time `json:"finished_at,omitempty"`
+}
+
+func (h *AssignmentHandler) toResponse(a *db.Assignment) AssignmentResponse {
+	return AssignmentResponse{
+		AssignmentID: a.AssignmentID,
+		Code:         a.Code,
+		QuizID:       a.QuizID,
+		OpensAt:      a.OpensAt,
+		ClosesAt:     a.ClosesAt,
+		ShareURL:     h.shareBaseURL + "/assignments/" + a.Code,
+	}
+}
+
+// CreateAssignment godoc
+// @Summary Assign a quiz for self-paced play
+// @Description Creates a shareable assignment link. Players can start the quiz between opens_at and closes_at and progress through it on their own.
+// @Tags assignments
+// @Accept json
+// @Produce json
+// @Param assignment body CreateAssignmentRequest true "Assignment details"
+// @Success 201 {object} AssignmentResponse
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string "Quiz not found"
+// @Failure 500 {object} map[string]string
+// @Router /assignments [post]
+// @Security BearerAuth
+func (h *AssignmentHandler) CreateAssignment(ctx *gin.Context) {
+	var req CreateAssignmentRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
+		return
+	}
+
+	opensAt := time.Now()
+	if req.OpensAt != nil {
+		opensAt = *req.OpensAt
+	}
+
+	jwtSub := ctx.GetString(middleware.GinContextKeyUserSub)
+	jwtEmail := ctx.GetString(middleware.GinContextKeyUserEmail)
+	assignment, err := h.assignmentService.CreateAssignment(ctx.Request.Context(), req.QuizID, jwtSub, jwtEmail, opensAt, req.ClosesAt)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidWindow):
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrQuizNotFound):
+			ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			logging.FromGin(ctx).Error("CreateAssignment failed", "error", err)
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create assignment"})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, h.toResponse(assignment))
+}
+
+// GetAssignment godoc
+// @Summary Get an assignment by its share code
+// @Tags assignments
+// @Produce json
+// @Param code path string true "Assignment share code"
+// @Success 200 {object} AssignmentResponse
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /assignments/{code} [get]
+// @Security BearerAuth
+func (h *AssignmentHandler) GetAssignment(ctx *gin.Context) {
+	assignment, err := h.assignmentService.GetAssignmentByCode(ctx.Request.Context(), ctx.Param("code"))
+	if err != nil {
+		if errors.Is(err, services.ErrAssignmentNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		logging.FromGin(ctx).Error("GetAssignmentByCode failed", "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve assignment"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, h.toResponse(assignment))
+}
+
+// GetAssignmentResults godoc
+// @Summary List the results of an assignment
+// @Description Every recorded attempt, best score first. Attempts abandoned part-way are included with completed=false. Only the teacher who set the assignment or created its quiz can see them.
+// @Tags assignments
+// @Produce json
+// @Param code path string true "Assignment share code"
+// @Success 200 {array} AssignmentResultEntry
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /assignments/{code}/results [get]
+// @Security BearerAuth
+func (h *AssignmentHandler) GetAssignmentResults(ctx *gin.Context) {
+	user, ok := lookupCurrentUser(ctx, h.userService)
+	if !ok {
+		return
+	}
+
+	rows, err := h.assignmentService.AssignmentResults(ctx.Request.Context(), ctx.Param("code"), user.UserID)
+	if err != nil {
+		if respondOwnershipError(ctx, err) {
+			return
+		}
+		if errors.Is(err, services.ErrAssignmentNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		logging.FromGin(ctx).Error("AssignmentResults failed", "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve assignment results"})
+		return
+	}
+
+	results := make([]AssignmentResultEntry, 0, len(rows))
+	for i, row := range rows {
+		// Rows are sorted by score, players with the same score share a rank
+		rank := i + 1
+		if i > 0 && row.Score == rows[i-1].Score {
+			rank = results[i-1].Rank
+		}
+		entry := AssignmentResultEntry{
+			Rank:           rank,
+			Name:           row.DisplayName,
+			Score:          row.Score,
+			CorrectAnswers: row.CorrectAnswers,
+			QuestionsSeen:  row.QuestionsSeen,
+			Completed:      row.FinishedAt.Valid,
+			StartedAt:      row.StartedAt,
+		}
+		if row.FinishedAt.Valid {
+			entry.FinishedAt = &row.FinishedAt.Time
+		}
+		results = append(results, entry)
+	}
+
+	ctx.JSON(http.StatusOK, results)
+}
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/oblongtable/beanbag-backend/internal/logging"
+	"github.com/oblongtable/beanbag-backend/middleware"
+)
+
+// DevHandler hands out access tokens when the server signs its own, see AUTH_PROVIDER=dev.
+type DevHandler struct {
+	auth *middleware.Authenticator
+}
+
+func NewDevHandler(auth *middleware.Authenticator) *DevHandler {
+	return &DevHandler{auth: auth}
+}
+
+// DevTokenRequest describes the user the token is for.
+// @Description Claims of the dev access token
+type DevTokenRequest struct {
+	Email     string `json:"email" binding:"required,email" example:"teacher@example.com"`
+	Subject   string `json:"sub" example:"dev|teacher"` // Defaults to dev|<email>
+	Scope     string `json:"scope" example:"write:quizzes admin"`
+	ExpiresIn int    `json:"expires_in" example:"3600"` // Seconds, defaults to an hour
+}
+
+// DevTokenResponse is an access token for the /api routes.
+type DevTokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type" example:"Bearer"`
+	ExpiresIn   int    `json:"expires_in" example:"3600"`
+}
+
+// IssueToken godoc
+// @Summary Get a dev access token
+// @Description Signs an access token for any user and scopes. Only exists when the server runs with AUTH_PROVIDER=dev, never in production.
+// @Tags dev
+// @Accept json
+// @Produce json
+// @Param claims body DevTokenRequest true "Who the token is for"
+// @Success 200 {object} DevTokenResponse
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /dev/token [post]
+func (h *DevHandler) IssueToken(ctx *gin.Context) {
+	var req DevTokenRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
+		return
+	}
+	if req.ExpiresIn < 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "expires_in must not be negative"})
+		return
+	}
+	if req.Subject == "" {
+		req.Subject = "dev|" + req.Email
+	}
+	if req.ExpiresIn == 0 {
+		req.ExpiresIn = int(time.Hour / time.Second)
+	}
+
+	token, err := h.auth.IssueToken(req.Subject, req.Email, req.Scope, time.Duration(req.ExpiresIn)*time.Second)
+	if err != nil {
+		logging.FromGin(ctx).Error("IssueToken failed", "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to issue a token"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, DevTokenResponse{AccessToken: token, TokenType: "Bearer", ExpiresIn: req.ExpiresIn})
+}
@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/oblongtable/beanbag-backend/internal/health"
+	"github.com/oblongtable/beanbag-backend/internal/logging"
+)
+
+type HealthHandler struct {
+	checker   *health.Checker
+	startedAt time.Time
+}
+
+func NewHealthHandler(checker *health.Checker) *HealthHandler {
+	return &HealthHandler{checker: checker, startedAt: time.Now()}
+}
+
+// Liveness godoc
+// @Summary Liveness probe
+// @Description Succeeds as long as the server is up and serving requests. It checks no dependencies, restart the server only when this fails.
+// @Tags health
+// @Produce json
+// @Success 200 {object} map[string]string
+// @Router /healthz [get]
+func (h *HealthHandler) Liveness(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, gin.H{
+		"status": health.StatusOK,
+		"uptime": time.Since(h.startedAt).Round(time.Second).String(),
+	})
+}
+
+// Readiness godoc
+// @Summary Readiness probe
+// @Description Checks the database is reachable and fully migrated, every room is responsive and the server isn't running too many games, plus optional components such as the identity provider's keys. Returns 503 if a critical component is failing and "degraded" if only optional ones are.
+// @Tags health
+// @Produce json
+// @Success 200 {object} health.Report
+// @Failure 503 {object} health.Report
+// @Router /readyz [get]
+func (h *HealthHandler) Readiness(ctx *gin.Context) {
+	report := h.checker.Run(ctx.Request.Context())
+	if report.Status == health.StatusUnavailable {
+		logging.FromGin(ctx).Warn("not ready", "components", report.Components)
+		ctx.JSON(http.StatusServiceUnavailable, report)
+		return
+	}
+	ctx.JSON(http.StatusOK, report)
+}
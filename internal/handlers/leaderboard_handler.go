@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/oblongtable/beanbag-backend/internal/logging"
+	"github.com/oblongtable/beanbag-backend/internal/services"
+	"github.com/oblongtable/beanbag-backend/middleware"
+)
+
+type LeaderboardHandler struct {
+	leaderboardService *services.LeaderboardService
+	userService        *services.UserService
+}
+
+func NewLeaderboardHandler(leaderboardService *services.LeaderboardService, userService *services.UserService) *LeaderboardHandler {
+	return &LeaderboardHandler{
+		leaderboardService: leaderboardService,
+		userService:        userService,
+	}
+}
+
+// GetLeaderboard godoc
+// @Summary Get the global high-score leaderboard
+// @Description Best scores across every quiz. Signed-in players appear once with their best score; how guests appear depends on the server's guest name policy. "you" is the requesting user's own entry.
+// @Tags leaderboards
+// @Produce json
+// @Param period query string false "all (default) or week for the past 7 days" Enums(all, week)
+// @Param limit query int false "Number of entries (default 10, max 100)"
+// @Success 200 {object} apimodels.LeaderboardApiModel
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /leaderboards [get]
+// @Security BearerAuth
+func (h *LeaderboardHandler) GetLeaderboard(ctx *gin.Context) {
+	h.leaderboard(ctx, 0)
+}
+
+// GetQuizLeaderboard godoc
+// @Summary Get the high-score leaderboard of a quiz
+// @Description Best scores of games played with the quiz. Signed-in players appear once with their best score; how guests appear depends on the server's guest name policy. "you" is the requesting user's own entry.
+// @Tags leaderboards
+// @Produce json
+// @Param id path int true "Quiz ID"
+// @Param period query string false "all (default) or week for the past 7 days" Enums(all, week)
+// @Param limit query int false "Number of entries (default 10, max 100)"
+// @Success 200 {object} apimodels.LeaderboardApiModel
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string "Quiz not found"
+// @Failure 500 {object} map[string]string
+// @Router /quizzes/{id}/leaderboard [get]
+// @Security BearerAuth
+func (h *LeaderboardHandler) GetQuizLeaderboard(ctx *gin.Context) {
+	quizID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil || quizID <= 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid quiz ID format"})
+		return
+	}
+	h.leaderboard(ctx, int32(quizID))
+}
+
+func (h *LeaderboardHandler) leaderboard(ctx *gin.Context, quizID int32) {
+	limit, err := strconv.Atoi(ctx.DefaultQuery("limit", "10"))
+	if err != nil || limit < 1 || limit > 100 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 100"})
+		return
+	}
+
+	// Players who haven't synced their account yet simply have no entry of their own
+	var viewerID int32
+	jwtEmail := ctx.GetString(middleware.GinContextKeyUserEmail)
+	if user, err := h.userService.FindUser(ctx.Request.Context(), ctx.GetString(middleware.GinContextKeyUserSub), middleware.VerifiedEmail(ctx)); err == nil {
+		viewerID = user.UserID
+	} else if !errors.Is(err, services.ErrUserNotFound) {
+		logging.FromGin(ctx).Error("looking up authenticated user failed", "email", jwtEmail, "error", err)
+	}
+
+	period := ctx.DefaultQuery("period", services.PeriodAllTime)
+	leaderboard, err := h.leaderboardService.Leaderboard(ctx.Request.Context(), quizID, period, int32(limit), viewerID)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidPeriod):
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrQuizNotFound):
+			ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			logging.FromGin(ctx).Error("Leaderboard failed", "quiz_id", quizID, "error", err)
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve leaderboard"})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, leaderboard)
+}
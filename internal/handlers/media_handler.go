@@ -0,0 +1,235 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/oblongtable/beanbag-backend/db"
+	"github.com/oblongtable/beanbag-backend/internal/logging"
+	"github.com/oblongtable/beanbag-backend/internal/services"
+	"github.com/oblongtable/beanbag-backend/internal/storage"
+)
+
+type MediaHandler struct {
+	mediaService *services.MediaService
+	userService  *services.UserService
+}
+
+func NewMediaHandler(mediaService *services.MediaService, userService *services.UserService) *MediaHandler {
+	return &MediaHandler{mediaService: mediaService, userService: userService}
+}
+
+// MediaResponse describes an uploaded media file.
+// @Description Uploaded media details
+type MediaResponse struct {
+	MediaID     int32  `json:"media_id"`
+	Kind        string `json:"kind"`
+	ContentType string `json:"content_type"`
+	SizeBytes   int64  `json:"size_bytes"`
+	URL         string `json:"url"`
+}
+
+func (h *MediaHandler) toResponse(asset *db.MediaAsset) MediaResponse {
+	return MediaResponse{
+		MediaID:     asset.MediaID,
+		Kind:        asset.Kind,
+		ContentType: asset.ContentType,
+		SizeBytes:   asset.SizeBytes,
+		URL:         h.mediaService.URL(asset.StorageKey),
+	}
+}
+
+// UploadQuestionImage godoc
+// @Summary Attach an image to a question
+// @Description Upload a PNG, JPEG, GIF or WebP image and attach it to the question. Replaces and deletes any existing image. Only the quiz's creator can do this.
+// @Tags media
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path int true "Question ID"
+// @Param file formData file true "Image file"
+// @Success 201 {object} MediaResponse
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 413 {object} map[string]string
+// @Failure 415 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /questions/{id}/image [post]
+// @Security BearerAuth
+func (h *MediaHandler) UploadQuestionImage(ctx *gin.Context) {
+	h.uploadQuestionMedia(ctx, services.MediaKindImage)
+}
+
+// UploadQuestionAudio godoc
+// @Summary Attach an audio clip to a question
+// @Description Upload an MP3, WAV or Ogg audio clip and attach it to the question. Replaces and deletes any existing clip. Only the quiz's creator can do this.
+// @Tags media
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path int true "Question ID"
+// @Param file formData file true "Audio file"
+// @Success 201 {object} MediaResponse
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 413 {object} map[string]string
+// @Failure 415 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /questions/{id}/audio [post]
+// @Security BearerAuth
+func (h *MediaHandler) UploadQuestionAudio(ctx *gin.Context) {
+	h.uploadQuestionMedia(ctx, services.MediaKindAudio)
+}
+
+func (h *MediaHandler) uploadQuestionMedia(ctx *gin.Context, kind services.MediaKind) {
+	questionID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid question ID"})
+		return
+	}
+
+	user, ok := lookupCurrentUser(ctx, h.userService)
+	if !ok {
+		return
+	}
+
+	file, ok := h.openUpload(ctx)
+	if !ok {
+		return
+	}
+	defer file.Close()
+
+	asset, err := h.mediaService.AttachQuestionMedia(ctx.Request.Context(), user.UserID, int32(questionID), kind, file)
+	if err != nil {
+		h.writeUploadError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, h.toResponse(asset))
+}
+
+// UploadAnswerImage godoc
+// @Summary Attach an image to an answer
+// @Description Upload a PNG, JPEG, GIF or WebP image and attach it to the answer option. Replaces and deletes any existing image. Only the quiz's creator can do this.
+// @Tags media
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path int true "Answer ID"
+// @Param file formData file true "Image file"
+// @Success 201 {object} MediaResponse
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 413 {object} map[string]string
+// @Failure 415 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /answers/{id}/image [post]
+// @Security BearerAuth
+func (h *MediaHandler) UploadAnswerImage(ctx *gin.Context) {
+	answerID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid answer ID"})
+		return
+	}
+
+	user, ok := lookupCurrentUser(ctx, h.userService)
+	if !ok {
+		return
+	}
+
+	file, ok := h.openUpload(ctx)
+	if !ok {
+		return
+	}
+	defer file.Close()
+
+	asset, err := h.mediaService.AttachAnswerImage(ctx.Request.Context(), user.UserID, int32(answerID), file)
+	if err != nil {
+		h.writeUploadError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, h.toResponse(asset))
+}
+
+// ServeMedia godoc
+// @Summary Download a media file
+// @Description Streams an uploaded media file from the configured storage backend.
+// @Tags media
+// @Produce octet-stream
+// @Param key path string true "Storage key"
+// @Success 200 {file} file
+// @Failure 404 {object} map[string]string
+// @Router /media/{key} [get]
+func (h *MediaHandler) ServeMedia(ctx *gin.Context) {
+	key := strings.TrimPrefix(ctx.Param("key"), "/")
+	body, contentType, err := h.mediaService.Open(ctx.Request.Context(), key)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Media not found"})
+			return
+		}
+		logging.FromGin(ctx).Error("opening media failed", "key", key, "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load media"})
+		return
+	}
+	defer body.Close()
+
+	// Keys are random and never reused, so the content can be cached indefinitely
+	ctx.Header("Cache-Control", "public, max-age=31536000, immutable")
+	ctx.Header("X-Content-Type-Options", "nosniff")
+	ctx.Header("Content-Type", contentType)
+	ctx.Status(http.StatusOK)
+	if _, err := io.Copy(ctx.Writer, body); err != nil {
+		logging.FromGin(ctx).Warn("streaming media failed", "key", key, "error", err)
+	}
+}
+
+// openUpload reads the "file" form field, enforcing the configured size limit.
+func (h *MediaHandler) openUpload(ctx *gin.Context) (io.ReadCloser, bool) {
+	// Allow a little headroom for the multipart envelope around the file
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, h.mediaService.MaxBytes()+64*1024)
+
+	header, err := ctx.FormFile("file")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			ctx.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": services.ErrMediaTooLarge.Error()})
+			return nil, false
+		}
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Missing file upload: " + err.Error()})
+		return nil, false
+	}
+	if header.Size > h.mediaService.MaxBytes() {
+		ctx.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": services.ErrMediaTooLarge.Error()})
+		return nil, false
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Could not read file upload"})
+		return nil, false
+	}
+	return file, true
+}
+
+func (h *MediaHandler) writeUploadError(ctx *gin.Context, err error) {
+	if respondOwnershipError(ctx, err) {
+		return
+	}
+	switch {
+	case errors.Is(err, services.ErrMediaTargetNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrMediaTooLarge):
+		ctx.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrUnsupportedMediaType):
+		ctx.JSON(http.StatusUnsupportedMediaType, gin.H{"error": err.Error()})
+	default:
+		logging.FromGin(ctx).Error("uploading media failed", "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload media"})
+	}
+}
@@ -2,7 +2,6 @@ package handlers
 
 import (
 	"fmt"
-	"log"
 	"net/http"
 	"strconv"
 
@@ -11,24 +10,38 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/oblongtable/beanbag-backend/internal/apimodels"
+	"github.com/oblongtable/beanbag-backend/internal/logging"
 	"github.com/oblongtable/beanbag-backend/internal/services"
-	"github.com/oblongtable/beanbag-backend/middleware" // Import middleware if needed for security checks
 	// Import db package only if needed for swagger docs, prefer apimodels
 )
 
 type QuizHandler struct {
 	quizService *services.QuizService
-	// userService *services.UserService // Inject if needed for creator ID validation
+	userService *services.UserService // Looks up the signed-in user, who becomes the creator
 }
 
-// Inject userService if needed for creator validation
-func NewQuizHandler(quizService *services.QuizService /*, userService *services.UserService */) *QuizHandler {
+func NewQuizHandler(quizService *services.QuizService, userService *services.UserService) *QuizHandler {
 	return &QuizHandler{
 		quizService: quizService,
-		// userService: userService,
+		userService: userService,
 	}
 }
 
+// setCreator makes the signed-in user the creator of the quiz in req. A request naming
+// someone else as the creator is refused with 403; false means a response was written.
+func (h *QuizHandler) setCreator(ctx *gin.Context, req *apimodels.QuizApiModel) bool {
+	user, ok := lookupCurrentUser(ctx, h.userService)
+	if !ok {
+		return false
+	}
+	if req.CreatorID != 0 && req.CreatorID != user.UserID {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "Creator ID does not match the signed-in user"})
+		return false
+	}
+	req.CreatorID = user.UserID
+	return true
+}
+
 // CreateQuiz godoc
 // @Summary Create a new basic quiz entry (DEPRECATED? Use POST /quizzes for full creation)
 // @Description Create only the quiz entry without questions/answers. Consider using POST /quizzes instead.
@@ -39,6 +52,7 @@ func NewQuizHandler(quizService *services.QuizService /*, userService *services.
 // @Success 201 {object} db.Quiz "The created basic quiz object"
 // @Failure 400 {object} map[string]string "Invalid input"
 // @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Forbidden (Creator ID mismatch)"
 // @Failure 500 {object} map[string]string "Internal server error"
 // @Router /quizzes/basic [post] // Suggest different path if keeping basic creation
 // @Security BearerAuth
@@ -51,19 +65,14 @@ func (h *QuizHandler) CreateQuiz(ctx *gin.Context) {
 		return
 	}
 
-	// SECURITY: Validate req.CreatorID against authenticated user from JWT
-	// jwtSub := ctx.GetString(middleware.GinContextKeyUserSub)
-	// loggedInUserID, err := h.userService.GetUserIDBySub(ctx, jwtSub) // Requires userService injection
-	// if err != nil || loggedInUserID != req.CreatorID {
-	// 	ctx.JSON(http.StatusForbidden, gin.H{"error": "Creator ID mismatch or lookup failed"})
-	// 	return
-	// }
-	log.Printf("Warning: CreatorID %d in CreateQuiz request not verified against JWT user.", req.CreatorID)
+	if !h.setCreator(ctx, &req) {
+		return
+	}
 
 	// Call the service that creates only the basic quiz row
 	quiz, err := h.quizService.CreateQuiz(ctx.Request.Context(), req.Title, req.CreatorID)
 	if err != nil {
-		log.Printf("Error calling CreateQuiz service: %v", err)
+		logging.FromGin(ctx).Error("CreateQuiz failed", "error", err)
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create quiz"})
 		return
 	}
@@ -100,7 +109,7 @@ func (h *QuizHandler) GetQuiz(ctx *gin.Context) {
 			ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 			return
 		}
-		log.Printf("Error calling GetQuiz service for ID %d: %v", quizID, err)
+		logging.FromGin(ctx).Error("GetQuiz failed", "quiz_id", quizID, "error", err)
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve quiz"})
 		return
 	}
@@ -130,20 +139,9 @@ func (h *QuizHandler) CreateQuizMinimal(ctx *gin.Context) {
 		return
 	}
 
-	// SECURITY: Verify CreatorID matches authenticated user
-	jwtEmail := ctx.GetString(middleware.GinContextKeyUserEmail) // Assuming middleware sets this
-	// You might need userService injected here to map email/sub to your internal user ID
-	// loggedInUserID, err := h.userService.GetUserIDByEmail(ctx, jwtEmail)
-	// if err != nil {
-	//     log.Printf("Error getting user ID for email %s: %v", jwtEmail, err)
-	//     ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify user identity"})
-	//     return
-	// }
-	// if loggedInUserID != req.CreatorID {
-	// 	ctx.JSON(http.StatusForbidden, gin.H{"error": "Creator ID does not match authenticated user"})
-	// 	return
-	// }
-	log.Printf("Warning: CreatorID %d in CreateQuizMinimal request not verified against JWT user %s.", req.CreatorID, jwtEmail)
+	if !h.setCreator(ctx, &req) {
+		return
+	}
 
 	// Validate input further? (e.g., must have questions, questions must have answers?)
 	if len(req.Questions) == 0 {
@@ -174,7 +172,7 @@ func (h *QuizHandler) CreateQuizMinimal(ctx *gin.Context) {
 	// Based on your service code, it returns (apimodels.QuizApiModel, error)
 	createdQuiz, err := h.quizService.CreateQuizMinimal(ctx.Request.Context(), req)
 	if err != nil {
-		log.Printf("Error calling CreateQuizMinimal service: %v", err)
+		logging.FromGin(ctx).Error("CreateQuizMinimal failed", "error", err)
 		// Check for specific error types if needed
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create quiz"})
 		return
@@ -214,7 +212,7 @@ func (h *QuizHandler) GetFullQuiz(ctx *gin.Context) {
 			return
 		}
 		// Log the unexpected error
-		log.Printf("Error calling GetFullQuiz service for ID %d: %v", quizID, err)
+		logging.FromGin(ctx).Error("GetFullQuiz failed", "quiz_id", quizID, "error", err)
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve full quiz details"})
 		return
 	}
@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/oblongtable/beanbag-backend/db"
+	"github.com/oblongtable/beanbag-backend/internal/logging"
+	"github.com/oblongtable/beanbag-backend/internal/roomcode"
+	"github.com/oblongtable/beanbag-backend/internal/services"
+)
+
+type RoomCodeHandler struct {
+	roomCodeService *services.RoomCodeService
+	userService     *services.UserService
+}
+
+func NewRoomCodeHandler(roomCodeService *services.RoomCodeService, userService *services.UserService) *RoomCodeHandler {
+	return &RoomCodeHandler{roomCodeService: roomCodeService, userService: userService}
+}
+
+// ReserveRoomCodeRequest represents the request body for reserving a room code.
+// @Description Room code reservation. starts_at defaults to now.
+type ReserveRoomCodeRequest struct {
+	Code     string     `json:"code" binding:"required" example:"MATHS101"`
+	Label    string     `json:"label" binding:"max=100" example:"Year 9 maths quiz night"`
+	StartsAt *time.Time `json:"starts_at"`
+	EndsAt   time.Time  `json:"ends_at" binding:"required"`
+}
+
+// RoomCodeReservationResponse describes a reserved room code.
+// @Description Room code reservation
+type RoomCodeReservationResponse struct {
+	Code     string    `json:"code"`
+	Label    string    `json:"label"`
+	StartsAt time.Time `json:"starts_at"`
+	EndsAt   time.Time `json:"ends_at"`
+}
+
+func toReservationResponse(r *db.RoomCodeReservation) RoomCodeReservationResponse {
+	return RoomCodeReservationResponse{Code: r.Code, Label: r.Label, StartsAt: r.StartsAt, EndsAt: r.EndsAt}
+}
+
+// ReserveRoomCode godoc
+// @Summary Reserve a room code for a scheduled event
+// @Description Sets a code aside between starts_at and ends_at. Only you can open a room with it then, by sending it as room_code in create_room. Codes are 4 to 12 letters or digits, case doesn't matter.
+// @Tags room-codes
+// @Accept json
+// @Produce json
+// @Param reservation body ReserveRoomCodeRequest true "Code and when it is reserved"
+// @Success 201 {object} RoomCodeReservationResponse
+// @Failure 400 {object} map[string]string
+// @Failure 409 {object} map[string]string "Already reserved, or you hold too many reservations"
+// @Failure 500 {object} map[string]string
+// @Router /room-codes [post]
+// @Security BearerAuth
+func (h *RoomCodeHandler) ReserveRoomCode(ctx *gin.Context) {
+	var req ReserveRoomCodeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
+		return
+	}
+	startsAt := time.Now()
+	if req.StartsAt != nil {
+		startsAt = *req.StartsAt
+	}
+
+	user, ok := lookupCurrentUser(ctx, h.userService)
+	if !ok {
+		return
+	}
+
+	reservation, err := h.roomCodeService.Reserve(ctx.Request.Context(), user.UserID, req.Code, req.Label, startsAt, req.EndsAt)
+	if err != nil {
+		switch {
+		case errors.Is(err, roomcode.ErrInvalidCode), errors.Is(err, services.ErrReservationWindow), errors.Is(err, services.ErrReservationTooLong):
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, roomcode.ErrReserved), errors.Is(err, services.ErrReservationLimit):
+			ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			logging.FromGin(ctx).Error("Reserve failed", "error", err)
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reserve room code"})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, toReservationResponse(reservation))
+}
+
+// ListRoomCodeReservations godoc
+// @Summary List your room code reservations
+// @Description Reservations that haven't ended, soonest first.
+// @Tags room-codes
+// @Produce json
+// @Success 200 {array} RoomCodeReservationResponse
+// @Failure 500 {object} map[string]string
+// @Router /room-codes [get]
+// @Security BearerAuth
+func (h *RoomCodeHandler) ListRoomCodeReservations(ctx *gin.Context) {
+	user, ok := lookupCurrentUser(ctx, h.userService)
+	if !ok {
+		return
+	}
+
+	reservations, err := h.roomCodeService.ListReservations(ctx.Request.Context(), user.UserID)
+	if err != nil {
+		logging.FromGin(ctx).Error("ListReservations failed", "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve room code reservations"})
+		return
+	}
+
+	response := make([]RoomCodeReservationResponse, 0, len(reservations))
+	for i := range reservations {
+		response = append(response, toReservationResponse(&reservations[i]))
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+// CancelRoomCodeReservation godoc
+// @Summary Cancel a room code reservation
+// @Description Frees the code for others. A room already open with it stays open.
+// @Tags room-codes
+// @Param code path string true "Reserved room code"
+// @Success 204
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /room-codes/{code} [delete]
+// @Security BearerAuth
+func (h *RoomCodeHandler) CancelRoomCodeReservation(ctx *gin.Context) {
+	user, ok := lookupCurrentUser(ctx, h.userService)
+	if !ok {
+		return
+	}
+
+	if err := h.roomCodeService.CancelReservation(ctx.Request.Context(), user.UserID, ctx.Param("code")); err != nil {
+		if errors.Is(err, services.ErrReservationNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		logging.FromGin(ctx).Error("CancelReservation failed", "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel room code reservation"})
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/oblongtable/beanbag-backend/middleware"
+)
+
+// APIHandlers are the handlers of the /api routes.
+type APIHandlers struct {
+	Admin       *AdminHandler
+	Analytics   *AnalyticsHandler
+	Answer      *AnswerHandler
+	Assignment  *AssignmentHandler
+	Leaderboard *LeaderboardHandler
+	Media       *MediaHandler
+	Question    *QuestionHandler
+	Quiz        *QuizHandler
+	RoomCode    *RoomCodeHandler
+	Session     *SessionHandler
+	User        *UserHandler
+}
+
+// RegisterAPIRoutes adds the /api routes to api, with the roles and scopes each needs.
+// api must already verify the token and set its claims, see middleware.ExtractAndSetClaims.
+// Roles are looked up with roleOf, once per request and only by routes that need them.
+func RegisterAPIRoutes(api *gin.RouterGroup, h APIHandlers, roleOf middleware.RoleLookup) {
+	requireRole := func(role string) gin.HandlerFunc {
+		return middleware.RequireRole(roleOf, role)
+	}
+
+	// User routes
+	api.POST("/users/sync", h.User.SyncUser)
+	api.GET("/users/me", h.User.GetMe)
+	api.PATCH("/users/me", h.User.UpdateMe)
+	api.DELETE("/users/me", h.User.DeleteMe)
+	api.GET("/users/me/export", h.User.ExportMe)
+	api.GET("/users/me/identities", h.User.ListMyIdentities)
+	api.DELETE("/users/me/identities/:subject", h.User.UnlinkMyIdentity)
+	api.GET("/users/:id", h.User.GetUser)
+	api.GET("/users/:id/history", h.User.GetUserHistory)
+	api.GET("/users/:id/stats", h.User.GetUserStats)
+	api.GET("/users/:id/achievements", h.User.GetUserAchievements)
+
+	// Reading quizzes and playing assignments is open to every player
+	api.GET("/quizzes/:id", h.Quiz.GetQuiz)
+	api.GET("/quizzes/:id/full", h.Quiz.GetFullQuiz)
+	api.GET("/quizzes/:id/leaderboard", h.Leaderboard.GetQuizLeaderboard)
+	api.GET("/questions/:id", h.Question.GetQuestion)
+	api.GET("/answers/:id", h.Answer.GetAnswer)
+	api.GET("/assignments/:code", h.Assignment.GetAssignment)
+	api.GET("/leaderboards", h.Leaderboard.GetLeaderboard)
+
+	// Teacher routes, results of their quizzes and assignments
+	teacher := api.Group("", requireRole(middleware.RoleTeacher))
+	teacher.GET("/quizzes/:id/analytics", h.Analytics.GetQuizAnalytics)
+	teacher.GET("/quizzes/:id/sessions", h.Session.ListQuizSessions)
+	teacher.GET("/sessions/:id/results", h.Session.GetSessionResults)
+	teacher.POST("/assignments", h.Assignment.CreateAssignment)
+	teacher.GET("/assignments/:code/results", h.Assignment.GetAssignmentResults)
+	teacher.POST("/room-codes", h.RoomCode.ReserveRoomCode)
+	teacher.GET("/room-codes", h.RoomCode.ListRoomCodeReservations)
+	teacher.DELETE("/room-codes/:code", h.RoomCode.CancelRoomCodeReservation)
+
+	// Writing quizzes, also needs a token allowed to
+	authoring := teacher.Group("", middleware.RequireScope(middleware.ScopeWriteQuizzes))
+	authoring.POST("/quizzes", h.Quiz.CreateQuiz)
+	authoring.POST("/quizzes/minimal", h.Quiz.CreateQuizMinimal)
+	authoring.POST("/questions", h.Question.CreateQuestion)
+	authoring.POST("/questions/:id/image", h.Media.UploadQuestionImage)
+	authoring.POST("/questions/:id/audio", h.Media.UploadQuestionAudio)
+	authoring.POST("/answers", h.Answer.CreateAnswer)
+	authoring.POST("/answers/:id/image", h.Media.UploadAnswerImage)
+
+	// Admin routes, for unsticking live rooms and games and handing out roles
+	admin := api.Group("/admin", middleware.RequireScope(middleware.ScopeAdmin), requireRole(middleware.RoleAdmin))
+	admin.GET("/rooms", h.Admin.ListRooms)
+	admin.GET("/rooms/:code", h.Admin.GetRoom)
+	admin.POST("/rooms/:code/advance", h.Admin.AdvanceGame)
+	admin.POST("/rooms/:code/end", h.Admin.EndGame)
+	admin.DELETE("/rooms/:code", h.Admin.CloseRoom)
+	admin.PUT("/users/:id/role", h.User.SetUserRole)
+}
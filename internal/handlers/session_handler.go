@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/oblongtable/beanbag-backend/internal/logging"
+	"github.com/oblongtable/beanbag-backend/internal/services"
+)
+
+type SessionHandler struct {
+	sessionService *services.SessionService
+	userService    *services.UserService
+}
+
+func NewSessionHandler(sessionService *services.SessionService, userService *services.UserService) *SessionHandler {
+	return &SessionHandler{sessionService: sessionService, userService: userService}
+}
+
+// ListQuizSessions godoc
+// @Summary List the recorded sessions of a quiz
+// @Description Live games and self-paced assignments played with the quiz, most recent first. Only the quiz's creator can list them.
+// @Tags sessions
+// @Produce json
+// @Param id path int true "Quiz ID"
+// @Success 200 {array} apimodels.SessionApiModel
+// @Failure 400 {object} map[string]string "Invalid ID"
+// @Failure 403 {object} map[string]string "Not the quiz's creator"
+// @Failure 404 {object} map[string]string "Quiz not found"
+// @Failure 500 {object} map[string]string
+// @Router /quizzes/{id}/sessions [get]
+// @Security BearerAuth
+func (h *SessionHandler) ListQuizSessions(ctx *gin.Context) {
+	quizID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid quiz ID format"})
+		return
+	}
+
+	user, ok := lookupCurrentUser(ctx, h.userService)
+	if !ok {
+		return
+	}
+
+	sessions, err := h.sessionService.ListQuizSessions(ctx.Request.Context(), int32(quizID), user.UserID)
+	if err != nil {
+		if respondOwnershipError(ctx, err) {
+			return
+		}
+		logging.FromGin(ctx).Error("ListQuizSessions failed", "quiz_id", quizID, "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve sessions"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, sessions)
+}
+
+// GetSessionResults godoc
+// @Summary Get the results of a recorded session
+// @Description Player standings plus, for every question, how many players chose each option, the percentage correct, the average and fastest response time and the fastest correct player. Only the creator of the session's quiz can see them.
+// @Tags sessions
+// @Produce json
+// @Param id path int true "Session ID"
+// @Success 200 {object} apimodels.SessionResultsApiModel
+// @Failure 400 {object} map[string]string "Invalid ID"
+// @Failure 403 {object} map[string]string "Not the creator of the session's quiz"
+// @Failure 404 {object} map[string]string "Session not found"
+// @Failure 500 {object} map[string]string
+// @Router /sessions/{id}/results [get]
+// @Security BearerAuth
+func (h *SessionHandler) GetSessionResults(ctx *gin.Context) {
+	sessionID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session ID format"})
+		return
+	}
+
+	user, ok := lookupCurrentUser(ctx, h.userService)
+	if !ok {
+		return
+	}
+
+	results, err := h.sessionService.GetSessionResults(ctx.Request.Context(), int32(sessionID), user.UserID)
+	if err != nil {
+		if errors.Is(err, services.ErrSessionNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if respondOwnershipError(ctx, err) {
+			return
+		}
+		logging.FromGin(ctx).Error("GetSessionResults failed", "session_id", sessionID, "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve session results"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, results)
+}
+
+// respondOwnershipError answers requests for a quiz the caller didn't create, or one that
+// doesn't exist. It returns false for other errors.
+func respondOwnershipError(ctx *gin.Context, err error) bool {
+	switch {
+	case errors.Is(err, services.ErrQuizNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrNotQuizOwner):
+		ctx.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	default:
+		return false
+	}
+	return true
+}
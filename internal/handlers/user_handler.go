@@ -1,72 +1,46 @@
 package handlers
 
 import (
-	"log"
+	"errors"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/oblongtable/beanbag-backend/db"
+	"github.com/oblongtable/beanbag-backend/internal/logging"
 	"github.com/oblongtable/beanbag-backend/internal/services"
 	"github.com/oblongtable/beanbag-backend/middleware"
 )
 
 type UserHandler struct {
-	userService *services.UserService
+	userService        *services.UserService
+	sessionService     *services.SessionService
+	achievementService *services.AchievementService
 }
 
-func NewUserHandler(userService *services.UserService) *UserHandler {
-	return &UserHandler{userService: userService}
-}
-
-// CreateUserRequest represents the request body for creating a user.
-// @Description User details
-type CreateUserRequest struct {
-	Name  string `json:"name" binding:"required"`
-	Email string `json:"email" binding:"required,email"`
-}
-
-// CreateUser godoc
-// @Summary Create a new user
-// @Description Create a new user with the given details
-// @Tags users
-// @Accept json
-// @Produce json
-// @Param user body CreateUserRequest true "User details"
-// @Success 201 {object} db.User
-// @Failure 400 {object} map[string]string
-// @Failure 500 {object} map[string]string
-// @Router /users [post]
-func (h *UserHandler) CreateUser(ctx *gin.Context) {
-	var req struct {
-		Name  string `json:"name" binding:"required"`
-		Email string `json:"email" binding:"required,email"`
+func NewUserHandler(userService *services.UserService, sessionService *services.SessionService, achievementService *services.AchievementService) *UserHandler {
+	return &UserHandler{
+		userService:        userService,
+		sessionService:     sessionService,
+		achievementService: achievementService,
 	}
-
-	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-
-	user, err := h.userService.CreateUser(ctx, req.Name, req.Email)
-	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-
-	ctx.JSON(http.StatusCreated, user)
 }
 
 // GetUser godoc
 // @Summary Get a user by ID
-// @Description Get a user by their ID
+// @Description The user's profile. Only available to the user themselves and to admins.
 // @Tags users
 // @Produce json
 // @Param id path int true "User ID"
-// @Success 200 {object} db.User
+// @Success 200 {object} apimodels.UserProfileApiModel
 // @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /users/{id} [get]
+// @Security BearerAuth
 func (h *UserHandler) GetUser(ctx *gin.Context) {
 	userIDStr := ctx.Param("id")
 	userID, err := strconv.Atoi(userIDStr)
@@ -75,13 +49,27 @@ func (h *UserHandler) GetUser(ctx *gin.Context) {
 		return
 	}
 
+	current, ok := h.currentUser(ctx)
+	if !ok {
+		return
+	}
+	if current.UserID != int32(userID) && current.Role != middleware.RoleAdmin {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "You can only view your own profile"})
+		return
+	}
+
 	user, err := h.userService.GetUserById(ctx, int32(userID))
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		if errors.Is(err, services.ErrUserNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		logging.FromGin(ctx).Error("GetUserById failed", "user_id", userID, "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve user"})
 		return
 	}
 
-	ctx.JSON(http.StatusOK, user)
+	ctx.JSON(http.StatusOK, services.Profile(user))
 }
 
 // Define the request struct specifically for the sync endpoint
@@ -91,8 +79,8 @@ type SyncUserRequest struct {
 }
 
 // SyncUser godoc
-// @Summary Sync Auth0 user with backend database via Email
-// @Description Creates a user if they don't exist based on email, or updates existing user's name.
+// @Summary Sync Auth0 user with backend database
+// @Description Finds the user by the token's subject and updates their name and email. A login not seen before is linked to the user with the same email if the token says it is verified, e.g. a Google login next to a password one, or creates a new user otherwise.
 // @Tags users
 // @Accept json
 // @Produce json
@@ -122,7 +110,7 @@ func (h *UserHandler) SyncUser(ctx *gin.Context) {
 
 	// --- Compare JWT email with request body email (Case-Insensitive) ---
 	if strings.ToLower(jwtEmail) != strings.ToLower(req.Email) {
-		log.Printf("Forbidden SyncUser attempt: JWT email '%s' != Request email '%s'", jwtEmail, req.Email)
+		logging.FromGin(ctx).Warn("forbidden SyncUser attempt, emails differ", "email", jwtEmail, "request_email", req.Email)
 		ctx.JSON(http.StatusForbidden, gin.H{"error": "Request email does not match authenticated user"})
 		return
 	}
@@ -140,10 +128,11 @@ func (h *UserHandler) SyncUser(ctx *gin.Context) {
 
 	// Emails match, proceed with the service call
 	// Pass the validated email from the JWT to the service layer
-	user, created, err := h.userService.SyncUser(ctx.Request.Context(), req.Name, jwtEmail) // Use jwtEmail
+	jwtSub := ctx.GetString(middleware.GinContextKeyUserSub)
+	user, created, err := h.userService.SyncUser(ctx.Request.Context(), jwtSub, req.Name, jwtEmail, ctx.GetBool(middleware.GinContextKeyEmailVerified)) // Use jwtEmail
 	if err != nil {
 		// Log the internal error for debugging
-		log.Printf("Error syncing user %s: %v", jwtEmail, err)
+		logging.FromGin(ctx).Error("syncing user failed", "email", jwtEmail, "error", err)
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to sync user data"}) // Keep error message generic for client
 		return
 	}
@@ -154,4 +143,383 @@ func (h *UserHandler) SyncUser(ctx *gin.Context) {
 	} else {
 		ctx.JSON(http.StatusOK, user) // 200 OK
 	}
-}
\ No newline at end of file
+}
+
+// currentUser looks up the authenticated user.
+// It writes the error response and returns false if they can't be found.
+func (h *UserHandler) currentUser(ctx *gin.Context) (*db.User, bool) {
+	return lookupCurrentUser(ctx, h.userService)
+}
+
+// lookupCurrentUser is currentUser for handlers that have the user service.
+func lookupCurrentUser(ctx *gin.Context, userService *services.UserService) (*db.User, bool) {
+	jwtEmail := ctx.GetString(middleware.GinContextKeyUserEmail)
+	user, err := userService.FindUser(ctx.Request.Context(), ctx.GetString(middleware.GinContextKeyUserSub), middleware.VerifiedEmail(ctx))
+	if err != nil {
+		if errors.Is(err, services.ErrUserNotFound) {
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "Authenticated user has not been synced"})
+			return nil, false
+		}
+		logging.FromGin(ctx).Error("looking up authenticated user failed", "email", jwtEmail, "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to look up authenticated user"})
+		return nil, false
+	}
+	return user, true
+}
+
+// authorizeSelf parses the :id path parameter and checks it is the authenticated user.
+// It writes the error response and returns false otherwise.
+func (h *UserHandler) authorizeSelf(ctx *gin.Context) (int32, bool) {
+	userID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return 0, false
+	}
+
+	user, ok := h.currentUser(ctx)
+	if !ok {
+		return 0, false
+	}
+	if user.UserID != int32(userID) {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "You can only view your own history, stats and achievements"})
+		return 0, false
+	}
+	return user.UserID, true
+}
+
+// GetUserHistory godoc
+// @Summary List the games a user has played
+// @Description Sessions the user played while signed in, most recent first. Only available to the user themselves.
+// @Tags users
+// @Produce json
+// @Param id path int true "User ID"
+// @Param limit query int false "Maximum number of sessions (default 20, max 100)"
+// @Param offset query int false "Number of sessions to skip"
+// @Success 200 {array} apimodels.UserHistoryEntryApiModel
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users/{id}/history [get]
+// @Security BearerAuth
+func (h *UserHandler) GetUserHistory(ctx *gin.Context) {
+	userID, ok := h.authorizeSelf(ctx)
+	if !ok {
+		return
+	}
+	limit, err := strconv.Atoi(ctx.DefaultQuery("limit", "20"))
+	if err != nil || limit < 1 || limit > 100 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 100"})
+		return
+	}
+	offset, err := strconv.Atoi(ctx.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "offset must not be negative"})
+		return
+	}
+
+	history, err := h.sessionService.UserHistory(ctx.Request.Context(), userID, int32(limit), int32(offset))
+	if err != nil {
+		logging.FromGin(ctx).Error("UserHistory failed", "user_id", userID, "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve history"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, history)
+}
+
+// GetUserStats godoc
+// @Summary Get a user's personal stats
+// @Description Sessions played, average rank, accuracy, best streak and total points over every game the user played while signed in. Only available to the user themselves.
+// @Tags users
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 200 {object} apimodels.UserStatsApiModel
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users/{id}/stats [get]
+// @Security BearerAuth
+func (h *UserHandler) GetUserStats(ctx *gin.Context) {
+	userID, ok := h.authorizeSelf(ctx)
+	if !ok {
+		return
+	}
+
+	stats, err := h.sessionService.UserStats(ctx.Request.Context(), userID)
+	if err != nil {
+		logging.FromGin(ctx).Error("UserStats failed", "user_id", userID, "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve stats"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, stats)
+}
+
+// GetUserAchievements godoc
+// @Summary List a user's achievements
+// @Description Badges the user has earned while signed in, oldest first. Only available to the user themselves.
+// @Tags users
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 200 {array} apimodels.UserAchievementApiModel
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users/{id}/achievements [get]
+// @Security BearerAuth
+func (h *UserHandler) GetUserAchievements(ctx *gin.Context) {
+	userID, ok := h.authorizeSelf(ctx)
+	if !ok {
+		return
+	}
+
+	earned, err := h.achievementService.ListUserAchievements(ctx.Request.Context(), userID)
+	if err != nil {
+		logging.FromGin(ctx).Error("ListUserAchievements failed", "user_id", userID, "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve achievements"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, earned)
+}
+
+// SetUserRoleRequest is the role to give a user.
+type SetUserRoleRequest struct {
+	Role string `json:"role" binding:"required" example:"teacher"`
+}
+
+// SetUserRole godoc
+// @Summary Change a user's role
+// @Description Players can play quizzes, teachers can also write them and set assignments, admins can do everything. Requires the admin role and scope.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path int true "User ID"
+// @Param role body SetUserRoleRequest true "One of admin, teacher or player"
+// @Success 200 {object} db.User
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string "Missing the admin role or scope"
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /admin/users/{id}/role [put]
+// @Security BearerAuth
+func (h *UserHandler) SetUserRole(ctx *gin.Context) {
+	userID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+	var req SetUserRoleRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
+		return
+	}
+	if !middleware.ValidRole(req.Role) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "role must be one of admin, teacher or player"})
+		return
+	}
+
+	user, err := h.userService.SetRole(ctx.Request.Context(), int32(userID), req.Role)
+	if err != nil {
+		if errors.Is(err, services.ErrUserNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+		logging.FromGin(ctx).Error("SetRole failed", "user_id", userID, "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to change the role"})
+		return
+	}
+
+	logging.FromGin(ctx).Warn("user role changed", "user_id", userID, "role", req.Role)
+	ctx.JSON(http.StatusOK, user)
+}
+
+// GetMe godoc
+// @Summary Get your profile
+// @Description The authenticated user's name, avatar, role and display preferences.
+// @Tags users
+// @Produce json
+// @Success 200 {object} apimodels.UserProfileApiModel
+// @Failure 403 {object} map[string]string "Not synced yet"
+// @Failure 500 {object} map[string]string
+// @Router /users/me [get]
+// @Security BearerAuth
+func (h *UserHandler) GetMe(ctx *gin.Context) {
+	user, ok := h.currentUser(ctx)
+	if !ok {
+		return
+	}
+	ctx.JSON(http.StatusOK, services.Profile(user))
+}
+
+// UpdateProfileRequest holds the profile fields to change, omitted ones are kept.
+type UpdateProfileRequest struct {
+	Name        *string                   `json:"name" binding:"omitempty,min=1,max=50" example:"Ms Smith"`
+	AvatarURL   *string                   `json:"avatar_url" binding:"omitempty,max=2048"` // "" removes the avatar
+	Preferences *UpdatePreferencesRequest `json:"preferences"`
+}
+
+// UpdatePreferencesRequest holds the display preferences to change.
+type UpdatePreferencesRequest struct {
+	Theme         *string `json:"theme" binding:"omitempty,oneof=system light dark"`
+	Language      *string `json:"language" binding:"omitempty,bcp47_language_tag"`
+	ReducedMotion *bool   `json:"reduced_motion"`
+}
+
+// UpdateMe godoc
+// @Summary Update your profile
+// @Description Changes the authenticated user's name, avatar or display preferences. Fields left out are kept. A name set here is no longer overwritten by /users/sync.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param profile body UpdateProfileRequest true "Fields to change"
+// @Success 200 {object} apimodels.UserProfileApiModel
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string "Not synced yet"
+// @Failure 500 {object} map[string]string
+// @Router /users/me [patch]
+// @Security BearerAuth
+func (h *UserHandler) UpdateMe(ctx *gin.Context) {
+	var req UpdateProfileRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
+		return
+	}
+
+	update := services.ProfileUpdate{Name: req.Name, AvatarURL: req.AvatarURL}
+	if req.Name != nil {
+		trimmed := strings.TrimSpace(*req.Name)
+		if trimmed == "" {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "name must not be blank"})
+			return
+		}
+		update.Name = &trimmed
+	}
+	if req.AvatarURL != nil && *req.AvatarURL != "" {
+		if avatar, err := url.Parse(*req.AvatarURL); err != nil || (avatar.Scheme != "https" && avatar.Scheme != "http") || avatar.Host == "" {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "avatar_url must be an http(s) URL"})
+			return
+		}
+	}
+	if req.Preferences != nil {
+		update.Theme = req.Preferences.Theme
+		update.Language = req.Preferences.Language
+		update.ReducedMotion = req.Preferences.ReducedMotion
+	}
+
+	user, ok := h.currentUser(ctx)
+	if !ok {
+		return
+	}
+	updated, err := h.userService.UpdateProfile(ctx.Request.Context(), user.UserID, update)
+	if err != nil {
+		logging.FromGin(ctx).Error("UpdateProfile failed", "user_id", user.UserID, "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update the profile"})
+		return
+	}
+	ctx.JSON(http.StatusOK, services.Profile(updated))
+}
+
+// DeleteMe godoc
+// @Summary Delete your account
+// @Description Removes the authenticated user's name, email, avatar, preferences and achievements. Games they played stay in other players' history and quiz analytics, as a guest's. Quizzes and assignments they wrote are kept. Signing in again starts a new account.
+// @Tags users
+// @Success 204
+// @Failure 403 {object} map[string]string "Not synced yet"
+// @Failure 500 {object} map[string]string
+// @Router /users/me [delete]
+// @Security BearerAuth
+func (h *UserHandler) DeleteMe(ctx *gin.Context) {
+	user, ok := h.currentUser(ctx)
+	if !ok {
+		return
+	}
+	if err := h.userService.SoftDelete(ctx.Request.Context(), user.UserID); err != nil && !errors.Is(err, services.ErrUserNotFound) {
+		logging.FromGin(ctx).Error("SoftDelete failed", "user_id", user.UserID, "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete the account"})
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+// ExportMe godoc
+// @Summary Export your data
+// @Description Everything stored about the authenticated user: their profile, linked logins, every game they played with their answers, their achievements and the quizzes and assignments they wrote.
+// @Tags users
+// @Produce json
+// @Success 200 {object} apimodels.UserExportApiModel
+// @Failure 403 {object} map[string]string "Not synced yet"
+// @Failure 500 {object} map[string]string
+// @Router /users/me/export [get]
+// @Security BearerAuth
+func (h *UserHandler) ExportMe(ctx *gin.Context) {
+	user, ok := h.currentUser(ctx)
+	if !ok {
+		return
+	}
+	export, err := h.userService.Export(ctx.Request.Context(), user, ctx.GetString(middleware.GinContextKeyUserSub))
+	if err != nil {
+		logging.FromGin(ctx).Error("Export failed", "user_id", user.UserID, "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export the data"})
+		return
+	}
+	ctx.Header("Content-Disposition", `attachment; filename="beanbag-export.json"`)
+	ctx.JSON(http.StatusOK, export)
+}
+
+// ListMyIdentities godoc
+// @Summary List your linked logins
+// @Description Every login that signs in as the authenticated user, e.g. a password and a Google login with the same email, which are linked automatically by /users/sync.
+// @Tags users
+// @Produce json
+// @Success 200 {array} apimodels.UserIdentityApiModel
+// @Failure 403 {object} map[string]string "Not synced yet"
+// @Failure 500 {object} map[string]string
+// @Router /users/me/identities [get]
+// @Security BearerAuth
+func (h *UserHandler) ListMyIdentities(ctx *gin.Context) {
+	user, ok := h.currentUser(ctx)
+	if !ok {
+		return
+	}
+	identities, err := h.userService.ListIdentities(ctx.Request.Context(), user.UserID, ctx.GetString(middleware.GinContextKeyUserSub))
+	if err != nil {
+		logging.FromGin(ctx).Error("ListIdentities failed", "user_id", user.UserID, "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list linked logins"})
+		return
+	}
+	ctx.JSON(http.StatusOK, identities)
+}
+
+// UnlinkMyIdentity godoc
+// @Summary Unlink a login
+// @Description Stops a login from signing in as the authenticated user. The login making the request can't be unlinked.
+// @Tags users
+// @Param subject path string true "Subject of the login, e.g. google-oauth2|1234"
+// @Success 204
+// @Failure 403 {object} map[string]string "Not synced yet"
+// @Failure 404 {object} map[string]string "Not linked to the user"
+// @Failure 409 {object} map[string]string "The login making the request"
+// @Failure 500 {object} map[string]string
+// @Router /users/me/identities/{subject} [delete]
+// @Security BearerAuth
+func (h *UserHandler) UnlinkMyIdentity(ctx *gin.Context) {
+	user, ok := h.currentUser(ctx)
+	if !ok {
+		return
+	}
+	err := h.userService.UnlinkIdentity(ctx.Request.Context(), user.UserID, ctx.Param("subject"), ctx.GetString(middleware.GinContextKeyUserSub))
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrIdentityNotFound):
+			ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrCurrentIdentity):
+			ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			logging.FromGin(ctx).Error("UnlinkIdentity failed", "user_id", user.UserID, "error", err)
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unlink the login"})
+		}
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
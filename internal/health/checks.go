@@ -0,0 +1,70 @@
+package health
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+
+	"github.com/pressly/goose/v3"
+)
+
+// Database checks the database answers queries.
+func Database(db *sql.DB) Check {
+	return func(ctx context.Context) (map[string]interface{}, error) {
+		stats := db.Stats()
+		details := map[string]interface{}{
+			"open_connections": stats.OpenConnections,
+			"in_use":           stats.InUse,
+		}
+		if err := db.PingContext(ctx); err != nil {
+			return details, err
+		}
+		return details, nil
+	}
+}
+
+// Migrations checks the database is at the version of the newest migration in migrations,
+// the directory of goose migration files.
+func Migrations(db *sql.DB, migrations fs.FS) (Check, error) {
+	provider, err := goose.NewProvider(goose.DialectPostgres, db, migrations)
+	if err != nil {
+		return nil, fmt.Errorf("reading migrations: %w", err)
+	}
+	return func(ctx context.Context) (map[string]interface{}, error) {
+		current, target, err := provider.GetVersions(ctx)
+		if err != nil {
+			return nil, err
+		}
+		details := map[string]interface{}{"current": current, "latest": target}
+		if current != target {
+			return details, fmt.Errorf("database is at version %d, migrations are at %d", current, target)
+		}
+		return details, nil
+	}, nil
+}
+
+// HTTPGet checks a GET of url succeeds, e.g. to see if an identity provider's keys can be fetched.
+func HTTPGet(client *http.Client, url string) Check {
+	return func(ctx context.Context) (map[string]interface{}, error) {
+		details := map[string]interface{}{"url": url}
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return details, err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return details, err
+		}
+		defer resp.Body.Close()
+		io.Copy(io.Discard, resp.Body)
+
+		details["status_code"] = resp.StatusCode
+		if resp.StatusCode >= 300 {
+			return details, fmt.Errorf("GET returned %s", resp.Status)
+		}
+		return details, nil
+	}
+}
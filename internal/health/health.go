@@ -0,0 +1,135 @@
+// Package health runs the checks behind the liveness and readiness endpoints.
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	StatusOK          = "ok"
+	StatusDegraded    = "degraded"    // Only optional components are failing, the server still takes traffic
+	StatusUnavailable = "unavailable" // A critical component is failing
+	StatusFailing     = "failing"     // Status of a single failing component
+)
+
+// Check reports on one component. The details describe it even when it is healthy.
+type Check func(ctx context.Context) (details map[string]interface{}, err error)
+
+// Component is the result of one check.
+type Component struct {
+	Status   string                 `json:"status"`
+	Critical bool                   `json:"critical"`
+	Duration string                 `json:"duration"`
+	Error    string                 `json:"error,omitempty"`
+	Details  map[string]interface{} `json:"details,omitempty"`
+}
+
+// Report is the result of every check.
+type Report struct {
+	Status     string               `json:"status"`
+	Components map[string]Component `json:"components"`
+}
+
+type namedCheck struct {
+	name     string
+	critical bool
+	check    Check
+}
+
+// Checker runs checks concurrently, giving each at most Timeout.
+type Checker struct {
+	Timeout time.Duration
+	checks  []namedCheck
+}
+
+func NewChecker(timeout time.Duration) *Checker {
+	return &Checker{Timeout: timeout}
+}
+
+// Add registers a component the server can't serve without.
+func (c *Checker) Add(name string, check Check) {
+	c.checks = append(c.checks, namedCheck{name: name, critical: true, check: check})
+}
+
+// AddOptional registers a component whose failure only degrades the server.
+func (c *Checker) AddOptional(name string, check Check) {
+	c.checks = append(c.checks, namedCheck{name: name, critical: false, check: check})
+}
+
+// Run checks every component. The report is unavailable if a critical component fails.
+func (c *Checker) Run(ctx context.Context) Report {
+	report := Report{Status: StatusOK, Components: make(map[string]Component, len(c.checks))}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, nc := range c.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			component := c.run(ctx, nc)
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Components[nc.name] = component
+			switch {
+			case component.Status == StatusOK:
+			case nc.critical:
+				report.Status = StatusUnavailable
+			case report.Status == StatusOK:
+				report.Status = StatusDegraded
+			}
+		}()
+	}
+	wg.Wait()
+	return report
+}
+
+func (c *Checker) run(ctx context.Context, nc namedCheck) Component {
+	ctx, cancel := context.WithTimeout(ctx, c.Timeout)
+	defer cancel()
+
+	type result struct {
+		details map[string]interface{}
+		err     error
+	}
+	done := make(chan result, 1)
+	start := time.Now()
+	go func() {
+		details, err := nc.check(ctx)
+		done <- result{details, err}
+	}()
+
+	component := Component{Status: StatusOK, Critical: nc.critical}
+	// A check that ignores its context still can't hold up the report
+	select {
+	case r := <-done:
+		component.Details = r.details
+		if r.err != nil {
+			component.Status = StatusFailing
+			component.Error = r.err.Error()
+		}
+	case <-ctx.Done():
+		component.Status = StatusFailing
+		component.Error = "timed out"
+	}
+	component.Duration = time.Since(start).Round(time.Microsecond).String()
+	return component
+}
+
+// Cached reuses the result of check for ttl, for checks too slow or costly to run on every probe.
+func Cached(check Check, ttl time.Duration) Check {
+	var mu sync.Mutex
+	var checkedAt time.Time
+	var details map[string]interface{}
+	var err error
+	return func(ctx context.Context) (map[string]interface{}, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if checkedAt.IsZero() || time.Since(checkedAt) > ttl {
+			details, err = check(ctx)
+			checkedAt = time.Now()
+		}
+		return details, err
+	}
+}
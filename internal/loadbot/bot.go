@@ -0,0 +1,266 @@
+package loadbot
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/oblongtable/beanbag-backend/internal/game"
+	mywebsoc "github.com/oblongtable/beanbag-backend/websocket"
+)
+
+var errTimeout = errors.New("timed out waiting for callback")
+
+// room is one simulated room: a creator, a host and the remaining players.
+type room struct {
+	cfg  Config
+	rec  *recorder
+	bots []*bot         // Creator first
+	wg   sync.WaitGroup // One per bot read loop
+
+	mu sync.Mutex // Guards bots and triggers
+	// When the event causing the n-th game message of the room was sent.
+	// For question results that is the last answer.
+	triggers map[int]time.Time
+}
+
+func (r *room) trigger(seq int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.triggers[seq] = time.Now()
+}
+
+// sinceTrigger is the broadcast latency of the n-th game message when it is received now.
+func (r *room) sinceTrigger(seq int) (time.Duration, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	sentAt, ok := r.triggers[seq]
+	return time.Since(sentAt), ok
+}
+
+// think waits a random time up to the configured think time before calling f.
+func (r *room) think(f func()) {
+	delay := time.Duration(0)
+	if r.cfg.ThinkTime > 0 {
+		delay = time.Duration(rand.Int63n(int64(r.cfg.ThinkTime)))
+	}
+	time.AfterFunc(delay, f)
+}
+
+// dial connects a bot and starts reading its messages.
+func (r *room) dial(name string) (*bot, error) {
+	conn, _, err := websocket.DefaultDialer.Dial(r.cfg.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	b := &bot{name: name, conn: conn, room: r, pending: make(map[string]pendingEvent)}
+	r.mu.Lock()
+	r.bots = append(r.bots, b)
+	r.mu.Unlock()
+	r.wg.Add(1)
+	go b.run()
+	return b, nil
+}
+
+func (r *room) members() []*bot {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*bot(nil), r.bots...)
+}
+
+// close disconnects every bot, players first so the room does not shut down under them.
+func (r *room) close() {
+	bots := r.members()
+	for i := len(bots) - 1; i >= 0; i-- {
+		bots[i].stop()
+	}
+}
+
+type pendingEvent struct {
+	sentAt time.Time
+	reply  chan mywebsoc.EventCallbackMessage // Set when someone waits for the callback
+}
+
+// bot is a single simulated player with its own connection.
+type bot struct {
+	name string
+	conn *websocket.Conn
+	room *room
+
+	writeMu sync.Mutex // gorilla connections allow one writer at a time
+
+	mu       sync.Mutex
+	nextID   int
+	pending  map[string]pendingEvent // Event ID -> waiting for its callback
+	host     bool
+	received int // Game messages
+	gameOver bool
+	stopped  bool
+}
+
+// send writes an event without waiting for its callback.
+func (b *bot) send(eventType string, payload interface{}) error {
+	_, err := b.write(eventType, payload, nil)
+	return err
+}
+
+// request writes an event and waits for its callback.
+func (b *bot) request(eventType string, payload interface{}) (mywebsoc.EventCallbackMessage, error) {
+	reply := make(chan mywebsoc.EventCallbackMessage, 1)
+	if _, err := b.write(eventType, payload, reply); err != nil {
+		return mywebsoc.EventCallbackMessage{}, err
+	}
+	select {
+	case msg := <-reply:
+		if !msg.IsSuccess {
+			return msg, fmt.Errorf("%s failed: %s", eventType, msg.Message)
+		}
+		return msg, nil
+	case <-time.After(b.room.cfg.Timeout):
+		return mywebsoc.EventCallbackMessage{}, fmt.Errorf("%s: %w", eventType, errTimeout)
+	}
+}
+
+func (b *bot) write(eventType string, payload interface{}, reply chan mywebsoc.EventCallbackMessage) (string, error) {
+	info, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+
+	b.mu.Lock()
+	b.nextID++
+	id := b.name + "-" + strconv.Itoa(b.nextID)
+	b.pending[id] = pendingEvent{sentAt: time.Now(), reply: reply}
+	b.mu.Unlock()
+
+	b.writeMu.Lock()
+	defer b.writeMu.Unlock()
+	return id, b.conn.WriteJSON(mywebsoc.Event{
+		Version: mywebsoc.ProtocolVersion,
+		ID:      id,
+		Type:    eventType,
+		Payload: info,
+	})
+}
+
+// run reads the bot's messages until its game ends or the connection fails.
+func (b *bot) run() {
+	defer b.room.wg.Done()
+	for {
+		b.conn.SetReadDeadline(time.Now().Add(b.room.cfg.Timeout))
+		var msg mywebsoc.EventCallbackMessage
+		if err := b.conn.ReadJSON(&msg); err != nil {
+			b.mu.Lock()
+			lost := !b.stopped && !b.gameOver
+			b.mu.Unlock()
+			if lost {
+				log.Printf("loadbot: %s disconnected: %v", b.name, err)
+				b.room.rec.update(func(r *Report) { r.Disconnected++ })
+			}
+			return
+		}
+		if done := b.handle(msg); done {
+			return
+		}
+	}
+}
+
+// handle reacts to a message like a player would and reports whether the bot is done.
+func (b *bot) handle(msg mywebsoc.EventCallbackMessage) bool {
+	rec := b.room.rec
+
+	switch msg.Type {
+	case mywebsoc.MessageRateLimited:
+		rec.update(func(r *Report) { r.RateLimited++ })
+	case mywebsoc.MessageError:
+		rec.update(func(r *Report) { r.Failed++ })
+	}
+
+	b.mu.Lock()
+	pending, isReply := b.pending[msg.CorrelationID]
+	delete(b.pending, msg.CorrelationID)
+	b.mu.Unlock()
+	if isReply && msg.CorrelationID != "" {
+		rec.callback(time.Since(pending.sentAt))
+		if msg.Type != mywebsoc.MessageRateLimited && msg.Type != mywebsoc.MessageError && !msg.IsSuccess {
+			rec.update(func(r *Report) { r.Failed++ })
+		}
+		if pending.reply != nil {
+			pending.reply <- msg
+		}
+		return false
+	}
+
+	switch msg.Type {
+	case game.MessageShowTitle, game.MessageShowSection, game.MessageQuestionResult:
+		seq := b.gameMessage()
+		if b.isHost() {
+			b.room.think(func() {
+				b.room.trigger(seq + 1)
+				b.send(mywebsoc.EventForwardQuiz, struct{}{})
+			})
+		}
+
+	case game.MessageNewQuestion:
+		seq := b.gameMessage()
+		var question game.NewQuestionPayload
+		if err := json.Unmarshal(msg.Info, &question); err != nil || len(question.Options) == 0 {
+			log.Printf("loadbot: %s got an unreadable question: %s", b.name, msg.Info)
+			break
+		}
+		b.room.think(func() {
+			b.room.trigger(seq + 1)
+			b.send(mywebsoc.EventSubmitAnswer, mywebsoc.SubmitAnswerEvent{AnswerIndex: rand.Intn(len(question.Options))})
+		})
+
+	case game.MessageGameOver:
+		b.gameMessage()
+		b.mu.Lock()
+		b.gameOver = true
+		b.mu.Unlock()
+		return true
+
+	case mywebsoc.MessageRoomShutdown:
+		return true
+	}
+	return false
+}
+
+// gameMessage counts a game message and records its latency. It returns the message's position in the game.
+func (b *bot) gameMessage() int {
+	b.mu.Lock()
+	seq := b.received
+	b.received++
+	b.mu.Unlock()
+
+	if latency, ok := b.room.sinceTrigger(seq); ok {
+		b.room.rec.broadcast(latency)
+	}
+	return seq
+}
+
+func (b *bot) isHost() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.host
+}
+
+func (b *bot) setHost(host bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.host = host
+}
+
+// stop closes the connection without counting it as a disconnect.
+func (b *bot) stop() {
+	b.mu.Lock()
+	b.stopped = true
+	b.mu.Unlock()
+	b.conn.Close()
+}
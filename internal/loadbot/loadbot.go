@@ -0,0 +1,175 @@
+// Package loadbot simulates rooms full of players over real websockets to find out
+// how many concurrent games a server survives.
+package loadbot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	mywebsoc "github.com/oblongtable/beanbag-backend/websocket"
+)
+
+// Config describes a load test run.
+type Config struct {
+	URL       string        // Websocket endpoint, e.g. ws://localhost:8080/ws
+	Rooms     int           // Rooms to play at the same time
+	Players   int           // Bot players per room besides the room's creator
+	QuizID    int32         // Stored quiz to play, 0 for the built-in quiz
+	ThinkTime time.Duration // Bots wait a random time up to this before answering or moving on
+	Ramp      time.Duration // Delay between starting rooms
+	Timeout   time.Duration // How long a bot waits for a callback or its next message
+}
+
+func (cfg Config) validate() error {
+	switch {
+	case cfg.URL == "":
+		return errors.New("a websocket URL is required")
+	case cfg.Rooms < 1:
+		return errors.New("at least one room is required")
+	case cfg.Players < 1 || cfg.Players > mywebsoc.MAX_ROOM_SIZE:
+		return fmt.Errorf("players per room must be between 1 and %d", mywebsoc.MAX_ROOM_SIZE)
+	case cfg.Timeout <= 0:
+		return errors.New("timeout must be positive")
+	}
+	return nil
+}
+
+// Run plays cfg.Rooms games at once and reports how the server coped.
+// Cancelling ctx disconnects every bot and reports what was measured so far.
+func Run(ctx context.Context, cfg Config) (Report, error) {
+	if err := cfg.validate(); err != nil {
+		return Report{}, err
+	}
+
+	rec := &recorder{}
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := range cfg.Rooms {
+		if i > 0 && cfg.Ramp > 0 {
+			select {
+			case <-time.After(cfg.Ramp):
+			case <-ctx.Done():
+			}
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r := &room{cfg: cfg, rec: rec, triggers: make(map[int]time.Time)}
+			if err := r.play(ctx, i); err != nil {
+				log.Printf("loadbot: room %d: %v", i, err)
+			}
+		}()
+	}
+	wg.Wait()
+	return rec.finish(time.Since(start)), nil
+}
+
+// play sets up room i, plays its game to the end and records how many messages went missing.
+func (r *room) play(ctx context.Context, i int) error {
+	stopOnCancel := context.AfterFunc(ctx, r.close)
+	defer stopOnCancel()
+	defer r.close()
+
+	creator, err := r.dial(fmt.Sprintf("r%d-creator", i))
+	if err != nil {
+		r.rec.update(func(rep *Report) { rep.Disconnected++ })
+		return err
+	}
+	r.rec.update(func(rep *Report) { rep.Bots++ })
+	callback, err := creator.request(mywebsoc.EventCreateRoom, mywebsoc.CreateRoomEvent{
+		RoomName: fmt.Sprintf("Load test %d", i),
+		RoomSize: r.cfg.Players,
+		UserName: creator.name,
+	})
+	if err != nil {
+		return err
+	}
+	var info mywebsoc.RoomInfo
+	if err := json.Unmarshal(callback.Info, &info); err != nil {
+		return err
+	}
+	r.rec.update(func(rep *Report) { rep.Rooms++ })
+
+	// The first player to join becomes the host, the rest join together
+	players := make([]*bot, r.cfg.Players)
+	join := func(p int) error {
+		b, err := r.dial(fmt.Sprintf("r%d-p%d", i, p))
+		if err != nil {
+			r.rec.update(func(rep *Report) { rep.Disconnected++ })
+			return err
+		}
+		r.rec.update(func(rep *Report) { rep.Bots++ })
+		callback, err := b.request(mywebsoc.EventJoinRoom, mywebsoc.JoinRoomEvent{RoomID: info.ID, Name: b.name})
+		if err != nil {
+			return err
+		}
+		var joined mywebsoc.RoomInfo
+		json.Unmarshal(callback.Info, &joined)
+		b.setHost(joined.IsHost)
+		players[p] = b
+		return nil
+	}
+	if err := join(0); err != nil {
+		return err
+	}
+	joinErrs := make(chan error, r.cfg.Players)
+	var joining sync.WaitGroup
+	for p := 1; p < r.cfg.Players; p++ {
+		joining.Add(1)
+		go func() {
+			defer joining.Done()
+			if err := join(p); err != nil {
+				joinErrs <- err
+			}
+		}()
+	}
+	joining.Wait()
+	close(joinErrs)
+	if err := <-joinErrs; err != nil {
+		return err
+	}
+
+	host := players[0]
+	r.trigger(0)
+	if _, err := host.request(mywebsoc.EventStartQuiz, mywebsoc.StartQuizEvent{RoomID: info.ID, QuizID: r.cfg.QuizID}); err != nil {
+		return err
+	}
+
+	// Every bot stops reading at game over, or when it loses its connection
+	r.wg.Wait()
+
+	r.record()
+	return nil
+}
+
+// record counts the room's result. A game message is dropped for a bot if another bot in the room received it.
+func (r *room) record() {
+	expected, finished := 0, false
+	for _, b := range r.members() {
+		b.mu.Lock()
+		expected = max(expected, b.received)
+		finished = finished || b.gameOver
+		b.mu.Unlock()
+	}
+	dropped := 0
+	for _, b := range r.members() {
+		b.mu.Lock()
+		dropped += expected - b.received
+		b.mu.Unlock()
+	}
+
+	r.rec.update(func(rep *Report) {
+		rep.Dropped += dropped
+		if finished {
+			rep.FinishedRooms++
+		}
+	})
+}
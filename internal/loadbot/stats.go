@@ -0,0 +1,108 @@
+package loadbot
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Latencies summarises a set of measured durations.
+type Latencies struct {
+	Count int
+	P50   time.Duration
+	P90   time.Duration
+	P99   time.Duration
+	Max   time.Duration
+}
+
+func (l Latencies) String() string {
+	if l.Count == 0 {
+		return "no samples"
+	}
+	return fmt.Sprintf("n=%d p50=%v p90=%v p99=%v max=%v", l.Count, l.P50, l.P90, l.P99, l.Max)
+}
+
+func summarise(samples []time.Duration) Latencies {
+	if len(samples) == 0 {
+		return Latencies{}
+	}
+	sorted := append([]time.Duration(nil), samples...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	percentile := func(p int) time.Duration {
+		return sorted[(len(sorted)-1)*p/100]
+	}
+	return Latencies{
+		Count: len(sorted),
+		P50:   percentile(50),
+		P90:   percentile(90),
+		P99:   percentile(99),
+		Max:   sorted[len(sorted)-1],
+	}
+}
+
+// Report is the outcome of a load test run.
+type Report struct {
+	Duration      time.Duration
+	Rooms         int // Rooms that were created
+	FinishedRooms int // Rooms whose game reached game_over
+	Bots          int // Bots that connected
+
+	Callbacks  Latencies // From sending an event to receiving its callback
+	Broadcasts Latencies // From the event that caused a game message to each bot receiving it
+
+	Dropped      int // Game messages some bot in the room received but another did not
+	Failed       int // Callbacks that reported an error and error messages
+	RateLimited  int // rate_limited messages
+	Disconnected int // Bots whose connection failed or timed out before their game ended
+}
+
+func (r Report) String() string {
+	return fmt.Sprintf(`Duration:     %v
+Rooms:        %d created, %d finished
+Bots:         %d
+Callbacks:    %v
+Broadcasts:   %v
+Dropped:      %d
+Failed:       %d
+Rate limited: %d
+Disconnected: %d`,
+		r.Duration.Round(time.Millisecond), r.Rooms, r.FinishedRooms, r.Bots,
+		r.Callbacks, r.Broadcasts, r.Dropped, r.Failed, r.RateLimited, r.Disconnected)
+}
+
+// recorder collects measurements from every bot.
+type recorder struct {
+	mu         sync.Mutex
+	report     Report
+	callbacks  []time.Duration
+	broadcasts []time.Duration
+}
+
+func (r *recorder) update(f func(report *Report)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	f(&r.report)
+}
+
+func (r *recorder) callback(latency time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.callbacks = append(r.callbacks, latency)
+}
+
+func (r *recorder) broadcast(latency time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.broadcasts = append(r.broadcasts, latency)
+}
+
+func (r *recorder) finish(duration time.Duration) Report {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	report := r.report
+	report.Duration = duration
+	report.Callbacks = summarise(r.callbacks)
+	report.Broadcasts = summarise(r.broadcasts)
+	return report
+}
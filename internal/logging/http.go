@@ -0,0 +1,54 @@
+package logging
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RequestIDHeader carries the request ID. An ID sent by a proxy is kept, otherwise one is generated.
+const RequestIDHeader = "X-Request-ID"
+
+// Middleware gives every request an ID, returns it in the X-Request-ID header,
+// attaches a logger with the ID to the request context and logs the request once it is handled.
+func Middleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+		requestID := ctx.GetHeader(RequestIDHeader)
+		if requestID == "" || len(requestID) > 128 {
+			requestID = uuid.New().String()
+		}
+		ctx.Header(RequestIDHeader, requestID)
+
+		logger := slog.Default().With("request_id", requestID)
+		if span := trace.SpanContextFromContext(ctx.Request.Context()); span.IsValid() {
+			// Set when the tracing middleware runs first, to find the request's trace
+			logger = logger.With("trace_id", span.TraceID().String())
+		}
+		ctx.Request = ctx.Request.WithContext(WithLogger(ctx.Request.Context(), logger))
+
+		ctx.Next()
+
+		status := ctx.Writer.Status()
+		level := slog.LevelInfo
+		if status >= 500 {
+			level = slog.LevelError
+		}
+		logger.LogAttrs(ctx.Request.Context(), level, "request",
+			slog.String("method", ctx.Request.Method),
+			slog.String("path", ctx.Request.URL.Path),
+			slog.String("route", ctx.FullPath()),
+			slog.Int("status", status),
+			slog.Duration("duration", time.Since(start)),
+			slog.String("ip", ctx.ClientIP()),
+		)
+	}
+}
+
+// FromGin returns the request's logger.
+func FromGin(ctx *gin.Context) *slog.Logger {
+	return FromContext(ctx.Request.Context())
+}
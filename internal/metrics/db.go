@@ -0,0 +1,65 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/oblongtable/beanbag-backend/db"
+)
+
+// instrumentedDB times the queries run through a db.DBTX.
+type instrumentedDB struct {
+	db db.DBTX
+}
+
+// InstrumentDB records the latency and errors of every query run through conn,
+// labelled with the sqlc query name. Use it in place of the connection or transaction given to db.New.
+func InstrumentDB(conn db.DBTX) db.DBTX {
+	return &instrumentedDB{db: conn}
+}
+
+// queryName extracts the name from the "-- name: GetUser :one" comment sqlc starts each query with.
+func queryName(query string) string {
+	rest, ok := strings.CutPrefix(query, "-- name: ")
+	if !ok {
+		return "raw"
+	}
+	name, _, _ := strings.Cut(rest, " ")
+	return name
+}
+
+func observe(query string, start time.Time, err error) {
+	name := queryName(query)
+	DBQueryDuration.WithLabelValues(name).Observe(time.Since(start).Seconds())
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		DBQueryErrors.WithLabelValues(name).Inc()
+	}
+}
+
+func (i *instrumentedDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	start := time.Now()
+	result, err := i.db.ExecContext(ctx, query, args...)
+	observe(query, start, err)
+	return result, err
+}
+
+func (i *instrumentedDB) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return i.db.PrepareContext(ctx, query)
+}
+
+func (i *instrumentedDB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	start := time.Now()
+	rows, err := i.db.QueryContext(ctx, query, args...)
+	observe(query, start, err)
+	return rows, err
+}
+
+func (i *instrumentedDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	start := time.Now()
+	row := i.db.QueryRowContext(ctx, query, args...)
+	observe(query, start, row.Err())
+	return row
+}
@@ -0,0 +1,103 @@
+// Package metrics defines the Prometheus metrics of the server and serves them on /metrics.
+package metrics
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "beanbag"
+
+var (
+	EventsReceived = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "ws_events_received_total",
+		Help:      "Websocket events received, by event type.",
+	}, []string{"type"})
+
+	CallbackFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "ws_callback_failures_total",
+		Help:      "Websocket events answered with an error, by callback type and error code.",
+	}, []string{"type", "code"})
+
+	BroadcastLatency = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "ws_broadcast_latency_seconds",
+		Help:      "Time from a room broadcasting a message to it being written to each client.",
+		Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	})
+
+	RoomsExpired = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "ws_rooms_expired_total",
+		Help:      "Rooms closed by their lifecycle limits, by reason.",
+	}, []string{"reason"})
+
+	DBQueryDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "db_query_duration_seconds",
+		Help:      "Database query latency, by sqlc query name.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"query"})
+
+	DBQueryErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "db_query_errors_total",
+		Help:      "Database queries that failed, by sqlc query name.",
+	}, []string{"query"})
+
+	HTTPRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests handled, by method, route and status code.",
+	}, []string{"method", "route", "status"})
+
+	HTTPRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency, by method and route.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+)
+
+// Handler serves every registered metric in the Prometheus text format.
+func Handler() gin.HandlerFunc {
+	return gin.WrapH(promhttp.Handler())
+}
+
+// StateGauge is a gauge vector whose values are read from a callback at scrape time,
+// e.g. the number of games in each state.
+type StateGauge struct {
+	desc  *prometheus.Desc
+	value func() map[string]float64
+}
+
+func NewStateGauge(name, help, label string, value func() map[string]float64) *StateGauge {
+	return &StateGauge{
+		desc:  prometheus.NewDesc(prometheus.BuildFQName(namespace, "", name), help, []string{label}, nil),
+		value: value,
+	}
+}
+
+func (g *StateGauge) Describe(ch chan<- *prometheus.Desc) {
+	ch <- g.desc
+}
+
+func (g *StateGauge) Collect(ch chan<- prometheus.Metric) {
+	for labelValue, v := range g.value() {
+		ch <- prometheus.MustNewConstMetric(g.desc, prometheus.GaugeValue, v, labelValue)
+	}
+}
+
+// NewGaugeFunc is a gauge read from f at scrape time.
+func NewGaugeFunc(name, help string, f func() float64) prometheus.GaugeFunc {
+	return prometheus.NewGaugeFunc(prometheus.GaugeOpts{Namespace: namespace, Name: name, Help: help}, f)
+}
+
+// NewCounterFunc is a counter read from f at scrape time.
+func NewCounterFunc(name, help string, f func() float64) prometheus.CounterFunc {
+	return prometheus.NewCounterFunc(prometheus.CounterOpts{Namespace: namespace, Name: name, Help: help}, f)
+}
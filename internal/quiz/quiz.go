@@ -8,12 +8,18 @@ import (
 
 // Question represents a single question in the quiz, matching the JSON structure.
 type Question struct {
+	ID                 int32    `json:"id,omitempty"` // questions.ques_id when loaded from the database
 	QuestionText       string   `json:"questionText"`
 	Options            []string `json:"options"`
 	CorrectOptionIndex int      `json:"correctOptionIndex"`
 	TimeLimit          int      `json:"timeLimit"` // Time in seconds
 	Points             int      `json:"points"`
 	Explanation        string   `json:"explanation"`
+
+	// Optional media attachments
+	ImageURL        string   `json:"imageUrl,omitempty"`
+	AudioURL        string   `json:"audioUrl,omitempty"`
+	OptionImageURLs []string `json:"optionImageUrls,omitempty"` // Same order as Options, "" for options without an image
 }
 
 // Section represents a section of questions within the quiz.
@@ -25,6 +31,7 @@ type Section struct {
 
 // Quiz represents the entire quiz structure.
 type Quiz struct {
+	ID          int32     `json:"id,omitempty"` // quizzes.quiz_id when loaded from the database
 	Title       string    `json:"title"`
 	Description string    `json:"description"`
 	Sections    []Section `json:"sections"`
@@ -0,0 +1,25 @@
+package roomcode
+
+import "strings"
+
+// DefaultBlocklist holds words no code may contain, in upper case. Codes are also checked
+// with their digits read as the letters they resemble, so 455 counts as ASS.
+var DefaultBlocklist = []string{
+	"ANAL", "ANUS", "ARSE", "ASS", "BOOB", "BUTT", "CLIT", "COCK", "COK", "CRAP", "CUM", "CUNT",
+	"DICK", "DIK", "DYKE", "FAG", "FCK", "FUC", "FUK", "FUX", "HOMO", "JIZ", "KKK", "KYS",
+	"NAZI", "NIG", "NGR", "PEE", "PENIS", "PISS", "POO", "PORN", "PUSY", "RAPE", "SEX", "SHAG",
+	"SHIT", "SHT", "SLUT", "SPAZ", "TIT", "TWAT", "WANK", "WTF", "WHORE", "XXX",
+}
+
+// lookalikes reads digits as the letters they resemble.
+var lookalikes = strings.NewReplacer("0", "O", "1", "I", "2", "Z", "3", "E", "4", "A", "5", "S", "6", "G", "7", "T", "8", "B", "9", "G")
+
+func containsBlocked(code string, blocklist []string) bool {
+	read := lookalikes.Replace(code)
+	for _, word := range blocklist {
+		if strings.Contains(code, word) || strings.Contains(read, word) {
+			return true
+		}
+	}
+	return false
+}
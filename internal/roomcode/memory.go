@@ -0,0 +1,76 @@
+package roomcode
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Reservation sets a code aside for a user between StartsAt and EndsAt.
+type Reservation struct {
+	Code     string
+	UserID   int32
+	StartsAt time.Time
+	EndsAt   time.Time
+}
+
+// MemoryStore keeps codes in memory, so they are only unique within one server.
+// It is safe for concurrent use.
+type MemoryStore struct {
+	mu           sync.Mutex
+	claimed      map[string]bool
+	reservations map[string]Reservation
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		claimed:      make(map[string]bool),
+		reservations: make(map[string]Reservation),
+	}
+}
+
+// Reserve sets the code aside, unless another reservation of it hasn't ended yet.
+func (m *MemoryStore) Reserve(r Reservation) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if existing, ok := m.reservations[r.Code]; ok && time.Now().Before(existing.EndsAt) {
+		return ErrReserved
+	}
+	m.reservations[r.Code] = r
+	return nil
+}
+
+func (m *MemoryStore) Claim(ctx context.Context, code string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if r, ok := m.reservations[code]; ok && time.Now().Before(r.EndsAt) {
+		return false, nil
+	}
+	if m.claimed[code] {
+		return false, nil
+	}
+	m.claimed[code] = true
+	return true, nil
+}
+
+func (m *MemoryStore) ClaimReserved(ctx context.Context, code string, userID int32) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	r, ok := m.reservations[code]
+	if !ok || r.UserID != userID || now.Before(r.StartsAt) || !now.Before(r.EndsAt) {
+		return ErrNotReserved
+	}
+	if m.claimed[code] {
+		return ErrCodeInUse
+	}
+	m.claimed[code] = true
+	return nil
+}
+
+func (m *MemoryStore) Release(ctx context.Context, code string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.claimed, code)
+	return nil
+}
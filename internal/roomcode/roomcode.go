@@ -0,0 +1,162 @@
+// Package roomcode hands out the codes players type to join a room.
+// The Allocator generates codes that are easy to read out and type, and claims them in a
+// Store so no two rooms share one, even when rooms are spread over several servers.
+// Codes can also be reserved ahead of scheduled events, for their host to open a room with.
+package roomcode
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"strings"
+	"sync/atomic"
+)
+
+// Alphabet of generated codes. It leaves out characters that are easy to confuse
+// when read aloud or typed: 0 and O, 1, I and L.
+const Alphabet = "ABCDEFGHJKMNPQRSTUVWXYZ23456789"
+
+const (
+	DefaultMinLength = 4
+	DefaultMaxLength = 8
+
+	// Reserved codes are chosen by people, so they may use any letter or digit.
+	MinVanityLength = 4
+	MaxVanityLength = 12
+
+	attemptsPerLength = 5
+)
+
+var (
+	ErrExhausted   = errors.New("no free room code")
+	ErrInvalidCode = errors.New("invalid room code")
+	ErrNotReserved = errors.New("room code is not reserved for you right now")
+	ErrCodeInUse   = errors.New("room code is already in use")
+	ErrReserved    = errors.New("room code is already reserved")
+)
+
+// Store records which codes are in use. Implementations shared by several servers make codes unique across them.
+type Store interface {
+	// Claim takes a generated code for a new room and reports whether it was free and not reserved.
+	Claim(ctx context.Context, code string) (bool, error)
+	// ClaimReserved takes a code reserved for the user, during its reservation.
+	// It returns ErrNotReserved or ErrCodeInUse if it can't.
+	ClaimReserved(ctx context.Context, code string, userID int32) error
+	// Release frees a code once its room has closed.
+	Release(ctx context.Context, code string) error
+}
+
+// Options configure an Allocator. Zero values take the defaults.
+type Options struct {
+	MinLength int      // Length of generated codes while they are plentiful
+	MaxLength int      // Longest generated codes get when shorter ones keep colliding
+	Blocklist []string // Words generated codes must not contain, DefaultBlocklist if nil
+}
+
+// Allocator hands out room codes. It is safe for concurrent use.
+type Allocator struct {
+	store     Store
+	maxLength int
+	blocklist []string
+
+	length atomic.Int32 // Length generation starts at, grows as the codes in use crowd it
+}
+
+func NewAllocator(store Store, opts Options) *Allocator {
+	if opts.MinLength < 1 {
+		opts.MinLength = DefaultMinLength
+	}
+	if opts.MaxLength < opts.MinLength {
+		opts.MaxLength = max(DefaultMaxLength, opts.MinLength)
+	}
+	if opts.Blocklist == nil {
+		opts.Blocklist = DefaultBlocklist
+	}
+	a := &Allocator{store: store, maxLength: opts.MaxLength, blocklist: opts.Blocklist}
+	a.length.Store(int32(opts.MinLength))
+	return a
+}
+
+// Allocate generates a code and claims it for a new room. Once codes of a length keep colliding,
+// this and later calls move on to longer codes, up to the maximum length.
+func (a *Allocator) Allocate(ctx context.Context) (string, error) {
+	for length := int(a.length.Load()); length <= a.maxLength; length++ {
+		for range attemptsPerLength {
+			code, err := a.generate(length)
+			if err != nil {
+				return "", err
+			}
+			claimed, err := a.store.Claim(ctx, code)
+			if err != nil {
+				return "", fmt.Errorf("failed to claim room code: %w", err)
+			}
+			if claimed {
+				return code, nil
+			}
+		}
+		if length < a.maxLength && a.length.CompareAndSwap(int32(length), int32(length+1)) {
+			slog.Warn("room codes are crowded, generating longer ones", "length", length+1)
+		}
+	}
+	return "", ErrExhausted
+}
+
+// ClaimReserved claims a code reserved for the user, for the room they are opening.
+func (a *Allocator) ClaimReserved(ctx context.Context, code string, userID int32) error {
+	code = Normalize(code)
+	if err := ValidateVanity(code); err != nil {
+		return err
+	}
+	if userID == 0 {
+		// Guests can't have reservations
+		return ErrNotReserved
+	}
+	return a.store.ClaimReserved(ctx, code, userID)
+}
+
+// Release frees the code of a room that has closed.
+func (a *Allocator) Release(ctx context.Context, code string) error {
+	return a.store.Release(ctx, code)
+}
+
+// generate returns a random code of the given length that contains no blocked word.
+func (a *Allocator) generate(length int) (string, error) {
+	alphabetSize := big.NewInt(int64(len(Alphabet)))
+	b := make([]byte, length)
+	for {
+		for i := range b {
+			n, err := rand.Int(rand.Reader, alphabetSize)
+			if err != nil {
+				return "", fmt.Errorf("failed to generate room code: %w", err)
+			}
+			b[i] = Alphabet[n.Int64()]
+		}
+		if code := string(b); !containsBlocked(code, a.blocklist) {
+			return code, nil
+		}
+	}
+}
+
+// Normalize returns the code as rooms are registered under, whatever case it was typed in.
+func Normalize(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// ValidateVanity checks a normalized code someone wants to reserve.
+func ValidateVanity(code string) error {
+	if len(code) < MinVanityLength || len(code) > MaxVanityLength {
+		return fmt.Errorf("%w: must be %d to %d characters", ErrInvalidCode, MinVanityLength, MaxVanityLength)
+	}
+	for _, r := range code {
+		if (r < 'A' || r > 'Z') && (r < '0' || r > '9') {
+			return fmt.Errorf("%w: only letters and digits are allowed", ErrInvalidCode)
+		}
+	}
+	if containsBlocked(code, DefaultBlocklist) {
+		return fmt.Errorf("%w: not allowed", ErrInvalidCode)
+	}
+	return nil
+}
@@ -4,7 +4,7 @@ import (
 	"context"
 	"database/sql" // Import database/sql
 	"fmt"
-	"log"
+	"log/slog"
 	"time"
 
 	"github.com/oblongtable/beanbag-backend/db"
@@ -24,7 +24,7 @@ func NewSeedData(dbConn *sql.DB, queries *db.Queries) *SeedData {
 // SeedDatabaseIfNeeded checks if the database needs seeding (e.g., if users table is empty)
 // and performs seeding if necessary.
 func (s *SeedData) SeedDatabaseIfNeeded(ctx context.Context) error {
-	log.Println("Checking if database seeding is required...")
+	slog.Info("checking if database seeding is required")
 
 	// Check if any users exist. If yes, assume DB is already seeded or populated.
 	userCount, err := s.queries.CountUsers(ctx)
@@ -33,11 +33,11 @@ func (s *SeedData) SeedDatabaseIfNeeded(ctx context.Context) error {
 	}
 
 	if userCount > 0 {
-		log.Printf("Found %d users. Skipping database seeding.\n", userCount)
+		slog.Info("users found, skipping database seeding", "users", userCount)
 		return nil // Seeding not needed
 	}
 
-	log.Println("No users found. Proceeding with database seeding...")
+	slog.Info("no users found, seeding the database")
 
 	// --- Start Seeding ---
 	// Use the dbConn to begin the transaction
@@ -84,7 +84,7 @@ func (s *SeedData) SeedDatabaseIfNeeded(ctx context.Context) error {
 		return fmt.Errorf("failed to commit seeding transaction: %w", err)
 	}
 
-	log.Println("Database seeding completed successfully.")
+	slog.Info("database seeding completed")
 	return nil
 }
 
@@ -92,7 +92,7 @@ func (s *SeedData) SeedDatabaseIfNeeded(ctx context.Context) error {
 // Modify helpers to accept *db.Queries directly, as they will receive the transaction-aware 'qtx'
 
 func (s *SeedData) createSeedUsersWithQueries(ctx context.Context, q *db.Queries) ([]db.User, error) {
-	log.Println("Seeding users...")
+	slog.Info("seeding users")
 	var users []db.User
 	userEmails := []string{"seed.user1@example.com", "seed.user2@example.com"}
 
@@ -108,14 +108,14 @@ func (s *SeedData) createSeedUsersWithQueries(ctx context.Context, q *db.Queries
 		if err != nil {
 			return nil, fmt.Errorf("failed to create user %s: %w", email, err)
 		}
-		log.Printf("Created User: ID=%d, Name=%s, Email=%s\n", user.UserID, user.Name, user.Email)
+		slog.Info("created user", "user_id", user.UserID, "name", user.Name, "email", user.Email)
 		users = append(users, user)
 	}
 	return users, nil
 }
 
 func (s *SeedData) createSeedQuizzesWithQueries(ctx context.Context, q *db.Queries, users []db.User) ([]db.Quiz, error) {
-	log.Println("Seeding quizzes...")
+	slog.Info("seeding quizzes")
 	var quizzes []db.Quiz
 	quizTitles := []string{"Sample Geography Quiz", "Basic Science Quiz"}
 
@@ -139,14 +139,14 @@ func (s *SeedData) createSeedQuizzesWithQueries(ctx context.Context, q *db.Queri
 		if err != nil {
 			return nil, fmt.Errorf("failed to create quiz '%s': %w", title, err)
 		}
-		log.Printf("Created Quiz: ID=%d, Title=%s, CreatorID=%d\n", quiz.QuizID, quiz.QuizTitle, creator.UserID)
+		slog.Info("created quiz", "quiz_id", quiz.QuizID, "title", quiz.QuizTitle, "creator_id", creator.UserID)
 		quizzes = append(quizzes, quiz)
 	}
 	return quizzes, nil
 }
 
 func (s *SeedData) createSeedQuestionsWithQueries(ctx context.Context, q *db.Queries, quizzes []db.Quiz) ([]db.Question, error) {
-	log.Println("Seeding questions...")
+	slog.Info("seeding questions")
 	var questions []db.Question
 	questionData := map[int][]string{
 		0: {"What is the capital of France?", "Which is the largest ocean?"},
@@ -174,20 +174,20 @@ func (s *SeedData) createSeedQuestionsWithQueries(ctx context.Context, q *db.Que
 				if err != nil {
 					return nil, fmt.Errorf("failed to create question '%s' for quiz %d: %w", text, quiz.QuizID, err)
 				}
-				log.Printf("Created Question: ID=%d, Text=%s, QuizID=%d\n", question.QuesID, question.Description, quiz.QuizID)
+				slog.Info("created question", "question_id", question.QuesID, "text", question.Description, "quiz_id", quiz.QuizID)
 				questions = append(questions, question)
 				qCount++
 			}
 		}
 	}
 	if qCount == 0 {
-		log.Println("Warning: No questions were seeded.")
+		slog.Warn("no questions were seeded")
 	}
 	return questions, nil
 }
 
 func (s *SeedData) createSeedAnswersWithQueries(ctx context.Context, q *db.Queries, questions []db.Question) error {
-	log.Println("Seeding answers...")
+	slog.Info("seeding answers")
 	answerData := map[string][]struct {
 		Text      string
 		IsCorrect bool
@@ -216,15 +216,15 @@ func (s *SeedData) createSeedAnswersWithQueries(ctx context.Context, q *db.Queri
 				// Use the passed-in queries (q)
 				_, err := q.CreateAnswer(ctx, answerParams)
 				if err != nil {
-					log.Printf("Error creating answer '%s' for question %d: %v\n", ans.Text, question.QuesID, err)
+					slog.Error("failed to create answer", "text", ans.Text, "question_id", question.QuesID, "error", err)
 				} else {
 					aCount++
 				}
 			}
 		} else {
-			log.Printf("Warning: No answer data defined for question ID %d ('%s')\n", question.QuesID, question.Description)
+			slog.Warn("no answers defined for question", "question_id", question.QuesID, "text", question.Description)
 		}
 	}
-	log.Printf("Attempted to seed %d answers.\n", aCount)
+	slog.Info("seeded answers", "answers", aCount)
 	return nil
 }
@@ -0,0 +1,68 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/oblongtable/beanbag-backend/db"
+	"github.com/oblongtable/beanbag-backend/internal/achievements"
+	"github.com/oblongtable/beanbag-backend/internal/apimodels"
+)
+
+// AchievementService stores awarded achievements. It implements achievements.Store.
+type AchievementService struct {
+	queries *db.Queries
+	catalog map[string]achievements.Achievement
+}
+
+func NewAchievementService(queries *db.Queries, rules []achievements.Rule) *AchievementService {
+	return &AchievementService{
+		queries: queries,
+		catalog: achievements.Catalog(rules),
+	}
+}
+
+func (s *AchievementService) Award(ctx context.Context, userID int32, achievementID string) (bool, error) {
+	rows, err := s.queries.AwardAchievement(ctx, db.AwardAchievementParams{
+		UserID:        userID,
+		AchievementID: achievementID,
+	})
+	if err != nil {
+		return false, fmt.Errorf("error awarding %s to user %d: %w", achievementID, userID, err)
+	}
+	return rows > 0, nil
+}
+
+func (s *AchievementService) IncrementCounter(ctx context.Context, userID int32, counter string) (int64, error) {
+	value, err := s.queries.IncrementAchievementCounter(ctx, db.IncrementAchievementCounterParams{
+		UserID:  userID,
+		Counter: counter,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("error incrementing %s of user %d: %w", counter, userID, err)
+	}
+	return value, nil
+}
+
+// ListUserAchievements returns the achievements the user has earned, oldest first.
+func (s *AchievementService) ListUserAchievements(ctx context.Context, userID int32) ([]apimodels.UserAchievementApiModel, error) {
+	rows, err := s.queries.ListUserAchievements(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error listing achievements of user %d: %w", userID, err)
+	}
+	result := make([]apimodels.UserAchievementApiModel, 0, len(rows))
+	for _, row := range rows {
+		definition, ok := s.catalog[row.AchievementID]
+		if !ok {
+			// The achievement has been retired
+			continue
+		}
+		result = append(result, apimodels.UserAchievementApiModel{
+			ID:          definition.ID,
+			Name:        definition.Name,
+			Description: definition.Description,
+			AwardedAt:   row.AwardedAt,
+		})
+	}
+	return result, nil
+}
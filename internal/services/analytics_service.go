@@ -0,0 +1,102 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/oblongtable/beanbag-backend/db"
+	"github.com/oblongtable/beanbag-backend/internal/apimodels"
+)
+
+// AnalyticsService aggregates the stored sessions of a quiz.
+type AnalyticsService struct {
+	queries *db.Queries
+}
+
+func NewAnalyticsService(queries *db.Queries) *AnalyticsService {
+	return &AnalyticsService{queries: queries}
+}
+
+// QuizAnalytics returns per-question statistics over every recorded session of the quiz
+// and the number of plays per day. Only the quiz's creator may see them, others get ErrNotQuizOwner.
+func (s *AnalyticsService) QuizAnalytics(ctx context.Context, quizID int32, userID int32) (*apimodels.QuizAnalyticsApiModel, error) {
+	if err := checkQuizOwner(ctx, s.queries, quizID, userID); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.queries.ListQuizQuestionAnalytics(ctx, quizID)
+	if err != nil {
+		return nil, fmt.Errorf("error aggregating answers of quiz %d: %w", quizID, err)
+	}
+	wrong, err := s.queries.ListQuizCommonWrongAnswers(ctx, quizID)
+	if err != nil {
+		return nil, fmt.Errorf("error finding common wrong answers of quiz %d: %w", quizID, err)
+	}
+	plays, err := s.queries.ListQuizPlaysPerDay(ctx, quizID)
+	if err != nil {
+		return nil, fmt.Errorf("error counting plays of quiz %d: %w", quizID, err)
+	}
+
+	// Answer indexes follow the order LoadGameQuiz sends the options in
+	quesIDs := make([]int32, 0, len(rows))
+	for _, row := range rows {
+		quesIDs = append(quesIDs, row.QuesID)
+	}
+	optionTexts := make(map[int32][]string)
+	if len(quesIDs) > 0 {
+		answers, err := s.queries.ListAnswersByQuestionIDs(ctx, quesIDs)
+		if err != nil {
+			return nil, fmt.Errorf("error listing answers of quiz %d: %w", quizID, err)
+		}
+		for _, a := range answers {
+			optionTexts[a.QuesID.Int32] = append(optionTexts[a.QuesID.Int32], a.Description)
+		}
+	}
+	wrongByQuestion := make(map[int32]db.ListQuizCommonWrongAnswersRow, len(wrong))
+	for _, w := range wrong {
+		wrongByQuestion[w.QuesID] = w
+	}
+
+	result := &apimodels.QuizAnalyticsApiModel{
+		QuizID:    quizID,
+		Questions: make([]apimodels.QuestionAnalyticsApiModel, 0, len(rows)),
+		Plays:     make([]apimodels.PlaysPerDayApiModel, 0, len(plays)),
+	}
+	for _, row := range rows {
+		question := apimodels.QuestionAnalyticsApiModel{
+			QuestionID:    row.QuesID,
+			Text:          row.Description,
+			Attempts:      row.Attempts,
+			Responses:     row.Responses,
+			AverageTimeMs: int64(math.Round(row.AverageTimeMs)),
+		}
+		if row.Attempts > 0 {
+			question.PercentCorrect = math.Round(float64(row.CorrectAnswers)*1000/float64(row.Attempts)) / 10
+		}
+		if w, ok := wrongByQuestion[row.QuesID]; ok {
+			question.CommonWrong = &apimodels.WrongAnswerApiModel{
+				AnswerIndex: w.AnswerIndex,
+				Players:     w.Players,
+			}
+			if options := optionTexts[row.QuesID]; int(w.AnswerIndex) < len(options) {
+				question.CommonWrong.Text = options[w.AnswerIndex]
+			}
+		}
+		if row.UpperAttempts > 0 && row.LowerAttempts > 0 {
+			upper := float64(row.UpperCorrect) / float64(row.UpperAttempts)
+			lower := float64(row.LowerCorrect) / float64(row.LowerAttempts)
+			d := math.Round((upper-lower)*100) / 100
+			question.Discrimination = &d
+		}
+		result.Questions = append(result.Questions, question)
+	}
+	for _, p := range plays {
+		result.Plays = append(result.Plays, apimodels.PlaysPerDayApiModel{
+			Day:      p.Day.Format("2006-01-02"),
+			Sessions: p.Sessions,
+			Players:  p.Players,
+		})
+	}
+	return result, nil
+}
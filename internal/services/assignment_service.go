@@ -0,0 +1,146 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/oblongtable/beanbag-backend/db"
+	"github.com/oblongtable/beanbag-backend/internal/game"
+)
+
+var (
+	ErrAssignmentNotFound = game.ErrAssignmentNotFound // Shared so websocket callbacks can report it too
+	ErrInvalidWindow      = errors.New("assignment must close after it opens")
+)
+
+// Share codes avoid characters that are easy to confuse when read aloud or typed.
+const (
+	assignmentCodeAlphabet = "ABCDEFGHJKMNPQRSTUVWXYZ23456789"
+	assignmentCodeLength   = 8
+)
+
+type AssignmentService struct {
+	queries *db.Queries
+}
+
+func NewAssignmentService(queries *db.Queries) *AssignmentService {
+	return &AssignmentService{queries: queries}
+}
+
+// CreateAssignment shares a quiz for self-paced play between opensAt and closesAt.
+// The assignment is linked to the creating user, signed in as creatorSubject, when they exist.
+func (s *AssignmentService) CreateAssignment(ctx context.Context, quizID int32, creatorSubject, creatorEmail string, opensAt, closesAt time.Time) (*db.Assignment, error) {
+	if !closesAt.After(opensAt) {
+		return nil, ErrInvalidWindow
+	}
+	if _, err := s.queries.GetQuiz(ctx, quizID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: quiz with ID %d not found", ErrQuizNotFound, quizID)
+		}
+		return nil, fmt.Errorf("failed to get quiz %d: %w", quizID, err)
+	}
+
+	var creatorID sql.NullInt32
+	if creator, err := findUser(ctx, s.queries, creatorSubject, creatorEmail); err == nil {
+		creatorID = sql.NullInt32{Int32: creator.UserID, Valid: true}
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("error looking up creator %s: %w", creatorSubject, err)
+	}
+
+	// Retry on the (unlikely) event of a code collision
+	for range 5 {
+		code, err := newAssignmentCode()
+		if err != nil {
+			return nil, err
+		}
+		assignment, err := s.queries.CreateAssignment(ctx, db.CreateAssignmentParams{
+			Code:      code,
+			QuizID:    quizID,
+			CreatorID: creatorID,
+			OpensAt:   opensAt,
+			ClosesAt:  closesAt,
+		})
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" { // unique_violation
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error creating assignment: %w", err)
+		}
+		return &assignment, nil
+	}
+	return nil, errors.New("error creating assignment: could not generate a unique code")
+}
+
+// GetAssignmentByCode returns the assignment shared under code.
+func (s *AssignmentService) GetAssignmentByCode(ctx context.Context, code string) (*db.Assignment, error) {
+	assignment, err := s.queries.GetAssignmentByCode(ctx, code)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrAssignmentNotFound
+		}
+		return nil, fmt.Errorf("error getting assignment %s: %w", code, err)
+	}
+	return &assignment, nil
+}
+
+// GetAssignment implements game.AssignmentLookup.
+func (s *AssignmentService) GetAssignment(ctx context.Context, code string) (*game.Assignment, error) {
+	assignment, err := s.GetAssignmentByCode(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	return &game.Assignment{
+		ID:       assignment.AssignmentID,
+		Code:     assignment.Code,
+		QuizID:   assignment.QuizID,
+		OpensAt:  assignment.OpensAt,
+		ClosesAt: assignment.ClosesAt,
+	}, nil
+}
+
+// AssignmentResults lists every recorded attempt at the assignment, best score first.
+// Only the user who set the assignment or created its quiz may see them, others get ErrNotQuizOwner.
+func (s *AssignmentService) AssignmentResults(ctx context.Context, code string, userID int32) ([]db.ListSessionPlayerSummariesRow, error) {
+	assignment, err := s.GetAssignmentByCode(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	if !assignment.CreatorID.Valid || assignment.CreatorID.Int32 != userID {
+		if err := checkQuizOwner(ctx, s.queries, assignment.QuizID, userID); err != nil {
+			return nil, err
+		}
+	}
+	session, err := s.queries.GetGameSessionByAssignment(ctx, sql.NullInt32{Int32: assignment.AssignmentID, Valid: true})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			// Nobody has attempted the assignment yet
+			return []db.ListSessionPlayerSummariesRow{}, nil
+		}
+		return nil, fmt.Errorf("error getting session for assignment %s: %w", code, err)
+	}
+	results, err := s.queries.ListSessionPlayerSummaries(ctx, session.SessionID)
+	if err != nil {
+		return nil, fmt.Errorf("error listing results for assignment %s: %w", code, err)
+	}
+	return results, nil
+}
+
+func newAssignmentCode() (string, error) {
+	b := make([]byte, assignmentCodeLength)
+	max := big.NewInt(int64(len(assignmentCodeAlphabet)))
+	for i := range b {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", fmt.Errorf("error generating assignment code: %w", err)
+		}
+		b[i] = assignmentCodeAlphabet[n.Int64()]
+	}
+	return string(b), nil
+}
@@ -0,0 +1,108 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/oblongtable/beanbag-backend/db"
+	"github.com/oblongtable/beanbag-backend/internal/apimodels"
+)
+
+// GuestNamePolicy controls how players who are not signed in appear on leaderboards.
+type GuestNamePolicy string
+
+const (
+	GuestNamesShow      GuestNamePolicy = "show"      // The name they chose for the game
+	GuestNamesAnonymize GuestNamePolicy = "anonymize" // A generic name
+	GuestNamesHide      GuestNamePolicy = "hide"      // Left off the leaderboard
+)
+
+const anonymousGuestName = "Guest"
+
+// Leaderboard periods
+const (
+	PeriodAllTime = "all"
+	PeriodWeekly  = "week" // The past 7 days
+)
+
+var ErrInvalidPeriod = errors.New("period must be 'all' or 'week'")
+
+type LeaderboardService struct {
+	queries    *db.Queries
+	guestNames GuestNamePolicy
+}
+
+func NewLeaderboardService(queries *db.Queries, guestNames string) (*LeaderboardService, error) {
+	policy := GuestNamePolicy(guestNames)
+	switch policy {
+	case GuestNamesShow, GuestNamesAnonymize, GuestNamesHide:
+	default:
+		return nil, fmt.Errorf("unknown guest name policy %q", guestNames)
+	}
+	return &LeaderboardService{queries: queries, guestNames: policy}, nil
+}
+
+// Leaderboard returns the top limit high scores of the period, of one quiz or of every quiz when quizID is 0.
+// viewerID is the requesting user, whose own entry is returned too; 0 for none.
+func (s *LeaderboardService) Leaderboard(ctx context.Context, quizID int32, period string, limit int32, viewerID int32) (*apimodels.LeaderboardApiModel, error) {
+	var since time.Time
+	switch period {
+	case PeriodAllTime:
+		since = time.Unix(0, 0)
+	case PeriodWeekly:
+		since = time.Now().AddDate(0, 0, -7)
+	default:
+		return nil, ErrInvalidPeriod
+	}
+
+	if quizID != 0 {
+		if _, err := s.queries.GetQuiz(ctx, quizID); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil, fmt.Errorf("%w: quiz with ID %d not found", ErrQuizNotFound, quizID)
+			}
+			return nil, fmt.Errorf("failed to get quiz %d: %w", quizID, err)
+		}
+	}
+
+	rows, err := s.queries.ListLeaderboard(ctx, db.ListLeaderboardParams{
+		MaxEntries:    limit,
+		ViewerID:      sql.NullInt32{Int32: viewerID, Valid: viewerID != 0},
+		Since:         since,
+		QuizID:        sql.NullInt32{Int32: quizID, Valid: quizID != 0},
+		IncludeGuests: s.guestNames != GuestNamesHide,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listing leaderboard: %w", err)
+	}
+
+	leaderboard := &apimodels.LeaderboardApiModel{
+		Period:  period,
+		QuizID:  quizID,
+		Entries: make([]apimodels.LeaderboardEntryApiModel, 0, len(rows)),
+	}
+	for _, row := range rows {
+		entry := apimodels.LeaderboardEntryApiModel{
+			Rank:       row.Rank,
+			Name:       row.Name,
+			Score:      row.Score,
+			Guest:      !row.UserID.Valid,
+			QuizID:     row.QuizID.Int32,
+			FinishedAt: row.FinishedAt.Time,
+		}
+		if entry.Guest && s.guestNames == GuestNamesAnonymize {
+			entry.Name = anonymousGuestName
+		}
+		if viewerID != 0 && row.UserID.Int32 == viewerID {
+			you := entry
+			leaderboard.You = &you
+		}
+		// The viewer's row is only included beyond the limit so they can see their rank
+		if row.Position <= int64(limit) {
+			leaderboard.Entries = append(leaderboard.Entries, entry)
+		}
+	}
+	return leaderboard, nil
+}
@@ -0,0 +1,247 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/oblongtable/beanbag-backend/db"
+	"github.com/oblongtable/beanbag-backend/internal/storage"
+)
+
+type MediaKind string
+
+const (
+	MediaKindImage MediaKind = "image"
+	MediaKindAudio MediaKind = "audio"
+)
+
+var (
+	ErrMediaTooLarge        = errors.New("media file is too large")
+	ErrUnsupportedMediaType = errors.New("unsupported media type")
+	ErrMediaTargetNotFound  = errors.New("media target not found")
+)
+
+// allowedMediaTypes maps a sniffed content type to the stored content type and file extension.
+var allowedMediaTypes = map[MediaKind]map[string]struct{ contentType, ext string }{
+	MediaKindImage: {
+		"image/png":  {"image/png", ".png"},
+		"image/jpeg": {"image/jpeg", ".jpg"},
+		"image/gif":  {"image/gif", ".gif"},
+		"image/webp": {"image/webp", ".webp"},
+	},
+	MediaKindAudio: {
+		"audio/mpeg":      {"audio/mpeg", ".mp3"},
+		"audio/wave":      {"audio/wav", ".wav"},
+		"application/ogg": {"audio/ogg", ".ogg"},
+	},
+}
+
+type MediaService struct {
+	queries  *db.Queries
+	store    storage.BlobStore
+	baseURL  string
+	maxBytes int64
+}
+
+func NewMediaService(queries *db.Queries, store storage.BlobStore, baseURL string, maxBytes int64) *MediaService {
+	return &MediaService{
+		queries:  queries,
+		store:    store,
+		baseURL:  strings.TrimRight(baseURL, "/"),
+		maxBytes: maxBytes,
+	}
+}
+
+// MaxBytes is the largest upload the service accepts.
+func (s *MediaService) MaxBytes() int64 {
+	return s.maxBytes
+}
+
+// URL returns the public URL for a stored blob.
+func (s *MediaService) URL(storageKey string) string {
+	return s.baseURL + "/" + storageKey
+}
+
+// Open returns the stored blob for serving over HTTP.
+func (s *MediaService) Open(ctx context.Context, storageKey string) (io.ReadCloser, string, error) {
+	return s.store.Get(ctx, storageKey)
+}
+
+// Upload validates r against the allowed types for kind, stores it and records it in the database.
+// The content type is sniffed from the data, never trusted from the client.
+func (s *MediaService) Upload(ctx context.Context, kind MediaKind, r io.Reader) (*db.MediaAsset, error) {
+	allowed, ok := allowedMediaTypes[kind]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown media kind %q", ErrUnsupportedMediaType, kind)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r, s.maxBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("error reading upload: %w", err)
+	}
+	if int64(len(data)) > s.maxBytes {
+		return nil, fmt.Errorf("%w: limit is %d bytes", ErrMediaTooLarge, s.maxBytes)
+	}
+
+	sniffed := http.DetectContentType(data)
+	// DetectContentType may append parameters such as "; charset=utf-8"
+	sniffed, _, _ = strings.Cut(sniffed, ";")
+	mediaType, ok := allowed[sniffed]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s is not an allowed %s type", ErrUnsupportedMediaType, sniffed, kind)
+	}
+
+	key, err := newStorageKey(kind, mediaType.ext)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.store.Put(ctx, key, bytes.NewReader(data), int64(len(data)), mediaType.contentType); err != nil {
+		return nil, fmt.Errorf("error storing media: %w", err)
+	}
+
+	asset, err := s.queries.CreateMediaAsset(ctx, db.CreateMediaAssetParams{
+		Kind:        string(kind),
+		ContentType: mediaType.contentType,
+		SizeBytes:   int64(len(data)),
+		StorageKey:  key,
+	})
+	if err != nil {
+		// Don't leave an orphaned blob behind if the row could not be written
+		if delErr := s.store.Delete(ctx, key); delErr != nil {
+			return nil, fmt.Errorf("error creating media asset: %w (cleanup failed: %v)", err, delErr)
+		}
+		return nil, fmt.Errorf("error creating media asset: %w", err)
+	}
+
+	return &asset, nil
+}
+
+// AttachQuestionMedia uploads a file and links it to a question as its image or audio clip,
+// deleting the media it replaces. Only the creator of the question's quiz may do this.
+func (s *MediaService) AttachQuestionMedia(ctx context.Context, userID, questionID int32, kind MediaKind, r io.Reader) (*db.MediaAsset, error) {
+	if err := s.checkQuestionOwner(ctx, userID, questionID); err != nil {
+		return nil, err
+	}
+
+	asset, err := s.Upload(ctx, kind, r)
+	if err != nil {
+		return nil, err
+	}
+
+	var replaced sql.NullInt32
+	mediaID := sql.NullInt32{Int32: asset.MediaID, Valid: true}
+	switch kind {
+	case MediaKindImage:
+		replaced, err = s.queries.SetQuestionImage(ctx, db.SetQuestionImageParams{QuesID: questionID, ImageID: mediaID})
+	case MediaKindAudio:
+		replaced, err = s.queries.SetQuestionAudio(ctx, db.SetQuestionAudioParams{QuesID: questionID, AudioID: mediaID})
+	}
+	if err != nil {
+		s.discard(ctx, asset.MediaID)
+		return nil, fmt.Errorf("error attaching media to question %d: %w", questionID, err)
+	}
+	if replaced.Valid {
+		s.discard(ctx, replaced.Int32)
+	}
+	return asset, nil
+}
+
+// AttachAnswerImage uploads an image and links it to an answer option, deleting the image it
+// replaces. Only the creator of the answer's quiz may do this.
+func (s *MediaService) AttachAnswerImage(ctx context.Context, userID, answerID int32, r io.Reader) (*db.MediaAsset, error) {
+	answer, err := s.queries.GetAnswer(ctx, answerID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: answer %d", ErrMediaTargetNotFound, answerID)
+		}
+		return nil, fmt.Errorf("error getting answer: %w", err)
+	}
+	if !answer.QuesID.Valid {
+		return nil, ErrNotQuizOwner
+	}
+	if err := s.checkQuestionOwner(ctx, userID, answer.QuesID.Int32); err != nil {
+		return nil, err
+	}
+
+	asset, err := s.Upload(ctx, MediaKindImage, r)
+	if err != nil {
+		return nil, err
+	}
+
+	replaced, err := s.queries.SetAnswerImage(ctx, db.SetAnswerImageParams{
+		AnsID:   answerID,
+		ImageID: sql.NullInt32{Int32: asset.MediaID, Valid: true},
+	})
+	if err != nil {
+		s.discard(ctx, asset.MediaID)
+		return nil, fmt.Errorf("error attaching image to answer %d: %w", answerID, err)
+	}
+	if replaced.Valid {
+		s.discard(ctx, replaced.Int32)
+	}
+	return asset, nil
+}
+
+// checkQuestionOwner returns ErrMediaTargetNotFound or ErrNotQuizOwner unless the user
+// created the question's quiz.
+func (s *MediaService) checkQuestionOwner(ctx context.Context, userID, questionID int32) error {
+	question, err := s.queries.GetQuestion(ctx, questionID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%w: question %d", ErrMediaTargetNotFound, questionID)
+		}
+		return fmt.Errorf("error getting question: %w", err)
+	}
+	if !question.QuizID.Valid {
+		return ErrNotQuizOwner
+	}
+	return checkQuizOwner(ctx, s.queries, question.QuizID.Int32, userID)
+}
+
+// discard deletes a media asset nothing links to any more, with its blob. It only logs
+// failures, the upload they belong to has already succeeded or failed.
+func (s *MediaService) discard(ctx context.Context, mediaID int32) {
+	// Clean up even if the request was cancelled meanwhile
+	ctx = context.WithoutCancel(ctx)
+	key, err := s.queries.DeleteMediaAsset(ctx, mediaID)
+	if err != nil {
+		slog.Error("failed to delete media asset", "media_id", mediaID, "error", err)
+		return
+	}
+	if err := s.store.Delete(ctx, key); err != nil {
+		slog.Error("failed to delete media blob", "media_id", mediaID, "key", key, "error", err)
+	}
+}
+
+// URLsByID resolves media IDs to public URLs in one query. Unknown IDs are skipped.
+func (s *MediaService) URLsByID(ctx context.Context, ids []int32) (map[int32]string, error) {
+	urls := make(map[int32]string, len(ids))
+	if len(ids) == 0 {
+		return urls, nil
+	}
+	assets, err := s.queries.ListMediaAssetsByIDs(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("error listing media assets: %w", err)
+	}
+	for _, a := range assets {
+		urls[a.MediaID] = s.URL(a.StorageKey)
+	}
+	return urls, nil
+}
+
+func newStorageKey(kind MediaKind, ext string) (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("error generating storage key: %w", err)
+	}
+	return string(kind) + "s/" + hex.EncodeToString(b) + ext, nil
+}
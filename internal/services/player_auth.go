@@ -0,0 +1,36 @@
+package services
+
+import (
+	"context"
+	"fmt"
+)
+
+// TokenValidator checks an access token and returns its subject and email claims.
+type TokenValidator interface {
+	ValidateToken(ctx context.Context, token string) (string, string, error)
+}
+
+// PlayerAuthenticator resolves the access token of a websocket client to their user ID,
+// so games can be linked to the players' accounts.
+type PlayerAuthenticator struct {
+	validator TokenValidator
+	users     *UserService
+}
+
+func NewPlayerAuthenticator(validator TokenValidator, users *UserService) *PlayerAuthenticator {
+	return &PlayerAuthenticator{validator: validator, users: users}
+}
+
+// Authenticate returns the ID of the user the token belongs to.
+// The user must have been synced through POST /users/sync.
+func (a *PlayerAuthenticator) Authenticate(ctx context.Context, token string) (int32, error) {
+	subject, email, err := a.validator.ValidateToken(ctx, token)
+	if err != nil {
+		return 0, fmt.Errorf("invalid token: %w", err)
+	}
+	user, err := a.users.FindUser(ctx, subject, email)
+	if err != nil {
+		return 0, err
+	}
+	return user.UserID, nil
+}
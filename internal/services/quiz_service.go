@@ -5,22 +5,38 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
-	"log"
 	"time"
 
 	"github.com/oblongtable/beanbag-backend/db"
 	"github.com/oblongtable/beanbag-backend/internal/apimodels"
+	"github.com/oblongtable/beanbag-backend/internal/game"
+	"github.com/oblongtable/beanbag-backend/internal/logging"
+	"github.com/oblongtable/beanbag-backend/internal/quiz"
+)
+
+// ErrQuizNotFound is returned by lookups that reference a quiz which doesn't exist.
+// It is the game's error so websocket callbacks can report it too.
+var ErrQuizNotFound = game.ErrQuizNotFound
+
+// ErrNotQuizOwner is returned when someone other than its creator asks for a quiz's results or changes its media.
+var ErrNotQuizOwner = errors.New("only the quiz's creator can do this")
+
+const (
+	defaultGameQuestionTimeLimit = 30 // seconds
+	defaultGameQuestionPoints    = 100
 )
 
 type QuizService struct {
 	connPool *sql.DB
 	queries  *db.Queries
+	media    *MediaService
 }
 
-func NewQuizService(connPool *sql.DB, queries *db.Queries) *QuizService {
+func NewQuizService(connPool *sql.DB, queries *db.Queries, media *MediaService) *QuizService {
 	return &QuizService{
 		connPool: connPool,
 		queries:  queries,
+		media:    media,
 	}
 }
 
@@ -43,12 +59,13 @@ func (s *QuizService) GetFullQuiz(ctx context.Context, quizID int32) (*apimodels
 			return nil, fmt.Errorf("failed to list questions for quiz %d: %w", quizID, err)
 		}
 		// If ErrNoRows is acceptable, dbQuestions will be an empty slice
-		log.Printf("No questions found for quiz %d", quizID)
+		logging.FromContext(ctx).Debug("no questions found", "quiz_id", quizID)
 	}
 
 	// Prepare map to hold answers grouped by question ID
 	answersMap := make(map[int32][]apimodels.AnswerApiModel)
 	questionIDs := make([]int32, 0, len(dbQuestions))
+	mediaURLs := make(map[int32]string)
 
 	if len(dbQuestions) > 0 {
 		// Extract question IDs
@@ -63,22 +80,29 @@ func (s *QuizService) GetFullQuiz(ctx context.Context, quizID int32) (*apimodels
 				return nil, fmt.Errorf("failed to list answers for questions of quiz %d: %w", quizID, err)
 			}
 			// If ErrNoRows is acceptable, dbAnswers will be an empty slice
-			log.Printf("No answers found for questions of quiz %d", quizID)
+			logging.FromContext(ctx).Debug("no answers found for questions", "quiz_id", quizID)
 		}
 
-		// 4. Group Answers by Question ID
+		// 4. Resolve any attached media to URLs
+		mediaURLs, err = s.mediaURLs(ctx, dbQuestions, dbAnswers)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve media for quiz %d: %w", quizID, err)
+		}
+
+		// 5. Group Answers by Question ID
 		for _, a := range dbAnswers {
 			if a.QuesID.Valid { // Check if QuesID is not NULL
 				quesID := a.QuesID.Int32
 				answersMap[quesID] = append(answersMap[quesID], apimodels.AnswerApiModel{
 					Text:      a.Description,
 					IsCorrect: a.IsCorrect,
+					ImageURL:  mediaURLs[a.ImageID.Int32],
 				})
 			}
 		}
 	}
 
-	// 5. Construct the final response object
+	// 6. Construct the final response object
 	apiQuestions := make([]apimodels.QuestionApiModel, 0, len(dbQuestions))
 	for _, q := range dbQuestions {
 		quesID := q.QuesID
@@ -92,6 +116,8 @@ func (s *QuizService) GetFullQuiz(ctx context.Context, quizID int32) (*apimodels
 			UseTimer:   q.TimerOption, 
 			TimerValue: q.Timer,       
 			Answers:    apiAnswers,
+			ImageURL:   mediaURLs[q.ImageID.Int32],
+			AudioURL:   mediaURLs[q.AudioID.Int32],
 		})
 	}
 
@@ -138,7 +164,7 @@ func (s *QuizService) CreateQuizMinimal(ctx context.Context, input apimodels.Qui
 	defer tx.Rollback() // Safe to call even if committed, it becomes a no-op
 
 	// 2. Get SQLC Queries bound to the transaction
-	qtx := s.queries.WithTx(tx)
+	qtx := txQueries(tx)
 
 	// 3. Create the Quiz entry
 
@@ -193,6 +219,124 @@ func (s *QuizService) CreateQuizMinimal(ctx context.Context, input apimodels.Qui
 	return *fullQuizReturn, nil // Return the created quiz data
 }
 
+// mediaURLs resolves the media attached to a quiz's questions and answers, keyed by media ID.
+func (s *QuizService) mediaURLs(ctx context.Context, questions []db.Question, answers []db.Answer) (map[int32]string, error) {
+	if s.media == nil {
+		return map[int32]string{}, nil
+	}
+	var ids []int32
+	for _, q := range questions {
+		if q.ImageID.Valid {
+			ids = append(ids, q.ImageID.Int32)
+		}
+		if q.AudioID.Valid {
+			ids = append(ids, q.AudioID.Int32)
+		}
+	}
+	for _, a := range answers {
+		if a.ImageID.Valid {
+			ids = append(ids, a.ImageID.Int32)
+		}
+	}
+	return s.media.URLsByID(ctx, ids)
+}
+
+// LoadGameQuiz converts a stored quiz into the structure the game engine plays.
+// All questions go into a single section named after the quiz.
+func (s *QuizService) LoadGameQuiz(ctx context.Context, quizID int32) (*quiz.Quiz, error) {
+	dbQuiz, err := s.queries.GetQuiz(ctx, quizID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("quiz with ID %d: %w", quizID, ErrQuizNotFound)
+		}
+		return nil, fmt.Errorf("failed to get quiz %d: %w", quizID, err)
+	}
+
+	dbQuestions, err := s.queries.ListQuestionsByQuiz(ctx, sql.NullInt32{Int32: quizID, Valid: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list questions for quiz %d: %w", quizID, err)
+	}
+	if len(dbQuestions) == 0 {
+		return nil, fmt.Errorf("quiz %d has no questions", quizID)
+	}
+
+	questionIDs := make([]int32, 0, len(dbQuestions))
+	for _, q := range dbQuestions {
+		questionIDs = append(questionIDs, q.QuesID)
+	}
+	dbAnswers, err := s.queries.ListAnswersByQuestionIDs(ctx, questionIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list answers for questions of quiz %d: %w", quizID, err)
+	}
+	answersByQuestion := make(map[int32][]db.Answer)
+	for _, a := range dbAnswers {
+		answersByQuestion[a.QuesID.Int32] = append(answersByQuestion[a.QuesID.Int32], a)
+	}
+
+	mediaURLs, err := s.mediaURLs(ctx, dbQuestions, dbAnswers)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve media for quiz %d: %w", quizID, err)
+	}
+
+	section := quiz.Section{
+		Section: dbQuiz.QuizTitle,
+		Type:    "multiple-choice",
+	}
+	for _, q := range dbQuestions {
+		answers := answersByQuestion[q.QuesID]
+		if len(answers) == 0 {
+			logging.FromContext(ctx).Warn("LoadGameQuiz: skipping question without answers", "question_id", q.QuesID, "quiz_id", quizID)
+			continue
+		}
+
+		timeLimit := int(dbQuiz.Timer)
+		if q.TimerOption {
+			timeLimit = int(q.Timer)
+		}
+		if timeLimit <= 0 {
+			timeLimit = defaultGameQuestionTimeLimit
+		}
+
+		gq := quiz.Question{
+			ID:           q.QuesID,
+			QuestionText: q.Description,
+			TimeLimit:    timeLimit,
+			Points:       defaultGameQuestionPoints,
+			ImageURL:     mediaURLs[q.ImageID.Int32],
+			AudioURL:     mediaURLs[q.AudioID.Int32],
+		}
+		// The game engine supports a single correct option, so the first one marked correct wins
+		foundCorrect := false
+		hasOptionImages := false
+		optionImages := make([]string, len(answers))
+		for i, a := range answers {
+			gq.Options = append(gq.Options, a.Description)
+			if a.IsCorrect && !foundCorrect {
+				gq.CorrectOptionIndex = i
+				foundCorrect = true
+			}
+			if url, ok := mediaURLs[a.ImageID.Int32]; ok {
+				optionImages[i] = url
+				hasOptionImages = true
+			}
+		}
+		if hasOptionImages {
+			gq.OptionImageURLs = optionImages
+		}
+		section.Questions = append(section.Questions, gq)
+	}
+	if len(section.Questions) == 0 {
+		return nil, fmt.Errorf("quiz %d has no playable questions", quizID)
+	}
+
+	return &quiz.Quiz{
+		ID:          dbQuiz.QuizID,
+		Title:       dbQuiz.QuizTitle,
+		Description: dbQuiz.Description.String,
+		Sections:    []quiz.Section{section},
+	}, nil
+}
+
 // GetQuiz might also need modification if you want it to return questions/answers
 func (s *QuizService) GetQuiz(ctx context.Context, id int32) (*db.Quiz, error) {
 	// Current implementation likely only gets the quiz row.
@@ -205,3 +349,18 @@ func (s *QuizService) GetQuiz(ctx context.Context, id int32) (*db.Quiz, error) {
 	}
 	return &quiz, nil
 }
+
+// checkQuizOwner returns ErrQuizNotFound or ErrNotQuizOwner unless the user created the quiz.
+func checkQuizOwner(ctx context.Context, queries *db.Queries, quizID int32, userID int32) error {
+	quiz, err := queries.GetQuiz(ctx, quizID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%w: quiz with ID %d not found", ErrQuizNotFound, quizID)
+		}
+		return fmt.Errorf("failed to get quiz %d: %w", quizID, err)
+	}
+	if !quiz.CreatorID.Valid || quiz.CreatorID.Int32 != userID {
+		return ErrNotQuizOwner
+	}
+	return nil
+}
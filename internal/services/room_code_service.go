@@ -0,0 +1,191 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/oblongtable/beanbag-backend/db"
+	"github.com/oblongtable/beanbag-backend/internal/roomcode"
+)
+
+var (
+	ErrReservationNotFound = errors.New("room code reservation not found")
+	ErrReservationWindow   = errors.New("reservation must end after it starts, in the future")
+	ErrReservationTooLong  = errors.New("reservation ends too far ahead")
+	ErrReservationLimit    = errors.New("too many room code reservations")
+)
+
+// RoomCodeService keeps the room codes of every server in the database, so they are unique
+// across servers, and manages reservations of codes for scheduled events.
+// It is the roomcode.Store of the websocket server.
+type RoomCodeService struct {
+	queries    *db.Queries
+	instanceID string
+	staleAfter time.Duration
+
+	maxReservations  int           // Per user, 0 for no limit
+	reservationAhead time.Duration // How far ahead a reservation may end, 0 for no limit
+}
+
+// NewRoomCodeService claims codes for this server. Codes of servers that haven't sent a
+// heartbeat for staleAfter are taken over, see KeepAlive.
+func NewRoomCodeService(queries *db.Queries, staleAfter time.Duration) (*RoomCodeService, error) {
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return nil, fmt.Errorf("failed to generate instance ID: %w", err)
+	}
+	host, _ := os.Hostname()
+	return &RoomCodeService{
+		queries:    queries,
+		instanceID: host + "-" + hex.EncodeToString(suffix), // Unique per start, a restarted server doesn't own the old codes
+		staleAfter: staleAfter,
+	}, nil
+}
+
+// KeepAlive renews this server's codes every interval, until stop is called.
+// interval must be well below staleAfter.
+func (s *RoomCodeService) KeepAlive(interval time.Duration) (stop func()) {
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				ctx, cancel := context.WithTimeout(context.Background(), interval)
+				if err := s.queries.TouchRoomCodes(ctx, s.instanceID); err != nil {
+					slog.Error("failed to renew room codes", "instance_id", s.instanceID, "error", err)
+				}
+				cancel()
+			case <-done:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+	return func() { close(done) }
+}
+
+// SetReservationLimits caps how many reservations that haven't ended a user may hold,
+// and how far ahead they may end. A reservation holds its code from when it is made.
+func (s *RoomCodeService) SetReservationLimits(maxPerUser int, ahead time.Duration) {
+	s.maxReservations = maxPerUser
+	s.reservationAhead = ahead
+}
+
+func (s *RoomCodeService) Claim(ctx context.Context, code string) (bool, error) {
+	_, err := s.queries.ClaimRoomCode(ctx, db.ClaimRoomCodeParams{
+		Code:         code,
+		InstanceID:   s.instanceID,
+		StaleSeconds: s.staleAfter.Seconds(),
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (s *RoomCodeService) ClaimReserved(ctx context.Context, code string, userID int32) error {
+	_, err := s.queries.ClaimReservedRoomCode(ctx, db.ClaimReservedRoomCodeParams{
+		Code:         code,
+		InstanceID:   s.instanceID,
+		UserID:       userID,
+		StaleSeconds: s.staleAfter.Seconds(),
+	})
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	// Nothing was claimed, either it isn't the user's code now or a room already has it
+	if _, err := s.queries.GetActiveRoomCodeReservation(ctx, db.GetActiveRoomCodeReservationParams{Code: code, ReservedBy: userID}); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return roomcode.ErrNotReserved
+		}
+		return err
+	}
+	return roomcode.ErrCodeInUse
+}
+
+func (s *RoomCodeService) Release(ctx context.Context, code string) error {
+	return s.queries.ReleaseRoomCode(ctx, db.ReleaseRoomCodeParams{Code: code, InstanceID: s.instanceID})
+}
+
+// Reserve sets a code aside for the user between startsAt and endsAt. It fails with
+// roomcode.ErrReserved while another reservation of the code hasn't ended, and with
+// ErrReservationTooLong or ErrReservationLimit past the limits.
+func (s *RoomCodeService) Reserve(ctx context.Context, userID int32, code, label string, startsAt, endsAt time.Time) (*db.RoomCodeReservation, error) {
+	code = roomcode.Normalize(code)
+	if err := roomcode.ValidateVanity(code); err != nil {
+		return nil, err
+	}
+	if !endsAt.After(startsAt) || !endsAt.After(time.Now()) {
+		return nil, ErrReservationWindow
+	}
+	if s.reservationAhead > 0 && endsAt.After(time.Now().Add(s.reservationAhead)) {
+		return nil, ErrReservationTooLong
+	}
+	if s.maxReservations > 0 {
+		count, err := s.queries.CountRoomCodeReservationsByUser(ctx, userID)
+		if err != nil {
+			return nil, fmt.Errorf("error counting room code reservations of user %d: %w", userID, err)
+		}
+		if count >= int64(s.maxReservations) {
+			return nil, ErrReservationLimit
+		}
+	}
+
+	// Reservations that ended no longer hold the code
+	if err := s.queries.DeleteEndedRoomCodeReservation(ctx, code); err != nil {
+		return nil, fmt.Errorf("error clearing ended reservation of %s: %w", code, err)
+	}
+	reservation, err := s.queries.CreateRoomCodeReservation(ctx, db.CreateRoomCodeReservationParams{
+		Code:       code,
+		Label:      label,
+		ReservedBy: userID,
+		StartsAt:   startsAt,
+		EndsAt:     endsAt,
+	})
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" { // unique_violation
+		return nil, roomcode.ErrReserved
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reserving room code %s: %w", code, err)
+	}
+	return &reservation, nil
+}
+
+// ListReservations returns the user's reservations that haven't ended, soonest first.
+func (s *RoomCodeService) ListReservations(ctx context.Context, userID int32) ([]db.RoomCodeReservation, error) {
+	reservations, err := s.queries.ListRoomCodeReservationsByUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error listing room code reservations of user %d: %w", userID, err)
+	}
+	return reservations, nil
+}
+
+// CancelReservation gives up the user's reservation of the code.
+// A room already open with the code stays open.
+func (s *RoomCodeService) CancelReservation(ctx context.Context, userID int32, code string) error {
+	rows, err := s.queries.DeleteRoomCodeReservation(ctx, db.DeleteRoomCodeReservationParams{Code: roomcode.Normalize(code), ReservedBy: userID})
+	if err != nil {
+		return fmt.Errorf("error cancelling reservation of %s: %w", code, err)
+	}
+	if rows == 0 {
+		return ErrReservationNotFound
+	}
+	return nil
+}
@@ -0,0 +1,332 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/oblongtable/beanbag-backend/db"
+	"github.com/oblongtable/beanbag-backend/internal/apimodels"
+	"github.com/oblongtable/beanbag-backend/internal/game"
+)
+
+var ErrSessionNotFound = errors.New("session not found")
+
+// SessionService stores finished games and self-paced attempts.
+// It implements game.SessionRecorder.
+type SessionService struct {
+	connPool *sql.DB
+	queries  *db.Queries
+}
+
+func NewSessionService(connPool *sql.DB, queries *db.Queries) *SessionService {
+	return &SessionService{
+		connPool: connPool,
+		queries:  queries,
+	}
+}
+
+// RecordSession writes a session, its players and their answers in one transaction.
+// Self-paced attempts at the same assignment are appended to the assignment's session.
+func (s *SessionService) RecordSession(ctx context.Context, result game.SessionResult) error {
+	tx, err := s.connPool.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback() // Safe to call even if committed, it becomes a no-op
+
+	qtx := txQueries(tx)
+
+	quizID := sql.NullInt32{Int32: result.QuizID, Valid: result.QuizID != 0}
+	var session db.GameSession
+	if result.AssignmentID != 0 {
+		session, err = qtx.UpsertAssignmentSession(ctx, db.UpsertAssignmentSessionParams{
+			GameCode:     result.GameID,
+			QuizID:       quizID,
+			AssignmentID: sql.NullInt32{Int32: result.AssignmentID, Valid: true},
+			Mode:         string(result.Mode),
+			StartedAt:    result.StartedAt,
+		})
+	} else {
+		session, err = qtx.CreateGameSession(ctx, db.CreateGameSessionParams{
+			GameCode:   result.GameID,
+			QuizID:     quizID,
+			Mode:       string(result.Mode),
+			StartedAt:  result.StartedAt,
+			FinishedAt: nullTime(result.FinishedAt),
+		})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create session for game %s: %w", result.GameID, err)
+	}
+
+	sessionPlayerIDs := make(map[string]int32, len(result.Players))
+	for _, p := range result.Players {
+		player, err := qtx.CreateSessionPlayer(ctx, db.CreateSessionPlayerParams{
+			SessionID:   session.SessionID,
+			PlayerRef:   p.PlayerID,
+			UserID:      sql.NullInt32{Int32: p.UserID, Valid: p.UserID != 0},
+			DisplayName: p.Name,
+			Score:       int32(p.Score),
+			Rank:        sql.NullInt32{Int32: int32(p.Rank), Valid: p.Rank > 0},
+			StartedAt:   p.StartedAt,
+			FinishedAt:  nullTime(p.FinishedAt),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to record player %s: %w", p.PlayerID, err)
+		}
+		sessionPlayerIDs[p.PlayerID] = player.SessionPlayerID
+	}
+
+	for _, a := range result.Answers {
+		sessionPlayerID, ok := sessionPlayerIDs[a.PlayerID]
+		if !ok {
+			// The player left before the game finished
+			continue
+		}
+		answered := a.AnswerIndex >= 0
+		err := qtx.CreateSessionAnswer(ctx, db.CreateSessionAnswerParams{
+			SessionID:       session.SessionID,
+			SessionPlayerID: sessionPlayerID,
+			QuestionIndex:   int32(a.QuestionIndex),
+			QuesID:          sql.NullInt32{Int32: a.QuestionID, Valid: a.QuestionID != 0},
+			AnswerIndex:     sql.NullInt32{Int32: int32(a.AnswerIndex), Valid: answered},
+			IsCorrect:       a.Correct,
+			TimeTakenMs:     sql.NullInt32{Int32: int32(a.TimeTaken.Milliseconds()), Valid: answered},
+			Points:          int32(a.Points),
+			AnsweredAt:      a.AnsweredAt,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to record answer of player %s to question %d: %w", a.PlayerID, a.QuestionIndex, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// ListQuizSessions returns the recorded sessions of a quiz, most recent first.
+// Only the quiz's creator, userID, may list them.
+func (s *SessionService) ListQuizSessions(ctx context.Context, quizID int32, userID int32) ([]apimodels.SessionApiModel, error) {
+	if err := checkQuizOwner(ctx, s.queries, quizID, userID); err != nil {
+		return nil, err
+	}
+	sessions, err := s.queries.ListGameSessionsByQuiz(ctx, sql.NullInt32{Int32: quizID, Valid: true})
+	if err != nil {
+		return nil, fmt.Errorf("error listing sessions of quiz %d: %w", quizID, err)
+	}
+	result := make([]apimodels.SessionApiModel, 0, len(sessions))
+	for _, session := range sessions {
+		result = append(result, toSessionApiModel(session))
+	}
+	return result, nil
+}
+
+// GetSessionResults returns the players of a session and per-question answer statistics.
+// Only the creator of the session's quiz, userID, may see them. Sessions of the built-in
+// quiz, or of a deleted one, have no creator.
+func (s *SessionService) GetSessionResults(ctx context.Context, sessionID int32, userID int32) (*apimodels.SessionResultsApiModel, error) {
+	session, err := s.queries.GetGameSession(ctx, sessionID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: session with ID %d not found", ErrSessionNotFound, sessionID)
+		}
+		return nil, fmt.Errorf("error getting session %d: %w", sessionID, err)
+	}
+	if !session.QuizID.Valid {
+		return nil, ErrNotQuizOwner
+	}
+	if err := checkQuizOwner(ctx, s.queries, session.QuizID.Int32, userID); err != nil {
+		return nil, err
+	}
+
+	players, err := s.queries.ListSessionPlayerSummaries(ctx, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("error listing players of session %d: %w", sessionID, err)
+	}
+	questions, err := s.questionStats(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &apimodels.SessionResultsApiModel{
+		Session:   toSessionApiModel(session),
+		Players:   make([]apimodels.SessionPlayerApiModel, 0, len(players)),
+		Questions: questions,
+	}
+	for _, p := range players {
+		player := apimodels.SessionPlayerApiModel{
+			Name:           p.DisplayName,
+			Score:          p.Score,
+			Rank:           p.Rank.Int32,
+			CorrectAnswers: p.CorrectAnswers,
+			QuestionsSeen:  p.QuestionsSeen,
+			StartedAt:      p.StartedAt,
+		}
+		if p.FinishedAt.Valid {
+			player.FinishedAt = &p.FinishedAt.Time
+		}
+		result.Players = append(result.Players, player)
+	}
+	return result, nil
+}
+
+// questionStats aggregates the stored answers of a session per question.
+func (s *SessionService) questionStats(ctx context.Context, sessionID int32) ([]apimodels.QuestionStatsApiModel, error) {
+	rows, err := s.queries.ListSessionQuestionStats(ctx, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("error aggregating answers of session %d: %w", sessionID, err)
+	}
+	counts, err := s.queries.ListSessionAnswerCounts(ctx, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("error counting answers of session %d: %w", sessionID, err)
+	}
+	fastest, err := s.queries.ListSessionFastestCorrect(ctx, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("error finding fastest answers of session %d: %w", sessionID, err)
+	}
+
+	// Size the answer counts by the question's options, so options nobody chose still show up
+	quesIDs := make([]int32, 0, len(rows))
+	for _, row := range rows {
+		if row.QuesID != 0 {
+			quesIDs = append(quesIDs, row.QuesID)
+		}
+	}
+	optionCounts := make(map[int32]int)
+	if len(quesIDs) > 0 {
+		answers, err := s.queries.ListAnswersByQuestionIDs(ctx, quesIDs)
+		if err != nil {
+			return nil, fmt.Errorf("error listing answers of session %d questions: %w", sessionID, err)
+		}
+		for _, a := range answers {
+			optionCounts[a.QuesID.Int32]++
+		}
+	}
+
+	stats := make([]apimodels.QuestionStatsApiModel, 0, len(rows))
+	for _, row := range rows {
+		q := apimodels.QuestionStatsApiModel{
+			QuestionIndex: row.QuestionIndex,
+			QuestionID:    row.QuesID,
+			AnswerCounts:  make([]int64, optionCounts[row.QuesID]),
+			Players:       row.Players,
+			Responses:     row.Responses,
+			AverageTimeMs: int64(math.Round(row.AverageTimeMs)),
+			FastestTimeMs: int64(row.FastestTimeMs),
+		}
+		if row.Players > 0 {
+			q.PercentCorrect = math.Round(float64(row.CorrectAnswers)*1000/float64(row.Players)) / 10
+		}
+		stats = append(stats, q)
+	}
+	byIndex := make(map[int32]*apimodels.QuestionStatsApiModel, len(stats))
+	for i := range stats {
+		byIndex[stats[i].QuestionIndex] = &stats[i]
+	}
+
+	for _, c := range counts {
+		q, ok := byIndex[c.QuestionIndex]
+		if !ok || c.AnswerIndex < 0 {
+			continue
+		}
+		for int(c.AnswerIndex) >= len(q.AnswerCounts) {
+			q.AnswerCounts = append(q.AnswerCounts, 0)
+		}
+		q.AnswerCounts[c.AnswerIndex] = c.Players
+	}
+	for _, f := range fastest {
+		if q, ok := byIndex[f.QuestionIndex]; ok {
+			q.FastestCorrect = &apimodels.FastestAnswerApiModel{
+				PlayerID: f.PlayerRef,
+				Name:     f.DisplayName,
+				TimeMs:   int64(f.TimeTakenMs),
+			}
+		}
+	}
+	return stats, nil
+}
+
+// UserHistory lists the sessions the user has played, most recent first.
+func (s *SessionService) UserHistory(ctx context.Context, userID int32, limit, offset int32) ([]apimodels.UserHistoryEntryApiModel, error) {
+	rows, err := s.queries.ListUserSessionHistory(ctx, db.ListUserSessionHistoryParams{
+		UserID: userID,
+		Limit:  limit,
+		Offset: offset,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listing history of user %d: %w", userID, err)
+	}
+	history := make([]apimodels.UserHistoryEntryApiModel, 0, len(rows))
+	for _, row := range rows {
+		entry := apimodels.UserHistoryEntryApiModel{
+			SessionID:      row.SessionID,
+			GameCode:       row.GameCode,
+			Mode:           row.Mode,
+			QuizID:         row.QuizID.Int32,
+			QuizTitle:      row.QuizTitle,
+			Name:           row.DisplayName,
+			Score:          row.Score,
+			Rank:           row.Rank.Int32,
+			Players:        row.Players,
+			CorrectAnswers: row.CorrectAnswers,
+			QuestionsSeen:  row.QuestionsSeen,
+			StartedAt:      row.StartedAt,
+		}
+		if row.FinishedAt.Valid {
+			entry.FinishedAt = &row.FinishedAt.Time
+		}
+		history = append(history, entry)
+	}
+	return history, nil
+}
+
+// UserStats summarises every session the user has played.
+func (s *SessionService) UserStats(ctx context.Context, userID int32) (*apimodels.UserStatsApiModel, error) {
+	row, err := s.queries.GetUserStats(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting stats of user %d: %w", userID, err)
+	}
+	streak, err := s.queries.GetUserBestStreak(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting best streak of user %d: %w", userID, err)
+	}
+
+	stats := &apimodels.UserStatsApiModel{
+		SessionsPlayed: row.SessionsPlayed,
+		BestStreak:     streak,
+		TotalPoints:    row.TotalPoints,
+	}
+	if row.AverageRank > 0 {
+		averageRank := math.Round(row.AverageRank*10) / 10
+		stats.AverageRank = &averageRank
+	}
+	if row.QuestionsSeen > 0 {
+		stats.Accuracy = math.Round(float64(row.CorrectAnswers)*1000/float64(row.QuestionsSeen)) / 10
+	}
+	return stats, nil
+}
+
+func toSessionApiModel(session db.GameSession) apimodels.SessionApiModel {
+	result := apimodels.SessionApiModel{
+		SessionID:    session.SessionID,
+		GameCode:     session.GameCode,
+		QuizID:       session.QuizID.Int32,
+		AssignmentID: session.AssignmentID.Int32,
+		Mode:         session.Mode,
+		StartedAt:    session.StartedAt,
+	}
+	if session.FinishedAt.Valid {
+		result.FinishedAt = &session.FinishedAt.Time
+	}
+	return result
+}
+
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
@@ -0,0 +1,14 @@
+package services
+
+import (
+	"database/sql"
+
+	"github.com/oblongtable/beanbag-backend/db"
+	"github.com/oblongtable/beanbag-backend/internal/metrics"
+	"github.com/oblongtable/beanbag-backend/internal/tracing"
+)
+
+// txQueries runs queries in tx. Like Queries.WithTx, but the queries stay timed and traced.
+func txQueries(tx *sql.Tx) *db.Queries {
+	return db.New(tracing.TraceDB(metrics.InstrumentDB(tx)))
+}
@@ -6,83 +6,460 @@ import (
 	"time"
 	"errors"
 	"database/sql"
+	"encoding/json"
+	"strings"
 
 	"github.com/oblongtable/beanbag-backend/db"
+	"github.com/oblongtable/beanbag-backend/internal/apimodels"
+	"github.com/oblongtable/beanbag-backend/internal/logging"
+)
+
+var (
+	ErrUserNotFound     = errors.New("user not found")
+	ErrIdentityNotFound = errors.New("identity not found")
+	ErrCurrentIdentity  = errors.New("can't unlink the identity you are signed in with")
 )
 
 type UserService struct {
-	queries *db.Queries
+	connPool *sql.DB
+	queries  *db.Queries
 }
 
-func NewUserService(queries *db.Queries) *UserService {
-	return &UserService{queries: queries}
+func NewUserService(connPool *sql.DB, queries *db.Queries) *UserService {
+	return &UserService{connPool: connPool, queries: queries}
 }
 
-func (s *UserService) CreateUser(ctx context.Context, name string, email string) (*db.User, error) {
-	params := db.CreateUserParams{
-		Name:      name,
-		Email:     email,
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
-	}
-
-	user, err := s.queries.CreateUser(ctx, params)
+func (s *UserService) GetUserById(ctx context.Context, userID int32) (*db.User, error) {
+	user, err := s.queries.GetUserById(ctx, userID)
 	if err != nil {
-		return nil, fmt.Errorf("error creating user: %w", err)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("error getting user: %w", err)
 	}
-
 	return &user, nil
 }
 
-func (s *UserService) GetUserById(ctx context.Context, userID int32) (*db.User, error) {
-	user, err := s.queries.GetUserById(ctx, userID)
+// GetUserByEmail returns the user with the given email, or ErrUserNotFound.
+func (s *UserService) GetUserByEmail(ctx context.Context, email string) (*db.User, error) {
+	user, err := s.queries.GetUserByEmail(ctx, email)
 	if err != nil {
-		return nil, fmt.Errorf("error getting user: %w", err)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("error getting user by email %s: %w", email, err)
 	}
 	return &user, nil
 }
 
-// SyncUser finds a user by Email, updates name if found, creates if not.
+// SyncUser finds the user signed in as subject and keeps their name and email
+// up to date with Auth0's. A new login with a verified email is linked to the user
+// with the same email, e.g. a Google login next to a password one. Otherwise it
+// creates a new user, since anyone can sign up with an email they don't own.
 // Returns the user, a boolean indicating if created, and an error.
-func (s *UserService) SyncUser(ctx context.Context, name string, email string) (*db.User, bool, error) {
-	// Try to find the user by email
-	existingUser, err := s.queries.GetUserByEmail(ctx, email)
+func (s *UserService) SyncUser(ctx context.Context, subject string, name string, email string, emailVerified bool) (*db.User, bool, error) {
+	tx, err := s.connPool.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback() // Safe to call even if committed, it becomes a no-op
 
+	qtx := txQueries(tx)
+	logger := logging.FromContext(ctx)
+
+	created := false
+	user, err := qtx.GetUserByAuthSubject(ctx, subject)
 	if err == nil {
-		// User found by Email
-		fmt.Printf("User found with email %s, checking name.\n", email)
-		// Check if the name needs updating
-		if existingUser.Name != name {
-			fmt.Printf("Updating name for user %d from '%s' to '%s'.\n", existingUser.UserID, existingUser.Name, name)
-			// Use the existing user's primary key (UserID) to update
-			updatedUser, updateErr := s.queries.UpdateUser(ctx, db.UpdateUserParams{
-				UserID: existingUser.UserID,
-				Name:   sql.NullString{String: name, Valid: true}, // Update name
+		if err := qtx.TouchUserIdentity(ctx, db.TouchUserIdentityParams{AuthSubject: subject, Email: email}); err != nil {
+			return nil, false, fmt.Errorf("failed to update identity %s: %w", subject, err)
+		}
+	} else if errors.Is(err, sql.ErrNoRows) {
+		// A login we haven't seen, for an existing user if one has the same verified email
+		if emailVerified {
+			user, err = qtx.GetUserByEmail(ctx, email)
+		} else {
+			logger.Info("email not verified, not linking to users with the same email", "email", email)
+			err = sql.ErrNoRows
+		}
+		if errors.Is(err, sql.ErrNoRows) {
+			logger.Info("user not found, creating new user", "email", email)
+			user, err = qtx.CreateLinkedUser(ctx, db.CreateLinkedUserParams{
+				Name:        name,
+				Email:       email,
+				AuthSubject: sql.NullString{String: subject, Valid: true},
+			})
+			if err != nil {
+				return nil, false, fmt.Errorf("failed to create new user for %s: %w", email, err)
+			}
+			created = true
+		} else if err != nil {
+			return nil, false, fmt.Errorf("error checking user by email %s: %w", email, err)
+		} else {
+			logger.Info("linking identity to existing user", "user_id", user.UserID)
+			err = qtx.SetUserAuthSubject(ctx, db.SetUserAuthSubjectParams{
+				UserID:      user.UserID,
+				AuthSubject: sql.NullString{String: subject, Valid: true},
 			})
-			if updateErr != nil {
-				return nil, false, fmt.Errorf("failed to update existing user %s: %w", email, updateErr)
+			if err != nil {
+				return nil, false, fmt.Errorf("failed to link %s to user %d: %w", subject, user.UserID, err)
 			}
-			return &updatedUser, false, nil // Return updated user, created = false
 		}
-		// Name is the same, no update needed
-		fmt.Printf("Name for user %s is already up-to-date.\n", email)
-		return &existingUser, false, nil // Return existing user, created = false
+		_, err = qtx.CreateUserIdentity(ctx, db.CreateUserIdentityParams{UserID: user.UserID, AuthSubject: subject, Email: email})
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to record identity %s of user %d: %w", subject, user.UserID, err)
+		}
+	} else {
+		return nil, false, fmt.Errorf("error checking user by subject %s: %w", subject, err)
+	}
+
+	// Emails change, and names too unless the user picked one themselves
+	params := db.UpdateUserParams{UserID: user.UserID}
+	if user.Name != name && !user.CustomName {
+		params.Name = sql.NullString{String: name, Valid: true}
+	}
+	if user.Email != email {
+		params.Email = sql.NullString{String: email, Valid: true}
+	}
+	if params.Name.Valid || params.Email.Valid {
+		logger.Info("updating user name or email", "user_id", user.UserID)
+		if user, err = qtx.UpdateUser(ctx, params); err != nil {
+			return nil, false, fmt.Errorf("failed to update existing user %d: %w", params.UserID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return &user, created, nil
+}
+
+// FindUser returns the user signed in as subject, or ErrUserNotFound.
+// email must be verified, or empty if it isn't.
+func (s *UserService) FindUser(ctx context.Context, subject string, email string) (*db.User, error) {
+	user, err := findUser(ctx, s.queries, subject, email)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("error getting user %s: %w", subject, err)
+	}
+	return &user, nil
+}
+
+// findUser looks the user up by subject. Users who haven't synced since
+// identities were introduced are matched by email instead.
+func findUser(ctx context.Context, queries *db.Queries, subject string, email string) (db.User, error) {
+	user, err := queries.GetUserByAuthSubject(ctx, subject)
+	if errors.Is(err, sql.ErrNoRows) && email != "" {
+		return queries.GetUnlinkedUserByEmail(ctx, email)
+	}
+	return user, err
+}
+
+// GetRole returns the role of the user signed in as subject, or an empty role
+// if they haven't been synced yet.
+func (s *UserService) GetRole(ctx context.Context, subject string, email string) (string, error) {
+	user, err := s.FindUser(ctx, subject, email)
+	if err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			return "", nil
+		}
+		return "", err
+	}
+	return user.Role, nil
+}
+
+// SetRole changes what the user is allowed to do, or returns ErrUserNotFound.
+func (s *UserService) SetRole(ctx context.Context, userID int32, role string) (*db.User, error) {
+	user, err := s.queries.SetUserRole(ctx, db.SetUserRoleParams{UserID: userID, Role: role})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("error setting role of user %d: %w", userID, err)
+	}
+	return &user, nil
+}
+
+// ProfileUpdate holds the profile fields to change, nil ones are left as they are.
+type ProfileUpdate struct {
+	Name          *string
+	AvatarURL     *string // Empty removes the avatar
+	Theme         *string
+	Language      *string
+	ReducedMotion *bool
+}
 
-	} else if !errors.Is(err, sql.ErrNoRows) {
-		// An actual error occurred during lookup (not just 'not found')
-		return nil, false, fmt.Errorf("error checking user by email %s: %w", email, err)
+// Profile describes the user as they see themselves.
+func Profile(user *db.User) apimodels.UserProfileApiModel {
+	profile := apimodels.UserProfileApiModel{
+		UserID:    user.UserID,
+		Name:      user.Name,
+		Email:     user.Email,
+		Role:      user.Role,
+		AvatarURL: user.AvatarUrl.String,
+		CreatedAt: user.CreatedAt,
+		UpdatedAt: user.UpdatedAt,
 	}
+	// Unknown keys, e.g. from older clients, are dropped
+	_ = json.Unmarshal(user.Preferences, &profile.Preferences)
+	return profile
+}
 
-	// User not found by email (err == sql.ErrNoRows), create them
-	fmt.Printf("User with email %s not found, creating new user.\n", email)
-	newUser, createErr := s.queries.CreateUserMinimal(ctx, db.CreateUserMinimalParams{
-		Name:      name,
-		Email:     email,
-	})
-	if createErr != nil {
-		// Handle potential constraint errors (e.g., duplicate email if UNIQUE constraint exists and lookup raced)
-		return nil, false, fmt.Errorf("failed to create new user for %s: %w", email, createErr)
+// UpdateProfile changes the user's name, avatar and display preferences.
+// Once changed here, the name is no longer overwritten by SyncUser.
+func (s *UserService) UpdateProfile(ctx context.Context, userID int32, update ProfileUpdate) (*db.User, error) {
+	user, err := s.queries.GetUserById(ctx, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("error getting user %d: %w", userID, err)
 	}
 
-	return &newUser, true, nil // Return newly created user, created = true
-}
\ No newline at end of file
+	params := db.UpdateUserProfileParams{
+		UserID:     userID,
+		Name:       user.Name,
+		CustomName: user.CustomName,
+		AvatarUrl:  user.AvatarUrl,
+	}
+	if update.Name != nil {
+		params.Name = *update.Name
+		params.CustomName = true
+	}
+	if update.AvatarURL != nil {
+		params.AvatarUrl = sql.NullString{String: *update.AvatarURL, Valid: *update.AvatarURL != ""}
+	}
+	preferences := Profile(&user).Preferences
+	if update.Theme != nil {
+		preferences.Theme = *update.Theme
+	}
+	if update.Language != nil {
+		preferences.Language = *update.Language
+	}
+	if update.ReducedMotion != nil {
+		preferences.ReducedMotion = *update.ReducedMotion
+	}
+	if params.Preferences, err = json.Marshal(preferences); err != nil {
+		return nil, fmt.Errorf("error encoding preferences of user %d: %w", userID, err)
+	}
+
+	updated, err := s.queries.UpdateUserProfile(ctx, params)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrUserNotFound // Deleted meanwhile
+		}
+		return nil, fmt.Errorf("error updating profile of user %d: %w", userID, err)
+	}
+	return &updated, nil
+}
+
+// SoftDelete removes what identifies the user, in one transaction. Their past
+// games are kept for the other players' history and quiz analytics, but as a
+// guest's, and their achievements are dropped. The quizzes and assignments
+// they wrote are kept for the players who use them.
+func (s *UserService) SoftDelete(ctx context.Context, userID int32) error {
+	tx, err := s.connPool.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback() // Safe to call even if committed, it becomes a no-op
+
+	qtx := txQueries(tx)
+
+	if _, err := qtx.SoftDeleteUser(ctx, userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrUserNotFound
+		}
+		return fmt.Errorf("failed to delete user %d: %w", userID, err)
+	}
+	sessions, err := qtx.AnonymizeUserSessions(ctx, sql.NullInt32{Int32: userID, Valid: true})
+	if err != nil {
+		return fmt.Errorf("failed to anonymize sessions of user %d: %w", userID, err)
+	}
+	if err := qtx.DeleteUserIdentities(ctx, userID); err != nil {
+		return fmt.Errorf("failed to delete identities of user %d: %w", userID, err)
+	}
+	if err := qtx.DeleteUserAchievements(ctx, userID); err != nil {
+		return fmt.Errorf("failed to delete achievements of user %d: %w", userID, err)
+	}
+	if err := qtx.DeleteUserAchievementCounters(ctx, userID); err != nil {
+		return fmt.Errorf("failed to delete achievement counters of user %d: %w", userID, err)
+	}
+	if err := qtx.DeleteUserRoomCodeReservations(ctx, userID); err != nil {
+		return fmt.Errorf("failed to delete room code reservations of user %d: %w", userID, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	logging.FromContext(ctx).Info("user deleted", "user_id", userID, "anonymized_sessions", sessions)
+	return nil
+}
+
+// ListIdentities returns the logins linked to the user, oldest first.
+// currentSubject marks the one making the request.
+func (s *UserService) ListIdentities(ctx context.Context, userID int32, currentSubject string) ([]apimodels.UserIdentityApiModel, error) {
+	identities, err := s.queries.ListUserIdentities(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error listing identities of user %d: %w", userID, err)
+	}
+	result := make([]apimodels.UserIdentityApiModel, 0, len(identities))
+	for _, identity := range identities {
+		provider, _, _ := strings.Cut(identity.AuthSubject, "|")
+		result = append(result, apimodels.UserIdentityApiModel{
+			Subject:    identity.AuthSubject,
+			Provider:   provider,
+			Email:      identity.Email,
+			Current:    identity.AuthSubject == currentSubject,
+			CreatedAt:  identity.CreatedAt,
+			LastSeenAt: identity.LastSeenAt,
+		})
+	}
+	return result, nil
+}
+
+// UnlinkIdentity stops a login from signing in as the user, e.g. one linked
+// by email that belongs to someone else. Signing in with it again starts a new
+// account unless the emails still match.
+func (s *UserService) UnlinkIdentity(ctx context.Context, userID int32, subject string, currentSubject string) error {
+	if subject == currentSubject {
+		return ErrCurrentIdentity
+	}
+	tx, err := s.connPool.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback() // Safe to call even if committed, it becomes a no-op
+
+	qtx := txQueries(tx)
+
+	deleted, err := qtx.DeleteUserIdentity(ctx, db.DeleteUserIdentityParams{UserID: userID, AuthSubject: subject})
+	if err != nil {
+		return fmt.Errorf("failed to unlink %s from user %d: %w", subject, userID, err)
+	}
+	if deleted == 0 {
+		return ErrIdentityNotFound
+	}
+	if err := qtx.ResetUserAuthSubject(ctx, userID); err != nil {
+		return fmt.Errorf("failed to reset the subject of user %d: %w", userID, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// Export returns everything stored about the user.
+func (s *UserService) Export(ctx context.Context, user *db.User, currentSubject string) (*apimodels.UserExportApiModel, error) {
+	userRef := sql.NullInt32{Int32: user.UserID, Valid: true}
+	export := &apimodels.UserExportApiModel{
+		ExportedAt:          time.Now(),
+		Profile:             Profile(user),
+		Sessions:            []apimodels.UserExportSessionApiModel{},
+		Achievements:        []apimodels.UserExportAchievementApiModel{},
+		AchievementCounters: map[string]int64{},
+		Quizzes:             []apimodels.UserExportQuizApiModel{},
+		Assignments:         []apimodels.UserExportAssignmentApiModel{},
+	}
+
+	identities, err := s.ListIdentities(ctx, user.UserID, currentSubject)
+	if err != nil {
+		return nil, err
+	}
+	export.Identities = identities
+
+	players, err := s.queries.ListUserSessionPlayers(ctx, userRef)
+	if err != nil {
+		return nil, fmt.Errorf("error listing sessions of user %d: %w", user.UserID, err)
+	}
+	answers, err := s.queries.ListUserSessionAnswers(ctx, userRef)
+	if err != nil {
+		return nil, fmt.Errorf("error listing answers of user %d: %w", user.UserID, err)
+	}
+	answersByPlayer := make(map[int32][]apimodels.UserExportAnswerApiModel)
+	for _, a := range answers {
+		answer := apimodels.UserExportAnswerApiModel{
+			QuestionIndex: a.QuestionIndex,
+			QuestionID:    a.QuesID.Int32,
+			Correct:       a.IsCorrect,
+			Points:        a.Points,
+			AnsweredAt:    a.AnsweredAt,
+		}
+		if a.AnswerIndex.Valid {
+			answer.AnswerIndex = &a.AnswerIndex.Int32
+		}
+		if a.TimeTakenMs.Valid {
+			answer.TimeTakenMs = &a.TimeTakenMs.Int32
+		}
+		answersByPlayer[a.SessionPlayerID] = append(answersByPlayer[a.SessionPlayerID], answer)
+	}
+	for _, p := range players {
+		session := apimodels.UserExportSessionApiModel{
+			SessionID:   p.SessionID,
+			GameCode:    p.GameCode,
+			Mode:        p.Mode,
+			QuizID:      p.QuizID.Int32,
+			QuizTitle:   p.QuizTitle,
+			PlayerRef:   p.PlayerRef,
+			DisplayName: p.DisplayName,
+			Score:       p.Score,
+			Rank:        p.Rank.Int32,
+			StartedAt:   p.StartedAt,
+			Answers:     answersByPlayer[p.SessionPlayerID],
+		}
+		if p.FinishedAt.Valid {
+			session.FinishedAt = &p.FinishedAt.Time
+		}
+		if session.Answers == nil {
+			session.Answers = []apimodels.UserExportAnswerApiModel{}
+		}
+		export.Sessions = append(export.Sessions, session)
+	}
+
+	achievements, err := s.queries.ListUserAchievements(ctx, user.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("error listing achievements of user %d: %w", user.UserID, err)
+	}
+	for _, a := range achievements {
+		export.Achievements = append(export.Achievements, apimodels.UserExportAchievementApiModel{ID: a.AchievementID, AwardedAt: a.AwardedAt})
+	}
+	counters, err := s.queries.ListUserAchievementCounters(ctx, user.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("error listing achievement counters of user %d: %w", user.UserID, err)
+	}
+	for _, c := range counters {
+		export.AchievementCounters[c.Counter] = c.Value
+	}
+
+	quizzes, err := s.queries.ListQuizzesByCreator(ctx, userRef)
+	if err != nil {
+		return nil, fmt.Errorf("error listing quizzes of user %d: %w", user.UserID, err)
+	}
+	for _, q := range quizzes {
+		export.Quizzes = append(export.Quizzes, apimodels.UserExportQuizApiModel{
+			QuizID:      q.QuizID,
+			Title:       q.QuizTitle,
+			Description: q.Description.String,
+			Private:     q.IsPriv,
+			CreatedAt:   q.CreatedAt,
+			UpdatedAt:   q.UpdatedAt,
+		})
+	}
+	assignments, err := s.queries.ListAssignmentsByCreator(ctx, userRef)
+	if err != nil {
+		return nil, fmt.Errorf("error listing assignments of user %d: %w", user.UserID, err)
+	}
+	for _, a := range assignments {
+		export.Assignments = append(export.Assignments, apimodels.UserExportAssignmentApiModel{
+			AssignmentID: a.AssignmentID,
+			Code:         a.Code,
+			QuizID:       a.QuizID,
+			OpensAt:      a.OpensAt,
+			ClosesAt:     a.ClosesAt,
+			CreatedAt:    a.CreatedAt,
+		})
+	}
+	return export, nil
+}
@@ -0,0 +1,48 @@
+// internal/storage/storage.go
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/oblongtable/beanbag-backend/initializers"
+)
+
+// ErrNotFound is returned when a blob does not exist in the store.
+var ErrNotFound = errors.New("blob not found")
+
+// BlobStore is the minimal interface the media service needs from a storage backend.
+// Keys are slash separated paths such as "images/3f2c.png".
+type BlobStore interface {
+	// Put stores size bytes read from r under key.
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Get opens the blob stored under key. The caller must close the returned reader.
+	Get(ctx context.Context, key string) (io.ReadCloser, string, error)
+	// Delete removes the blob stored under key. Deleting a missing blob is not an error.
+	Delete(ctx context.Context, key string) error
+}
+
+const (
+	BackendLocal = "local"
+	BackendS3    = "s3"
+)
+
+// NewFromConfig builds the BlobStore selected by STORAGE_BACKEND.
+func NewFromConfig(config *initializers.Config) (BlobStore, error) {
+	switch config.StorageBackend {
+	case "", BackendLocal:
+		return NewLocalStore(config.MediaDir)
+	case BackendS3:
+		return NewS3Store(S3Config{
+			Endpoint:  config.S3Endpoint,
+			Bucket:    config.S3Bucket,
+			Region:    config.S3Region,
+			AccessKey: config.S3AccessKey,
+			SecretKey: config.S3SecretKey,
+		})
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", config.StorageBackend)
+	}
+}
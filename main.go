@@ -4,23 +4,37 @@ import (
 	"context"
 	"database/sql"
 	"embed"
-	"log"
+	"errors"
+	"io/fs"
+	"log/slog"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/oblongtable/beanbag-backend/db"
 	"github.com/oblongtable/beanbag-backend/initializers"
+	"github.com/oblongtable/beanbag-backend/internal/achievements"
 	"github.com/oblongtable/beanbag-backend/internal/handlers"
+	"github.com/oblongtable/beanbag-backend/internal/health"
+	"github.com/oblongtable/beanbag-backend/internal/logging"
+	"github.com/oblongtable/beanbag-backend/internal/metrics"
+	"github.com/oblongtable/beanbag-backend/internal/roomcode"
 	"github.com/oblongtable/beanbag-backend/internal/seed"
 	"github.com/oblongtable/beanbag-backend/internal/services"
+	"github.com/oblongtable/beanbag-backend/internal/storage"
+	"github.com/oblongtable/beanbag-backend/internal/tracing"
 	"github.com/oblongtable/beanbag-backend/middleware"
 	"github.com/oblongtable/beanbag-backend/websocket"
 
 	adaptor "github.com/gwatts/gin-adapter"
+	"github.com/prometheus/client_golang/prometheus"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 
 	_ "github.com/oblongtable/beanbag-backend/docs" // docs is generated by Swag CLI, you have to import it.
 )
@@ -42,22 +56,30 @@ var (
 )
 
 func init() {
+	// Log as text until the configured level and format are loaded
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, nil)))
 	err := initializers.LoadConfig(".")
 	if err != nil {
-		log.Fatal("? Could not load environment variables", err)
+		fatal("could not load environment variables", err)
 	}
+	if err := logging.Setup(initializers.GetConfig().LogLevel, initializers.GetConfig().LogFormat); err != nil {
+		fatal("could not set up logging", err)
+	}
+	initializers.LogConfig(initializers.GetConfig())
 
 	// Connect to the database
 	dbConn, err := initializers.NewDBConnection(initializers.GetConfig())
 	if err != nil {
-		log.Fatal("? Could not connect to the database", err)
+		fatal("could not connect to the database", err)
 	}
 
 	// Run migrations
-	initializers.MigrateDB(dbConn, embedMigrations)
+	if err := initializers.MigrateDB(dbConn, embedMigrations); err != nil {
+		fatal("could not migrate the database", err)
+	}
 
-	// Create a new Queries instance
-	queries := db.New(dbConn)
+	// Create a new Queries instance, timing every query for /metrics and tracing it
+	queries := db.New(tracing.TraceDB(metrics.InstrumentDB(dbConn)))
 
 	// Make the connection object available globally
 	db_conn = dbConn
@@ -70,32 +92,159 @@ func init() {
 	// Pass both db_conn and DBQueries to NewSeedData
 	seeder := seed.NewSeedData(db_conn, DBQueries)
 	if err := seeder.SeedDatabaseIfNeeded(seedCtx); err != nil {
-		log.Printf("! Database seeding failed: %v\n", err)
+		slog.Warn("database seeding failed", "error", err)
 	}
 	// --- Seeding Done ---
 
-	server = gin.Default()
+	// Requests are logged by logging.Middleware, with their request ID
+	server = gin.New()
+	server.ContextWithFallback = true // Services given the gin context see the request's logger
+	server.Use(gin.Recovery())
 }
 
 func main() {
 	config := initializers.GetConfig()
 	wssvr := websocket.NewWebSockServer()
 
+	shutdownTracing, err := tracing.Setup(context.Background(), config.TracingExporter, config.TracingSampleRatio)
+	if err != nil {
+		fatal("could not set up tracing", err)
+	}
+
+	// Initialize media storage
+	blobStore, err := storage.NewFromConfig(config)
+	if err != nil {
+		fatal("could not initialize media storage", err)
+	}
+
 	// Initialize services
-	quizService := services.NewQuizService(db_conn, DBQueries)
-	userService := services.NewUserService(DBQueries)
+	mediaService := services.NewMediaService(DBQueries, blobStore, config.MediaBaseURL, config.MediaMaxUploadBytes)
+	quizService := services.NewQuizService(db_conn, DBQueries, mediaService)
+	userService := services.NewUserService(db_conn, DBQueries)
 	questionService := services.NewQuestionService(DBQueries)
 	answerService := services.NewAnswerService(DBQueries)
+	sessionService := services.NewSessionService(db_conn, DBQueries)
+	assignmentService := services.NewAssignmentService(DBQueries)
+	analyticsService := services.NewAnalyticsService(DBQueries)
+	achievementRules := achievements.DefaultRules()
+	achievementService := services.NewAchievementService(DBQueries, achievementRules)
+	leaderboardService, err := services.NewLeaderboardService(DBQueries, config.LeaderboardGuestNames)
+	if err != nil {
+		fatal("could not initialize leaderboards", err)
+	}
+
+	// Let games play quizzes stored in the database and record their results
+	wssvr.Games.SetQuizLoader(quizService)
+	wssvr.Games.SetSessionRecorder(sessionService)
+	wssvr.Games.SetAssignmentLookup(assignmentService)
+
+	// Stop clients from flooding the websocket server
+	eventBudgets, err := websocket.ParseEventBudgets(config.WSEventBudgets)
+	if err != nil {
+		fatal("could not parse websocket event budgets", err)
+	}
+	wssvr.SetRateLimits(websocket.RateLimits{
+		Client: websocket.Limit{Rate: config.WSClientRate, Burst: config.WSClientBurst},
+		IP:     websocket.Limit{Rate: config.WSIPRate, Burst: config.WSIPBurst},
+		Events: eventBudgets,
+	})
+	// The IP limit goes by ClientIP, so it must not believe a forged X-Forwarded-For
+	if err := middleware.TrustProxies(server, config.TrustedProxies, config.TrustedPlatform); err != nil {
+		fatal("could not set trusted proxies", err)
+	}
+
+	// Drop clients that cannot keep up instead of buffering for them forever
+	wssvr.SetQueueLimits(websocket.QueueLimits{Size: config.WSSendQueueSize, MaxBehind: config.WSSlowClientTimeout})
+
+	// Close abandoned rooms and forget finished games instead of keeping them forever
+	wssvr.SetRoomLimits(websocket.RoomLimits{
+		IdleTimeout:     config.RoomIdleTimeout,
+		MaxGameDuration: config.GameMaxDuration,
+		ExpiryWarning:   config.RoomExpiryWarning,
+	})
+	wssvr.Games.SetRetention(config.FinishedGameRetention)
+
+	// Room codes are claimed in the database, so they stay unique with several servers
+	roomCodeService, err := services.NewRoomCodeService(DBQueries, 3*config.RoomCodeHeartbeat)
+	if err != nil {
+		fatal("could not initialize room codes", err)
+	}
+	roomCodeService.KeepAlive(config.RoomCodeHeartbeat) // For the life of the server
+	roomCodeService.SetReservationLimits(config.RoomCodeMaxReservations, config.RoomCodeReservationAhead)
+	wssvr.Codes = roomcode.NewAllocator(roomCodeService, roomcode.Options{
+		MinLength: config.RoomCodeLength,
+		MaxLength: config.RoomCodeMaxLength,
+	})
+
+	// Access tokens from Auth0, a static key file, or signed locally in development
+	auth, err := middleware.NewAuthenticator(middleware.AuthOptions{
+		Provider: config.AuthProvider,
+		Domain:   config.AuthDomain,
+		Audience: config.AuthAudience,
+		Issuer:   config.AuthIssuer,
+		KeyFile:  config.AuthKeyFile,
+	})
+	if err != nil {
+		fatal("could not set up authentication", err)
+	}
+	if auth.CanIssue() {
+		slog.Warn("using the dev auth provider, anyone can get a token from /dev/token")
+	}
+
+	// Link signed-in players to their accounts
+	wssvr.Auth = services.NewPlayerAuthenticator(auth, userService)
+
+	// Award achievements as games are played
+	wssvr.Games.SetEventListener(achievements.NewEngine(achievementService, wssvr, achievementRules))
+
+	// Expose clients, rooms, games and send queues on /metrics
+	if err := wssvr.RegisterMetrics(prometheus.DefaultRegisterer); err != nil {
+		fatal("could not register websocket metrics", err)
+	}
+
+	// Components /readyz checks before the server takes traffic
+	migrationsDir, err := fs.Sub(embedMigrations, "migrations")
+	if err != nil {
+		fatal("could not read embedded migrations", err)
+	}
+	migrationsCheck, err := health.Migrations(db_conn, migrationsDir)
+	if err != nil {
+		fatal("could not set up the migrations health check", err)
+	}
+	checker := health.NewChecker(2 * time.Second)
+	checker.Add("database", health.Database(db_conn))
+	checker.Add("migrations", migrationsCheck)
+	checker.Add("websocket", wssvr.CheckRooms)
+	checker.Add("games", wssvr.CheckGames(config.ReadyMaxGames))
+	if config.AuthProvider == middleware.AuthProviderAuth0 && config.AuthDomain != "" {
+		// Signed-in users can't connect without the keys, but guests can still play
+		jwksURL := "https://" + config.AuthDomain + "/.well-known/jwks.json"
+		checker.AddOptional("jwks", health.Cached(health.HTTPGet(http.DefaultClient, jwksURL), time.Minute))
+	}
 
 	// Initialize handlers
-	quizHandler := handlers.NewQuizHandler(quizService)
-	userHandler := handlers.NewUserHandler(userService)
-	questionHandler := handlers.NewQuestionHandler(questionService)
-	answerHandler := handlers.NewAnswerHandler(answerService)
+	healthHandler := handlers.NewHealthHandler(checker)
+	mediaHandler := handlers.NewMediaHandler(mediaService, userService)
+	apiHandlers := handlers.APIHandlers{
+		Admin:       handlers.NewAdminHandler(wssvr),
+		Analytics:   handlers.NewAnalyticsHandler(analyticsService, userService),
+		Answer:      handlers.NewAnswerHandler(answerService),
+		Assignment:  handlers.NewAssignmentHandler(assignmentService, userService, config.ClientOrigin),
+		Leaderboard: handlers.NewLeaderboardHandler(leaderboardService, userService),
+		Media:       mediaHandler,
+		Question:    handlers.NewQuestionHandler(questionService),
+		Quiz:        handlers.NewQuizHandler(quizService, userService),
+		RoomCode:    handlers.NewRoomCodeHandler(roomCodeService, userService),
+		Session:     handlers.NewSessionHandler(sessionService, userService),
+		User:        handlers.NewUserHandler(userService, sessionService, achievementService),
+	}
 
+	server.Use(otelgin.Middleware(tracing.ServiceName))
+	server.Use(logging.Middleware())
+	server.Use(metrics.GinMiddleware())
 	server.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{config.ClientOrigin},
-		AllowMethods:     []string{"GET", "PUT", "POST", "PATCH"},
+		AllowMethods:     []string{"GET", "PUT", "POST", "PATCH", "DELETE"},
 		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization"},
 		ExposeHeaders:    []string{"Content-Length"},
 		AllowCredentials: true,
@@ -116,7 +265,7 @@ func main() {
 		defer cancel()
 		err := db_conn.QueryRowContext(ctxTimeout, "SELECT NOW()").Scan(&tm)
 		if err != nil {
-			log.Printf("Query failed: %v\n", err)
+			logging.FromGin(ctx).Error("db_health query failed", "error", err)
 			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get database time"})
 			return
 		}
@@ -131,39 +280,67 @@ func main() {
 		ctx.JSON(http.StatusOK, "pong")
 	})
 
-	// WebSocket route
-	router.GET("/ws", wssvr.ServeWs)
+	router.GET("/healthz", healthHandler.Liveness)
+	router.GET("/readyz", healthHandler.Readiness)
 
-	// API routes
-	api := router.Group("/api")
-	{
-		api.Use(adaptor.Wrap(middleware.VerifyToken()))
-		api.Use(middleware.ExtractAndSetClaims())
+	router.GET("/metrics", metrics.Handler())
 
-		// User routes
-		api.POST("/users", userHandler.CreateUser)
-		api.POST("/users/sync", userHandler.SyncUser)
-		api.GET("/users/:id", userHandler.GetUser)
-
-		// Quiz routes
-		api.POST("/quizzes", quizHandler.CreateQuiz)
-		api.POST("/quizzes/minimal", quizHandler.CreateQuizMinimal) // Assuming this maps to full creation
-		api.GET("/quizzes/:id", quizHandler.GetQuiz)
-		api.GET("/quizzes/:id/full", quizHandler.GetFullQuiz) // Add this route for the full quiz
-
-		// Question routes
-		api.POST("/questions", questionHandler.CreateQuestion)
-		api.GET("/questions/:id", questionHandler.GetQuestion)
+	// WebSocket route
+	router.GET("/ws", wssvr.ServeWs)
 
-		// Answer routes
-		api.POST("/answers", answerHandler.CreateAnswer)
-		api.GET("/answers/:id", answerHandler.GetAnswer)
+	// Uploaded media is public so it can be embedded in game clients
+	router.GET("/media/*key", mediaHandler.ServeMedia)
 
+	// Tokens for trying the API locally, only with the dev auth provider
+	if auth.CanIssue() {
+		router.POST("/dev/token", handlers.NewDevHandler(auth).IssueToken)
 	}
 
+	// API routes, see handlers.RegisterAPIRoutes for who may use them
+	api := router.Group("/api")
+	api.Use(adaptor.Wrap(auth.VerifyToken()))
+	api.Use(middleware.ExtractAndSetClaims())
+	handlers.RegisterAPIRoutes(api, apiHandlers, userService.GetRole)
+
 	// Swagger route
 	url := ginSwagger.URL("http://localhost:8080/swagger/doc.json") // The url pointing to API definition
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler, url))
 
-	log.Fatal(server.Run(":" + config.ServerPort))
+	// Websocket protocol, the AsyncAPI counterpart of the Swagger docs
+	router.GET("/asyncapi.json", websocket.ServeAsyncAPI)
+
+	httpServer := &http.Server{Addr: ":" + config.ServerPort, Handler: server}
+	go func() {
+		slog.Info("listening", "addr", httpServer.Addr)
+		if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			fatal("server stopped", err)
+		}
+	}()
+
+	// Stop on Ctrl-C or when the container is stopped, letting requests in flight finish
+	stopped, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	<-stopped.Done()
+	stop()
+	slog.Info("shutting down")
+
+	// How long requests in flight get to finish
+	const shutdownTimeout = 10 * time.Second
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := httpServer.Shutdown(ctx); err != nil {
+		slog.Error("failed to shut down the server", "error", err)
+	}
+	// Export the spans still buffered
+	if err := shutdownTracing(ctx); err != nil {
+		slog.Error("failed to shut down tracing", "error", err)
+	}
+	if err := db_conn.Close(); err != nil {
+		slog.Error("failed to close the database", "error", err)
+	}
+}
+
+// fatal logs an error the server can't run with and exits.
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
 }
@@ -2,7 +2,9 @@ package middleware
 
 import (
 	"context"
-	"log"
+	"crypto/rsa"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/url"
 	"strings"
@@ -12,13 +14,14 @@ import (
 	"github.com/auth0/go-jwt-middleware/v2/jwks"
 	"github.com/auth0/go-jwt-middleware/v2/validator"
 	"github.com/gin-gonic/gin"
-	"github.com/oblongtable/beanbag-backend/initializers"
+	"github.com/oblongtable/beanbag-backend/internal/logging"
 )
 
 // CustomClaims contains custom data we want from the token.
 type CustomClaims struct {
-	Scope string `json:"scope"`
-	Email string `json:"email"`
+	Scope         string `json:"scope"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
 }
 
 // Validate does nothing for this example, but we need
@@ -27,21 +30,79 @@ func (c CustomClaims) Validate(ctx context.Context) error {
 	return nil
 }
 
-// EnsureValidToken is a middleware that will check the validity of our JWT.
-func VerifyToken() func(next http.Handler) http.Handler {
-	config := initializers.GetConfig()
-	issuerURL, err := url.Parse("https://" + config.AuthDomain + "/")
-	if err != nil {
-		log.Fatalf("Failed to parse the issuer url: %v", err)
+// Auth providers, picked with AUTH_PROVIDER.
+const (
+	AuthProviderAuth0  = "auth0"  // Keys fetched from the Auth0 tenant's JWKS
+	AuthProviderStatic = "static" // Keys read from a JWKS or PEM public key file
+	AuthProviderDev    = "dev"    // Tokens signed by the server itself, see IssueToken
+)
+
+// Issuer and audience of dev tokens unless configured otherwise.
+const (
+	DevIssuer   = "beanbag-dev"
+	DevAudience = "beanbag-api"
+)
+
+// AuthOptions says where an Authenticator gets its keys from and which tokens it accepts.
+type AuthOptions struct {
+	Provider string
+	Domain   string // Auth0 tenant
+	Audience string
+	Issuer   string // Defaults to https://Domain/, or DevIssuer for the dev provider
+	KeyFile  string // Static: JWKS or PEM public key. Dev: optional PEM private key to sign with
+}
+
+// Authenticator validates access tokens, over HTTP with VerifyToken or directly
+// with ValidateToken. With the dev provider it can issue them too.
+type Authenticator struct {
+	validator  *validator.Validator
+	issuer     string
+	audience   string
+	signingKey *rsa.PrivateKey // Dev provider only
+	keyID      string
+}
+
+// NewAuthenticator sets up the validation of RS256 access tokens from the configured provider.
+func NewAuthenticator(opts AuthOptions) (*Authenticator, error) {
+	a := &Authenticator{issuer: opts.Issuer, audience: opts.Audience}
+	if a.issuer == "" && opts.Provider != AuthProviderDev {
+		a.issuer = "https://" + opts.Domain + "/"
 	}
 
-	provider := jwks.NewCachingProvider(issuerURL, 5*time.Minute)
+	var keyFunc func(context.Context) (interface{}, error)
+	switch opts.Provider {
+	case AuthProviderAuth0, "":
+		issuerURL, err := url.Parse(a.issuer)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse the issuer url: %w", err)
+		}
+		keyFunc = jwks.NewCachingProvider(issuerURL, 5*time.Minute).KeyFunc
+	case AuthProviderStatic:
+		key, err := readPublicKeys(opts.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		keyFunc = func(context.Context) (interface{}, error) { return key, nil }
+	case AuthProviderDev:
+		if a.issuer == "" {
+			a.issuer = DevIssuer
+		}
+		if a.audience == "" {
+			a.audience = DevAudience
+		}
+		if err := a.loadSigningKey(opts.KeyFile); err != nil {
+			return nil, err
+		}
+		keyFunc = func(context.Context) (interface{}, error) { return &a.signingKey.PublicKey, nil }
+	default:
+		return nil, fmt.Errorf("unknown auth provider %q, use %s, %s or %s", opts.Provider, AuthProviderAuth0, AuthProviderStatic, AuthProviderDev)
+	}
 
 	jwtValidator, err := validator.New(
-		provider.KeyFunc,
+		keyFunc,
 		validator.RS256,
-		issuerURL.String(),
-		[]string{config.AuthAudience},
+		a.issuer,
+		[]string{a.audience},
 		validator.WithCustomClaims(
 			func() validator.CustomClaims {
 				return &CustomClaims{}
@@ -50,11 +111,16 @@ func VerifyToken() func(next http.Handler) http.Handler {
 		validator.WithAllowedClockSkew(time.Minute),
 	)
 	if err != nil {
-		log.Fatalf("Failed to set up the jwt validator")
+		return nil, fmt.Errorf("failed to set up the jwt validator: %w", err)
 	}
+	a.validator = jwtValidator
+	return a, nil
+}
 
+// VerifyToken is a middleware that will check the validity of our JWT.
+func (a *Authenticator) VerifyToken() func(next http.Handler) http.Handler {
 	errorHandler := func(w http.ResponseWriter, r *http.Request, err error) {
-		log.Printf("Encountered error while validating JWT: %v", err)
+		logging.FromContext(r.Context()).Info("rejected JWT", "error", err)
 
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusUnauthorized)
@@ -62,7 +128,7 @@ func VerifyToken() func(next http.Handler) http.Handler {
 	}
 
 	middleware := jwtmiddleware.New(
-		jwtValidator.ValidateToken,
+		a.validator.ValidateToken,
 		jwtmiddleware.WithErrorHandler(errorHandler),
 	)
 
@@ -72,10 +138,21 @@ func VerifyToken() func(next http.Handler) http.Handler {
 }
 
 const (
-	GinContextKeyUserEmail = "user_email"
-	GinContextKeyUserSub   = "user_sub"
+	GinContextKeyUserEmail     = "user_email"
+	GinContextKeyEmailVerified = "email_verified"
+	GinContextKeyUserSub       = "user_sub"
+	GinContextKeyScope         = "scope" // Space separated scopes granted by the token
 )
 
+// VerifiedEmail returns the user's email if the token says it was verified, or "".
+// Only a verified email may match a login to an existing user.
+func VerifiedEmail(c *gin.Context) string {
+	if !c.GetBool(GinContextKeyEmailVerified) {
+		return ""
+	}
+	return c.GetString(GinContextKeyUserEmail)
+}
+
 // ExtractAndSetClaims is a Gin middleware that should run *after*
 // the JWT validation middleware (like VerifyToken adapted).
 // It extracts claims set by go-jwt-middleware/v2 into the request context
@@ -87,14 +164,14 @@ func ExtractAndSetClaims() gin.HandlerFunc {
 		
 		if token == nil {
 			// This should ideally be caught by VerifyToken, but check defensively
-			log.Println("Middleware Error: No token found in request context after VerifyToken")
+			logging.FromGin(c).Error("no token found in request context after VerifyToken")
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authorization token not found in context"})
 			return
 		}
 
 		validatedClaims, ok := token.(*validator.ValidatedClaims)
 		if !ok {
-			log.Printf("Middleware Error: Token in context is not *validator.ValidatedClaims: %T", token)
+			logging.FromGin(c).Error("token in context is not *validator.ValidatedClaims", "type", fmt.Sprintf("%T", token))
 			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Invalid token format in context"})
 			return
 		}
@@ -102,7 +179,7 @@ func ExtractAndSetClaims() gin.HandlerFunc {
 		// --- Extract Subject (sub) ---
 		sub := validatedClaims.RegisteredClaims.Subject
 		if sub == "" {
-			log.Println("Middleware Error: Subject (sub) claim missing in validated token")
+			logging.FromGin(c).Warn("subject (sub) claim missing in validated token")
 			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Token missing required user identifier"})
 			return
 		}
@@ -111,7 +188,7 @@ func ExtractAndSetClaims() gin.HandlerFunc {
 		// Cast the CustomClaims interface{} to our specific *CustomClaims type
 		customClaims, ok := validatedClaims.CustomClaims.(*CustomClaims)
 		if !ok || customClaims == nil {
-			log.Printf("Middleware Error: CustomClaims in context is not *CustomClaims or is nil")
+			logging.FromGin(c).Error("CustomClaims in context is not *CustomClaims or is nil")
 			// This might happen if the token validation succeeded but custom claims parsing failed,
 			// or if the CustomClaims field wasn't populated correctly by the validator.
 			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Could not process custom claims from token"})
@@ -120,7 +197,7 @@ func ExtractAndSetClaims() gin.HandlerFunc {
 
 		email := customClaims.Email // Access the Email field we added
 		if email == "" {
-			log.Println("Middleware Error: Email claim missing or empty in validated token's custom claims")
+			logging.FromGin(c).Warn("email claim missing or empty in validated token's custom claims", "user_sub", sub)
 			// This is added by a custom action post-login in our Auth0 setup
 			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Token missing required user email information"})
 			return
@@ -128,14 +205,41 @@ func ExtractAndSetClaims() gin.HandlerFunc {
 
 		// --- Set values in Gin context for the handler ---
 		c.Set(GinContextKeyUserEmail, email)
+		c.Set(GinContextKeyEmailVerified, customClaims.EmailVerified)
 		c.Set(GinContextKeyUserSub, sub)
-		log.Printf("Middleware: Set user_email=%s, user_sub=%s in Gin context", email, sub) // Debug log
+		c.Set(GinContextKeyScope, customClaims.Scope)
+		// Later lines of the request are tagged with the user
+		logger := logging.FromGin(c).With("user_sub", sub)
+		c.Request = c.Request.WithContext(logging.WithLogger(c.Request.Context(), logger))
+		logger.Debug("set user claims in Gin context", "email", email)
 
 		c.Next() // Proceed to the next handler (e.g., the actual API handler)
 	}
 }
 
 
+// ValidateToken checks the token and returns its subject and email claims,
+// e.g. for websocket connections that pass the token as a query parameter.
+// The email is only returned if it was verified.
+func (a *Authenticator) ValidateToken(ctx context.Context, token string) (string, string, error) {
+	claims, err := a.validator.ValidateToken(ctx, token)
+	if err != nil {
+		return "", "", err
+	}
+	validatedClaims, ok := claims.(*validator.ValidatedClaims)
+	if !ok {
+		return "", "", fmt.Errorf("unexpected claims type %T", claims)
+	}
+	customClaims, ok := validatedClaims.CustomClaims.(*CustomClaims)
+	if !ok || customClaims == nil || customClaims.Email == "" {
+		return "", "", errors.New("token missing required user email information")
+	}
+	if !customClaims.EmailVerified {
+		return validatedClaims.RegisteredClaims.Subject, "", nil
+	}
+	return validatedClaims.RegisteredClaims.Subject, customClaims.Email, nil
+}
+
 // HasScope checks whether our claims have a specific scope.
 func (c CustomClaims) HasScope(expectedScope string) bool {
 	result := strings.Split(c.Scope, " ")
@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TrustProxies makes ClientIP, which rate limits and logs go by, believe X-Forwarded-For
+// only from the given proxies, IPs or CIDRs separated by commas. With none, the address
+// the request came from is used. A platform sets the header its load balancer puts the
+// client's address in, e.g. CF-Connecting-IP, and takes precedence.
+func TrustProxies(engine *gin.Engine, proxies string, platform string) error {
+	var trusted []string
+	for _, proxy := range strings.Split(proxies, ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			trusted = append(trusted, proxy)
+		}
+	}
+	if err := engine.SetTrustedProxies(trusted); err != nil {
+		return fmt.Errorf("invalid trusted proxies %q: %w", proxies, err)
+	}
+	engine.TrustedPlatform = platform
+	return nil
+}
@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/oblongtable/beanbag-backend/internal/logging"
+)
+
+// Roles stored on users, each allowed everything the ones below it are.
+const (
+	RoleAdmin   = "admin"   // Runs the service, e.g. unsticks live rooms and hands out roles
+	RoleTeacher = "teacher" // Writes quizzes, sets assignments and reads their results
+	RolePlayer  = "player"  // Plays quizzes, every user starts as one
+)
+
+const GinContextKeyRole = "role"
+
+var roleRanks = map[string]int{RolePlayer: 0, RoleTeacher: 1, RoleAdmin: 2}
+
+// ValidRole reports whether role is one users can have.
+func ValidRole(role string) bool {
+	_, ok := roleRanks[role]
+	return ok
+}
+
+// RoleLookup returns the role of the user signed in as subject, or an empty
+// role for users who haven't been synced yet.
+type RoleLookup func(ctx context.Context, subject string, email string) (string, error)
+
+// RequireRole rejects requests from users below role with 403.
+// It must run after ExtractAndSetClaims. The role is looked up once per request.
+func RequireRole(lookup RoleLookup, role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userRole, err := roleOf(c, lookup)
+		if err != nil {
+			logging.FromGin(c).Error("failed to look up role", "error", err)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to check permissions"})
+			return
+		}
+		if roleRanks[userRole] < roleRanks[role] {
+			logging.FromGin(c).Warn("missing role", "role", role, "user_role", userRole)
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Forbidden", "missing_permission": "role:" + role})
+			return
+		}
+		c.Next()
+	}
+}
+
+func roleOf(c *gin.Context, lookup RoleLookup) (string, error) {
+	if role := c.GetString(GinContextKeyRole); role != "" {
+		return role, nil
+	}
+	role, err := lookup(c.Request.Context(), c.GetString(GinContextKeyUserSub), VerifiedEmail(c))
+	if err != nil {
+		return "", err
+	}
+	if role == "" {
+		role = RolePlayer
+	}
+	c.Set(GinContextKeyRole, role)
+	return role, nil
+}
@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/oblongtable/beanbag-backend/internal/logging"
+)
+
+// Scopes Auth0 grants to the client application, checked on top of the user's role.
+const (
+	ScopeAdmin        = "admin"         // Inspect and control every live room and game, hand out roles
+	ScopeWriteQuizzes = "write:quizzes" // Create quizzes, their questions, answers and media
+)
+
+// RequireScope rejects requests whose token wasn't granted scope with 403.
+// It must run after ExtractAndSetClaims.
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims := CustomClaims{Scope: c.GetString(GinContextKeyScope)}
+		if !claims.HasScope(scope) {
+			logging.FromGin(c).Warn("missing scope", "scope", scope)
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Forbidden", "missing_permission": scope})
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,35 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS media_assets (
+    media_id SERIAL PRIMARY KEY,
+    kind TEXT NOT NULL,
+    content_type TEXT NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    storage_key TEXT NOT NULL UNIQUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE questions
+    ADD COLUMN image_id INTEGER REFERENCES media_assets(media_id) ON DELETE SET NULL,
+    ADD COLUMN audio_id INTEGER REFERENCES media_assets(media_id) ON DELETE SET NULL;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE answers
+    ADD COLUMN image_id INTEGER REFERENCES media_assets(media_id) ON DELETE SET NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE answers DROP COLUMN IF EXISTS image_id;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE questions DROP COLUMN IF EXISTS audio_id, DROP COLUMN IF EXISTS image_id;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE IF EXISTS media_assets;
+-- +goose StatementEnd
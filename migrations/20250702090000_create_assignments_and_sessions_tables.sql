@@ -0,0 +1,76 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS assignments (
+    assignment_id SERIAL PRIMARY KEY,
+    code TEXT NOT NULL UNIQUE,
+    quiz_id INTEGER NOT NULL REFERENCES quizzes(quiz_id) ON DELETE CASCADE,
+    creator_id INTEGER REFERENCES users(user_id) ON DELETE SET NULL,
+    opens_at TIMESTAMPTZ NOT NULL,
+    closes_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS game_sessions (
+    session_id SERIAL PRIMARY KEY,
+    game_code TEXT NOT NULL,
+    quiz_id INTEGER REFERENCES quizzes(quiz_id) ON DELETE SET NULL,
+    assignment_id INTEGER UNIQUE REFERENCES assignments(assignment_id) ON DELETE CASCADE,
+    mode TEXT NOT NULL,
+    started_at TIMESTAMPTZ NOT NULL,
+    finished_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS session_players (
+    session_player_id SERIAL PRIMARY KEY,
+    session_id INTEGER NOT NULL REFERENCES game_sessions(session_id) ON DELETE CASCADE,
+    player_ref TEXT NOT NULL,
+    display_name TEXT NOT NULL,
+    score INTEGER NOT NULL DEFAULT 0,
+    rank INTEGER,
+    started_at TIMESTAMPTZ NOT NULL,
+    finished_at TIMESTAMPTZ
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS session_answers (
+    session_answer_id SERIAL PRIMARY KEY,
+    session_id INTEGER NOT NULL REFERENCES game_sessions(session_id) ON DELETE CASCADE,
+    session_player_id INTEGER NOT NULL REFERENCES session_players(session_player_id) ON DELETE CASCADE,
+    question_index INTEGER NOT NULL,
+    ques_id INTEGER REFERENCES questions(ques_id) ON DELETE SET NULL,
+    answer_index INTEGER,
+    is_correct BOOLEAN NOT NULL,
+    time_taken_ms INTEGER,
+    points INTEGER NOT NULL DEFAULT 0,
+    answered_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS idx_session_players_session ON session_players(session_id);
+CREATE INDEX IF NOT EXISTS idx_session_answers_player ON session_answers(session_player_id);
+CREATE INDEX IF NOT EXISTS idx_game_sessions_quiz ON game_sessions(quiz_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS session_answers;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE IF EXISTS session_players;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE IF EXISTS game_sessions;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE IF EXISTS assignments;
+-- +goose StatementEnd
@@ -0,0 +1,12 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE session_players
+    ADD COLUMN IF NOT EXISTS user_id INTEGER REFERENCES users(user_id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS idx_session_players_user ON session_players(user_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_session_players_user;
+ALTER TABLE session_players DROP COLUMN IF EXISTS user_id;
+-- +goose StatementEnd
@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE users
+    ADD COLUMN role TEXT NOT NULL DEFAULT 'player'
+    CHECK (role IN ('admin', 'teacher', 'player'));
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+-- Whoever has already written a quiz keeps being able to
+UPDATE users SET role = 'teacher'
+WHERE user_id IN (SELECT creator_id FROM quizzes WHERE creator_id IS NOT NULL);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE users DROP COLUMN IF EXISTS role;
+-- +goose StatementEnd
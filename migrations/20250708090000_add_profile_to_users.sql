@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE users
+    ADD COLUMN avatar_url TEXT,
+    ADD COLUMN preferences JSONB NOT NULL DEFAULT '{}',
+    -- Set once the user picks a name, so syncing with Auth0 stops overwriting it
+    ADD COLUMN custom_name BOOLEAN NOT NULL DEFAULT false;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS idx_users_email_active ON users(email) WHERE deleted_at IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_users_email_active;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE users
+    DROP COLUMN IF EXISTS custom_name,
+    DROP COLUMN IF EXISTS preferences,
+    DROP COLUMN IF EXISTS avatar_url;
+-- +goose StatementEnd
@@ -0,0 +1,30 @@
+-- +goose Up
+-- +goose StatementBegin
+-- The Auth0 subject the user first signed in with. Existing users get theirs
+-- the next time they sync, matched by email.
+ALTER TABLE users ADD COLUMN auth_subject TEXT;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_auth_subject ON users(auth_subject);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+-- Every login linked to a user, e.g. a password and a Google login with the same email.
+CREATE TABLE IF NOT EXISTS user_identities (
+    identity_id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    auth_subject TEXT NOT NULL UNIQUE,
+    email TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_seen_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_user_identities_user ON user_identities(user_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS user_identities;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_users_auth_subject;
+ALTER TABLE users DROP COLUMN IF EXISTS auth_subject;
+-- +goose StatementEnd
@@ -0,0 +1,36 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Codes of the rooms open on every server, so no two rooms share one. Servers renew
+-- heartbeat_at of their codes, the codes of a server that stopped may be taken over.
+CREATE TABLE IF NOT EXISTS room_codes (
+    code TEXT PRIMARY KEY,
+    instance_id TEXT NOT NULL,
+    claimed_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    heartbeat_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_room_codes_instance ON room_codes(instance_id);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+-- Codes set aside for scheduled events. Generated codes avoid them until they end,
+-- and only the user who reserved one can open a room with it while it lasts.
+CREATE TABLE IF NOT EXISTS room_code_reservations (
+    code TEXT PRIMARY KEY,
+    label TEXT NOT NULL DEFAULT '',
+    reserved_by INTEGER NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    starts_at TIMESTAMPTZ NOT NULL,
+    ends_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK (ends_at > starts_at)
+);
+CREATE INDEX IF NOT EXISTS idx_room_code_reservations_user ON room_code_reservations(reserved_by);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS room_code_reservations;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE IF EXISTS room_codes;
+-- +goose StatementEnd
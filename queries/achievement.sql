@@ -0,0 +1,28 @@
+-- name: AwardAchievement :execrows
+-- Affects no rows when the user already has the achievement.
+INSERT INTO user_achievements (
+    user_id, achievement_id
+) VALUES (
+    $1, $2
+)
+ON CONFLICT (user_id, achievement_id) DO NOTHING;
+
+-- name: IncrementAchievementCounter :one
+INSERT INTO user_achievement_counters (
+    user_id, counter, value
+) VALUES (
+    $1, $2, 1
+)
+ON CONFLICT (user_id, counter) DO UPDATE
+SET value = user_achievement_counters.value + 1
+RETURNING value;
+
+-- name: ListUserAchievements :many
+SELECT * FROM user_achievements
+WHERE user_id = $1
+ORDER BY awarded_at, achievement_id;
+
+-- name: ListUserAchievementCounters :many
+SELECT * FROM user_achievement_counters
+WHERE user_id = $1
+ORDER BY counter;
@@ -0,0 +1,60 @@
+-- name: ListQuizQuestionAnalytics :many
+-- The discrimination index compares how often the top and bottom 27% of
+-- players got a question right. Players are ranked by score across every
+-- session of the quiz, as self-paced attempts have one player each, and tied
+-- players share the lower rank. Players who never answered, like presenters
+-- of games recorded before they were left out, aren't ranked.
+WITH ranked AS (
+    SELECT
+        sp.session_player_id,
+        PERCENT_RANK() OVER (ORDER BY sp.score) AS score_rank
+    FROM session_players sp
+    JOIN game_sessions gs ON gs.session_id = sp.session_id
+    WHERE gs.quiz_id = sqlc.arg(quiz_id)::int
+        AND EXISTS (
+            SELECT 1 FROM session_answers a
+            WHERE a.session_player_id = sp.session_player_id AND a.answer_index IS NOT NULL
+        )
+)
+SELECT
+    q.ques_id,
+    q.description,
+    COUNT(sa.session_answer_id) AS attempts,
+    COUNT(sa.answer_index) AS responses,
+    COUNT(sa.session_answer_id) FILTER (WHERE sa.is_correct) AS correct_answers,
+    COALESCE(AVG(sa.time_taken_ms), 0)::float8 AS average_time_ms,
+    COUNT(sa.session_answer_id) FILTER (WHERE r.score_rank >= 0.73) AS upper_attempts,
+    COUNT(sa.session_answer_id) FILTER (WHERE r.score_rank >= 0.73 AND sa.is_correct) AS upper_correct,
+    COUNT(sa.session_answer_id) FILTER (WHERE r.score_rank <= 0.27) AS lower_attempts,
+    COUNT(sa.session_answer_id) FILTER (WHERE r.score_rank <= 0.27 AND sa.is_correct) AS lower_correct
+FROM questions q
+LEFT JOIN session_answers sa ON sa.ques_id = q.ques_id
+LEFT JOIN ranked r ON r.session_player_id = sa.session_player_id
+WHERE q.quiz_id = sqlc.arg(quiz_id)::int
+GROUP BY q.ques_id
+ORDER BY q.ques_id;
+
+-- name: ListQuizCommonWrongAnswers :many
+-- The wrong option chosen most often for each question, ties go to the lowest index.
+SELECT DISTINCT ON (sa.ques_id)
+    sa.ques_id::int AS ques_id,
+    sa.answer_index::int AS answer_index,
+    COUNT(*) AS players
+FROM session_answers sa
+JOIN questions q ON q.ques_id = sa.ques_id
+WHERE q.quiz_id = sqlc.arg(quiz_id)::int
+    AND NOT sa.is_correct
+    AND sa.answer_index IS NOT NULL
+GROUP BY sa.ques_id, sa.answer_index
+ORDER BY sa.ques_id, COUNT(*) DESC, sa.answer_index;
+
+-- name: ListQuizPlaysPerDay :many
+SELECT
+    (sp.started_at AT TIME ZONE 'UTC')::date AS day,
+    COUNT(DISTINCT sp.session_id) AS sessions,
+    COUNT(*) AS players
+FROM session_players sp
+JOIN game_sessions gs ON gs.session_id = sp.session_id
+WHERE gs.quiz_id = sqlc.arg(quiz_id)::int
+GROUP BY day
+ORDER BY day;
@@ -0,0 +1,15 @@
+-- name: CreateAssignment :one
+INSERT INTO assignments (
+    code, quiz_id, creator_id, opens_at, closes_at
+) VALUES (
+    $1, $2, $3, $4, $5
+) RETURNING *;
+
+-- name: GetAssignmentByCode :one
+SELECT * FROM assignments
+WHERE code = $1 LIMIT 1;
+
+-- name: ListAssignmentsByCreator :many
+SELECT * FROM assignments
+WHERE creator_id = $1
+ORDER BY created_at, assignment_id;
@@ -0,0 +1,45 @@
+-- name: ListLeaderboard :many
+-- High scores of finished games, best first. Signed-in users appear once with
+-- their best score, guests can't be told apart across games so each of their
+-- games is its own entry. Leave quiz_id NULL for the global leaderboard.
+-- The row of viewer_id is returned as well even when it is below the limit.
+WITH entries AS (
+    SELECT
+        sp.session_player_id,
+        sp.user_id,
+        COALESCE(u.name, sp.display_name)::text AS name,
+        sp.score,
+        gs.quiz_id,
+        sp.finished_at,
+        ROW_NUMBER() OVER (
+            PARTITION BY sp.user_id
+            ORDER BY sp.score DESC, sp.finished_at
+        ) AS user_best
+    FROM session_players sp
+    JOIN game_sessions gs ON gs.session_id = sp.session_id
+    LEFT JOIN users u ON u.user_id = sp.user_id
+    WHERE sp.finished_at IS NOT NULL
+        AND sp.finished_at >= sqlc.arg(since)::timestamptz
+        AND (sqlc.narg(quiz_id)::int IS NULL OR gs.quiz_id = sqlc.narg(quiz_id)::int)
+        AND (sqlc.arg(include_guests)::bool OR sp.user_id IS NOT NULL)
+),
+ranked AS (
+    SELECT
+        *,
+        RANK() OVER (ORDER BY score DESC) AS rank,
+        ROW_NUMBER() OVER (ORDER BY score DESC, finished_at) AS position
+    FROM entries
+    WHERE user_id IS NULL OR user_best = 1
+)
+SELECT
+    session_player_id,
+    user_id,
+    name,
+    score,
+    quiz_id,
+    finished_at,
+    rank,
+    position
+FROM ranked
+WHERE position <= sqlc.arg(max_entries)::int OR user_id = sqlc.narg(viewer_id)::int
+ORDER BY position;
@@ -0,0 +1,49 @@
+-- name: CreateMediaAsset :one
+INSERT INTO media_assets (
+    kind, content_type, size_bytes, storage_key
+) VALUES (
+    $1, $2, $3, $4
+) RETURNING *;
+
+-- name: GetMediaAsset :one
+SELECT * FROM media_assets
+WHERE media_id = $1 LIMIT 1;
+
+-- name: DeleteMediaAsset :one
+DELETE FROM media_assets
+WHERE media_id = $1
+RETURNING storage_key;
+
+-- name: ListMediaAssetsByIDs :many
+SELECT * FROM media_assets
+WHERE media_id = ANY($1::int[]);
+
+-- name: SetQuestionImage :one
+-- Returns the image it replaced. The row is locked, so concurrent uploads each get the one they replaced.
+UPDATE questions q
+SET
+    image_id = sqlc.narg(image_id),
+    updated_at = NOW()
+FROM (SELECT p.ques_id, p.image_id FROM questions p WHERE p.ques_id = sqlc.arg(ques_id) FOR UPDATE) old
+WHERE q.ques_id = old.ques_id
+RETURNING old.image_id AS replaced_id;
+
+-- name: SetQuestionAudio :one
+-- Returns the audio clip it replaced, see SetQuestionImage.
+UPDATE questions q
+SET
+    audio_id = sqlc.narg(audio_id),
+    updated_at = NOW()
+FROM (SELECT p.ques_id, p.audio_id FROM questions p WHERE p.ques_id = sqlc.arg(ques_id) FOR UPDATE) old
+WHERE q.ques_id = old.ques_id
+RETURNING old.audio_id AS replaced_id;
+
+-- name: SetAnswerImage :one
+-- Returns the image it replaced, see SetQuestionImage.
+UPDATE answers a
+SET
+    image_id = sqlc.narg(image_id),
+    updated_at = NOW()
+FROM (SELECT p.ans_id, p.image_id FROM answers p WHERE p.ans_id = sqlc.arg(ans_id) FOR UPDATE) old
+WHERE a.ans_id = old.ans_id
+RETURNING old.image_id AS replaced_id;
@@ -35,3 +35,8 @@ SET
     updated_at = NOW()
 WHERE quiz_id = $1
 RETURNING *;
+
+-- name: ListQuizzesByCreator :many
+SELECT * FROM quizzes
+WHERE creator_id = $1
+ORDER BY created_at, quiz_id;
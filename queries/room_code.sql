@@ -0,0 +1,66 @@
+-- name: ClaimRoomCode :one
+-- Takes a generated code unless a live room has it or it is reserved.
+INSERT INTO room_codes (code, instance_id)
+SELECT sqlc.arg(code)::text, sqlc.arg(instance_id)::text
+WHERE NOT EXISTS (
+    SELECT 1 FROM room_code_reservations r
+    WHERE r.code = sqlc.arg(code)::text AND r.ends_at > NOW()
+)
+ON CONFLICT (code) DO UPDATE
+SET instance_id = EXCLUDED.instance_id, claimed_at = NOW(), heartbeat_at = NOW()
+WHERE room_codes.heartbeat_at < NOW() - make_interval(secs => sqlc.arg(stale_seconds)::float8)
+RETURNING code;
+
+-- name: ClaimReservedRoomCode :one
+-- Takes a code reserved for the user while the reservation lasts, unless a live room has it.
+INSERT INTO room_codes (code, instance_id)
+SELECT r.code, sqlc.arg(instance_id)::text
+FROM room_code_reservations r
+WHERE r.code = sqlc.arg(code)::text AND r.reserved_by = sqlc.arg(user_id)::int
+  AND r.starts_at <= NOW() AND r.ends_at > NOW()
+ON CONFLICT (code) DO UPDATE
+SET instance_id = EXCLUDED.instance_id, claimed_at = NOW(), heartbeat_at = NOW()
+WHERE room_codes.heartbeat_at < NOW() - make_interval(secs => sqlc.arg(stale_seconds)::float8)
+RETURNING code;
+
+-- name: ReleaseRoomCode :exec
+DELETE FROM room_codes
+WHERE code = $1 AND instance_id = $2;
+
+-- name: TouchRoomCodes :exec
+UPDATE room_codes
+SET heartbeat_at = NOW()
+WHERE instance_id = $1;
+
+-- name: GetActiveRoomCodeReservation :one
+SELECT * FROM room_code_reservations
+WHERE code = $1 AND reserved_by = $2 AND starts_at <= NOW() AND ends_at > NOW()
+LIMIT 1;
+
+-- name: DeleteEndedRoomCodeReservation :exec
+DELETE FROM room_code_reservations
+WHERE code = $1 AND ends_at <= NOW();
+
+-- name: CreateRoomCodeReservation :one
+INSERT INTO room_code_reservations (
+    code, label, reserved_by, starts_at, ends_at
+) VALUES (
+    $1, $2, $3, $4, $5
+) RETURNING *;
+
+-- name: CountRoomCodeReservationsByUser :one
+SELECT COUNT(*) FROM room_code_reservations
+WHERE reserved_by = $1 AND ends_at > NOW();
+
+-- name: ListRoomCodeReservationsByUser :many
+SELECT * FROM room_code_reservations
+WHERE reserved_by = $1 AND ends_at > NOW()
+ORDER BY starts_at, code;
+
+-- name: DeleteRoomCodeReservation :execrows
+DELETE FROM room_code_reservations
+WHERE code = $1 AND reserved_by = $2;
+
+-- name: DeleteUserRoomCodeReservations :exec
+DELETE FROM room_code_reservations
+WHERE reserved_by = $1;
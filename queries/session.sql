@@ -0,0 +1,175 @@
+-- name: CreateGameSession :one
+INSERT INTO game_sessions (
+    game_code, quiz_id, mode, started_at, finished_at
+) VALUES (
+    $1, $2, $3, $4, $5
+) RETURNING *;
+
+-- name: UpsertAssignmentSession :one
+-- Every attempt at an assignment is collected into one session.
+INSERT INTO game_sessions (
+    game_code, quiz_id, assignment_id, mode, started_at
+) VALUES (
+    $1, $2, $3, $4, $5
+)
+ON CONFLICT (assignment_id) DO UPDATE
+SET assignment_id = EXCLUDED.assignment_id
+RETURNING *;
+
+-- name: GetGameSession :one
+SELECT * FROM game_sessions
+WHERE session_id = $1 LIMIT 1;
+
+-- name: GetGameSessionByAssignment :one
+SELECT * FROM game_sessions
+WHERE assignment_id = $1 LIMIT 1;
+
+-- name: CreateSessionPlayer :one
+INSERT INTO session_players (
+    session_id, player_ref, user_id, display_name, score, rank, started_at, finished_at
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8
+) RETURNING *;
+
+-- name: CreateSessionAnswer :exec
+INSERT INTO session_answers (
+    session_id, session_player_id, question_index, ques_id, answer_index, is_correct, time_taken_ms, points, answered_at
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9
+);
+
+-- name: ListSessionPlayerSummaries :many
+SELECT
+    sp.session_player_id,
+    sp.display_name,
+    sp.score,
+    sp.rank,
+    sp.started_at,
+    sp.finished_at,
+    COUNT(sa.session_answer_id) AS questions_seen,
+    COUNT(sa.session_answer_id) FILTER (WHERE sa.is_correct) AS correct_answers
+FROM session_players sp
+LEFT JOIN session_answers sa ON sa.session_player_id = sp.session_player_id
+WHERE sp.session_id = $1
+GROUP BY sp.session_player_id
+ORDER BY sp.score DESC, sp.finished_at ASC NULLS LAST;
+
+-- name: ListGameSessionsByQuiz :many
+SELECT * FROM game_sessions
+WHERE quiz_id = $1
+ORDER BY started_at DESC;
+
+-- name: ListSessionQuestionStats :many
+-- Rows without an answer_index are players who ran out of time.
+SELECT
+    question_index,
+    COALESCE(MAX(ques_id), 0)::int AS ques_id,
+    COUNT(*) AS players,
+    COUNT(answer_index) AS responses,
+    COUNT(*) FILTER (WHERE is_correct) AS correct_answers,
+    COALESCE(AVG(time_taken_ms), 0)::float8 AS average_time_ms,
+    COALESCE(MIN(time_taken_ms), 0)::int AS fastest_time_ms
+FROM session_answers
+WHERE session_id = $1
+GROUP BY question_index
+ORDER BY question_index;
+
+-- name: ListSessionAnswerCounts :many
+SELECT
+    question_index,
+    answer_index::int AS answer_index,
+    COUNT(*) AS players
+FROM session_answers
+WHERE session_id = $1 AND answer_index IS NOT NULL
+GROUP BY question_index, answer_index
+ORDER BY question_index, answer_index;
+
+-- name: ListSessionFastestCorrect :many
+SELECT DISTINCT ON (sa.question_index)
+    sa.question_index,
+    sp.player_ref,
+    sp.display_name,
+    sa.time_taken_ms::int AS time_taken_ms
+FROM session_answers sa
+JOIN session_players sp ON sp.session_player_id = sa.session_player_id
+WHERE sa.session_id = $1 AND sa.is_correct
+ORDER BY sa.question_index, sa.time_taken_ms ASC;
+
+-- name: ListUserSessionHistory :many
+SELECT
+    gs.session_id,
+    gs.game_code,
+    gs.mode,
+    gs.quiz_id,
+    COALESCE(qz.quiz_title, '')::text AS quiz_title,
+    sp.display_name,
+    sp.score,
+    sp.rank,
+    sp.started_at,
+    sp.finished_at,
+    (SELECT COUNT(*) FROM session_players o WHERE o.session_id = sp.session_id) AS players,
+    COUNT(sa.session_answer_id) AS questions_seen,
+    COUNT(sa.session_answer_id) FILTER (WHERE sa.is_correct) AS correct_answers
+FROM session_players sp
+JOIN game_sessions gs ON gs.session_id = sp.session_id
+LEFT JOIN quizzes qz ON qz.quiz_id = gs.quiz_id
+LEFT JOIN session_answers sa ON sa.session_player_id = sp.session_player_id
+WHERE sp.user_id = sqlc.arg(user_id)::int
+GROUP BY sp.session_player_id, gs.session_id, qz.quiz_title
+ORDER BY sp.started_at DESC
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
+
+-- name: GetUserStats :one
+SELECT
+    COUNT(DISTINCT sp.session_id) AS sessions_played,
+    COALESCE(SUM(sp.score), 0)::bigint AS total_points,
+    COALESCE(AVG(sp.rank), 0)::float8 AS average_rank,
+    (SELECT COUNT(*) FROM session_answers sa
+        JOIN session_players p ON p.session_player_id = sa.session_player_id
+        WHERE p.user_id = sqlc.arg(user_id)::int) AS questions_seen,
+    (SELECT COUNT(*) FROM session_answers sa
+        JOIN session_players p ON p.session_player_id = sa.session_player_id
+        WHERE p.user_id = sqlc.arg(user_id)::int AND sa.is_correct) AS correct_answers
+FROM session_players sp
+WHERE sp.user_id = sqlc.arg(user_id)::int;
+
+-- name: GetUserBestStreak :one
+-- Longest run of consecutive correct answers within one game.
+WITH ordered AS (
+    SELECT
+        sa.session_player_id,
+        sa.is_correct,
+        ROW_NUMBER() OVER (PARTITION BY sa.session_player_id ORDER BY sa.question_index, sa.session_answer_id)
+          - ROW_NUMBER() OVER (PARTITION BY sa.session_player_id, sa.is_correct ORDER BY sa.question_index, sa.session_answer_id) AS run
+    FROM session_answers sa
+    JOIN session_players sp ON sp.session_player_id = sa.session_player_id
+    WHERE sp.user_id = sqlc.arg(user_id)::int
+)
+SELECT COALESCE(MAX(streak), 0)::int AS best_streak
+FROM (
+    SELECT COUNT(*) AS streak
+    FROM ordered
+    WHERE is_correct
+    GROUP BY session_player_id, run
+) runs;
+
+-- name: ListUserSessionPlayers :many
+-- Every game the user played, for exporting their data.
+SELECT
+    sp.*,
+    gs.game_code,
+    gs.mode,
+    gs.quiz_id,
+    COALESCE(qz.quiz_title, '')::text AS quiz_title
+FROM session_players sp
+JOIN game_sessions gs ON gs.session_id = sp.session_id
+LEFT JOIN quizzes qz ON qz.quiz_id = gs.quiz_id
+WHERE sp.user_id = $1
+ORDER BY sp.started_at, sp.session_player_id;
+
+-- name: ListUserSessionAnswers :many
+SELECT sa.*
+FROM session_answers sa
+JOIN session_players sp ON sp.session_player_id = sa.session_player_id
+WHERE sp.user_id = $1
+ORDER BY sa.session_player_id, sa.question_index, sa.session_answer_id;
@@ -1,5 +1,6 @@
 -- name: CountUsers :one
-SELECT count(*) FROM users;
+SELECT count(*) FROM users
+WHERE deleted_at IS NULL;
 
 -- name: CreateUser :one
 INSERT INTO users (
@@ -25,11 +26,12 @@ WHERE user_id = $1;
 
 -- name: GetUserById :one
 SELECT * FROM users
-WHERE user_id = $1 LIMIT 1;
+WHERE user_id = $1 AND deleted_at IS NULL
+LIMIT 1;
 
 -- name: GetUserByEmail :one
 SELECT * FROM users
-WHERE email = $1
+WHERE email = $1 AND deleted_at IS NULL
 LIMIT 1;
 
 -- name: UpdateUser :one
@@ -38,5 +40,125 @@ SET
     name = COALESCE(sqlc.narg(name), name),
     email = COALESCE(sqlc.narg(email), email),
     updated_at = NOW()
-WHERE user_id = $1
+WHERE user_id = $1 AND deleted_at IS NULL
+RETURNING *;
+
+-- name: SetUserRole :one
+UPDATE users
+SET
+    role = $2,
+    updated_at = NOW()
+WHERE user_id = $1 AND deleted_at IS NULL
+RETURNING *;
+
+-- name: UpdateUserProfile :one
+UPDATE users
+SET
+    name = $2,
+    custom_name = $3,
+    avatar_url = $4,
+    preferences = $5,
+    updated_at = NOW()
+WHERE user_id = $1 AND deleted_at IS NULL
+RETURNING *;
+
+-- name: SoftDeleteUser :one
+-- Keeps the row, so quizzes and assignments the user wrote keep their creator,
+-- but drops everything that identifies them. The email is freed so signing in
+-- again starts a new account.
+UPDATE users
+SET
+    name = 'Deleted user',
+    email = 'deleted-' || user_id || '@users.invalid',
+    avatar_url = NULL,
+    preferences = '{}',
+    custom_name = false,
+    role = 'player',
+    auth_subject = NULL,
+    deleted_at = NOW(),
+    updated_at = NOW()
+WHERE user_id = $1 AND deleted_at IS NULL
 RETURNING *;
+
+-- name: AnonymizeUserSessions :execrows
+-- The user's past games count as a guest's from now on.
+UPDATE session_players
+SET
+    user_id = NULL,
+    display_name = 'Deleted user'
+WHERE user_id = $1;
+
+-- name: DeleteUserAchievements :exec
+DELETE FROM user_achievements
+WHERE user_id = $1;
+
+-- name: DeleteUserAchievementCounters :exec
+DELETE FROM user_achievement_counters
+WHERE user_id = $1;
+
+-- name: GetUserByAuthSubject :one
+SELECT * FROM users
+WHERE user_id = (SELECT i.user_id FROM user_identities i WHERE i.auth_subject = $1)
+    AND deleted_at IS NULL
+LIMIT 1;
+
+-- name: GetUnlinkedUserByEmail :one
+-- Users who haven't signed in since identities were introduced.
+SELECT * FROM users
+WHERE email = $1 AND auth_subject IS NULL AND deleted_at IS NULL
+LIMIT 1;
+
+-- name: CreateLinkedUser :one
+INSERT INTO users (
+    name,
+    email,
+    auth_subject
+) VALUES (
+    $1, $2, $3
+) RETURNING *;
+
+-- name: SetUserAuthSubject :exec
+-- Only the first identity linked becomes the user's subject.
+UPDATE users
+SET auth_subject = $2
+WHERE user_id = $1 AND auth_subject IS NULL;
+
+-- name: ResetUserAuthSubject :exec
+-- Falls back to the oldest identity still linked, or NULL if none is.
+UPDATE users
+SET auth_subject = (
+    SELECT i.auth_subject FROM user_identities i
+    WHERE i.user_id = users.user_id
+    ORDER BY i.created_at, i.identity_id
+    LIMIT 1
+)
+WHERE users.user_id = $1;
+
+-- name: CreateUserIdentity :one
+INSERT INTO user_identities (
+    user_id,
+    auth_subject,
+    email
+) VALUES (
+    $1, $2, $3
+) RETURNING *;
+
+-- name: TouchUserIdentity :exec
+UPDATE user_identities
+SET
+    email = $2,
+    last_seen_at = NOW()
+WHERE auth_subject = $1;
+
+-- name: ListUserIdentities :many
+SELECT * FROM user_identities
+WHERE user_id = $1
+ORDER BY created_at, identity_id;
+
+-- name: DeleteUserIdentity :execrows
+DELETE FROM user_identities
+WHERE user_id = $1 AND auth_subject = $2;
+
+-- name: DeleteUserIdentities :exec
+DELETE FROM user_identities
+WHERE user_id = $1;
@@ -0,0 +1,93 @@
+package test
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/oblongtable/beanbag-backend/internal/achievements"
+	"github.com/oblongtable/beanbag-backend/internal/game"
+)
+
+type memoryAchievementStore struct {
+	mu       sync.Mutex
+	awarded  map[int32]map[string]bool
+	counters map[string]int64
+}
+
+func (s *memoryAchievementStore) Award(ctx context.Context, userID int32, achievementID string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.awarded[userID] == nil {
+		s.awarded[userID] = make(map[string]bool)
+	}
+	if s.awarded[userID][achievementID] {
+		return false, nil
+	}
+	s.awarded[userID][achievementID] = true
+	return true, nil
+}
+
+func (s *memoryAchievementStore) IncrementCounter(ctx context.Context, userID int32, counter string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.counters[counter]++
+	return s.counters[counter], nil
+}
+
+type unlockNotifier chan string
+
+func (n unlockNotifier) NotifyPlayer(playerID string, msgType string, payload interface{}) {
+	n <- playerID + ":" + payload.(achievements.Achievement).ID
+}
+
+func TestAchievementsEngine(t *testing.T) {
+	store := &memoryAchievementStore{awarded: map[int32]map[string]bool{}, counters: map[string]int64{}}
+	unlocked := make(unlockNotifier, 16)
+	engine := achievements.NewEngine(store, unlocked, achievements.DefaultRules())
+
+	// p1 and the host are signed in, p2 is a guest
+	userIDs := map[string]int32{"p1": 1, "host": 2}
+	var history []game.AnswerRecord
+	for i := range 5 {
+		answers := []game.AnswerRecord{
+			{PlayerID: "p1", QuestionIndex: i, AnswerIndex: 1, Correct: true, TimeTaken: time.Second},
+			{PlayerID: "p2", QuestionIndex: i, AnswerIndex: 1, Correct: true, TimeTaken: 2 * time.Second},
+			{PlayerID: "host", QuestionIndex: i, AnswerIndex: -1},
+		}
+		history = append(history, answers...)
+		engine.HandleGameEvent(game.Event{
+			Type: game.EventQuestionFinished, GameID: "ROOM", Mode: game.ModeLive,
+			UserIDs: userIDs, HostID: "host", HostUserID: 2, Answers: answers, TotalQuestions: 5,
+		})
+	}
+	engine.HandleGameEvent(game.Event{
+		Type: game.EventGameFinished, GameID: "ROOM", Mode: game.ModeLive,
+		UserIDs: userIDs, HostID: "host", HostUserID: 2, Answers: history, TotalQuestions: 5,
+	})
+
+	want := []string{"host:host_1", "p1:fastest_answer", "p1:perfect_game", "p1:streak_5"}
+	var got []string
+	for range want {
+		select {
+		case u := <-unlocked:
+			got = append(got, u)
+		case <-time.After(2 * time.Second):
+			t.Fatalf("Unlocked %v; want %v", got, want)
+		}
+	}
+	select {
+	case u := <-unlocked:
+		t.Errorf("Unexpected unlock %s", u)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	sort.Strings(got)
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("Unlocked %v; want %v", got, want)
+		}
+	}
+}
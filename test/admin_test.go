@@ -0,0 +1,132 @@
+package test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/oblongtable/beanbag-backend/internal/apimodels"
+	"github.com/oblongtable/beanbag-backend/internal/game"
+	"github.com/oblongtable/beanbag-backend/internal/handlers"
+	"github.com/oblongtable/beanbag-backend/middleware"
+	mywebsoc "github.com/oblongtable/beanbag-backend/websocket"
+)
+
+func TestAdminRoomAPI(t *testing.T) {
+	adminSvr := mywebsoc.NewWebSockServer()
+	engine := gin.New()
+	engine.GET("/ws", adminSvr.ServeWs)
+	httpSvr := httptest.NewServer(engine)
+	defer httpSvr.Close()
+	wsURL := "ws" + strings.TrimPrefix(httpSvr.URL, "http") + "/ws"
+
+	// Stands in for the JWT middleware, the scope comes from a header
+	api := gin.New()
+	api.Use(func(c *gin.Context) {
+		c.Set(middleware.GinContextKeyScope, c.GetHeader("X-Test-Scope"))
+	})
+	adminHandler := handlers.NewAdminHandler(adminSvr)
+	admin := api.Group("/admin", middleware.RequireScope(middleware.ScopeAdmin))
+	admin.GET("/rooms", adminHandler.ListRooms)
+	admin.GET("/rooms/:code", adminHandler.GetRoom)
+	admin.POST("/rooms/:code/advance", adminHandler.AdvanceGame)
+	admin.POST("/rooms/:code/end", adminHandler.EndGame)
+	admin.DELETE("/rooms/:code", adminHandler.CloseRoom)
+
+	request := func(method, path, scope string, into interface{}) int {
+		t.Helper()
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("X-Test-Scope", scope)
+		rec := httptest.NewRecorder()
+		api.ServeHTTP(rec, req)
+		if into != nil && rec.Code < 300 {
+			if err := json.Unmarshal(rec.Body.Bytes(), into); err != nil {
+				t.Fatalf("%s %s returned %s: %v", method, path, rec.Body, err)
+			}
+		}
+		return rec.Code
+	}
+
+	creator, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer creator.Close()
+	host, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer host.Close()
+
+	created := sendAndWait(t, creator, mywebsoc.EventCreateRoom, mywebsoc.CreateRoomEvent{RoomName: "Stuck", RoomSize: 4, UserName: "Teacher"}, mywebsoc.MessageCreateRoom)
+	var room mywebsoc.RoomInfo
+	json.Unmarshal(created.Info, &room)
+	sendAndWait(t, host, mywebsoc.EventJoinRoom, mywebsoc.JoinRoomEvent{RoomID: room.ID, Name: "Bob"}, mywebsoc.MessageJoinRoom)
+	sendAndWait(t, host, mywebsoc.EventStartQuiz, mywebsoc.StartQuizEvent{RoomID: room.ID}, game.MessageShowTitle)
+
+	// Without the scope nothing is shown
+	req := httptest.NewRequest(http.MethodGet, "/admin/rooms", nil)
+	rec := httptest.NewRecorder()
+	api.ServeHTTP(rec, req)
+	if rec.Code != http.StatusForbidden || !strings.Contains(rec.Body.String(), `"missing_permission":"admin"`) {
+		t.Errorf("Without the admin scope: got %d %s; want 403 naming the missing permission", rec.Code, rec.Body)
+	}
+
+	var rooms []apimodels.AdminRoomApiModel
+	if code := request(http.MethodGet, "/admin/rooms", "read:quizzes admin", &rooms); code != http.StatusOK {
+		t.Fatalf("ListRooms returned %d", code)
+	}
+	if len(rooms) != 1 || rooms[0].RoomCode != room.ID || len(rooms[0].Participants) != 2 {
+		t.Fatalf("Unexpected rooms: %+v", rooms)
+	}
+	if p := rooms[0].Participants; p[0].Role != mywebsoc.RoleCreator.String() || p[1].Role != mywebsoc.RoleHost.String() || rooms[0].HostID != p[1].ClientID {
+		t.Errorf("Unexpected participants: %+v", p)
+	}
+	// The creator presents, so only the host plays
+	if g := rooms[0].Game; g == nil || g.State != string(game.StateTitle) || len(g.Scores) != 1 || g.Scores[0].Name != "Bob" {
+		t.Fatalf("Unexpected game: %+v", g)
+	}
+
+	// Title -> section -> first question -> its scores, without the host
+	var model apimodels.AdminRoomApiModel
+	for _, want := range []game.GameState{game.StateSection, game.StateQuestion, game.StateScores} {
+		if code := request(http.MethodPost, "/admin/rooms/"+room.ID+"/advance", "admin", &model); code != http.StatusOK {
+			t.Fatalf("AdvanceGame returned %d", code)
+		}
+		if model.Game.State != string(want) {
+			t.Errorf("After advancing the game is %s; want %s", model.Game.State, want)
+		}
+	}
+	if model.Game.Section != 1 || model.Game.Question != 1 {
+		t.Errorf("Expected to be on section 1 question 1, got %+v", model.Game)
+	}
+
+	if code := request(http.MethodPost, "/admin/rooms/"+room.ID+"/end", "admin", &model); code != http.StatusOK || model.Game.State != string(game.StateFinished) {
+		t.Errorf("EndGame returned %d with state %s", code, model.Game.State)
+	}
+	if code := request(http.MethodPost, "/admin/rooms/"+room.ID+"/end", "admin", nil); code != http.StatusConflict {
+		t.Errorf("Ending a finished game returned %d; want 409", code)
+	}
+
+	if code := request(http.MethodDelete, "/admin/rooms/"+room.ID, "admin", nil); code != http.StatusNoContent {
+		t.Fatalf("CloseRoom returned %d", code)
+	}
+	host.SetReadDeadline(time.Now().Add(2 * time.Second))
+	for {
+		var msg mywebsoc.EventCallbackMessage
+		if err := host.ReadJSON(&msg); err != nil {
+			t.Fatalf("Waiting for %s: %v", mywebsoc.MessageRoomShutdown, err)
+		}
+		if msg.Type == mywebsoc.MessageRoomShutdown {
+			break
+		}
+	}
+	if code := request(http.MethodGet, "/admin/rooms/"+room.ID, "admin", nil); code != http.StatusNotFound {
+		t.Errorf("Closed room returned %d; want 404", code)
+	}
+}
@@ -0,0 +1,91 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/oblongtable/beanbag-backend/db"
+	"github.com/oblongtable/beanbag-backend/internal/handlers"
+	"github.com/oblongtable/beanbag-backend/internal/services"
+	"github.com/oblongtable/beanbag-backend/middleware"
+)
+
+func TestQuizAnalytics(t *testing.T) {
+	fake := newFakeDB(t)
+	fake.rows("GetQuiz", []any{int32(1), int32(7), "Capitals", nil, false, int32(20), time.Now(), time.Now()})
+	fake.rows("ListQuizQuestionAnalytics",
+		// 20 attempts, the top players got it right 8 times out of 10 and the bottom ones 2
+		[]any{int32(10), "Capital of France?", int64(20), int64(19), int64(10), 5400.4, int64(10), int64(8), int64(10), int64(2)},
+		// Everyone tied, so nobody is in the top group
+		[]any{int32(11), "Capital of Spain?", int64(4), int64(4), int64(4), 3000.0, int64(0), int64(0), int64(4), int64(4)},
+		[]any{int32(12), "Capital of Peru?", int64(0), int64(0), int64(0), 0.0, int64(0), int64(0), int64(0), int64(0)},
+	)
+	fake.rows("ListQuizCommonWrongAnswers", []any{int32(10), int32(2), int64(6)})
+	fake.rows("ListQuizPlaysPerDay", []any{time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC), int64(3), int64(20)})
+	fake.rows("ListAnswersByQuestionIDs",
+		[]any{int32(1), int32(10), "Paris", true, time.Now(), time.Now(), nil},
+		[]any{int32(2), int32(10), "Lyon", false, time.Now(), time.Now(), nil},
+		[]any{int32(3), int32(10), "Nice", false, time.Now(), time.Now(), nil},
+	)
+
+	analytics, err := services.NewAnalyticsService(db.New(fake.DB())).QuizAnalytics(context.Background(), 1, 7)
+	if err != nil {
+		t.Fatalf("QuizAnalytics failed: %v", err)
+	}
+	if len(analytics.Questions) != 3 {
+		t.Fatalf("Got %d questions; want 3", len(analytics.Questions))
+	}
+
+	france := analytics.Questions[0]
+	if france.PercentCorrect != 50 || france.AverageTimeMs != 5400 {
+		t.Errorf("France: %v%% correct in %dms; want 50%% in 5400ms", france.PercentCorrect, france.AverageTimeMs)
+	}
+	if france.Discrimination == nil || *france.Discrimination != 0.6 {
+		t.Errorf("France discrimination = %v; want 0.6", france.Discrimination)
+	}
+	if france.CommonWrong == nil || france.CommonWrong.Text != "Nice" || france.CommonWrong.Players != 6 {
+		t.Errorf("France common wrong answer = %+v; want Nice by 6 players", france.CommonWrong)
+	}
+	// Without players in both groups there is nothing to compare
+	for _, q := range analytics.Questions[1:] {
+		if q.Discrimination != nil {
+			t.Errorf("Question %d discrimination = %v; want none", q.QuestionID, *q.Discrimination)
+		}
+	}
+	if len(analytics.Plays) != 1 || analytics.Plays[0].Day != "2025-07-01" || analytics.Plays[0].Players != 20 {
+		t.Errorf("Plays = %+v; want 20 players on 2025-07-01", analytics.Plays)
+	}
+
+	if _, err := services.NewAnalyticsService(db.New(fake.DB())).QuizAnalytics(context.Background(), 1, 8); !errors.Is(err, services.ErrNotQuizOwner) {
+		t.Errorf("Another teacher getting analytics = %v; want ErrNotQuizOwner", err)
+	}
+
+	missing := newFakeDB(t)
+	missing.rows("GetQuiz")
+	if _, err := services.NewAnalyticsService(db.New(missing.DB())).QuizAnalytics(context.Background(), 2, 7); !errors.Is(err, services.ErrQuizNotFound) {
+		t.Errorf("Analytics of a missing quiz = %v; want ErrQuizNotFound", err)
+	}
+}
+
+func TestAnalyticsCSVOnlyForQuizCreator(t *testing.T) {
+	fake := newFakeDB(t)
+	fake.rows("GetUserByAuthSubject", userRow(8, "Eve", "eve@example.com", "auth0|eve"))
+	fake.rows("GetQuiz", []any{int32(1), int32(7), "Capitals", nil, false, int32(20), time.Now(), time.Now()})
+
+	router := gin.New()
+	router.GET("/quizzes/:id/analytics", func(c *gin.Context) {
+		c.Set(middleware.GinContextKeyUserSub, "auth0|eve")
+	}, handlers.NewAnalyticsHandler(services.NewAnalyticsService(db.New(fake.DB())), userService(fake)).GetQuizAnalytics)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/quizzes/1/analytics?format=csv", nil))
+	if rec.Code != http.StatusForbidden || strings.Contains(rec.Body.String(), "question_id") {
+		t.Errorf("Another teacher exporting analytics = %d %q; want 403", rec.Code, rec.Body.String())
+	}
+}
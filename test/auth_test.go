@@ -0,0 +1,159 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	adaptor "github.com/gwatts/gin-adapter"
+	"github.com/oblongtable/beanbag-backend/internal/handlers"
+	"github.com/oblongtable/beanbag-backend/middleware"
+	"gopkg.in/go-jose/go-jose.v2"
+)
+
+// authServer serves /dev/token and an authenticated /api like main does.
+func authServer(t *testing.T, auth *middleware.Authenticator) *gin.Engine {
+	t.Helper()
+	router := gin.New()
+	router.POST("/dev/token", handlers.NewDevHandler(auth).IssueToken)
+	api := router.Group("/api")
+	api.Use(adaptor.Wrap(auth.VerifyToken()))
+	api.Use(middleware.ExtractAndSetClaims())
+	api.GET("/me", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"email": c.GetString(middleware.GinContextKeyUserEmail), "sub": c.GetString(middleware.GinContextKeyUserSub)})
+	})
+	api.POST("/quizzes", middleware.RequireScope(middleware.ScopeWriteQuizzes), func(c *gin.Context) {
+		c.Status(http.StatusCreated)
+	})
+	return router
+}
+
+func devToken(t *testing.T, router *gin.Engine, req handlers.DevTokenRequest) string {
+	t.Helper()
+	body, _ := json.Marshal(req)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/dev/token", bytes.NewReader(body)))
+	var resp handlers.DevTokenResponse
+	if rec.Code != http.StatusOK || json.Unmarshal(rec.Body.Bytes(), &resp) != nil || resp.AccessToken == "" {
+		t.Fatalf("POST /dev/token returned %d %s", rec.Code, rec.Body)
+	}
+	return resp.AccessToken
+}
+
+func callAPI(router *gin.Engine, method, path, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestDevAuthProvider(t *testing.T) {
+	auth, err := middleware.NewAuthenticator(middleware.AuthOptions{Provider: middleware.AuthProviderDev})
+	if err != nil {
+		t.Fatalf("NewAuthenticator failed: %v", err)
+	}
+	router := authServer(t, auth)
+
+	if rec := callAPI(router, http.MethodGet, "/api/me", ""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("Without a token: got %d; want 401", rec.Code)
+	}
+
+	token := devToken(t, router, handlers.DevTokenRequest{Email: "teacher@example.com"})
+	rec := callAPI(router, http.MethodGet, "/api/me", token)
+	var me map[string]string
+	json.Unmarshal(rec.Body.Bytes(), &me)
+	if rec.Code != http.StatusOK || me["email"] != "teacher@example.com" || me["sub"] != "dev|teacher@example.com" {
+		t.Errorf("GET /api/me returned %d %s", rec.Code, rec.Body)
+	}
+	if rec := callAPI(router, http.MethodPost, "/api/quizzes", token); rec.Code != http.StatusForbidden {
+		t.Errorf("Token without scopes writing a quiz: got %d; want 403", rec.Code)
+	}
+
+	scoped := devToken(t, router, handlers.DevTokenRequest{Email: "teacher@example.com", Scope: "read:quizzes write:quizzes"})
+	if rec := callAPI(router, http.MethodPost, "/api/quizzes", scoped); rec.Code != http.StatusCreated {
+		t.Errorf("Token with write:quizzes writing a quiz: got %d %s; want 201", rec.Code, rec.Body)
+	}
+	if sub, email, err := auth.ValidateToken(context.Background(), scoped); err != nil || sub != "dev|teacher@example.com" || email != "teacher@example.com" {
+		t.Errorf("ValidateToken = %q, %q, %v", sub, email, err)
+	}
+
+	// Each dev server has its own key unless one is configured
+	other, err := middleware.NewAuthenticator(middleware.AuthOptions{Provider: middleware.AuthProviderDev})
+	if err != nil {
+		t.Fatalf("NewAuthenticator failed: %v", err)
+	}
+	if rec := callAPI(authServer(t, other), http.MethodGet, "/api/me", token); rec.Code != http.StatusUnauthorized {
+		t.Errorf("Token signed by another server: got %d; want 401", rec.Code)
+	}
+}
+
+func TestStaticAuthProvider(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	dir := t.TempDir()
+	writePEM := func(name, blockType string, der []byte) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600); err != nil {
+			t.Fatalf("WriteFile failed: %v", err)
+		}
+		return path
+	}
+	privateDER, _ := x509.MarshalPKCS8PrivateKey(key)
+	publicDER, _ := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	privatePath := writePEM("private.pem", "PRIVATE KEY", privateDER)
+	publicPath := writePEM("public.pem", "PUBLIC KEY", publicDER)
+
+	// Tokens come from a dev server signing with the same key
+	issuer, err := middleware.NewAuthenticator(middleware.AuthOptions{Provider: middleware.AuthProviderDev, KeyFile: privatePath})
+	if err != nil {
+		t.Fatalf("NewAuthenticator(dev) failed: %v", err)
+	}
+	token, err := issuer.IssueToken("auth0|alice", "alice@example.com", "", time.Hour)
+	if err != nil {
+		t.Fatalf("IssueToken failed: %v", err)
+	}
+
+	jwksPath := filepath.Join(dir, "jwks.json")
+	var keySet jose.JSONWebKeySet
+	keySet.Keys = append(keySet.Keys, jose.JSONWebKey{Key: &key.PublicKey, Algorithm: "RS256", Use: "sig"})
+	jwksJSON, _ := json.Marshal(keySet)
+	os.WriteFile(jwksPath, jwksJSON, 0o600)
+
+	for _, keyFile := range []string{publicPath, jwksPath} {
+		auth, err := middleware.NewAuthenticator(middleware.AuthOptions{
+			Provider: middleware.AuthProviderStatic,
+			Issuer:   middleware.DevIssuer,
+			Audience: middleware.DevAudience,
+			KeyFile:  keyFile,
+		})
+		if err != nil {
+			t.Fatalf("NewAuthenticator(static, %s) failed: %v", filepath.Base(keyFile), err)
+		}
+		if auth.CanIssue() {
+			t.Errorf("The static provider shouldn't issue tokens")
+		}
+		if sub, _, err := auth.ValidateToken(context.Background(), token); err != nil || sub != "auth0|alice" {
+			t.Errorf("Validating with %s = %q, %v", filepath.Base(keyFile), sub, err)
+		}
+	}
+
+	if _, err := middleware.NewAuthenticator(middleware.AuthOptions{Provider: middleware.AuthProviderStatic, KeyFile: privatePath}); err == nil {
+		t.Errorf("Expected a private key to be rejected as the static provider's keys")
+	}
+}
@@ -0,0 +1,238 @@
+package test
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/oblongtable/beanbag-backend/db"
+	"github.com/oblongtable/beanbag-backend/internal/achievements"
+	"github.com/oblongtable/beanbag-backend/internal/handlers"
+	"github.com/oblongtable/beanbag-backend/internal/services"
+	"github.com/oblongtable/beanbag-backend/middleware"
+	mywebsoc "github.com/oblongtable/beanbag-backend/websocket"
+)
+
+// routePermissions is who may use each /api route. A route missing here fails the test,
+// so every new route has to be given one.
+var routePermissions = map[string]string{
+	"POST /api/users/sync":                     middleware.RolePlayer,
+	"GET /api/users/me":                        middleware.RolePlayer,
+	"PATCH /api/users/me":                      middleware.RolePlayer,
+	"DELETE /api/users/me":                     middleware.RolePlayer,
+	"GET /api/users/me/export":                 middleware.RolePlayer,
+	"GET /api/users/me/identities":             middleware.RolePlayer,
+	"DELETE /api/users/me/identities/:subject": middleware.RolePlayer,
+	"GET /api/users/:id":                       middleware.RolePlayer,
+	"GET /api/users/:id/history":               middleware.RolePlayer,
+	"GET /api/users/:id/stats":                 middleware.RolePlayer,
+	"GET /api/users/:id/achievements":          middleware.RolePlayer,
+	"GET /api/quizzes/:id":                     middleware.RolePlayer,
+	"GET /api/quizzes/:id/full":                middleware.RolePlayer,
+	"GET /api/quizzes/:id/leaderboard":         middleware.RolePlayer,
+	"GET /api/questions/:id":                   middleware.RolePlayer,
+	"GET /api/answers/:id":                     middleware.RolePlayer,
+	"GET /api/assignments/:code":               middleware.RolePlayer,
+	"GET /api/leaderboards":                    middleware.RolePlayer,
+	"GET /api/quizzes/:id/analytics":           middleware.RoleTeacher,
+	"GET /api/quizzes/:id/sessions":            middleware.RoleTeacher,
+	"GET /api/sessions/:id/results":            middleware.RoleTeacher,
+	"POST /api/assignments":                    middleware.RoleTeacher,
+	"GET /api/assignments/:code/results":       middleware.RoleTeacher,
+	"POST /api/room-codes":                     middleware.RoleTeacher,
+	"GET /api/room-codes":                      middleware.RoleTeacher,
+	"DELETE /api/room-codes/:code":             middleware.RoleTeacher,
+	"POST /api/quizzes":                        middleware.ScopeWriteQuizzes,
+	"POST /api/quizzes/minimal":                middleware.ScopeWriteQuizzes,
+	"POST /api/questions":                      middleware.ScopeWriteQuizzes,
+	"POST /api/questions/:id/image":            middleware.ScopeWriteQuizzes,
+	"POST /api/questions/:id/audio":            middleware.ScopeWriteQuizzes,
+	"POST /api/answers":                        middleware.ScopeWriteQuizzes,
+	"POST /api/answers/:id/image":              middleware.ScopeWriteQuizzes,
+	"GET /api/admin/rooms":                     middleware.RoleAdmin,
+	"GET /api/admin/rooms/:code":               middleware.RoleAdmin,
+	"POST /api/admin/rooms/:code/advance":      middleware.RoleAdmin,
+	"POST /api/admin/rooms/:code/end":          middleware.RoleAdmin,
+	"DELETE /api/admin/rooms/:code":            middleware.RoleAdmin,
+	"PUT /api/admin/users/:id/role":            middleware.RoleAdmin,
+}
+
+// apiRoutes serves the routes of main.go. The database has no rows, so requests that get
+// past authorization fail further on, but never for a missing permission.
+// The claims come from headers, standing in for the JWT middleware.
+func apiRoutes(t *testing.T, roleOf middleware.RoleLookup) *gin.Engine {
+	fake := newFakeDB(t)
+	fake.otherwise(func([]driver.Value) ([][]any, error) { return nil, nil })
+	conn := fake.DB()
+	queries := db.New(conn)
+
+	userService := services.NewUserService(conn, queries)
+	sessionService := services.NewSessionService(conn, queries)
+	mediaService := services.NewMediaService(queries, nil, "http://localhost/media", 1024)
+	leaderboardService, err := services.NewLeaderboardService(queries, string(services.GuestNamesShow))
+	if err != nil {
+		t.Fatalf("NewLeaderboardService failed: %v", err)
+	}
+	roomCodeService, err := services.NewRoomCodeService(queries, time.Minute)
+	if err != nil {
+		t.Fatalf("NewRoomCodeService failed: %v", err)
+	}
+
+	engine := gin.New()
+	api := engine.Group("/api")
+	api.Use(func(c *gin.Context) {
+		c.Set(middleware.GinContextKeyUserEmail, c.GetHeader("X-Test-Email"))
+		c.Set(middleware.GinContextKeyEmailVerified, true)
+		c.Set(middleware.GinContextKeyUserSub, "auth0|"+c.GetHeader("X-Test-Email"))
+		c.Set(middleware.GinContextKeyScope, c.GetHeader("X-Test-Scope"))
+	})
+	handlers.RegisterAPIRoutes(api, handlers.APIHandlers{
+		Admin:       handlers.NewAdminHandler(mywebsoc.NewWebSockServer()),
+		Analytics:   handlers.NewAnalyticsHandler(services.NewAnalyticsService(queries), userService),
+		Answer:      handlers.NewAnswerHandler(services.NewAnswerService(queries)),
+		Assignment:  handlers.NewAssignmentHandler(services.NewAssignmentService(queries), userService, "http://localhost"),
+		Leaderboard: handlers.NewLeaderboardHandler(leaderboardService, userService),
+		Media:       handlers.NewMediaHandler(mediaService, userService),
+		Question:    handlers.NewQuestionHandler(services.NewQuestionService(queries)),
+		Quiz:        handlers.NewQuizHandler(services.NewQuizService(conn, queries, mediaService), userService),
+		RoomCode:    handlers.NewRoomCodeHandler(roomCodeService, userService),
+		Session:     handlers.NewSessionHandler(sessionService, userService),
+		User:        handlers.NewUserHandler(userService, sessionService, services.NewAchievementService(queries, achievements.DefaultRules())),
+	}, roleOf)
+	return engine
+}
+
+// missingPermission sends a request as the user and returns the permission it was refused for.
+func missingPermission(t *testing.T, engine *gin.Engine, method, path, email, scope string) (int, string) {
+	t.Helper()
+	req := httptest.NewRequest(method, path, nil)
+	req.Header.Set("X-Test-Email", email)
+	req.Header.Set("X-Test-Scope", scope)
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, req)
+	_, missing, found := strings.Cut(rec.Body.String(), `"missing_permission":"`)
+	if !found {
+		return rec.Code, ""
+	}
+	missing, _, _ = strings.Cut(missing, `"`)
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("%s %s refused with %d; want 403", method, path, rec.Code)
+	}
+	return rec.Code, missing
+}
+
+func TestRoleAndScopeAuthorization(t *testing.T) {
+	roles := map[string]string{
+		"admin@example.com":   middleware.RoleAdmin,
+		"teacher@example.com": middleware.RoleTeacher,
+		"player@example.com":  middleware.RolePlayer,
+	}
+	lookups := 0
+	lookup := func(ctx context.Context, subject, email string) (string, error) {
+		lookups++
+		if subject != "auth0|"+email {
+			t.Errorf("Looked up %s by the subject %s", email, subject)
+		}
+		if email == "broken@example.com" {
+			return "", errors.New("database down")
+		}
+		return roles[email], nil // Unknown users have no role yet
+	}
+	engine := apiRoutes(t, lookup)
+
+	tests := []struct {
+		name, method, path, email, scope string
+		wantMissing                      string
+	}{
+		{"teacher reads results", http.MethodGet, "/api/sessions/1/results", "teacher@example.com", "", ""},
+		{"admin is also a teacher", http.MethodGet, "/api/sessions/1/results", "admin@example.com", "", ""},
+		{"player can't read results", http.MethodGet, "/api/sessions/1/results", "player@example.com", "", "role:teacher"},
+		{"unsynced user is a player", http.MethodGet, "/api/sessions/1/results", "new@example.com", "", "role:teacher"},
+		{"teacher writes quizzes", http.MethodPost, "/api/quizzes", "teacher@example.com", "read:quizzes write:quizzes", ""},
+		{"token without write scope", http.MethodPost, "/api/quizzes", "teacher@example.com", "read:quizzes", "write:quizzes"},
+		{"admin scope without admin role", http.MethodGet, "/api/admin/rooms", "teacher@example.com", "admin", "role:admin"},
+		{"admin role without admin scope", http.MethodGet, "/api/admin/rooms", "admin@example.com", "", "admin"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code, missing := missingPermission(t, engine, tt.method, tt.path, tt.email, tt.scope); missing != tt.wantMissing {
+				t.Errorf("Got %d missing %q; want missing %q", code, missing, tt.wantMissing)
+			}
+		})
+	}
+	if code, _ := missingPermission(t, engine, http.MethodGet, "/api/sessions/1/results", "broken@example.com", ""); code != http.StatusInternalServerError {
+		t.Errorf("Failed lookup answered %d; want 500", code)
+	}
+
+	// Nested groups needing roles share one lookup
+	lookups = 0
+	nested := engine.Group("/nested", func(c *gin.Context) {
+		c.Set(middleware.GinContextKeyUserEmail, "teacher@example.com")
+		c.Set(middleware.GinContextKeyEmailVerified, true)
+		c.Set(middleware.GinContextKeyUserSub, "auth0|teacher@example.com")
+	}, middleware.RequireRole(lookup, middleware.RolePlayer), middleware.RequireRole(lookup, middleware.RoleTeacher))
+	nested.GET("", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+	engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/nested", nil))
+	if lookups != 1 {
+		t.Errorf("Looked the role up %d times; want 1", lookups)
+	}
+}
+
+func TestEveryAPIRouteAuthorized(t *testing.T) {
+	roles := map[string]string{
+		"admin@example.com":   middleware.RoleAdmin,
+		"teacher@example.com": middleware.RoleTeacher,
+	}
+	engine := apiRoutes(t, func(ctx context.Context, subject, email string) (string, error) {
+		return roles[email], nil
+	})
+
+	// What each kind of user is refused for, by who may use the route
+	callers := []struct {
+		name, email, scope string
+		missing            map[string]string
+	}{
+		{"player", "player@example.com", "", map[string]string{
+			middleware.RoleTeacher:       "role:teacher",
+			middleware.ScopeWriteQuizzes: "role:teacher",
+			middleware.RoleAdmin:         "admin",
+		}},
+		{"teacher without write scope", "teacher@example.com", "admin", map[string]string{
+			middleware.ScopeWriteQuizzes: "write:quizzes",
+			middleware.RoleAdmin:         "role:admin",
+		}},
+		{"admin", "admin@example.com", "write:quizzes admin", map[string]string{}},
+	}
+	params := strings.NewReplacer(":id", "1", ":code", "ABCD", ":subject", "other")
+
+	registered := make(map[string]bool)
+	for _, route := range engine.Routes() {
+		if !strings.HasPrefix(route.Path, "/api/") {
+			continue
+		}
+		key := route.Method + " " + route.Path
+		registered[key] = true
+		permission, ok := routePermissions[key]
+		if !ok {
+			t.Errorf("Route %s has no expected permission", key)
+			continue
+		}
+		for _, caller := range callers {
+			want := caller.missing[permission]
+			if code, missing := missingPermission(t, engine, route.Method, params.Replace(route.Path), caller.email, caller.scope); missing != want {
+				t.Errorf("%s as %s: got %d missing %q; want missing %q", key, caller.name, code, missing, want)
+			}
+		}
+	}
+	for key := range routePermissions {
+		if !registered[key] {
+			t.Errorf("Route %s is not registered", key)
+		}
+	}
+}
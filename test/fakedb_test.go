@@ -0,0 +1,152 @@
+package test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeAnswer returns the rows of a query, or for an exec query one row per affected row.
+type fakeAnswer func(args []driver.Value) ([][]any, error)
+
+// fakeDB answers the queries sqlc generates by their name, so services can be tested
+// without Postgres. Queries it has no answer for fail the test.
+type fakeDB struct {
+	t       *testing.T
+	mu      sync.Mutex
+	answers map[string]fakeAnswer
+	calls   map[string][][]driver.Value
+	rest    fakeAnswer
+}
+
+func newFakeDB(t *testing.T) *fakeDB {
+	return &fakeDB{t: t, answers: make(map[string]fakeAnswer), calls: make(map[string][][]driver.Value)}
+}
+
+// on sets the answer to the query with the given sqlc name.
+func (f *fakeDB) on(name string, answer fakeAnswer) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.answers[name] = answer
+}
+
+// rows answers every call of the query with the same rows.
+func (f *fakeDB) rows(name string, rows ...[]any) {
+	f.on(name, func([]driver.Value) ([][]any, error) { return rows, nil })
+}
+
+// otherwise answers the queries without an answer of their own, instead of failing the test.
+func (f *fakeDB) otherwise(answer fakeAnswer) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.rest = answer
+}
+
+// called returns the arguments of every call of the query so far.
+func (f *fakeDB) called(name string) [][]driver.Value {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls[name]
+}
+
+func (f *fakeDB) DB() *sql.DB {
+	return sql.OpenDB(fakeConnector{f})
+}
+
+func (f *fakeDB) answer(query string, args []driver.Value) ([][]any, error) {
+	name := query
+	if line, _, _ := strings.Cut(query, "\n"); strings.HasPrefix(line, "-- name: ") {
+		name = strings.Fields(line)[2]
+	}
+	f.mu.Lock()
+	answer, ok := f.answers[name]
+	if !ok && f.rest != nil {
+		answer, ok = f.rest, true
+	}
+	f.calls[name] = append(f.calls[name], args)
+	f.mu.Unlock()
+	if !ok {
+		f.t.Errorf("Unexpected query %s", name)
+		return nil, fmt.Errorf("no answer for %s", name)
+	}
+	return answer(args)
+}
+
+type fakeConnector struct{ db *fakeDB }
+
+func (c fakeConnector) Connect(context.Context) (driver.Conn, error) { return fakeConn{c.db}, nil }
+func (c fakeConnector) Driver() driver.Driver                        { return nil }
+
+type fakeConn struct{ db *fakeDB }
+
+func (c fakeConn) Prepare(query string) (driver.Stmt, error) { return fakeStmt{c.db, query}, nil }
+func (c fakeConn) Close() error                              { return nil }
+func (c fakeConn) Begin() (driver.Tx, error)                 { return fakeTx{}, nil }
+
+// fakeTx commits nothing, every query takes effect as it runs.
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+type fakeStmt struct {
+	db    *fakeDB
+	query string
+}
+
+func (s fakeStmt) Close() error  { return nil }
+func (s fakeStmt) NumInput() int { return -1 }
+
+func (s fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	rows, err := s.db.answer(s.query, args)
+	if err != nil {
+		return nil, err
+	}
+	return driver.RowsAffected(len(rows)), nil
+}
+
+func (s fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	rows, err := s.db.answer(s.query, args)
+	if err != nil {
+		return nil, err
+	}
+	return &fakeRows{rows: rows}, nil
+}
+
+type fakeRows struct {
+	rows [][]any
+	next int
+}
+
+func (r *fakeRows) Columns() []string {
+	if len(r.rows) == 0 {
+		return nil
+	}
+	columns := make([]string, len(r.rows[0]))
+	for i := range columns {
+		columns[i] = fmt.Sprintf("column%d", i)
+	}
+	return columns
+}
+
+func (r *fakeRows) Close() error { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.next == len(r.rows) {
+		return io.EOF
+	}
+	for i, v := range r.rows[r.next] {
+		value, err := driver.DefaultParameterConverter.ConvertValue(v)
+		if err != nil {
+			return err
+		}
+		dest[i] = value
+	}
+	r.next++
+	return nil
+}
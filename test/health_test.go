@@ -0,0 +1,106 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/oblongtable/beanbag-backend/internal/handlers"
+	"github.com/oblongtable/beanbag-backend/internal/health"
+	mywebsoc "github.com/oblongtable/beanbag-backend/websocket"
+)
+
+func passing(context.Context) (map[string]interface{}, error) { return nil, nil }
+
+func failing(context.Context) (map[string]interface{}, error) { return nil, errors.New("down") }
+
+func readyz(t *testing.T, checker *health.Checker) (int, health.Report) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/readyz", handlers.NewHealthHandler(checker).Readiness)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	var report health.Report
+	if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+		t.Fatalf("Body is not a report: %s", rec.Body)
+	}
+	return rec.Code, report
+}
+
+func TestReadiness(t *testing.T) {
+	checker := health.NewChecker(100 * time.Millisecond)
+	checker.Add("database", passing)
+	checker.AddOptional("jwks", failing)
+	code, report := readyz(t, checker)
+	if code != http.StatusOK || report.Status != health.StatusDegraded {
+		t.Errorf("Failing optional component: got %d %s; want 200 %s", code, report.Status, health.StatusDegraded)
+	}
+	if jwks := report.Components["jwks"]; jwks.Status != health.StatusFailing || jwks.Error != "down" {
+		t.Errorf("Unexpected jwks component: %+v", jwks)
+	}
+
+	// A check that hangs counts as failing once the timeout passes
+	checker.Add("migrations", func(context.Context) (map[string]interface{}, error) {
+		time.Sleep(time.Second)
+		return nil, nil
+	})
+	start := time.Now()
+	code, report = readyz(t, checker)
+	if code != http.StatusServiceUnavailable || report.Status != health.StatusUnavailable {
+		t.Errorf("Hanging critical component: got %d %s; want 503 %s", code, report.Status, health.StatusUnavailable)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Readiness took %v despite the timeout", elapsed)
+	}
+	if report.Components["database"].Status != health.StatusOK {
+		t.Errorf("Expected the database to still be reported ok: %+v", report.Components["database"])
+	}
+}
+
+func TestCachedCheck(t *testing.T) {
+	calls := 0
+	check := health.Cached(func(context.Context) (map[string]interface{}, error) {
+		calls++
+		return nil, nil
+	}, time.Hour)
+	check(context.Background())
+	check(context.Background())
+	if calls != 1 {
+		t.Errorf("Check ran %d times; want 1", calls)
+	}
+}
+
+func TestWebsocketHealthChecks(t *testing.T) {
+	healthSvr := mywebsoc.NewWebSockServer()
+	engine := gin.New()
+	engine.GET("/ws", healthSvr.ServeWs)
+	httpSvr := httptest.NewServer(engine)
+	defer httpSvr.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(httpSvr.URL, "http")+"/ws", nil)
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer conn.Close()
+	sendAndWait(t, conn, mywebsoc.EventCreateRoom, mywebsoc.CreateRoomEvent{RoomName: "Healthy", RoomSize: 4, UserName: "Alice"}, mywebsoc.MessageCreateRoom)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	details, err := healthSvr.CheckRooms(ctx)
+	if err != nil || details["rooms"] != 1 || details["clients"] != 1 {
+		t.Errorf("CheckRooms = %v, %v; want one responsive room and client", details, err)
+	}
+
+	if _, err := healthSvr.CheckGames(0)(ctx); err != nil {
+		t.Errorf("CheckGames without a limit failed: %v", err)
+	}
+}
@@ -0,0 +1,118 @@
+package test
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/oblongtable/beanbag-backend/db"
+	"github.com/oblongtable/beanbag-backend/internal/services"
+)
+
+func userRow(userID int32, name, email, subject string) []any {
+	return []any{userID, name, email, time.Now(), time.Now(), nil, "player", nil, []byte("{}"), false, subject}
+}
+
+func identityRow(userID int32, subject, email string) []any {
+	return []any{int32(1), userID, subject, email, time.Now(), time.Now()}
+}
+
+func userService(fake *fakeDB) *services.UserService {
+	conn := fake.DB()
+	return services.NewUserService(conn, db.New(conn))
+}
+
+func TestSyncUserMatchesSubjectFirst(t *testing.T) {
+	fake := newFakeDB(t)
+	fake.rows("GetUserByAuthSubject", userRow(1, "Alice", "old@example.com", "auth0|alice"))
+	fake.rows("TouchUserIdentity")
+	fake.rows("UpdateUser", userRow(1, "Alice", "new@example.com", "auth0|alice"))
+
+	// The subject wins over another user having the email, GetUserByEmail has no answer
+	user, created, err := userService(fake).SyncUser(context.Background(), "auth0|alice", "Alice", "new@example.com", true)
+	if err != nil || created || user.UserID != 1 || user.Email != "new@example.com" {
+		t.Fatalf("SyncUser = %+v, %v, %v; want user 1 with the new email", user, created, err)
+	}
+	if updates := fake.called("UpdateUser"); len(updates) != 1 || updates[0][2] != "new@example.com" {
+		t.Errorf("UpdateUser calls = %v; want one changing the email", updates)
+	}
+}
+
+func TestSyncUserLinksVerifiedEmail(t *testing.T) {
+	fake := newFakeDB(t)
+	fake.rows("GetUserByAuthSubject")
+	fake.rows("GetUserByEmail", userRow(2, "Bob", "bob@example.com", "auth0|bob"))
+	fake.rows("SetUserAuthSubject")
+	fake.on("CreateUserIdentity", func(args []driver.Value) ([][]any, error) {
+		return [][]any{identityRow(int32(args[0].(int64)), args[1].(string), args[2].(string))}, nil
+	})
+
+	user, created, err := userService(fake).SyncUser(context.Background(), "google-oauth2|bob", "Bob", "bob@example.com", true)
+	if err != nil || created || user.UserID != 2 {
+		t.Fatalf("SyncUser = %+v, %v, %v; want the existing user 2", user, created, err)
+	}
+	if linked := fake.called("CreateUserIdentity"); len(linked) != 1 || linked[0][0] != int64(2) || linked[0][1] != "google-oauth2|bob" {
+		t.Errorf("CreateUserIdentity calls = %v; want the Google login linked to user 2", linked)
+	}
+}
+
+func TestSyncUserIgnoresUnverifiedEmail(t *testing.T) {
+	fake := newFakeDB(t)
+	fake.rows("GetUserByAuthSubject")
+	fake.rows("CreateLinkedUser", userRow(3, "Mallory", "bob@example.com", "auth0|mallory"))
+	fake.rows("CreateUserIdentity", identityRow(3, "auth0|mallory", "bob@example.com"))
+
+	// GetUserByEmail has no answer, an unverified email must not be looked up
+	user, created, err := userService(fake).SyncUser(context.Background(), "auth0|mallory", "Mallory", "bob@example.com", false)
+	if err != nil || !created || user.UserID != 3 {
+		t.Fatalf("SyncUser = %+v, %v, %v; want a new user", user, created, err)
+	}
+	if len(fake.called("SetUserAuthSubject")) != 0 {
+		t.Errorf("An unverified login was linked to an existing user")
+	}
+}
+
+func TestFindUserFallsBackToEmail(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeDB(t)
+	fake.rows("GetUserByAuthSubject")
+	fake.rows("GetUnlinkedUserByEmail", userRow(4, "Carol", "carol@example.com", ""))
+	users := userService(fake)
+
+	// Users from before logins were tracked are found by their verified email
+	if user, err := users.FindUser(ctx, "auth0|carol", "carol@example.com"); err != nil || user.UserID != 4 {
+		t.Errorf("FindUser = %+v, %v; want user 4", user, err)
+	}
+	if _, err := users.FindUser(ctx, "auth0|carol", ""); !errors.Is(err, services.ErrUserNotFound) {
+		t.Errorf("FindUser without a verified email = %v; want ErrUserNotFound", err)
+	}
+	if lookups := len(fake.called("GetUnlinkedUserByEmail")); lookups != 1 {
+		t.Errorf("Looked up by email %d times; want 1", lookups)
+	}
+}
+
+func TestUnlinkIdentity(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeDB(t)
+	unlinked := 0
+	fake.on("DeleteUserIdentity", func([]driver.Value) ([][]any, error) { return make([][]any, unlinked), nil })
+	fake.rows("ResetUserAuthSubject")
+	users := userService(fake)
+
+	if err := users.UnlinkIdentity(ctx, 5, "auth0|dave", "auth0|dave"); !errors.Is(err, services.ErrCurrentIdentity) {
+		t.Errorf("Unlinking the current login = %v; want ErrCurrentIdentity", err)
+	}
+	if err := users.UnlinkIdentity(ctx, 5, "google-oauth2|dave", "auth0|dave"); !errors.Is(err, services.ErrIdentityNotFound) {
+		t.Errorf("Unlinking a login of someone else = %v; want ErrIdentityNotFound", err)
+	}
+	unlinked = 1
+	if err := users.UnlinkIdentity(ctx, 5, "google-oauth2|dave", "auth0|dave"); err != nil {
+		t.Fatalf("UnlinkIdentity failed: %v", err)
+	}
+	// The user's subject moves on to a login still linked
+	if resets := fake.called("ResetUserAuthSubject"); len(resets) != 1 || resets[0][0] != int64(5) {
+		t.Errorf("ResetUserAuthSubject calls = %v; want one for user 5", resets)
+	}
+}
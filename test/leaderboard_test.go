@@ -0,0 +1,114 @@
+package test
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/oblongtable/beanbag-backend/db"
+	"github.com/oblongtable/beanbag-backend/internal/services"
+)
+
+// leaderboardDB answers ListLeaderboard with a tie for first place between a user and a guest,
+// and the viewer, user 9, far below the top 3. Guests are left out when the query asks to.
+func leaderboardDB(t *testing.T) *fakeDB {
+	finished := time.Now().Add(-time.Hour)
+	rows := [][]any{
+		{int32(1), int32(1), "Alice", int32(900), int32(4), finished, int64(1), int64(1)},
+		{int32(2), nil, "Zed", int32(900), int32(4), finished, int64(1), int64(2)},
+		{int32(3), int32(2), "Bob", int32(700), int32(4), finished, int64(3), int64(3)},
+		{int32(4), int32(9), "Viewer", int32(100), int32(4), finished, int64(10), int64(12)},
+	}
+	fake := newFakeDB(t)
+	fake.on("ListLeaderboard", func(args []driver.Value) ([][]any, error) {
+		if args[4] == true {
+			return rows, nil
+		}
+		var users [][]any
+		for _, row := range rows {
+			if row[1] != nil {
+				users = append(users, row)
+			}
+		}
+		return users, nil
+	})
+	return fake
+}
+
+func TestLeaderboardGuestNames(t *testing.T) {
+	for _, tt := range []struct {
+		policy string
+		names  []string
+	}{
+		{"show", []string{"Alice", "Zed", "Bob"}},
+		{"anonymize", []string{"Alice", "Guest", "Bob"}},
+		{"hide", []string{"Alice", "Bob"}},
+	} {
+		leaderboards, err := services.NewLeaderboardService(db.New(leaderboardDB(t).DB()), tt.policy)
+		if err != nil {
+			t.Fatalf("NewLeaderboardService(%q) failed: %v", tt.policy, err)
+		}
+		leaderboard, err := leaderboards.Leaderboard(context.Background(), 0, services.PeriodAllTime, 3, 0)
+		if err != nil {
+			t.Fatalf("Leaderboard with %s failed: %v", tt.policy, err)
+		}
+		var names []string
+		for _, entry := range leaderboard.Entries {
+			names = append(names, entry.Name)
+		}
+		if len(names) != len(tt.names) {
+			t.Errorf("With %s got %v; want %v", tt.policy, names, tt.names)
+			continue
+		}
+		for i := range names {
+			if names[i] != tt.names[i] {
+				t.Errorf("With %s got %v; want %v", tt.policy, names, tt.names)
+				break
+			}
+		}
+	}
+
+	for _, policy := range []string{"", "reveal", "Show"} {
+		if _, err := services.NewLeaderboardService(nil, policy); err == nil {
+			t.Errorf("NewLeaderboardService accepted the guest name policy %q", policy)
+		}
+	}
+}
+
+func TestLeaderboardRanks(t *testing.T) {
+	fake := leaderboardDB(t)
+	fake.rows("GetQuiz", []any{int32(4), int32(7), "Capitals", nil, false, int32(20), time.Now(), time.Now()})
+	leaderboards, err := services.NewLeaderboardService(db.New(fake.DB()), "show")
+	if err != nil {
+		t.Fatalf("NewLeaderboardService failed: %v", err)
+	}
+
+	leaderboard, err := leaderboards.Leaderboard(context.Background(), 4, services.PeriodWeekly, 3, 9)
+	if err != nil {
+		t.Fatalf("Leaderboard failed: %v", err)
+	}
+	// Tied players share a rank and the next one skips it
+	var ranks []int64
+	for _, entry := range leaderboard.Entries {
+		ranks = append(ranks, entry.Rank)
+	}
+	if len(ranks) != 3 || ranks[0] != 1 || ranks[1] != 1 || ranks[2] != 3 {
+		t.Errorf("Ranks = %v; want [1 1 3]", ranks)
+	}
+	if !leaderboard.Entries[1].Guest || leaderboard.Entries[0].Guest {
+		t.Errorf("Guest flags = %v, %v; want only the second entry a guest", leaderboard.Entries[0].Guest, leaderboard.Entries[1].Guest)
+	}
+	// The viewer is below the limit, so only shown as their own entry
+	if leaderboard.You == nil || leaderboard.You.Rank != 10 || leaderboard.You.Name != "Viewer" {
+		t.Errorf("You = %+v; want the viewer at rank 10", leaderboard.You)
+	}
+	if params := fake.called("ListLeaderboard"); len(params) != 1 || params[0][0] != int64(3) || params[0][1] != int64(9) || params[0][3] != int64(4) {
+		t.Errorf("ListLeaderboard called with %v; want limit 3, viewer 9 and quiz 4", params)
+	}
+
+	if _, err := leaderboards.Leaderboard(context.Background(), 0, "month", 3, 0); !errors.Is(err, services.ErrInvalidPeriod) {
+		t.Errorf("Leaderboard of a month = %v; want ErrInvalidPeriod", err)
+	}
+}
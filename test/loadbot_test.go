@@ -0,0 +1,46 @@
+package test
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/oblongtable/beanbag-backend/internal/loadbot"
+	mywebsoc "github.com/oblongtable/beanbag-backend/websocket"
+)
+
+func TestLoadbotPlaysGames(t *testing.T) {
+	loadSvr := mywebsoc.NewWebSockServer()
+	engine := gin.New()
+	engine.GET("/ws", loadSvr.ServeWs)
+	httpSvr := httptest.NewServer(engine)
+	defer httpSvr.Close()
+
+	report, err := loadbot.Run(context.Background(), loadbot.Config{
+		URL:       "ws" + strings.TrimPrefix(httpSvr.URL, "http") + "/ws",
+		Rooms:     5,
+		Players:   3,
+		ThinkTime: 20 * time.Millisecond,
+		Timeout:   10 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	t.Log("\n" + report.String())
+
+	if report.Rooms != 5 || report.FinishedRooms != 5 {
+		t.Errorf("Created %d and finished %d rooms; want 5 of each", report.Rooms, report.FinishedRooms)
+	}
+	if report.Bots != 20 {
+		t.Errorf("Connected %d bots; want 20", report.Bots)
+	}
+	if report.Dropped != 0 || report.Disconnected != 0 {
+		t.Errorf("Dropped %d messages and %d bots; want none", report.Dropped, report.Disconnected)
+	}
+	if report.Callbacks.Count == 0 || report.Broadcasts.Count == 0 {
+		t.Errorf("Measured %d callbacks and %d broadcasts; want some of each", report.Callbacks.Count, report.Broadcasts.Count)
+	}
+}
@@ -0,0 +1,82 @@
+package test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/oblongtable/beanbag-backend/internal/logging"
+)
+
+func TestRequestLogging(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := logging.New(&buf, "debug", "json")
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(logger)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(logging.Middleware())
+	router.GET("/hello", func(ctx *gin.Context) {
+		logging.FromGin(ctx).Info("handling hello")
+		ctx.Status(http.StatusNoContent)
+	})
+
+	// An ID set by a proxy is kept
+	req := httptest.NewRequest(http.MethodGet, "/hello", nil)
+	req.Header.Set(logging.RequestIDHeader, "proxy-id-1")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if got := rec.Header().Get(logging.RequestIDHeader); got != "proxy-id-1" {
+		t.Errorf("Expected the incoming request ID to be echoed, got %q", got)
+	}
+
+	// Otherwise one is generated
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/hello", nil))
+	generated := rec.Header().Get(logging.RequestIDHeader)
+	if generated == "" || generated == "proxy-id-1" {
+		t.Errorf("Expected a new request ID, got %q", generated)
+	}
+
+	// Both the handler's line and the request line of each request carry its ID
+	seen := map[string][]string{}
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		var line map[string]interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			t.Fatalf("Log line is not JSON: %q", scanner.Text())
+		}
+		id, _ := line["request_id"].(string)
+		msg, _ := line["msg"].(string)
+		seen[id] = append(seen[id], msg)
+		if msg == "request" && (line["route"] != "/hello" || line["status"] != float64(http.StatusNoContent)) {
+			t.Errorf("Unexpected request line: %v", line)
+		}
+	}
+	for _, id := range []string{"proxy-id-1", generated} {
+		if len(seen[id]) != 2 || seen[id][0] != "handling hello" || seen[id][1] != "request" {
+			t.Errorf("Expected the handler and request lines for %s, got %v", id, seen[id])
+		}
+	}
+}
+
+func TestLoggingConfig(t *testing.T) {
+	if _, err := logging.New(&bytes.Buffer{}, "warn", "text"); err != nil {
+		t.Errorf("Expected text format to be accepted: %v", err)
+	}
+	if _, err := logging.New(&bytes.Buffer{}, "loud", "json"); err == nil {
+		t.Error("Expected an invalid level to be rejected")
+	}
+	if _, err := logging.New(&bytes.Buffer{}, "info", "xml"); err == nil {
+		t.Error("Expected an invalid format to be rejected")
+	}
+}
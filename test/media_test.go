@@ -0,0 +1,138 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/oblongtable/beanbag-backend/db"
+	"github.com/oblongtable/beanbag-backend/internal/services"
+	"github.com/oblongtable/beanbag-backend/internal/storage"
+)
+
+var pngData = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+// mediaService stores blobs in a temporary directory and gives uploads IDs from 11 up.
+func mediaService(t *testing.T, fake *fakeDB, maxBytes int64) (*services.MediaService, storage.BlobStore) {
+	store, err := storage.NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewLocalStore failed: %v", err)
+	}
+	nextID := int32(11)
+	fake.on("CreateMediaAsset", func(args []driver.Value) ([][]any, error) {
+		row := []any{nextID, args[0], args[1], args[2], args[3], time.Now()}
+		nextID++
+		return [][]any{row}, nil
+	})
+	return services.NewMediaService(db.New(fake.DB()), store, "http://localhost/media", maxBytes), store
+}
+
+func questionRow(questionID, quizID int32) []any {
+	return []any{questionID, quizID, "Which?", false, int32(20), time.Now(), time.Now(), nil, nil}
+}
+
+func TestMediaUpload(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeDB(t)
+	media, store := mediaService(t, fake, 64)
+
+	asset, err := media.Upload(ctx, services.MediaKindImage, bytes.NewReader(pngData))
+	if err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+	if asset.ContentType != "image/png" || !strings.HasSuffix(asset.StorageKey, ".png") || asset.SizeBytes != int64(len(pngData)) {
+		t.Errorf("Upload = %+v; want a PNG of %d bytes", asset, len(pngData))
+	}
+	if _, contentType, err := store.Get(ctx, asset.StorageKey); err != nil || contentType != "image/png" {
+		t.Errorf("Stored blob has type %q, %v; want image/png", contentType, err)
+	}
+
+	// The type comes from the data, whatever the file claims to be
+	rejected := []struct {
+		name string
+		kind services.MediaKind
+		data []byte
+		want error
+	}{
+		{"text as image", services.MediaKindImage, []byte("<svg onload=alert(1)>"), services.ErrUnsupportedMediaType},
+		{"image as audio", services.MediaKindAudio, pngData, services.ErrUnsupportedMediaType},
+		{"unknown kind", services.MediaKind("video"), pngData, services.ErrUnsupportedMediaType},
+		{"over the limit", services.MediaKindImage, append(append([]byte{}, pngData...), make([]byte, 64)...), services.ErrMediaTooLarge},
+	}
+	for _, tc := range rejected {
+		if _, err := media.Upload(ctx, tc.kind, bytes.NewReader(tc.data)); !errors.Is(err, tc.want) {
+			t.Errorf("Upload of %s = %v; want %v", tc.name, err, tc.want)
+		}
+	}
+	if created := len(fake.called("CreateMediaAsset")); created != 1 {
+		t.Errorf("%d media assets created; want 1", created)
+	}
+}
+
+func TestAttachQuestionMedia(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeDB(t)
+	media, store := mediaService(t, fake, 1024)
+	fake.rows("GetQuestion", questionRow(3, 7))
+	fake.rows("GetQuiz", []any{int32(7), int32(1), "Capitals", nil, false, int32(20), time.Now(), time.Now()})
+	fake.rows("SetQuestionImage", []any{int32(10)})
+	if err := store.Put(ctx, "images/old.png", bytes.NewReader(pngData), int64(len(pngData)), "image/png"); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	fake.rows("DeleteMediaAsset", []any{"images/old.png"})
+
+	if _, err := media.AttachQuestionMedia(ctx, 2, 3, services.MediaKindImage, bytes.NewReader(pngData)); !errors.Is(err, services.ErrNotQuizOwner) {
+		t.Errorf("Someone else attaching media = %v; want ErrNotQuizOwner", err)
+	}
+	if created := len(fake.called("CreateMediaAsset")); created != 0 {
+		t.Errorf("%d media assets created for someone else; want 0", created)
+	}
+
+	asset, err := media.AttachQuestionMedia(ctx, 1, 3, services.MediaKindImage, bytes.NewReader(pngData))
+	if err != nil {
+		t.Fatalf("AttachQuestionMedia failed: %v", err)
+	}
+	if asset.MediaID != 11 {
+		t.Errorf("Attached media %d; want 11", asset.MediaID)
+	}
+	// The image it replaced is gone, with its blob
+	if deleted := fake.called("DeleteMediaAsset"); len(deleted) != 1 || deleted[0][0] != int64(10) {
+		t.Errorf("DeleteMediaAsset calls = %v; want one for media 10", deleted)
+	}
+	if _, _, err := store.Get(ctx, "images/old.png"); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("Replaced blob still stored: %v", err)
+	}
+}
+
+func TestAttachMediaCleansUpAfterFailure(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeDB(t)
+	media, store := mediaService(t, fake, 1024)
+	fake.rows("GetQuestion", questionRow(3, 7))
+	fake.rows("GetQuiz", []any{int32(7), int32(1), "Capitals", nil, false, int32(20), time.Now(), time.Now()})
+	fake.on("SetQuestionAudio", func([]driver.Value) ([][]any, error) { return nil, errors.New("connection reset") })
+	fake.on("DeleteMediaAsset", func([]driver.Value) ([][]any, error) {
+		created := fake.called("CreateMediaAsset")
+		return [][]any{{created[len(created)-1][3]}}, nil
+	})
+
+	wav := append([]byte("RIFF\x24\x00\x00\x00WAVEfmt "), make([]byte, 16)...)
+	if _, err := media.AttachQuestionMedia(ctx, 1, 3, services.MediaKindAudio, bytes.NewReader(wav)); err == nil {
+		t.Fatalf("AttachQuestionMedia succeeded without linking the media")
+	}
+	// The upload that couldn't be linked is deleted again
+	created := fake.called("CreateMediaAsset")
+	if len(created) != 1 {
+		t.Fatalf("%d media assets created; want 1", len(created))
+	}
+	if deleted := fake.called("DeleteMediaAsset"); len(deleted) != 1 || deleted[0][0] != int64(11) {
+		t.Errorf("DeleteMediaAsset calls = %v; want one for media 11", deleted)
+	}
+	if _, _, err := store.Get(ctx, created[0][3].(string)); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("Unlinked blob still stored: %v", err)
+	}
+}
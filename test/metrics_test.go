@@ -0,0 +1,84 @@
+package test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/oblongtable/beanbag-backend/internal/metrics"
+	mywebsoc "github.com/oblongtable/beanbag-backend/websocket"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// failingDB fails every query.
+type failingDB struct{}
+
+func (failingDB) ExecContext(context.Context, string, ...interface{}) (sql.Result, error) {
+	return nil, errors.New("connection refused")
+}
+
+func (failingDB) PrepareContext(context.Context, string) (*sql.Stmt, error) {
+	return nil, errors.New("connection refused")
+}
+
+func (failingDB) QueryContext(context.Context, string, ...interface{}) (*sql.Rows, error) {
+	return nil, errors.New("connection refused")
+}
+
+func (failingDB) QueryRowContext(context.Context, string, ...interface{}) *sql.Row {
+	return &sql.Row{}
+}
+
+func TestWebsocketMetrics(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	metricsSvr := mywebsoc.NewWebSockServer()
+	if err := metricsSvr.RegisterMetrics(reg); err != nil {
+		t.Fatalf("RegisterMetrics failed: %v", err)
+	}
+
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("Gather failed: %v", err)
+	}
+	names := map[string]bool{}
+	for _, f := range families {
+		names[f.GetName()] = true
+	}
+	for _, name := range []string{"beanbag_ws_connected_clients", "beanbag_ws_active_rooms", "beanbag_ws_messages_dropped_total"} {
+		if !names[name] {
+			t.Errorf("Metric %s is missing from %v", name, names)
+		}
+	}
+}
+
+func TestDBQueryMetrics(t *testing.T) {
+	conn := metrics.InstrumentDB(failingDB{})
+	before := testutil.ToFloat64(metrics.DBQueryErrors.WithLabelValues("CreateUser"))
+	conn.ExecContext(context.Background(), "-- name: CreateUser :one\nINSERT INTO users DEFAULT VALUES")
+
+	if got := testutil.ToFloat64(metrics.DBQueryErrors.WithLabelValues("CreateUser")) - before; got != 1 {
+		t.Errorf("CreateUser errors went up by %v; want 1", got)
+	}
+}
+
+func TestHTTPMetrics(t *testing.T) {
+	engine := gin.New()
+	engine.Use(metrics.GinMiddleware())
+	engine.GET("/api/users/:id", func(ctx *gin.Context) { ctx.Status(http.StatusNotFound) })
+	engine.GET("/metrics", metrics.Handler())
+
+	engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/users/7", nil))
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	want := `beanbag_http_requests_total{method="GET",route="/api/users/:id",status="404"}`
+	if !strings.Contains(rec.Body.String(), want) {
+		t.Errorf("/metrics does not contain %s", want)
+	}
+}
@@ -0,0 +1,94 @@
+package test
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/oblongtable/beanbag-backend/db"
+	"github.com/oblongtable/beanbag-backend/internal/handlers"
+	"github.com/oblongtable/beanbag-backend/internal/services"
+	"github.com/oblongtable/beanbag-backend/middleware"
+)
+
+func TestUpdateProfileValidation(t *testing.T) {
+	// Bodies are rejected before the user is looked up, so no database is needed
+	router := gin.New()
+	router.PATCH("/users/me", handlers.NewUserHandler(nil, nil, nil).UpdateMe)
+
+	for _, body := range []string{
+		`{"name": "   "}`,
+		`{"name": "` + strings.Repeat("a", 51) + `"}`,
+		`{"avatar_url": "javascript:alert(1)"}`,
+		`{"avatar_url": "/relative.png"}`,
+		`{"preferences": {"theme": "purple"}}`,
+		`{"preferences": {"language": "not a language"}}`,
+		`{"preferences": {"reduced_motion": "yes"}}`,
+	} {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodPatch, "/users/me", strings.NewReader(body)))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("PATCH %s returned %d; want 400", body, rec.Code)
+		}
+	}
+}
+
+func TestProfileFromUser(t *testing.T) {
+	profile := services.Profile(&db.User{
+		UserID:      7,
+		Name:        "Ms Smith",
+		Role:        "teacher",
+		AvatarUrl:   sql.NullString{String: "https://example.com/me.png", Valid: true},
+		Preferences: json.RawMessage(`{"theme": "dark", "language": "en-GB", "reduced_motion": true, "retired": 1}`),
+	})
+	if profile.AvatarURL != "https://example.com/me.png" || profile.Role != "teacher" {
+		t.Errorf("Unexpected profile: %+v", profile)
+	}
+	if p := profile.Preferences; p.Theme != "dark" || p.Language != "en-GB" || !p.ReducedMotion {
+		t.Errorf("Unexpected preferences: %+v", p)
+	}
+
+	// Users created before preferences existed have the column's default
+	if p := services.Profile(&db.User{Preferences: json.RawMessage(`{}`)}).Preferences; p.Theme != "" || p.ReducedMotion {
+		t.Errorf("Unexpected default preferences: %+v", p)
+	}
+}
+
+func TestGetUserOnlyToSelfOrAdmin(t *testing.T) {
+	fake := newFakeDB(t)
+	admin := userRow(1, "Admin", "admin@example.com", "auth0|admin")
+	admin[6] = "admin"
+	signedIn := map[string][]any{
+		"auth0|admin": admin,
+		"auth0|bob":   userRow(2, "Bob", "bob@example.com", "auth0|bob"),
+		"auth0|eve":   userRow(3, "Eve", "eve@example.com", "auth0|eve"),
+	}
+	fake.on("GetUserByAuthSubject", func(args []driver.Value) ([][]any, error) {
+		return [][]any{signedIn[args[0].(string)]}, nil
+	})
+	fake.rows("GetUserById", signedIn["auth0|bob"])
+
+	router := gin.New()
+	router.GET("/users/:id", func(c *gin.Context) {
+		c.Set(middleware.GinContextKeyUserSub, c.GetHeader("X-Test-Subject"))
+	}, handlers.NewUserHandler(userService(fake), nil, nil).GetUser)
+
+	for subject, want := range map[string]int{"auth0|bob": http.StatusOK, "auth0|admin": http.StatusOK, "auth0|eve": http.StatusForbidden} {
+		req := httptest.NewRequest(http.MethodGet, "/users/2", nil)
+		req.Header.Set("X-Test-Subject", subject)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if rec.Code != want {
+			t.Errorf("%s getting user 2 = %d; want %d", subject, rec.Code, want)
+		}
+		// The login and deletion details stay private, even to admins
+		if body := rec.Body.String(); strings.Contains(body, "auth0|bob") || strings.Contains(body, "DeletedAt") {
+			t.Errorf("%s got private fields: %s", subject, body)
+		}
+	}
+}
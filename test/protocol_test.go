@@ -0,0 +1,135 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/oblongtable/beanbag-backend/internal/game"
+	mywebsoc "github.com/oblongtable/beanbag-backend/websocket"
+)
+
+func TestCallbackCorrelation(t *testing.T) {
+	protoSvr := mywebsoc.NewWebSockServer()
+	engine := gin.New()
+	engine.GET("/ws", protoSvr.ServeWs)
+	httpSvr := httptest.NewServer(engine)
+	defer httpSvr.Close()
+	wsURL := "ws" + strings.TrimPrefix(httpSvr.URL, "http") + "/ws"
+
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer conn.Close()
+
+	info, _ := json.Marshal(mywebsoc.CreateRoomEvent{RoomName: "Versioned", RoomSize: 4, UserName: "Alice"})
+	if err := conn.WriteJSON(mywebsoc.Event{
+		Version: mywebsoc.ProtocolVersion,
+		ID:      "req-1",
+		Type:    mywebsoc.EventCreateRoom,
+		Payload: info,
+	}); err != nil {
+		t.Fatalf("WriteJSON failed: %v", err)
+	}
+
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	var callback mywebsoc.EventCallbackMessage
+	if err := conn.ReadJSON(&callback); err != nil {
+		t.Fatalf("ReadJSON failed: %v", err)
+	}
+	if callback.Type != mywebsoc.MessageCreateRoom || !callback.IsSuccess {
+		t.Fatalf("Got %s (success %v); want a successful %s", callback.Type, callback.IsSuccess, mywebsoc.MessageCreateRoom)
+	}
+	if callback.Version != mywebsoc.ProtocolVersion || callback.ID == "" {
+		t.Errorf("Callback has version %d and ID %q; want version %d and an ID", callback.Version, callback.ID, mywebsoc.ProtocolVersion)
+	}
+	if callback.CorrelationID != "req-1" {
+		t.Errorf("Callback CorrelationID = %q; want req-1", callback.CorrelationID)
+	}
+
+	var room mywebsoc.RoomInfo
+	if err := json.Unmarshal(callback.Info, &room); err != nil || room.ID == "" {
+		t.Errorf("Callback info %s is not a room: %v", callback.Info, err)
+	}
+}
+
+func TestAsyncAPIDocument(t *testing.T) {
+	doc, err := json.Marshal(mywebsoc.AsyncAPI())
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+
+	var parsed struct {
+		Components struct {
+			Messages map[string]json.RawMessage `json:"messages"`
+			Schemas  map[string]struct {
+				Properties map[string]json.RawMessage `json:"properties"`
+			} `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(doc, &parsed); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+
+	for _, msgType := range []string{mywebsoc.EventCreateRoom, mywebsoc.MessageCreateRoom, game.MessageQuestionResult} {
+		if _, ok := parsed.Components.Messages[msgType]; !ok {
+			t.Errorf("Document has no %s message", msgType)
+		}
+	}
+	if _, ok := parsed.Components.Schemas["QuestionResultPayload"].Properties["stats"]; !ok {
+		t.Errorf("QuestionResultPayload schema has no stats property")
+	}
+}
+
+func TestCallbackErrorCodes(t *testing.T) {
+	protoSvr := mywebsoc.NewWebSockServer()
+	engine := gin.New()
+	engine.GET("/ws", protoSvr.ServeWs)
+	httpSvr := httptest.NewServer(engine)
+	defer httpSvr.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(httpSvr.URL, "http")+"/ws", nil)
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer conn.Close()
+
+	info, _ := json.Marshal(mywebsoc.JoinRoomEvent{RoomID: "NOPE", Name: "Bob"})
+	conn.WriteJSON(mywebsoc.Event{Type: mywebsoc.EventJoinRoom, Payload: info})
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	var callback mywebsoc.EventCallbackMessage
+	if err := conn.ReadJSON(&callback); err != nil {
+		t.Fatalf("ReadJSON failed: %v", err)
+	}
+	if callback.IsSuccess || callback.Code != mywebsoc.CodeRoomNotFound {
+		t.Errorf("Joining a missing room got success %v code %q; want %s", callback.IsSuccess, callback.Code, mywebsoc.CodeRoomNotFound)
+	}
+
+	// Errors of the game service map to their own codes
+	svc := game.NewService()
+	svc.SetQuizLoader(fakeQuizLoader{})
+	players := []game.InitialPlayerInfo{{ID: "p1", Username: "Alice"}, {ID: "p2", Username: "Bob"}}
+	if _, err := svc.CreateGame(context.Background(), "CODES", "presenter", "host", 7, func(context.Context, string, interface{}) {}, players); err != nil {
+		t.Fatalf("CreateGame failed: %v", err)
+	}
+	cases := []struct {
+		err  error
+		want mywebsoc.ErrorCode
+	}{
+		{svc.HandleAnswer(context.Background(), "MISSING", "p1", 0), mywebsoc.CodeGameNotFound},
+		{svc.StartGame(context.Background(), "CODES", "p1"), mywebsoc.CodeNotHost},
+		{svc.HandleAnswer(context.Background(), "CODES", "p1", 0), mywebsoc.CodeNotAcceptingAnswers},
+		{nil, ""},
+	}
+	for _, c := range cases {
+		if got := mywebsoc.ErrorCodeOf(c.err); got != c.want {
+			t.Errorf("ErrorCodeOf(%v) = %q; want %q", c.err, got, c.want)
+		}
+	}
+}
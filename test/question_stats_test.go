@@ -0,0 +1,87 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/oblongtable/beanbag-backend/internal/game"
+)
+
+func TestQuestionResultStats(t *testing.T) {
+	ctx := context.Background()
+	svc := game.NewService()
+	svc.SetQuizLoader(fakeQuizLoader{})
+
+	results := make(chan game.QuestionResultPayload, 1)
+	broadcast := func(_ context.Context, msgType string, payload interface{}) {
+		if msgType == game.MessageQuestionResult {
+			results <- payload.(game.QuestionResultPayload)
+		}
+	}
+	// The room passes everyone in it, the presenter doesn't play
+	players := []game.InitialPlayerInfo{
+		{ID: "presenter", Username: "Teacher"},
+		{ID: "p1", Username: "Alice"},
+		{ID: "p2", Username: "Bob"},
+		{ID: "p3", Username: "Carol"},
+	}
+	if _, err := svc.CreateGame(ctx, "STATS", "presenter", "host", 7, broadcast, players); err != nil {
+		t.Fatalf("CreateGame failed: %v", err)
+	}
+	if err := svc.StartGame(ctx, "STATS", "host"); err != nil {
+		t.Fatalf("StartGame failed: %v", err)
+	}
+	// Title -> section -> first question
+	for range 2 {
+		if err := svc.NextAction(ctx, "STATS", "host"); err != nil {
+			t.Fatalf("NextAction failed: %v", err)
+		}
+	}
+
+	// Watchers can't answer, so they can't end the question before the players have
+	for _, watcher := range []string{"presenter", "late"} {
+		if err := svc.HandleAnswer(ctx, "STATS", watcher, 1); !errors.Is(err, game.ErrNotAPlayer) {
+			t.Errorf("HandleAnswer(%s) = %v; want ErrNotAPlayer", watcher, err)
+		}
+	}
+	// The question has two options, so an answer past them doesn't count
+	if err := svc.HandleAnswer(ctx, "STATS", "p1", 2); !errors.Is(err, game.ErrNoSuchOption) {
+		t.Errorf("HandleAnswer with option 2 = %v; want ErrNoSuchOption", err)
+	}
+
+	for _, answer := range []struct {
+		playerID string
+		index    int
+	}{{"p1", 1}, {"p2", 0}, {"p3", 1}} {
+		if err := svc.HandleAnswer(ctx, "STATS", answer.playerID, answer.index); err != nil {
+			t.Fatalf("HandleAnswer(%s) failed: %v", answer.playerID, err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	var payload game.QuestionResultPayload
+	select {
+	case payload = <-results:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("No question_result after every player answered")
+	}
+	stats := payload.Stats
+
+	if len(stats.AnswerCounts) != 2 || stats.AnswerCounts[0] != 1 || stats.AnswerCounts[1] != 2 {
+		t.Errorf("AnswerCounts = %v; want [1 2]", stats.AnswerCounts)
+	}
+	if stats.Players != 3 || stats.Responses != 3 {
+		t.Errorf("Players/Responses = %d/%d; want 3/3", stats.Players, stats.Responses)
+	}
+	if stats.PercentCorrect != 66.7 {
+		t.Errorf("PercentCorrect = %v; want 66.7", stats.PercentCorrect)
+	}
+	if stats.FastestTimeMs > stats.AverageTimeMs {
+		t.Errorf("FastestTimeMs %d is slower than AverageTimeMs %d", stats.FastestTimeMs, stats.AverageTimeMs)
+	}
+	if stats.FastestCorrect == nil || stats.FastestCorrect.PlayerID != "p1" || stats.FastestCorrect.Name != "Alice" {
+		t.Errorf("FastestCorrect = %+v; want Alice (p1)", stats.FastestCorrect)
+	}
+}
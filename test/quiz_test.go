@@ -0,0 +1,52 @@
+package test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/oblongtable/beanbag-backend/db"
+	"github.com/oblongtable/beanbag-backend/internal/handlers"
+	"github.com/oblongtable/beanbag-backend/internal/services"
+	"github.com/oblongtable/beanbag-backend/middleware"
+)
+
+func TestCreateQuizBySignedInUser(t *testing.T) {
+	fake := newFakeDB(t)
+	fake.rows("GetUserByAuthSubject", userRow(7, "Bob", "bob@example.com", "auth0|bob"))
+	fake.rows("CreateQuiz", []any{int32(4), int32(7), "Capitals", nil, false, int32(0), time.Now(), time.Now()})
+
+	router := gin.New()
+	router.POST("/quizzes", func(c *gin.Context) {
+		c.Set(middleware.GinContextKeyUserSub, "auth0|bob")
+	}, handlers.NewQuizHandler(services.NewQuizService(fake.DB(), db.New(fake.DB()), nil), userService(fake)).CreateQuiz)
+
+	tests := []struct {
+		name, body string
+		want       int
+	}{
+		{"someone else as creator", `{"title": "Capitals", "creator_id": 8}`, http.StatusForbidden},
+		{"no creator given", `{"title": "Capitals"}`, http.StatusCreated},
+		{"themselves as creator", `{"title": "Capitals", "creator_id": 7}`, http.StatusCreated},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/quizzes", strings.NewReader(tt.body)))
+		if rec.Code != tt.want {
+			t.Errorf("Creating a quiz with %s = %d; want %d", tt.name, rec.Code, tt.want)
+		}
+	}
+	// Only the two allowed requests reach the database, both as the signed-in user
+	created := fake.called("CreateQuiz")
+	if len(created) != 2 {
+		t.Fatalf("%d quizzes created; want 2", len(created))
+	}
+	for _, args := range created {
+		if args[0] != int64(7) {
+			t.Errorf("Quiz created by %v; want user 7", args[0])
+		}
+	}
+}
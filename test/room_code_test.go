@@ -0,0 +1,178 @@
+package test
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/oblongtable/beanbag-backend/db"
+	"github.com/oblongtable/beanbag-backend/internal/roomcode"
+	"github.com/oblongtable/beanbag-backend/internal/services"
+	mywebsoc "github.com/oblongtable/beanbag-backend/websocket"
+)
+
+func TestRoomCodeAllocator(t *testing.T) {
+	ctx := context.Background()
+	codes := roomcode.NewAllocator(roomcode.NewMemoryStore(), roomcode.Options{MinLength: 2, MaxLength: 3})
+
+	// More codes than there are of length 2, so the allocator has to move on to 3
+	seen := make(map[string]bool)
+	longest := 0
+	var err error
+	for range 1500 {
+		var code string
+		code, err = codes.Allocate(ctx)
+		if err != nil {
+			t.Fatalf("Allocate failed after %d codes: %v", len(seen), err)
+		}
+		if seen[code] {
+			t.Fatalf("Code %s handed out twice", code)
+		}
+		seen[code] = true
+		longest = max(longest, len(code))
+		for _, c := range code {
+			if !strings.ContainsRune(roomcode.Alphabet, c) {
+				t.Fatalf("Code %s has %q, which is not in the alphabet", code, c)
+			}
+		}
+	}
+	if longest != 3 {
+		t.Errorf("Longest code has %d characters; want 3", longest)
+	}
+
+	// With nowhere to grow, the allocator gives up once it can't find a free code
+	full := roomcode.NewAllocator(roomcode.NewMemoryStore(), roomcode.Options{MinLength: 1, MaxLength: 1})
+	handedOut := 0
+	for {
+		if _, err = full.Allocate(ctx); err != nil {
+			break
+		}
+		handedOut++
+	}
+	if !errors.Is(err, roomcode.ErrExhausted) || handedOut > len(roomcode.Alphabet) {
+		t.Errorf("Allocate gave up with %v after %d codes; want ErrExhausted after at most %d", err, handedOut, len(roomcode.Alphabet))
+	}
+}
+
+func TestReservedRoomCodes(t *testing.T) {
+	ctx := context.Background()
+	store := roomcode.NewMemoryStore()
+	codes := roomcode.NewAllocator(store, roomcode.Options{})
+	now := time.Now()
+	if err := store.Reserve(roomcode.Reservation{Code: "MATHS101", UserID: 7, StartsAt: now.Add(-time.Minute), EndsAt: now.Add(time.Hour)}); err != nil {
+		t.Fatalf("Reserve failed: %v", err)
+	}
+	if err := store.Reserve(roomcode.Reservation{Code: "MATHS101", UserID: 8, StartsAt: now, EndsAt: now.Add(time.Hour)}); !errors.Is(err, roomcode.ErrReserved) {
+		t.Errorf("Reserving a reserved code = %v; want ErrReserved", err)
+	}
+
+	if claimed, _ := store.Claim(ctx, "MATHS101"); claimed {
+		t.Errorf("A reserved code was claimed as a generated one")
+	}
+	for _, userID := range []int32{0, 8} {
+		if err := codes.ClaimReserved(ctx, "MATHS101", userID); !errors.Is(err, roomcode.ErrNotReserved) {
+			t.Errorf("User %d claiming someone else's code = %v; want ErrNotReserved", userID, err)
+		}
+	}
+	if err := codes.ClaimReserved(ctx, " maths101", 7); err != nil {
+		t.Fatalf("Claiming your own code failed: %v", err)
+	}
+	if err := codes.ClaimReserved(ctx, "MATHS101", 7); !errors.Is(err, roomcode.ErrCodeInUse) {
+		t.Errorf("Claiming a code twice = %v; want ErrCodeInUse", err)
+	}
+	codes.Release(ctx, "MATHS101")
+	if err := codes.ClaimReserved(ctx, "MATHS101", 7); err != nil {
+		t.Errorf("Claiming a released code failed: %v", err)
+	}
+
+	for _, code := range []string{"AB", "QUIZ NIGHT", "THIRTEENCHARS", "A55HAT"} {
+		if err := roomcode.ValidateVanity(code); !errors.Is(err, roomcode.ErrInvalidCode) {
+			t.Errorf("ValidateVanity(%q) = %v; want ErrInvalidCode", code, err)
+		}
+	}
+}
+
+func TestRoomCodeReservationLimits(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeDB(t)
+	held := int64(2)
+	fake.on("CountRoomCodeReservationsByUser", func([]driver.Value) ([][]any, error) { return [][]any{{held}}, nil })
+	fake.rows("DeleteEndedRoomCodeReservation")
+	fake.on("CreateRoomCodeReservation", func(args []driver.Value) ([][]any, error) {
+		return [][]any{{args[0], args[1], args[2], args[3], args[4], time.Now()}}, nil
+	})
+	codes, err := services.NewRoomCodeService(db.New(fake.DB()), time.Minute)
+	if err != nil {
+		t.Fatalf("NewRoomCodeService failed: %v", err)
+	}
+	codes.SetReservationLimits(3, 24*time.Hour)
+	now := time.Now()
+
+	if _, err := codes.Reserve(ctx, 7, "MATHS101", "", now, now.Add(25*time.Hour)); !errors.Is(err, services.ErrReservationTooLong) {
+		t.Errorf("Reserving past the window = %v; want ErrReservationTooLong", err)
+	}
+	reservation, err := codes.Reserve(ctx, 7, "maths101", "", now.Add(time.Hour), now.Add(2*time.Hour))
+	if err != nil || reservation.Code != "MATHS101" {
+		t.Fatalf("Reserve = %+v, %v", reservation, err)
+	}
+	held = 3
+	if _, err := codes.Reserve(ctx, 7, "MATHS102", "", now, now.Add(time.Hour)); !errors.Is(err, services.ErrReservationLimit) {
+		t.Errorf("Reserving over the limit = %v; want ErrReservationLimit", err)
+	}
+	if created := len(fake.called("CreateRoomCodeReservation")); created != 1 {
+		t.Errorf("%d reservations created; want 1", created)
+	}
+}
+
+func TestCreateRoomWithCode(t *testing.T) {
+	wssvr := mywebsoc.NewWebSockServer()
+	store := roomcode.NewMemoryStore()
+	wssvr.Codes = roomcode.NewAllocator(store, roomcode.Options{})
+	store.Reserve(roomcode.Reservation{Code: "QUIZNITE", UserID: 7, StartsAt: time.Now(), EndsAt: time.Now().Add(time.Hour)})
+	engine := gin.New()
+	engine.GET("/ws", wssvr.ServeWs)
+	httpSvr := httptest.NewServer(engine)
+	defer httpSvr.Close()
+	wsURL := "ws" + strings.TrimPrefix(httpSvr.URL, "http") + "/ws"
+
+	creator, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer creator.Close()
+	player, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer player.Close()
+
+	// Guests have no reservations
+	reply := sendAndWait(t, creator, mywebsoc.EventCreateRoom, mywebsoc.CreateRoomEvent{RoomName: "Quiz night", RoomSize: 4, UserName: "Guest", RoomCode: "quiznite"}, mywebsoc.MessageCreateRoom)
+	if reply.IsSuccess || reply.Code != mywebsoc.CodeRoomCodeNotReserved {
+		t.Errorf("Guest opening a reserved code: success=%v code=%s", reply.IsSuccess, reply.Code)
+	}
+
+	created := sendAndWait(t, creator, mywebsoc.EventCreateRoom, mywebsoc.CreateRoomEvent{RoomName: "Quiz night", RoomSize: 4, UserName: "Teacher"}, mywebsoc.MessageCreateRoom)
+	var room mywebsoc.RoomInfo
+	json.Unmarshal(created.Info, &room)
+	if len(room.ID) != roomcode.DefaultMinLength {
+		t.Fatalf("Generated code %q", room.ID)
+	}
+
+	// Typed in lower case
+	joined := sendAndWait(t, player, mywebsoc.EventJoinRoom, mywebsoc.JoinRoomEvent{RoomID: strings.ToLower(room.ID), Name: "Bob"}, mywebsoc.MessageJoinRoom)
+	if !joined.IsSuccess {
+		t.Errorf("Joining with %q failed: %s", strings.ToLower(room.ID), joined.Message)
+	}
+	left := sendAndWait(t, player, mywebsoc.EventLeaveRoom, mywebsoc.LeaveRoomEvent{RoomID: strings.ToLower(room.ID)}, mywebsoc.MessageLeaveRoom)
+	if !left.IsSuccess {
+		t.Errorf("Leaving with %q failed: %s", strings.ToLower(room.ID), left.Message)
+	}
+}
@@ -0,0 +1,127 @@
+package test
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/oblongtable/beanbag-backend/internal/game"
+	mywebsoc "github.com/oblongtable/beanbag-backend/websocket"
+)
+
+// lifecycleServer serves a websocket server whose rooms have the given limits.
+func lifecycleServer(t *testing.T, limits mywebsoc.RoomLimits) (*mywebsoc.WebSocServer, string) {
+	t.Helper()
+	wssvr := mywebsoc.NewWebSockServer()
+	wssvr.SetRoomLimits(limits)
+	engine := gin.New()
+	engine.GET("/ws", wssvr.ServeWs)
+	httpSvr := httptest.NewServer(engine)
+	t.Cleanup(httpSvr.Close)
+	return wssvr, "ws" + strings.TrimPrefix(httpSvr.URL, "http") + "/ws"
+}
+
+// readTypes reads messages until one of the last type, returning the types seen on the way.
+func readTypes(t *testing.T, conn *websocket.Conn, last string) ([]string, map[string]json.RawMessage) {
+	t.Helper()
+	var types []string
+	infos := make(map[string]json.RawMessage)
+	conn.SetReadDeadline(time.Now().Add(3 * time.Second))
+	for {
+		var msg mywebsoc.Event
+		if err := conn.ReadJSON(&msg); err != nil {
+			t.Fatalf("Waiting for %s after %v: %v", last, types, err)
+		}
+		types = append(types, msg.Type)
+		infos[msg.Type] = msg.Payload
+		if msg.Type == last {
+			return types, infos
+		}
+	}
+}
+
+func TestIdleRoomExpires(t *testing.T) {
+	wssvr, wsURL := lifecycleServer(t, mywebsoc.RoomLimits{
+		IdleTimeout:   300 * time.Millisecond,
+		ExpiryWarning: 200 * time.Millisecond,
+		CheckInterval: 10 * time.Millisecond,
+	})
+
+	creator, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer creator.Close()
+	sendAndWait(t, creator, mywebsoc.EventCreateRoom, mywebsoc.CreateRoomEvent{RoomName: "Forgotten", RoomSize: 4, UserName: "Teacher"}, mywebsoc.MessageCreateRoom)
+
+	types, infos := readTypes(t, creator, mywebsoc.MessageRoomShutdown)
+	if len(types) != 2 || types[0] != mywebsoc.MessageRoomExpiring {
+		t.Fatalf("Got %v; want a warning before the shutdown", types)
+	}
+	var expiring mywebsoc.RoomExpiringInfo
+	json.Unmarshal(infos[mywebsoc.MessageRoomExpiring], &expiring)
+	if expiring.Reason != mywebsoc.ExpiryIdle || expiring.ExpiresInMs <= 0 || expiring.ExpiresInMs > 200 {
+		t.Errorf("Unexpected warning: %+v", expiring)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for wssvr.Rooms.Len() > 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := wssvr.Rooms.Len(); n != 0 {
+		t.Errorf("%d rooms still open after expiring", n)
+	}
+}
+
+func TestLongGameExpires(t *testing.T) {
+	wssvr, wsURL := lifecycleServer(t, mywebsoc.RoomLimits{
+		IdleTimeout:     time.Hour,
+		MaxGameDuration: 300 * time.Millisecond,
+		ExpiryWarning:   150 * time.Millisecond,
+		CheckInterval:   10 * time.Millisecond,
+	})
+	wssvr.Games.SetRetention(50 * time.Millisecond)
+
+	creator, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer creator.Close()
+	host, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer host.Close()
+
+	created := sendAndWait(t, creator, mywebsoc.EventCreateRoom, mywebsoc.CreateRoomEvent{RoomName: "Marathon", RoomSize: 4, UserName: "Teacher"}, mywebsoc.MessageCreateRoom)
+	var room mywebsoc.RoomInfo
+	json.Unmarshal(created.Info, &room)
+	sendAndWait(t, host, mywebsoc.EventJoinRoom, mywebsoc.JoinRoomEvent{RoomID: room.ID, Name: "Bob"}, mywebsoc.MessageJoinRoom)
+	sendAndWait(t, host, mywebsoc.EventStartQuiz, mywebsoc.StartQuizEvent{RoomID: room.ID}, game.MessageShowTitle)
+
+	// The game ends in front of everyone before the room closes
+	types, infos := readTypes(t, host, mywebsoc.MessageRoomShutdown)
+	want := []string{mywebsoc.MessageRoomExpiring, game.MessageGameOver, mywebsoc.MessageRoomShutdown}
+	if strings.Join(types, ",") != strings.Join(want, ",") {
+		t.Fatalf("Got %v; want %v", types, want)
+	}
+	var expiring mywebsoc.RoomExpiringInfo
+	json.Unmarshal(infos[mywebsoc.MessageRoomExpiring], &expiring)
+	if expiring.Reason != mywebsoc.ExpiryMaxGameDuration {
+		t.Errorf("Warned for %q; want %q", expiring.Reason, mywebsoc.ExpiryMaxGameDuration)
+	}
+
+	// Kept for the retention, then forgotten
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if _, found := wssvr.Games.GetGame(room.ID); !found {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Errorf("Finished game still held after its retention")
+}
@@ -0,0 +1,141 @@
+package test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	mywebsoc "github.com/oblongtable/beanbag-backend/websocket"
+)
+
+// TestConcurrentRooms fills hundreds of rooms at once. Run it with -race to check the rooms share no state.
+func TestConcurrentRooms(t *testing.T) {
+	const rooms, playersPerRoom = 200, 3
+
+	loadSvr := mywebsoc.NewWebSockServer()
+	engine := gin.New()
+	engine.GET("/ws", loadSvr.ServeWs)
+	httpSvr := httptest.NewServer(engine)
+	defer httpSvr.Close()
+	wsURL := "ws" + strings.TrimPrefix(httpSvr.URL, "http") + "/ws"
+
+	// send writes an event and waits for its callback, skipping the room updates in between
+	send := func(conn *websocket.Conn, id, eventType string, payload interface{}) (mywebsoc.EventCallbackMessage, error) {
+		info, _ := json.Marshal(payload)
+		evt := mywebsoc.Event{Version: mywebsoc.ProtocolVersion, ID: id, Type: eventType, Payload: info}
+		if err := conn.WriteJSON(evt); err != nil {
+			return mywebsoc.EventCallbackMessage{}, err
+		}
+		conn.SetReadDeadline(time.Now().Add(10 * time.Second))
+		for {
+			var callback mywebsoc.EventCallbackMessage
+			if err := conn.ReadJSON(&callback); err != nil {
+				return callback, err
+			}
+			if callback.CorrelationID == id {
+				return callback, nil
+			}
+		}
+	}
+
+	var mu sync.Mutex
+	var conns []*websocket.Conn
+	defer func() {
+		for _, conn := range conns {
+			conn.Close()
+		}
+	}()
+	dial := func() (*websocket.Conn, error) {
+		conn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+		if err == nil {
+			mu.Lock()
+			conns = append(conns, conn)
+			mu.Unlock()
+		}
+		return conn, err
+	}
+
+	errs := make(chan error, rooms*(playersPerRoom+1))
+	creators := make([]*websocket.Conn, rooms)
+	var wg sync.WaitGroup
+	for i := range rooms {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			creator, err := dial()
+			if err != nil {
+				errs <- err
+				return
+			}
+			creators[i] = creator
+			callback, err := send(creator, "create", mywebsoc.EventCreateRoom, mywebsoc.CreateRoomEvent{
+				RoomName: fmt.Sprintf("Room %d", i), RoomSize: playersPerRoom, UserName: "Creator",
+			})
+			if err != nil || !callback.IsSuccess {
+				errs <- fmt.Errorf("create room %d: %v %s", i, err, callback.Message)
+				return
+			}
+			var room mywebsoc.RoomInfo
+			json.Unmarshal(callback.Info, &room)
+
+			var players sync.WaitGroup
+			for p := range playersPerRoom {
+				players.Add(1)
+				go func() {
+					defer players.Done()
+					conn, err := dial()
+					if err != nil {
+						errs <- err
+						return
+					}
+					callback, err := send(conn, "join", mywebsoc.EventJoinRoom, mywebsoc.JoinRoomEvent{
+						RoomID: room.ID, Name: fmt.Sprintf("Player %d", p),
+					})
+					if err != nil || !callback.IsSuccess {
+						errs <- fmt.Errorf("join room %s: %v %s", room.ID, err, callback.Message)
+					}
+				}()
+			}
+			players.Wait()
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+	if t.Failed() {
+		return
+	}
+
+	if got := loadSvr.Rooms.Len(); got != rooms {
+		t.Errorf("Room list size = %d; want %d", got, rooms)
+	}
+	if got := loadSvr.Clients.Len(); got != rooms*(playersPerRoom+1) {
+		t.Errorf("Client list size = %d; want %d", got, rooms*(playersPerRoom+1))
+	}
+	loadSvr.Rooms.Range(func(r *mywebsoc.Room) bool {
+		if n := r.ParticipantCount(); n != playersPerRoom+1 {
+			t.Errorf("Room %s has %d participants; want %d", r.ID, n, playersPerRoom+1)
+		}
+		return true
+	})
+
+	// Rooms close as soon as their creators leave
+	for _, creator := range creators {
+		creator.Close()
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for loadSvr.Rooms.Len() > 0 && time.Now().Before(deadline) {
+		time.Sleep(20 * time.Millisecond)
+	}
+	if got := loadSvr.Rooms.Len(); got != 0 {
+		t.Errorf("%d rooms still open after their creators left", got)
+	}
+}
@@ -0,0 +1,200 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/oblongtable/beanbag-backend/internal/game"
+	"github.com/oblongtable/beanbag-backend/internal/quiz"
+)
+
+type fakeAssignments map[string]*game.Assignment
+
+func (f fakeAssignments) GetAssignment(ctx context.Context, code string) (*game.Assignment, error) {
+	if a, ok := f[code]; ok {
+		return a, nil
+	}
+	return nil, errors.New("assignment not found")
+}
+
+type fakeQuizLoader struct{}
+
+func (fakeQuizLoader) LoadGameQuiz(ctx context.Context, quizID int32) (*quiz.Quiz, error) {
+	question := quiz.Question{
+		QuestionText:       "2 + 2?",
+		Options:            []string{"3", "4"},
+		CorrectOptionIndex: 1,
+		TimeLimit:          1,
+		Points:             100,
+	}
+	return &quiz.Quiz{
+		ID:       quizID,
+		Title:    "Homework",
+		Sections: []quiz.Section{{Section: "Maths", Questions: []quiz.Question{question, question}}},
+	}, nil
+}
+
+type fakeRecorder struct {
+	results chan game.SessionResult
+}
+
+func (f *fakeRecorder) RecordSession(ctx context.Context, result game.SessionResult) error {
+	f.results <- result
+	return nil
+}
+
+type sentMessages struct {
+	mu   sync.Mutex
+	msgs []string
+}
+
+func (s *sentMessages) send(msgType string, payload interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.msgs = append(s.msgs, msgType)
+}
+
+func (s *sentMessages) last() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.msgs) == 0 {
+		return ""
+	}
+	return s.msgs[len(s.msgs)-1]
+}
+
+func TestSelfPacedAttempt(t *testing.T) {
+	now := time.Now()
+	svc := game.NewService()
+	svc.SetQuizLoader(fakeQuizLoader{})
+	svc.SetAssignmentLookup(fakeAssignments{
+		"OPEN": {ID: 1, Code: "OPEN", QuizID: 7, OpensAt: now.Add(-time.Hour), ClosesAt: now.Add(time.Hour)},
+		"SHUT": {ID: 2, Code: "SHUT", QuizID: 7, OpensAt: now.Add(-2 * time.Hour), ClosesAt: now.Add(-time.Hour)},
+	})
+	recorder := &fakeRecorder{results: make(chan game.SessionResult, 1)}
+	svc.SetSessionRecorder(recorder)
+
+	ctx := context.Background()
+	sent := &sentMessages{}
+	alice := game.InitialPlayerInfo{ID: "p1", Username: "Alice"}
+	if _, err := svc.CreateAttempt(ctx, "SHUT", alice, sent.send); err == nil {
+		t.Fatalf("CreateAttempt succeeded for a closed assignment")
+	}
+
+	if _, err := svc.CreateAttempt(ctx, "OPEN", alice, sent.send); err != nil {
+		t.Fatalf("CreateAttempt failed: %v", err)
+	}
+	if _, err := svc.CreateAttempt(ctx, "OPEN", alice, sent.send); err == nil {
+		t.Errorf("CreateAttempt allowed a second attempt in progress")
+	}
+	if err := svc.StartAttempt("p1"); err != nil {
+		t.Fatalf("StartAttempt failed: %v", err)
+	}
+	if got := sent.last(); got != "show_title" {
+		t.Errorf("After start sent %q; want show_title", got)
+	}
+
+	// Question 1: answered correctly
+	if err := svc.AttemptNext("p1"); err != nil {
+		t.Fatalf("AttemptNext failed: %v", err)
+	}
+	if err := svc.AttemptAnswer("p1", 2); !errors.Is(err, game.ErrNoSuchOption) {
+		t.Errorf("AttemptAnswer with option 2 = %v; want ErrNoSuchOption", err)
+	}
+	if err := svc.AttemptAnswer("p1", 1); err != nil {
+		t.Fatalf("AttemptAnswer failed: %v", err)
+	}
+	if got := sent.last(); got != "question_result" {
+		t.Errorf("After answer sent %q; want question_result", got)
+	}
+	if err := svc.AttemptAnswer("p1", 1); err == nil {
+		t.Errorf("AttemptAnswer accepted a second answer")
+	}
+
+	// Question 2: the server-side timer closes it
+	if err := svc.AttemptNext("p1"); err != nil {
+		t.Fatalf("AttemptNext failed: %v", err)
+	}
+	time.Sleep(1500 * time.Millisecond)
+	if got := sent.last(); got != "question_result" {
+		t.Errorf("After timeout sent %q; want question_result", got)
+	}
+	if err := svc.AttemptAnswer("p1", 1); err == nil {
+		t.Errorf("AttemptAnswer accepted an answer after the timer expired")
+	}
+
+	if err := svc.AttemptNext("p1"); err != nil {
+		t.Fatalf("AttemptNext failed: %v", err)
+	}
+	if got := sent.last(); got != "game_over" {
+		t.Errorf("At the end sent %q; want game_over", got)
+	}
+
+	select {
+	case result := <-recorder.results:
+		if result.Mode != game.ModeSelfPaced || result.AssignmentID != 1 {
+			t.Errorf("Recorded mode %s assignment %d; want self_paced assignment 1", result.Mode, result.AssignmentID)
+		}
+		if len(result.Answers) != 2 {
+			t.Fatalf("Recorded %d answers; want 2", len(result.Answers))
+		}
+		if !result.Answers[0].Correct || result.Answers[1].AnswerIndex != -1 {
+			t.Errorf("Recorded answers %+v; want a correct answer then a timeout", result.Answers)
+		}
+		if result.FinishedAt.IsZero() {
+			t.Errorf("Finished attempt recorded without a finish time")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("Attempt was not recorded")
+	}
+}
+
+func TestAttemptEndsWhenAssignmentCloses(t *testing.T) {
+	now := time.Now()
+	svc := game.NewService()
+	svc.SetQuizLoader(fakeQuizLoader{})
+	svc.SetAssignmentLookup(fakeAssignments{
+		"SOON": {ID: 3, Code: "SOON", QuizID: 7, OpensAt: now.Add(-time.Hour), ClosesAt: now.Add(1500 * time.Millisecond)},
+	})
+	recorder := &fakeRecorder{results: make(chan game.SessionResult, 1)}
+	svc.SetSessionRecorder(recorder)
+
+	ctx := context.Background()
+	sent := &sentMessages{}
+	bob := game.InitialPlayerInfo{ID: "p2", Username: "Bob", UserID: 9}
+	if _, err := svc.CreateAttempt(ctx, "SOON", bob, sent.send); err != nil {
+		t.Fatalf("CreateAttempt failed: %v", err)
+	}
+	// The same user on another connection can't start a second attempt
+	if _, err := svc.CreateAttempt(ctx, "SOON", game.InitialPlayerInfo{ID: "p3", Username: "Bob", UserID: 9}, sent.send); !errors.Is(err, game.ErrAttemptInProgress) {
+		t.Errorf("Second attempt of user 9 = %v; want ErrAttemptInProgress", err)
+	}
+	if err := svc.StartAttempt("p2"); err != nil {
+		t.Fatalf("StartAttempt failed: %v", err)
+	}
+	if err := svc.AttemptNext("p2"); err != nil {
+		t.Fatalf("AttemptNext failed: %v", err)
+	}
+	if err := svc.AttemptAnswer("p2", 1); err != nil {
+		t.Fatalf("AttemptAnswer failed: %v", err)
+	}
+
+	// Bob idles on the result until the assignment closes
+	select {
+	case result := <-recorder.results:
+		if result.FinishedAt.IsZero() || len(result.Answers) != 1 {
+			t.Errorf("Recorded %d answers, finished at %v; want the one answer, finished", len(result.Answers), result.FinishedAt)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatalf("Attempt did not end when the assignment closed")
+	}
+	if got := sent.last(); got != "game_over" {
+		t.Errorf("At close sent %q; want game_over", got)
+	}
+	if err := svc.AttemptNext("p2"); err == nil {
+		t.Errorf("AttemptNext succeeded after the assignment closed")
+	}
+}
@@ -0,0 +1,73 @@
+package test
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/oblongtable/beanbag-backend/db"
+	"github.com/oblongtable/beanbag-backend/internal/services"
+)
+
+func TestSessionResultsOnlyForQuizCreator(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeDB(t)
+	fake.rows("GetQuiz", []any{int32(4), int32(7), "Capitals", nil, false, int32(20), time.Now(), time.Now()})
+	fake.rows("ListGameSessionsByQuiz", []any{int32(30), "K7QX", int32(4), nil, "live", time.Now(), time.Now(), time.Now()})
+	fake.rows("GetGameSession", []any{int32(30), "K7QX", int32(4), nil, "live", time.Now(), time.Now(), time.Now()})
+	fake.rows("ListSessionPlayerSummaries")
+	fake.rows("ListSessionQuestionStats")
+	fake.rows("ListSessionAnswerCounts")
+	fake.rows("ListSessionFastestCorrect")
+	sessions := services.NewSessionService(fake.DB(), db.New(fake.DB()))
+
+	if list, err := sessions.ListQuizSessions(ctx, 4, 7); err != nil || len(list) != 1 {
+		t.Errorf("Creator listing sessions = %v, %v; want the session", list, err)
+	}
+	if _, err := sessions.ListQuizSessions(ctx, 4, 8); !errors.Is(err, services.ErrNotQuizOwner) {
+		t.Errorf("Another teacher listing sessions = %v; want ErrNotQuizOwner", err)
+	}
+	if _, err := sessions.GetSessionResults(ctx, 30, 7); err != nil {
+		t.Errorf("Creator getting results failed: %v", err)
+	}
+	if _, err := sessions.GetSessionResults(ctx, 30, 8); !errors.Is(err, services.ErrNotQuizOwner) {
+		t.Errorf("Another teacher getting results = %v; want ErrNotQuizOwner", err)
+	}
+
+	// Games of the built-in quiz belong to nobody
+	builtIn := newFakeDB(t)
+	builtIn.rows("GetGameSession", []any{int32(31), "ABCD", nil, nil, "live", time.Now(), time.Now(), time.Now()})
+	if _, err := services.NewSessionService(builtIn.DB(), db.New(builtIn.DB())).GetSessionResults(ctx, 31, 7); !errors.Is(err, services.ErrNotQuizOwner) {
+		t.Errorf("Results of a built-in quiz game = %v; want ErrNotQuizOwner", err)
+	}
+}
+
+func TestAssignmentResultsOnlyForCreator(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeDB(t)
+	setBy := any(int32(9))
+	fake.on("GetAssignmentByCode", func([]driver.Value) ([][]any, error) {
+		return [][]any{{int32(5), "ABCDEFGH", int32(4), setBy, time.Now(), time.Now(), time.Now()}}, nil
+	})
+	fake.rows("GetQuiz", []any{int32(4), int32(7), "Capitals", nil, false, int32(20), time.Now(), time.Now()})
+	fake.rows("GetGameSessionByAssignment", []any{int32(30), "ABCDEFGH", int32(4), int32(5), "live", time.Now(), time.Now(), time.Now()})
+	fake.rows("ListSessionPlayerSummaries")
+	assignments := services.NewAssignmentService(db.New(fake.DB()))
+
+	// The teacher who set it and the quiz's creator may both see the results
+	for _, userID := range []int32{9, 7} {
+		if _, err := assignments.AssignmentResults(ctx, "ABCDEFGH", userID); err != nil {
+			t.Errorf("User %d getting results failed: %v", userID, err)
+		}
+	}
+	if _, err := assignments.AssignmentResults(ctx, "ABCDEFGH", 8); !errors.Is(err, services.ErrNotQuizOwner) {
+		t.Errorf("Another teacher getting results = %v; want ErrNotQuizOwner", err)
+	}
+
+	setBy = nil
+	if _, err := assignments.AssignmentResults(ctx, "ABCDEFGH", 9); !errors.Is(err, services.ErrNotQuizOwner) {
+		t.Errorf("Results of an assignment with no creator = %v; want ErrNotQuizOwner", err)
+	}
+}
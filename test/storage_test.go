@@ -0,0 +1,113 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/oblongtable/beanbag-backend/internal/storage"
+)
+
+// fakeS3 is a minimal in-memory stand-in for an S3-compatible API.
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+	types   map[string]string
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=test-key/") || !strings.Contains(auth, "Signature=") {
+		http.Error(w, "missing signature", http.StatusForbidden)
+		return
+	}
+	if r.Header.Get("X-Amz-Date") == "" {
+		http.Error(w, "missing date", http.StatusForbidden)
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch r.Method {
+	case http.MethodPut:
+		body, _ := io.ReadAll(r.Body)
+		f.objects[r.URL.Path] = body
+		f.types[r.URL.Path] = r.Header.Get("Content-Type")
+		w.WriteHeader(http.StatusOK)
+	case http.MethodGet:
+		body, ok := f.objects[r.URL.Path]
+		if !ok {
+			http.Error(w, "NoSuchKey", http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", f.types[r.URL.Path])
+		w.Write(body)
+	case http.MethodDelete:
+		delete(f.objects, r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func exerciseBlobStore(t *testing.T, store storage.BlobStore) {
+	ctx := context.Background()
+	data := []byte("\x89PNG\r\n\x1a\nnot really a png")
+
+	if err := store.Put(ctx, "images/abc.png", bytes.NewReader(data), int64(len(data)), "image/png"); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+
+	body, contentType, err := store.Get(ctx, "images/abc.png")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	got, _ := io.ReadAll(body)
+	body.Close()
+	if !bytes.Equal(got, data) {
+		t.Errorf("Get returned %q; want %q", got, data)
+	}
+	if contentType != "image/png" {
+		t.Errorf("Content type = %q; want image/png", contentType)
+	}
+
+	if err := store.Delete(ctx, "images/abc.png"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, _, err := store.Get(ctx, "images/abc.png"); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("Get after delete returned %v; want ErrNotFound", err)
+	}
+}
+
+func TestLocalBlobStore(t *testing.T) {
+	store, err := storage.NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewLocalStore failed: %v", err)
+	}
+	exerciseBlobStore(t, store)
+
+	if err := store.Put(context.Background(), "../escape.png", strings.NewReader("x"), 1, "image/png"); err == nil {
+		t.Errorf("Put accepted a key outside the media directory")
+	}
+}
+
+func TestS3BlobStore(t *testing.T) {
+	fake := &fakeS3{objects: map[string][]byte{}, types: map[string]string{}}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	store, err := storage.NewS3Store(storage.S3Config{
+		Endpoint:  srv.URL,
+		Bucket:    "beanbag-media",
+		AccessKey: "test-key",
+		SecretKey: "test-secret",
+	})
+	if err != nil {
+		t.Fatalf("NewS3Store failed: %v", err)
+	}
+	exerciseBlobStore(t, store)
+}
@@ -0,0 +1,124 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/oblongtable/beanbag-backend/internal/game"
+	"github.com/oblongtable/beanbag-backend/internal/tracing"
+	mywebsoc "github.com/oblongtable/beanbag-backend/websocket"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// spanRecorder records the spans of the whole test package. Tracers follow the first
+// provider installed globally, so it is installed once.
+var spanRecorder = func() *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	return recorder
+}()
+
+// waitForSpan returns the first ended span with the given name that matches, failing the test after a while.
+func waitForSpan(t *testing.T, name string, match func(sdktrace.ReadOnlySpan) bool) sdktrace.ReadOnlySpan {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		for _, span := range spanRecorder.Ended() {
+			if span.Name() == name && match(span) {
+				return span
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("No %s span was recorded", name)
+	return nil
+}
+
+// sendAndWait sends an event and reads messages until one of the given type arrives.
+func sendAndWait(t *testing.T, conn *websocket.Conn, evtType string, payload interface{}, msgType string) mywebsoc.EventCallbackMessage {
+	t.Helper()
+	info, _ := json.Marshal(payload)
+	if err := conn.WriteJSON(mywebsoc.Event{ID: evtType, Type: evtType, Payload: info}); err != nil {
+		t.Fatalf("WriteJSON failed: %v", err)
+	}
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	for {
+		var msg mywebsoc.EventCallbackMessage
+		if err := conn.ReadJSON(&msg); err != nil {
+			t.Fatalf("Waiting for %s: %v", msgType, err)
+		}
+		if msg.Type == msgType {
+			return msg
+		}
+	}
+}
+
+func TestStartQuizTrace(t *testing.T) {
+	traceSvr := mywebsoc.NewWebSockServer()
+	engine := gin.New()
+	engine.GET("/ws", traceSvr.ServeWs)
+	httpSvr := httptest.NewServer(engine)
+	defer httpSvr.Close()
+	wsURL := "ws" + strings.TrimPrefix(httpSvr.URL, "http") + "/ws"
+
+	creator, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer creator.Close()
+	host, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer host.Close()
+
+	created := sendAndWait(t, creator, mywebsoc.EventCreateRoom, mywebsoc.CreateRoomEvent{RoomName: "Traced", RoomSize: 4, UserName: "Alice"}, mywebsoc.MessageCreateRoom)
+	var room mywebsoc.RoomInfo
+	json.Unmarshal(created.Info, &room)
+	sendAndWait(t, host, mywebsoc.EventJoinRoom, mywebsoc.JoinRoomEvent{RoomID: room.ID, Name: "Bob"}, mywebsoc.MessageJoinRoom)
+	sendAndWait(t, host, mywebsoc.EventStartQuiz, mywebsoc.StartQuizEvent{RoomID: room.ID}, game.MessageShowTitle)
+
+	// ws start_quiz -> game.create and game.title -> room.broadcast of the title screen
+	event := waitForSpan(t, "ws "+mywebsoc.EventStartQuiz, func(span sdktrace.ReadOnlySpan) bool {
+		for _, attr := range span.Attributes() {
+			if attr.Key == "ws.room.id" && attr.Value.AsString() == room.ID {
+				return true
+			}
+		}
+		return false
+	})
+	childOf := func(parent sdktrace.ReadOnlySpan) func(sdktrace.ReadOnlySpan) bool {
+		return func(span sdktrace.ReadOnlySpan) bool {
+			return span.Parent().SpanID() == parent.SpanContext().SpanID()
+		}
+	}
+	waitForSpan(t, "game.create", childOf(event))
+	title := waitForSpan(t, "game.title", childOf(event))
+	waitForSpan(t, "room.broadcast", childOf(title))
+	if event.Status().Code == codes.Error {
+		t.Errorf("start_quiz span failed: %s", event.Status().Description)
+	}
+}
+
+func TestDBQueryTrace(t *testing.T) {
+	conn := tracing.TraceDB(failingDB{})
+	ctx, parent := otel.Tracer("test").Start(context.Background(), "parent")
+	conn.ExecContext(ctx, "-- name: CreateUser :one\nINSERT INTO users DEFAULT VALUES")
+	parent.End()
+
+	span := waitForSpan(t, "db.CreateUser", func(span sdktrace.ReadOnlySpan) bool {
+		return span.Parent().SpanID() == parent.SpanContext().SpanID()
+	})
+	if span.Status().Code != codes.Error {
+		t.Errorf("Expected the failed query's span to have an error status, got %v", span.Status())
+	}
+}
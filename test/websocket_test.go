@@ -45,8 +45,8 @@ func TestUserRegister(t *testing.T) {
 	}
 	time.Sleep(DELAY)
 	fmt.Println(wssvr.Clients)
-	if len(wssvr.Clients) != 1 {
-		t.Errorf("Client list size != 1; Expected 1; Current %d", len(wssvr.Clients))
+	if wssvr.Clients.Len() != 1 {
+		t.Errorf("Client list size != 1; Expected 1; Current %d", wssvr.Clients.Len())
 	}
 }
 
@@ -75,8 +75,8 @@ func TestUserCreateRoom(t *testing.T) {
 	time.Sleep(DELAY)
 
 	fmt.Println(wssvr.Rooms)
-	if len(wssvr.Rooms) != 1 {
-		t.Errorf("Room list size != 1; Expected 1; Current %d", len(wssvr.Rooms))
+	if wssvr.Rooms.Len() != 1 {
+		t.Errorf("Room list size != 1; Expected 1; Current %d", wssvr.Rooms.Len())
 	}
 
 	var evtCbMsg mywebsoc.EventCallbackMessage
@@ -0,0 +1,63 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	mywebsoc "github.com/oblongtable/beanbag-backend/websocket"
+)
+
+type fakeAuthenticator map[string]int32
+
+func (f fakeAuthenticator) Authenticate(ctx context.Context, token string) (int32, error) {
+	if userID, ok := f[token]; ok {
+		return userID, nil
+	}
+	return 0, errors.New("unknown token")
+}
+
+func TestWebsocketAuthentication(t *testing.T) {
+	authSvr := mywebsoc.NewWebSockServer()
+	authSvr.Auth = fakeAuthenticator{"good": 42}
+	engine := gin.New()
+	engine.GET("/ws", authSvr.ServeWs)
+	httpSvr := httptest.NewServer(engine)
+	defer httpSvr.Close()
+	wsURL := "ws" + strings.TrimPrefix(httpSvr.URL, "http") + "/ws"
+
+	_, res, err := websocket.DefaultDialer.Dial(wsURL+"?token=bad", nil)
+	if err == nil {
+		t.Fatalf("Dial with an invalid token succeeded")
+	}
+	if res == nil || res.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Dial with an invalid token got %v; want 401", res)
+	}
+
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL+"?token=good", nil)
+	if err != nil {
+		t.Fatalf("Dial with a valid token failed: %v", err)
+	}
+	defer conn.Close()
+	guest, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	if err != nil {
+		t.Fatalf("Dial without a token failed: %v", err)
+	}
+	defer guest.Close()
+	time.Sleep(100 * time.Millisecond)
+
+	userIDs := map[int32]int{}
+	authSvr.Clients.Range(func(c *mywebsoc.Client) bool {
+		userIDs[c.UserID]++
+		return true
+	})
+	if userIDs[42] != 1 || userIDs[0] != 1 {
+		t.Errorf("Connected clients have user IDs %v; want one 42 and one guest", userIDs)
+	}
+}
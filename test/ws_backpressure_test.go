@@ -0,0 +1,88 @@
+package test
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	mywebsoc "github.com/oblongtable/beanbag-backend/websocket"
+)
+
+func TestSlowClientQueue(t *testing.T) {
+	slowSvr := mywebsoc.NewWebSockServer()
+	slowSvr.SetQueueLimits(mywebsoc.QueueLimits{Size: 8, MaxBehind: 300 * time.Millisecond})
+	engine := gin.New()
+	engine.GET("/ws", slowSvr.ServeWs)
+	httpSvr := httptest.NewServer(engine)
+	defer httpSvr.Close()
+	wsURL := "ws" + strings.TrimPrefix(httpSvr.URL, "http") + "/ws"
+
+	request := func(conn *websocket.Conn, eventType string, payload interface{}) mywebsoc.RoomInfo {
+		t.Helper()
+		info, _ := json.Marshal(payload)
+		conn.WriteJSON(mywebsoc.Event{Version: mywebsoc.ProtocolVersion, ID: eventType, Type: eventType, Payload: info})
+		conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		for {
+			var callback mywebsoc.EventCallbackMessage
+			if err := conn.ReadJSON(&callback); err != nil {
+				t.Fatalf("%s: ReadJSON failed: %v", eventType, err)
+			}
+			if callback.CorrelationID == eventType {
+				var room mywebsoc.RoomInfo
+				json.Unmarshal(callback.Info, &room)
+				return room
+			}
+		}
+	}
+
+	slow, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer slow.Close()
+	room := request(slow, mywebsoc.EventCreateRoom, mywebsoc.CreateRoomEvent{RoomName: "Slow", RoomSize: 4, UserName: "Slowpoke"})
+
+	// From here on the slow client stops reading. Messages bigger than the socket buffers stall its writer.
+	big := strings.Repeat("x", 8<<20)
+	for range 4 {
+		slowSvr.NotifyPlayer(room.SenderID, "big", big)
+	}
+
+	// Room updates for the slow client replace each other while they wait
+	other, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer other.Close()
+	request(other, mywebsoc.EventJoinRoom, mywebsoc.JoinRoomEvent{RoomID: room.ID, Name: "Bob"})
+	request(other, mywebsoc.EventLeaveRoom, mywebsoc.LeaveRoomEvent{RoomID: room.ID})
+	request(other, mywebsoc.EventJoinRoom, mywebsoc.JoinRoomEvent{RoomID: room.ID, Name: "Bob"})
+	if stats := slowSvr.QueueStats(); stats.Coalesced == 0 {
+		t.Errorf("QueueStats %+v; want coalesced room updates", stats)
+	}
+
+	// Overflow the queue, then stay behind for longer than allowed
+	for range 16 {
+		slowSvr.NotifyPlayer(room.SenderID, "small", "x")
+	}
+	if stats := slowSvr.QueueStats(); stats.Dropped == 0 {
+		t.Errorf("QueueStats %+v; want dropped messages", stats)
+	}
+	time.Sleep(400 * time.Millisecond)
+	slowSvr.NotifyPlayer(room.SenderID, "small", "x")
+
+	deadline := time.Now().Add(3 * time.Second)
+	for slowSvr.Clients.Len() > 1 && time.Now().Before(deadline) {
+		time.Sleep(20 * time.Millisecond)
+	}
+	if stats := slowSvr.QueueStats(); stats.SlowDisconnects != 1 {
+		t.Errorf("QueueStats %+v; want one slow disconnect", stats)
+	}
+	if n := slowSvr.Clients.Len(); n != 1 {
+		t.Errorf("%d clients connected; want only the one keeping up", n)
+	}
+}
@@ -0,0 +1,130 @@
+package test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/oblongtable/beanbag-backend/middleware"
+	mywebsoc "github.com/oblongtable/beanbag-backend/websocket"
+)
+
+func TestEventBudgetsRateLimit(t *testing.T) {
+	budgets, err := mywebsoc.ParseEventBudgets("join_room=0.01/1, submit_answer=2/3")
+	if err != nil {
+		t.Fatalf("ParseEventBudgets failed: %v", err)
+	}
+	if budgets[mywebsoc.EventSubmitAnswer] != (mywebsoc.Limit{Rate: 2, Burst: 3}) {
+		t.Errorf("submit_answer budget = %+v; want 2/3", budgets[mywebsoc.EventSubmitAnswer])
+	}
+	if _, err := mywebsoc.ParseEventBudgets("join_room=fast"); err == nil {
+		t.Errorf("ParseEventBudgets accepted a budget without a burst")
+	}
+
+	limitSvr := mywebsoc.NewWebSockServer()
+	limitSvr.SetRateLimits(mywebsoc.RateLimits{Events: budgets})
+	engine := gin.New()
+	engine.GET("/ws", limitSvr.ServeWs)
+	httpSvr := httptest.NewServer(engine)
+	defer httpSvr.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(httpSvr.URL, "http")+"/ws", nil)
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer conn.Close()
+
+	info, _ := json.Marshal(mywebsoc.JoinRoomEvent{RoomID: "NOPE", Name: "Bob"})
+	for _, id := range []string{"first", "second"} {
+		conn.WriteJSON(mywebsoc.Event{Version: mywebsoc.ProtocolVersion, ID: id, Type: mywebsoc.EventJoinRoom, Payload: info})
+	}
+
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	replies := map[string]mywebsoc.Event{}
+	for range 2 {
+		var evt mywebsoc.Event
+		if err := conn.ReadJSON(&evt); err != nil {
+			t.Fatalf("ReadJSON failed: %v", err)
+		}
+		replies[evt.Type] = evt
+	}
+	if _, ok := replies[mywebsoc.MessageJoinRoom]; !ok {
+		t.Errorf("First join_room got no callback: %v", replies)
+	}
+	limited, ok := replies[mywebsoc.MessageRateLimited]
+	if !ok {
+		t.Fatalf("Second join_room was not rate limited: %v", replies)
+	}
+	var limitedInfo mywebsoc.RateLimitedInfo
+	json.Unmarshal(limited.Payload, &limitedInfo)
+	if limited.CorrelationID != "second" || limitedInfo.Event != mywebsoc.EventJoinRoom || limitedInfo.RetryAfterMs <= 0 {
+		t.Errorf("rate_limited = %s %s; want the second join_room with a retry delay", limited.CorrelationID, limited.Payload)
+	}
+}
+
+func TestFloodingClientDisconnected(t *testing.T) {
+	limitSvr := mywebsoc.NewWebSockServer()
+	limitSvr.SetRateLimits(mywebsoc.RateLimits{Client: mywebsoc.Limit{Rate: 0.01, Burst: 1}})
+	engine := gin.New()
+	engine.GET("/ws", limitSvr.ServeWs)
+	httpSvr := httptest.NewServer(engine)
+	defer httpSvr.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(httpSvr.URL, "http")+"/ws", nil)
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer conn.Close()
+
+	// One event goes through and the next is rate limited, then ignoring retry_after_ms
+	// costs a strike per event until the fifth disconnects the client
+	info, _ := json.Marshal(mywebsoc.JoinRoomEvent{RoomID: "NOPE", Name: "Bob"})
+	for range 7 {
+		conn.WriteJSON(mywebsoc.Event{Version: mywebsoc.ProtocolVersion, Type: mywebsoc.EventJoinRoom, Payload: info})
+	}
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	for {
+		if _, _, err = conn.ReadMessage(); err != nil {
+			break
+		}
+	}
+	if !websocket.IsCloseError(err, websocket.ClosePolicyViolation) {
+		t.Errorf("Flooding client got %v; want a policy violation close", err)
+	}
+}
+
+func TestTrustedProxies(t *testing.T) {
+	clientIP := func(proxies, platform string, header string, value string) string {
+		t.Helper()
+		engine := gin.New()
+		if err := middleware.TrustProxies(engine, proxies, platform); err != nil {
+			t.Fatalf("TrustProxies failed: %v", err)
+		}
+		engine.GET("/ip", func(c *gin.Context) { c.String(http.StatusOK, c.ClientIP()) })
+		req := httptest.NewRequest(http.MethodGet, "/ip", nil)
+		req.RemoteAddr = "10.0.0.2:1234"
+		req.Header.Set(header, value)
+		rec := httptest.NewRecorder()
+		engine.ServeHTTP(rec, req)
+		return rec.Body.String()
+	}
+
+	// Anyone can send X-Forwarded-For, it must not dodge the per-IP limit
+	if ip := clientIP("", "", "X-Forwarded-For", "203.0.113.9"); ip != "10.0.0.2" {
+		t.Errorf("Without trusted proxies ClientIP = %s; want the remote address", ip)
+	}
+	if ip := clientIP("10.0.0.0/8, 192.168.0.1", "", "X-Forwarded-For", "203.0.113.9"); ip != "203.0.113.9" {
+		t.Errorf("Behind a trusted proxy ClientIP = %s; want the forwarded address", ip)
+	}
+	if ip := clientIP("", "CF-Connecting-IP", "CF-Connecting-IP", "203.0.113.9"); ip != "203.0.113.9" {
+		t.Errorf("With a trusted platform ClientIP = %s; want the platform's header", ip)
+	}
+	if err := middleware.TrustProxies(gin.New(), "not an ip", ""); err == nil {
+		t.Errorf("TrustProxies accepted an invalid proxy")
+	}
+}
@@ -0,0 +1,69 @@
+package test
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	mywebsoc "github.com/oblongtable/beanbag-backend/websocket"
+)
+
+func TestBadEventsGetErrorsThenDisconnect(t *testing.T) {
+	strikeSvr := mywebsoc.NewWebSockServer()
+	engine := gin.New()
+	engine.GET("/ws", strikeSvr.ServeWs)
+	httpSvr := httptest.NewServer(engine)
+	defer httpSvr.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(httpSvr.URL, "http")+"/ws", nil)
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer conn.Close()
+
+	readError := func() (mywebsoc.Event, mywebsoc.ErrorInfo) {
+		t.Helper()
+		conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		var evt mywebsoc.Event
+		if err := conn.ReadJSON(&evt); err != nil {
+			t.Fatalf("ReadJSON failed: %v", err)
+		}
+		var info mywebsoc.ErrorInfo
+		if evt.Type != mywebsoc.MessageError || json.Unmarshal(evt.Payload, &info) != nil {
+			t.Fatalf("Got %s %s; want an error message", evt.Type, evt.Payload)
+		}
+		return evt, info
+	}
+
+	badPayload, _ := json.Marshal(mywebsoc.JoinRoomEvent{Name: "Bob"})
+	bad := []struct {
+		frame string
+		code  mywebsoc.ErrorCode
+	}{
+		{`{"type": "join_room", "info":`, mywebsoc.CodeMalformedEvent},
+		{`{"v": 2, "id": "e2", "type": "fly_away"}`, mywebsoc.CodeUnknownEvent},
+		{`{"v": 2, "id": "e3", "type": "join_room", "info": ` + string(badPayload) + `}`, mywebsoc.CodeInvalidPayload},
+		{`{"v": 99, "id": "e4", "type": "join_room"}`, mywebsoc.CodeUnsupportedVersion},
+	}
+	for i, b := range bad {
+		conn.WriteMessage(websocket.TextMessage, []byte(b.frame))
+		evt, info := readError()
+		if info.Code != b.code {
+			t.Errorf("Frame %d got code %s; want %s", i, info.Code, b.code)
+		}
+		if i > 0 && evt.CorrelationID == "" {
+			t.Errorf("Frame %d error has no correlation ID", i)
+		}
+	}
+
+	// Still connected, the next bad event is the fifth strike
+	conn.WriteMessage(websocket.TextMessage, []byte(`not json`))
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	if _, _, err := conn.ReadMessage(); !websocket.IsCloseError(err, websocket.ClosePolicyViolation) {
+		t.Errorf("After five bad events got %v; want a policy violation close", err)
+	}
+}
@@ -3,11 +3,13 @@ package websocket
 import (
 	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
+	"github.com/oblongtable/beanbag-backend/internal/metrics"
 )
 
 var (
@@ -17,50 +19,92 @@ var (
 
 	// Maximum message size allowed from peer.
 	maxMessageSize = 512
+
+	// Bad events tolerated within strikeWindow before the client is disconnected.
+	maxStrikes   = 5
+	strikeWindow = time.Minute
 )
 
 type Client struct {
 	ID       string // UUID
 	Username string // Not sure how to get it upon init, set to dummy for now
-	RoomID   string // Room Joined
+	UserID   int32  // users.user_id when connected with a valid token, 0 for guests
+	IP       string // Address the client connected from
 	Conn     *websocket.Conn
 
 	Wssvr *WebSocServer
 
-	// Buffered channel of outbound messages.
-	Send chan []byte
+	// Outbound messages waiting for WriteMessage.
+	out *outbox
+
+	// Bad events sent since strikesSince, only touched by ReadMessage.
+	strikes      int
+	strikesSince time.Time
+
+	limiter        *clientLimiter // Nil when the server has no rate limits
+	throttledUntil time.Time      // Retry delay of the last rate limited event, only touched by ReadMessage
+
+	mu     sync.Mutex
+	roomID string // Room joined, set by the room's goroutine
+}
+
+// logger returns a logger with the client's ID and, while it is in one, its room's ID.
+func (c *Client) logger() *slog.Logger {
+	logger := slog.Default().With("client_id", c.ID)
+	if roomID := c.RoomID(); roomID != "" {
+		logger = logger.With("room_id", roomID)
+	}
+	return logger
+}
+
+func (c *Client) String() string {
+	return fmt.Sprintf("Client {ID:\"%s\", Username:\"%s\", RoomID:\"%s\"}", c.ID, c.Username, c.RoomID())
 }
 
-func (c Client) String() string {
-	return fmt.Sprintf("Client {ID:\"%s\", Username:\"%s\", RoomID:\"%s\"}", c.ID, c.Username, c.RoomID)
+// RoomID returns the code of the room the client is in, "" if none.
+func (c *Client) RoomID() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.roomID
 }
 
-func NewClient(conn *websocket.Conn, wssvr *WebSocServer) (c *Client) {
+func (c *Client) setRoomID(roomID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.roomID = roomID
+}
+
+func NewClient(conn *websocket.Conn, wssvr *WebSocServer, userID int32, ip string) (c *Client) {
 	c = &Client{
 		ID:       uuid.New().String(),
 		Username: "foo",
+		UserID:   userID,
+		IP:       ip,
 		Conn:     conn,
 		Wssvr:    wssvr,
-		Send:     make(chan []byte, 512),
 	}
+	c.out = newOutbox(c.ID, wssvr.queueLimits, &wssvr.queueCounters)
 
-	wssvr.Register <- c
+	if wssvr.limits != nil {
+		c.limiter = newClientLimiter(wssvr.limits)
+	}
+
+	wssvr.AddClient(c)
 	return c
 }
 
 func (c *Client) PongHandler(pongMsg string) error {
-	log.Println("pong")
+	c.logger().Debug("pong")
 	return c.Conn.SetReadDeadline(time.Now().Add(pongWait))
 }
 
 func (c *Client) ReadMessage() {
 	defer func() {
-		c.Wssvr.Unregister <- c
-		fmt.Println("ReadMessage ERR: Client removed")
+		c.Wssvr.RemoveClient(c)
+		c.logger().Debug("reader stopped, client removed")
 	}()
 	if err := c.Conn.SetReadDeadline(time.Now().Add(pongWait)); err != nil {
-		log.Println(err)
-		fmt.Println(err)
+		c.logger().Error("failed to set read deadline", "error", err)
 		return
 	}
 	c.Conn.SetReadLimit(int64(maxMessageSize))
@@ -71,52 +115,108 @@ func (c *Client) ReadMessage() {
 
 		if err != nil {
 			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
-				log.Println("Unexpected Close error")
-
+				c.logger().Warn("unexpected close", "error", err)
+			} else {
+				c.logger().Info("connection closed", "error", err)
 			}
-			log.Println(err)
-			fmt.Println(evtJson)
 			break
 		}
-		fmt.Println(evtJson)
+		c.logger().Debug("event received", "event", string(evtJson))
 		var evt Event
 		if err := json.Unmarshal(evtJson, &evt); err != nil {
-			log.Printf("error marshalling event: %v", err)
-			break
+			metrics.EventsReceived.WithLabelValues("malformed").Inc()
+			if !c.strike("", fmt.Errorf("%w: %v", ErrMalformedEvent, err)) {
+				break
+			}
+			continue
+		}
+		if _, known := c.Wssvr.Handlers[evt.Type]; known {
+			metrics.EventsReceived.WithLabelValues(evt.Type).Inc()
+		} else {
+			metrics.EventsReceived.WithLabelValues("unknown").Inc()
+		}
+		if ok, wait := c.allow(evt.Type); !ok {
+			c.logger().Warn("rate limited", "event_type", evt.Type, "retry_after", wait)
+			// Clients that keep sending before their retry delay is up are flooding, not unlucky
+			now := time.Now()
+			if now.Before(c.throttledUntil) && !c.countStrike(fmt.Errorf("%w: %s", ErrRateLimited, evt.Type)) {
+				break
+			}
+			c.throttledUntil = now.Add(wait)
+			SendRateLimited(c, evt.ID, evt.Type, wait)
+			continue
 		}
 		if err := c.Wssvr.RouteEvent(&evt, c); err != nil {
-			log.Println("error handling message: ", err)
-			break
+			if !c.strike(evt.ID, err) {
+				break
+			}
 		}
 	}
 }
 
 func (c *Client) WriteMessage() {
 	defer func() {
-		c.Wssvr.Unregister <- c
-		fmt.Println("WriteMessage ERR: Client removed")
+		c.Wssvr.RemoveClient(c)
+		c.logger().Debug("writer stopped, client removed")
 	}()
 	ticker := time.NewTicker(pingInterval)
 	for {
 		select {
-		case data, ok := <-c.Send:
-			if !ok {
-				if err := c.Conn.WriteMessage(websocket.CloseMessage, nil); err != nil {
-					log.Println("connection closed")
+		case <-c.out.ready:
+			for {
+				msg, ok := c.out.next()
+				if !ok {
+					break
+				}
+				if err := c.Conn.WriteMessage(websocket.TextMessage, msg.data); err != nil {
+					c.logger().Info("failed to send message", "error", err)
+					return
+				}
+				if !msg.broadcastAt.IsZero() {
+					metrics.BroadcastLatency.Observe(time.Since(msg.broadcastAt).Seconds())
 				}
-				return
-			}
-
-			if err := c.Conn.WriteMessage(websocket.TextMessage, data); err != nil {
-				log.Printf("failed to send message: %v", err)
 			}
-			log.Println("message sent")
+		case <-c.out.done:
+			return
 		case <-ticker.C:
-			log.Println("Ping")
+			c.logger().Debug("ping")
 			if err := c.Conn.WriteMessage(websocket.PingMessage, []byte(``)); err != nil {
-				log.Println("writeMessage Error:", err)
+				c.logger().Info("failed to send ping", "error", err)
 				return
 			}
 		}
 	}
 }
+
+// strike tells the client their event was rejected and counts it against them.
+// It returns false once the client has sent too many bad events and should be disconnected.
+func (c *Client) strike(correlationID string, err error) bool {
+	if !c.countStrike(err) {
+		return false
+	}
+	SendError(c, correlationID, err)
+	return true
+}
+
+// countStrike counts a bad event against the client. Once they have sent too many
+// it tells them why in the close frame and returns false.
+func (c *Client) countStrike(err error) bool {
+	now := time.Now()
+	if now.Sub(c.strikesSince) > strikeWindow {
+		c.strikes = 0
+		c.strikesSince = now
+	}
+	c.strikes++
+
+	c.logger().Warn("event rejected", "strike", c.strikes, "max_strikes", maxStrikes, "error", err)
+	if c.strikes < maxStrikes {
+		return true
+	}
+
+	// The connection closes before queued messages are flushed, so say why in the close frame
+	closeMsg := websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "too many bad events")
+	if err := c.Conn.WriteControl(websocket.CloseMessage, closeMsg, time.Now().Add(time.Second)); err != nil {
+		c.logger().Info("failed to send close message", "error", err)
+	}
+	return false
+}
@@ -0,0 +1,112 @@
+package websocket
+
+import (
+	"errors"
+
+	"github.com/oblongtable/beanbag-backend/internal/game"
+	"github.com/oblongtable/beanbag-backend/internal/roomcode"
+)
+
+// Errors of the room logic.
+var (
+	ErrMalformedEvent     = errors.New("malformed event")
+	ErrUnknownEvent       = errors.New("there is no such event")
+	ErrUnsupportedVersion = errors.New("unsupported protocol version")
+	ErrInvalidPayload     = errors.New("invalid event payload")
+	ErrRoomNotFound       = errors.New("room not found")
+	ErrRoomFull           = errors.New("room is full")
+	ErrRoomTooLarge       = errors.New("room size is too large")
+	ErrAlreadyInRoom      = errors.New("you have already joined a room")
+	ErrNotInRoom          = errors.New("you haven't joined a room")
+	ErrNotRoomHost        = errors.New("only the room host can do this")
+	ErrServerBusy         = errors.New("server overloaded, please try again later")
+	ErrRateLimited        = errors.New("rate limited")
+)
+
+// ErrorCode tells clients why an event failed, so they don't have to match messages.
+type ErrorCode string
+
+const (
+	CodeMalformedEvent      ErrorCode = "MALFORMED_EVENT"
+	CodeUnknownEvent        ErrorCode = "UNKNOWN_EVENT"
+	CodeUnsupportedVersion  ErrorCode = "UNSUPPORTED_VERSION"
+	CodeInvalidPayload      ErrorCode = "INVALID_PAYLOAD"
+	CodeRoomNotFound        ErrorCode = "ROOM_NOT_FOUND"
+	CodeRoomFull            ErrorCode = "ROOM_FULL"
+	CodeRoomTooLarge        ErrorCode = "ROOM_TOO_LARGE"
+	CodeAlreadyInRoom       ErrorCode = "ALREADY_IN_ROOM"
+	CodeNotInRoom           ErrorCode = "NOT_IN_ROOM"
+	CodeNotHost             ErrorCode = "NOT_HOST"
+	CodeServerBusy          ErrorCode = "SERVER_BUSY"
+	CodeRoomCodeNotReserved ErrorCode = "ROOM_CODE_NOT_RESERVED"
+	CodeRoomCodeInUse       ErrorCode = "ROOM_CODE_IN_USE"
+	CodeGameNotFound        ErrorCode = "GAME_NOT_FOUND"
+	CodeInvalidGameState    ErrorCode = "INVALID_GAME_STATE"
+	CodeNotAcceptingAnswers ErrorCode = "GAME_NOT_ACCEPTING_ANSWERS"
+	CodeAlreadyAnswered     ErrorCode = "ALREADY_ANSWERED"
+	CodeNotAPlayer          ErrorCode = "NOT_A_PLAYER"
+	CodeTimeUp              ErrorCode = "TIME_UP"
+	CodeQuizNotFound        ErrorCode = "QUIZ_NOT_FOUND"
+	CodeAssignmentNotFound  ErrorCode = "ASSIGNMENT_NOT_FOUND"
+	CodeAssignmentNotOpen   ErrorCode = "ASSIGNMENT_NOT_OPEN"
+	CodeAssignmentClosed    ErrorCode = "ASSIGNMENT_CLOSED"
+	CodeAttemptInProgress   ErrorCode = "ATTEMPT_IN_PROGRESS"
+	CodeNoAttempt           ErrorCode = "NO_ATTEMPT"
+	CodeFeatureDisabled     ErrorCode = "FEATURE_DISABLED"
+	CodeInternal            ErrorCode = "INTERNAL_ERROR"
+)
+
+// errorCodes maps typed errors to the code clients see. The first match wins.
+var errorCodes = []struct {
+	err  error
+	code ErrorCode
+}{
+	{ErrMalformedEvent, CodeMalformedEvent},
+	{ErrUnknownEvent, CodeUnknownEvent},
+	{ErrUnsupportedVersion, CodeUnsupportedVersion},
+	{ErrInvalidPayload, CodeInvalidPayload},
+	{ErrRoomNotFound, CodeRoomNotFound},
+	{ErrRoomFull, CodeRoomFull},
+	{ErrRoomTooLarge, CodeRoomTooLarge},
+	{ErrAlreadyInRoom, CodeAlreadyInRoom},
+	{ErrNotInRoom, CodeNotInRoom},
+	{ErrNotRoomHost, CodeNotHost},
+	{ErrServerBusy, CodeServerBusy},
+	{roomcode.ErrExhausted, CodeServerBusy},
+	{roomcode.ErrNotReserved, CodeRoomCodeNotReserved},
+	{roomcode.ErrCodeInUse, CodeRoomCodeInUse},
+	{roomcode.ErrInvalidCode, CodeInvalidPayload},
+	{game.ErrNoSuchOption, CodeInvalidPayload},
+
+	{game.ErrGameNotFound, CodeGameNotFound},
+	{game.ErrNotHost, CodeNotHost},
+	{game.ErrInvalidState, CodeInvalidGameState},
+	{game.ErrAttemptStarted, CodeInvalidGameState},
+	{game.ErrAttemptFinished, CodeInvalidGameState},
+	{game.ErrQuestionOpen, CodeInvalidGameState},
+	{game.ErrNotAcceptingAnswers, CodeNotAcceptingAnswers},
+	{game.ErrAlreadyAnswered, CodeAlreadyAnswered},
+	{game.ErrNotAPlayer, CodeNotAPlayer},
+	{game.ErrTimeUp, CodeTimeUp},
+	{game.ErrQuizNotFound, CodeQuizNotFound},
+	{game.ErrQuizUnavailable, CodeFeatureDisabled},
+	{game.ErrAssignmentsDisabled, CodeFeatureDisabled},
+	{game.ErrAssignmentNotFound, CodeAssignmentNotFound},
+	{game.ErrAssignmentNotOpen, CodeAssignmentNotOpen},
+	{game.ErrAssignmentClosed, CodeAssignmentClosed},
+	{game.ErrAttemptInProgress, CodeAttemptInProgress},
+	{game.ErrNoAttempt, CodeNoAttempt},
+}
+
+// ErrorCodeOf returns the code for err, CodeInternal for unexpected errors and "" for nil.
+func ErrorCodeOf(err error) ErrorCode {
+	if err == nil {
+		return ""
+	}
+	for _, e := range errorCodes {
+		if errors.Is(err, e.err) {
+			return e.code
+		}
+	}
+	return CodeInternal
+}
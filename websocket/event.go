@@ -1,16 +1,56 @@
 package websocket
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"errors"
 
+	"github.com/oblongtable/beanbag-backend/internal/roomcode"
+)
+
+// ProtocolVersion is the version of the websocket protocol this server speaks.
+// Events without a version are read as this version, and every message the server
+// sends is in this version's shape. Version 1 clients must be updated, see the README.
+const ProtocolVersion = 2
+
+// Event is the envelope of every websocket message, in both directions.
 type Event struct {
-	Type    string          `json:"type"`
-	Payload json.RawMessage `json:"info"`
+	Version       int             `json:"v,omitempty"`
+	ID            string          `json:"id,omitempty"` // Chosen by the sender, echoed back as the correlation ID of callbacks
+	Type          string          `json:"type"`
+	CorrelationID string          `json:"correlation_id,omitempty"`
+	Payload       json.RawMessage `json:"info,omitempty"`
 }
 
+// Validator is implemented by event payloads, to reject bad fields before the event is dispatched.
+type Validator interface {
+	Validate() error
+}
+
+// Limits on the text clients choose themselves.
+const (
+	maxRoomNameLength = 64
+	maxUserNameLength = 32
+)
+
 type CreateRoomEvent struct {
 	RoomName string `json:"room_name"`
 	RoomSize int    `json:"room_size"`
 	UserName string `json:"username"`
+	RoomCode string `json:"room_code,omitempty"` // A code reserved for the signed-in creator, generated if empty
+}
+
+func (e *CreateRoomEvent) Validate() error {
+	switch {
+	case e.RoomName == "" || len(e.RoomName) > maxRoomNameLength:
+		return errors.New("room_name must be 1 to 64 characters")
+	case e.RoomSize < 1:
+		return errors.New("room_size must be at least 1")
+	case len(e.UserName) > maxUserNameLength:
+		return errors.New("username must be at most 32 characters")
+	case e.RoomCode != "":
+		return roomcode.ValidateVanity(roomcode.Normalize(e.RoomCode))
+	}
+	return nil
 }
 
 type JoinRoomEvent struct {
@@ -18,18 +58,65 @@ type JoinRoomEvent struct {
 	Name   string `json:"name"`
 }
 
+func (e *JoinRoomEvent) Validate() error {
+	switch {
+	case e.RoomID == "":
+		return errors.New("room_id is required")
+	case len(e.Name) > maxUserNameLength:
+		return errors.New("name must be at most 32 characters")
+	}
+	return nil
+}
+
 type LeaveRoomEvent struct {
 	RoomID string `json:"room_id"`
 }
 
+func (e *LeaveRoomEvent) Validate() error {
+	if e.RoomID == "" {
+		return errors.New("room_id is required")
+	}
+	return nil
+}
+
 type StartQuizEvent struct {
 	RoomID string `json:"room_id"`
+	QuizID int32  `json:"quiz_id,omitempty"` // Stored quiz to play, the built-in quiz when omitted
+}
+
+func (e *StartQuizEvent) Validate() error {
+	if e.QuizID < 0 {
+		return errors.New("quiz_id must not be negative")
+	}
+	return nil
 }
 
 type SubmitAnswerEvent struct {
 	AnswerIndex int `json:"answer_index"`
 }
 
+func (e *SubmitAnswerEvent) Validate() error {
+	if e.AnswerIndex < 0 {
+		return errors.New("answer_index must not be negative")
+	}
+	return nil
+}
+
+type StartAssignmentEvent struct {
+	AssignmentCode string `json:"assignment_code"`
+	Name           string `json:"name"`
+}
+
+func (e *StartAssignmentEvent) Validate() error {
+	switch {
+	case e.AssignmentCode == "":
+		return errors.New("assignment_code is required")
+	case len(e.Name) > maxUserNameLength:
+		return errors.New("name must be at most 32 characters")
+	}
+	return nil
+}
+
 const (
 	// EventStatusUpdate = "notify_user_status"
 	// EventSendMessage    = "send_message"
@@ -39,4 +126,21 @@ const (
 	EventStartQuiz    = "start_quiz"
 	EventForwardQuiz  = "quiz_forward" // New event for moving the quiz forward
 	EventSubmitAnswer = "submit_answer"
+
+	// Self-paced assignments, each player drives their own attempt
+	EventStartAssignment  = "start_assignment"
+	EventAssignmentNext   = "assignment_next"
+	EventAssignmentAnswer = "assignment_answer" // Same payload as submit_answer
 )
+
+// eventPayloads returns an empty payload for each event type that carries one,
+// so RouteEvent can validate it before dispatch.
+var eventPayloads = map[string]func() Validator{
+	EventCreateRoom:       func() Validator { return &CreateRoomEvent{} },
+	EventJoinRoom:         func() Validator { return &JoinRoomEvent{} },
+	EventLeaveRoom:        func() Validator { return &LeaveRoomEvent{} },
+	EventStartQuiz:        func() Validator { return &StartQuizEvent{} },
+	EventSubmitAnswer:     func() Validator { return &SubmitAnswerEvent{} },
+	EventStartAssignment:  func() Validator { return &StartAssignmentEvent{} },
+	EventAssignmentAnswer: func() Validator { return &SubmitAnswerEvent{} },
+}
@@ -1,31 +1,48 @@
 package websocket
 
+// Event handlers run on the requesting client's goroutine.
+
 func CreateRoomEventHandler(cliEvt *ClientEvent) error {
-	cliEvt.Requester.Wssvr.RegisterRoom <- cliEvt
+	cliEvt.Requester.Wssvr.AddRoom(cliEvt)
 	return nil
 }
 
 func JoinRoomEventHandler(cliEvt *ClientEvent) error {
-	cliEvt.Requester.Wssvr.JoinRoom <- cliEvt
+	cliEvt.Requester.Wssvr.JoinRoomF(cliEvt)
 	return nil
 }
 
 func LeaveRoomEventHandler(cliEvt *ClientEvent) error {
-	cliEvt.Requester.Wssvr.LeaveRoom <- cliEvt
+	cliEvt.Requester.Wssvr.LeaveRoomF(cliEvt)
 	return nil
 }
 
 func StartQuizEventHandler(cliEvt *ClientEvent) error {
-	cliEvt.Requester.Wssvr.StartQuiz<- cliEvt
+	cliEvt.Requester.Wssvr.StartQuizF(cliEvt)
 	return nil
 }
 
 func ForwardQuizEventHandler(cliEvt *ClientEvent) error {
-	cliEvt.Requester.Wssvr.ForwardQuiz <- cliEvt
+	cliEvt.Requester.Wssvr.ForwardQuizF(cliEvt)
 	return nil
 }
 
 func SubmitAnswerEventHandler(cliEvt *ClientEvent) error {
-	cliEvt.Requester.Wssvr.SubmitAnswer <- cliEvt
+	cliEvt.Requester.Wssvr.SubmitAnswerF(cliEvt)
+	return nil
+}
+
+func StartAssignmentEventHandler(cliEvt *ClientEvent) error {
+	cliEvt.Requester.Wssvr.StartAssignmentF(cliEvt)
+	return nil
+}
+
+func AssignmentNextEventHandler(cliEvt *ClientEvent) error {
+	cliEvt.Requester.Wssvr.AssignmentNextF(cliEvt)
+	return nil
+}
+
+func AssignmentAnswerEventHandler(cliEvt *ClientEvent) error {
+	cliEvt.Requester.Wssvr.AssignmentAnswerF(cliEvt)
 	return nil
 }
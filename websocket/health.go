@@ -0,0 +1,63 @@
+package websocket
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/oblongtable/beanbag-backend/internal/game"
+)
+
+// CheckRooms pings every open room and fails if any room's goroutine is stuck.
+func (wssvr *WebSocServer) CheckRooms(ctx context.Context) (map[string]interface{}, error) {
+	var rooms []*Room
+	wssvr.Rooms.Range(func(r *Room) bool {
+		rooms = append(rooms, r)
+		return true
+	})
+
+	var unresponsive atomic.Int32
+	var wg sync.WaitGroup
+	for _, r := range rooms {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// A room that closed meanwhile is fine
+			if err := r.Ping(ctx); err != nil && !errors.Is(err, ErrRoomNotFound) {
+				unresponsive.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	details := map[string]interface{}{
+		"clients":      wssvr.Clients.Len(),
+		"rooms":        len(rooms),
+		"unresponsive": unresponsive.Load(),
+	}
+	if n := unresponsive.Load(); n > 0 {
+		return details, fmt.Errorf("%d of %d rooms are not responding", n, len(rooms))
+	}
+	return details, nil
+}
+
+// CheckGames fails once more than maxGames games are being played, 0 means no limit.
+func (wssvr *WebSocServer) CheckGames(maxGames int) func(ctx context.Context) (map[string]interface{}, error) {
+	return func(ctx context.Context) (map[string]interface{}, error) {
+		live := 0
+		byState := make(map[string]int)
+		for state, n := range wssvr.Games.CountByState() {
+			byState[string(state)] = n
+			if state != game.StateFinished {
+				live += n
+			}
+		}
+		details := map[string]interface{}{"live": live, "by_state": byState, "max": maxGames}
+		if maxGames > 0 && live > maxGames {
+			return details, fmt.Errorf("%d live games, more than the limit of %d", live, maxGames)
+		}
+		return details, nil
+	}
+}
@@ -0,0 +1,101 @@
+package websocket
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/oblongtable/beanbag-backend/internal/game"
+	"github.com/oblongtable/beanbag-backend/internal/metrics"
+)
+
+// Why a room closed by itself, sent in room_expiring.
+const (
+	ExpiryIdle            = "idle"
+	ExpiryMaxGameDuration = "max_game_duration"
+)
+
+// RoomLimits bound how long rooms stay open. A zero duration disables that limit.
+type RoomLimits struct {
+	IdleTimeout     time.Duration // How long a room without a game in play may go without an event, join or leave
+	MaxGameDuration time.Duration // How long after its game started a room is closed
+	ExpiryWarning   time.Duration // How long before closing a room room_expiring is sent, 0 sends none
+	CheckInterval   time.Duration // How often rooms look at their deadline, defaults to a second
+}
+
+const defaultCheckInterval = time.Second
+
+func (l RoomLimits) enabled() bool {
+	return l.IdleTimeout > 0 || l.MaxGameDuration > 0
+}
+
+// SetRoomLimits closes rooms created afterwards once they are idle or their game has run for too long.
+func (wssvr *WebSocServer) SetRoomLimits(limits RoomLimits) {
+	if limits.CheckInterval <= 0 {
+		limits.CheckInterval = defaultCheckInterval
+	}
+	wssvr.roomLimits = limits
+}
+
+// touch records activity in the room. Any goroutine may call it.
+func (r *Room) touch() {
+	r.lastActive.Store(time.Now().UnixNano())
+}
+
+// deadline returns when the room closes by itself and why, or the zero time if it never does.
+func (r *Room) deadline(limits RoomLimits) (time.Time, string) {
+	lastActive := time.Unix(0, r.lastActive.Load())
+	if g, ok := r.wssvr.Games.GetGame(r.ID); ok {
+		state, startedAt, finishedAt := g.Progress()
+		switch state {
+		case game.StateLobby:
+			// Not started yet, as idle as the room
+		case game.StateFinished:
+			// Idle from the end of the game, however long it lasted
+			if finishedAt.After(lastActive) {
+				lastActive = finishedAt
+			}
+		default:
+			// Playing is no activity in the room, and playing too long doesn't count as idle
+			if limits.MaxGameDuration > 0 {
+				return startedAt.Add(limits.MaxGameDuration), ExpiryMaxGameDuration
+			}
+			return time.Time{}, ""
+		}
+	}
+	if limits.IdleTimeout > 0 {
+		return lastActive.Add(limits.IdleTimeout), ExpiryIdle
+	}
+	return time.Time{}, ""
+}
+
+// checkExpiry warns everyone in the room ahead of its deadline and closes it once the deadline passes.
+func (r *Room) checkExpiry(now time.Time, limits RoomLimits) {
+	deadline, reason := r.deadline(limits)
+	if deadline.IsZero() {
+		return
+	}
+	if !now.Before(deadline) {
+		r.logger.Info("room expired", "reason", reason)
+		metrics.RoomsExpired.WithLabelValues(reason).Inc()
+		r.shutdown()
+		return
+	}
+	// Warned once per deadline, activity moves an idle room's deadline and warns again later
+	if limits.ExpiryWarning > 0 && now.After(deadline.Add(-limits.ExpiryWarning)) && !r.warnedFor.Equal(deadline) {
+		r.warnedFor = deadline
+		r.logger.Debug("room expiring", "reason", reason, "deadline", deadline)
+		r.broadcastGameMessage(context.Background(), MessageRoomExpiring, RoomExpiringInfo{
+			Reason:      reason,
+			ExpiresInMs: deadline.Sub(now).Milliseconds(),
+		})
+	}
+}
+
+// endGame finishes the room's game, if one is in play, while everyone is still there to see the leaderboard.
+func (r *Room) endGame() {
+	err := r.wssvr.Games.EndWithRoom(context.Background(), r.ID)
+	if err != nil && !errors.Is(err, game.ErrGameNotFound) && !errors.Is(err, game.ErrInvalidState) {
+		r.logger.Error("failed to end the game of a closing room", "error", err)
+	}
+}
@@ -2,20 +2,17 @@ package websocket
 
 import "encoding/json"
 
-type BaseMessage struct {
-	Type string `json:"type"`
-}
-
+// EventCallbackMessage answers an event, in the same envelope as Event.
+// CorrelationID is the ID of the event it answers.
 type EventCallbackMessage struct {
-	BaseMessage
-	IsSuccess bool            `json:"success"`
-	Message   string          `json:"message"`
-	Info      json.RawMessage `json:"info"`
-}
-
-type UserInfoMessage struct {
-	BaseMessage
-	User UserInfo `json:"user"`
+	Version       int             `json:"v"`
+	ID            string          `json:"id"`
+	Type          string          `json:"type"`
+	CorrelationID string          `json:"correlation_id,omitempty"`
+	IsSuccess     bool            `json:"success"`
+	Code          ErrorCode       `json:"code,omitempty"` // Set when the event failed
+	Message       string          `json:"message"`
+	Info          json.RawMessage `json:"info"`
 }
 
 type UserInfo struct {
@@ -25,13 +22,7 @@ type UserInfo struct {
 	UserID      string `json:"user_id"` // Add UserID
 }
 
-type UserStatusMessages struct {
-	BaseMessage
-	Users []*UserInfo
-}
-
 type RoomInfo struct {
-	BaseMessage
 	ID        string      `json:"room_id"`
 	Name      string      `json:"room_name"`
 	Size      int         `json:"room_size"`
@@ -40,18 +31,37 @@ type RoomInfo struct {
 	IsHost    bool        `json:"is_host"` // Add IsHost field
 }
 
-type RoomInfoMessages struct {
-	BaseMessage
-	Rooms []*RoomInfo `json:"rooms_info"`
+// ErrorInfo is the info of an error message, sent when an event is rejected before reaching its handler.
+type ErrorInfo struct {
+	Code    ErrorCode `json:"code"`
+	Message string    `json:"message"`
 }
 
+// RateLimitedInfo is the info of a rate_limited message, sent instead of handling an event.
+type RateLimitedInfo struct {
+	Event        string `json:"event"`
+	RetryAfterMs int64  `json:"retry_after_ms"`
+}
+
+// RoomExpiringInfo is the info of a room_expiring message, sent ahead of a room closing by itself.
+type RoomExpiringInfo struct {
+	Reason      string `json:"reason"` // idle or max_game_duration
+	ExpiresInMs int64  `json:"expires_in_ms"`
+}
+
+// NoInfo is the info of callbacks that have nothing to report besides success.
+type NoInfo struct{}
+
 type Serialisable interface {
-	RoomInfo | UserInfo | EventCallbackMessage | BaseMessage // Include BaseMessage
+	RoomInfo | UserInfo | NoInfo
 }
 
 const (
 	MessageRoomStatusUpdate = "room_status_update"
 
+	MessageError       = "error"
+	MessageRateLimited = "rate_limited"
+
 	MessageUserJoinRoomUpdate  = "user_join_room_update"
 	MessageUserLeaveRoomUpdate = "user_leave_room_update"
 
@@ -59,8 +69,13 @@ const (
 	MessageJoinRoom     = "join_room_callback"
 	MessageLeaveRoom    = "leave_room_callback"
 	MessageRoomShutdown = "room_shutdown"
+	MessageRoomExpiring = "room_expiring"
 
 	MessageQuizStart   = "quiz_start_callback"
 	MessageQuizForward = "quiz_forward_callback" // New message type for quiz forward callback
 	MessageSubmitAnswer = "submit_answer_callback"
+
+	MessageStartAssignment  = "start_assignment_callback"
+	MessageAssignmentNext   = "assignment_next_callback"
+	MessageAssignmentAnswer = "assignment_answer_callback"
 )
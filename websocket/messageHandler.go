@@ -2,31 +2,42 @@ package websocket
 
 import (
 	"encoding/json"
-	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/oblongtable/beanbag-backend/internal/metrics"
 )
 
-func NewEventCallbackMessage() *EventCallbackMessage {
-	return &EventCallbackMessage{
-		BaseMessage: BaseMessage{},
-		IsSuccess:   true,
-		Message:     "",
-		Info:        nil,
-	}
+// newMessageID returns a unique ID for a message sent by the server.
+func newMessageID() string {
+	return uuid.New().String()
 }
 
-func SendEventCallback[S Serialisable](c *Client, msg_type string, isSuccess bool, msg string, ser *S) {
+// SendEventCallback answers the event with the given ID. The event failed if err is not nil,
+// its error code is sent along with msg. Info is only sent on success.
+func SendEventCallback[S Serialisable](c *Client, correlationID string, msg_type string, err error, msg string, ser *S) {
 	evtCbMsg := &EventCallbackMessage{
-		BaseMessage: BaseMessage{msg_type},
-		IsSuccess:   isSuccess,
-		Message:     msg,
-		Info:        nil,
+		Version:       ProtocolVersion,
+		ID:            newMessageID(),
+		Type:          msg_type,
+		CorrelationID: correlationID,
+		IsSuccess:     err == nil,
+		Code:          ErrorCodeOf(err),
+		Message:       msg,
+		Info:          nil,
+	}
+	logger := c.logger().With("type", msg_type, "correlation_id", correlationID)
+	if err != nil {
+		metrics.CallbackFailures.WithLabelValues(msg_type, string(evtCbMsg.Code)).Inc()
+		logger.Warn(msg, "code", evtCbMsg.Code)
+	} else {
+		logger.Info(msg)
 	}
 
 	// Serialise Info
-	if isSuccess {
+	if evtCbMsg.IsSuccess {
 		if jsonBytes, err := json.Marshal(ser); err != nil {
-			isSuccess = false
-			log.Printf("SendEventCallback: Failed to marshal message (%v)\n", err)
+			logger.Error("failed to marshal callback info", "error", err)
 			return
 
 		} else {
@@ -36,49 +47,92 @@ func SendEventCallback[S Serialisable](c *Client, msg_type string, isSuccess boo
 
 	// Serialise callback message and send it
 	if strmsg, err := json.Marshal(evtCbMsg); err == nil {
-		log.Printf("Marshaled: %s", strmsg)
-		c.Send <- strmsg
+		logger.Debug("callback queued", "message", string(strmsg))
+		c.queue("", strmsg)
 	} else {
-		log.Printf("Failed to marshal: %v", err)
+		logger.Error("failed to marshal callback", "error", err)
 	}
 }
 
+// NotifyUserRoomStatus sends the room to one of its participants. It must run on the room's goroutine.
+// Only the latest status of each msg_type waits in the client's queue.
 func NotifyUserRoomStatus(r *Room, c *Client, userInfo []*UserInfo, msg_type string) error {
 	roomInfo := &RoomInfo{
-		BaseMessage: BaseMessage{msg_type},
-		ID:          r.ID,
-		Name:        r.Name,
-		Size:        r.Size,
-		UsersInfo:   userInfo,
-		SenderID:    c.ID,
-		IsHost:      c.ID == r.Host.ID, // Set IsHost based on recipient client
+		ID:        r.ID,
+		Name:      r.Name,
+		Size:      r.Size,
+		UsersInfo: userInfo,
+		SenderID:  c.ID,
+		IsHost:    r.host != nil && c.ID == r.host.ID, // Set IsHost based on recipient client
 	}
 
-	if strmsg, err := json.Marshal(roomInfo); err == nil {
-		log.Printf("Sending room status update: %s", strmsg)
-		c.Send <- strmsg
-	} else {
-		log.Printf("Failed to marshal: %v", err)
+	strmsg, err := MarshalMessage(msg_type, roomInfo)
+	if err != nil {
+		c.logger().Error("failed to marshal room status", "error", err)
+		return err
 	}
-
+	c.logger().Debug("room status queued", "message", string(strmsg))
+	c.queue(msg_type, strmsg)
 	return nil
 }
 
-func NotifyUserRoomUpdate(r *Room, c *Client, msg_type string) error {
-	var msg UserInfoMessage
-	msg.Type = msg_type
-	msg.User.Username = r.Name
-
-	if strmsg, err := json.Marshal(&msg); err == nil {
-		for _, pd := range r.Participants {
-			if pd.Client == c {
-				continue
-			}
-			pd.Client.Send <- strmsg
+// MarshalMessage wraps a payload in an Event envelope so clients can tell message types apart.
+// A nil payload sends a message without info.
+func MarshalMessage(msgType string, payload interface{}) ([]byte, error) {
+	evt := &Event{
+		Version: ProtocolVersion,
+		ID:      newMessageID(),
+		Type:    msgType,
+	}
+	if payload != nil {
+		jsonPayload, err := json.Marshal(payload)
+		if err != nil {
+			return nil, err
 		}
-	} else {
-		log.Printf("Failed to marshal: %v", err)
+		evt.Payload = jsonPayload
+	}
+	return json.Marshal(evt)
+}
+
+// SendError tells the client their event was rejected. correlationID is the ID of the event, if it had one.
+func SendError(c *Client, correlationID string, err error) {
+	metrics.CallbackFailures.WithLabelValues(MessageError, string(ErrorCodeOf(err))).Inc()
+	sendReply(c, correlationID, MessageError, &ErrorInfo{Code: ErrorCodeOf(err), Message: err.Error()})
+}
+
+// SendRateLimited tells the client their event was dropped and when to retry.
+func SendRateLimited(c *Client, correlationID string, eventType string, retryAfter time.Duration) {
+	metrics.CallbackFailures.WithLabelValues(MessageRateLimited, "RATE_LIMITED").Inc()
+	sendReply(c, correlationID, MessageRateLimited, &RateLimitedInfo{Event: eventType, RetryAfterMs: retryAfter.Milliseconds()})
+}
+
+// sendReply sends a message answering the event with the given ID without blocking.
+func sendReply(c *Client, correlationID string, msgType string, payload interface{}) {
+	info, err := json.Marshal(payload)
+	if err != nil {
+		c.logger().Error("failed to marshal message", "type", msgType, "error", err)
+		return
+	}
+	strmsg, err := json.Marshal(&Event{
+		Version:       ProtocolVersion,
+		ID:            newMessageID(),
+		Type:          msgType,
+		CorrelationID: correlationID,
+		Payload:       info,
+	})
+	if err != nil {
+		c.logger().Error("failed to marshal message", "type", msgType, "error", err)
+		return
 	}
+	c.queue("", strmsg)
+}
 
-	return nil
+// SendGameMessage sends a game payload to a single client without blocking.
+func SendGameMessage(c *Client, msgType string, payload interface{}) {
+	strmsg, err := MarshalMessage(msgType, payload)
+	if err != nil {
+		c.logger().Error("failed to marshal game message", "type", msgType, "error", err)
+		return
+	}
+	c.queue("", strmsg)
 }
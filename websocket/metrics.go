@@ -0,0 +1,40 @@
+package websocket
+
+import (
+	"github.com/oblongtable/beanbag-backend/internal/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// RegisterMetrics exposes the server's clients, rooms, games and send queues as Prometheus metrics.
+func (wssvr *WebSocServer) RegisterMetrics(reg prometheus.Registerer) error {
+	collectors := []prometheus.Collector{
+		metrics.NewGaugeFunc("ws_connected_clients", "Websocket clients currently connected.", func() float64 {
+			return float64(wssvr.Clients.Len())
+		}),
+		metrics.NewGaugeFunc("ws_active_rooms", "Rooms currently open.", func() float64 {
+			return float64(wssvr.Rooms.Len())
+		}),
+		metrics.NewStateGauge("games", "Live games, by state.", "state", func() map[string]float64 {
+			counts := make(map[string]float64)
+			for state, n := range wssvr.Games.CountByState() {
+				counts[string(state)] = float64(n)
+			}
+			return counts
+		}),
+		metrics.NewCounterFunc("ws_messages_dropped_total", "Messages dropped because a client's send queue was full.", func() float64 {
+			return float64(wssvr.QueueStats().Dropped)
+		}),
+		metrics.NewCounterFunc("ws_messages_coalesced_total", "Waiting messages replaced by a newer message of the same kind.", func() float64 {
+			return float64(wssvr.QueueStats().Coalesced)
+		}),
+		metrics.NewCounterFunc("ws_slow_disconnects_total", "Clients disconnected for falling behind on their messages.", func() float64 {
+			return float64(wssvr.QueueStats().SlowDisconnects)
+		}),
+	}
+	for _, c := range collectors {
+		if err := reg.Register(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,166 @@
+package websocket
+
+import (
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// QueueLimits bound the messages waiting to be written to each client.
+type QueueLimits struct {
+	Size      int           // Messages a client may have waiting, newer ones are dropped
+	MaxBehind time.Duration // How long a queue may stay more than half full before its client is disconnected, 0 never
+}
+
+var defaultQueueLimits = QueueLimits{Size: 256, MaxBehind: 10 * time.Second}
+
+// QueueStats count what the outbound queues did to cope with slow clients.
+type QueueStats struct {
+	Dropped         uint64 // Messages dropped because a client's queue was full
+	Coalesced       uint64 // Waiting messages replaced by a newer message of the same kind
+	SlowDisconnects uint64 // Clients disconnected for staying behind
+}
+
+type queueCounters struct {
+	dropped         atomic.Uint64
+	coalesced       atomic.Uint64
+	slowDisconnects atomic.Uint64
+}
+
+// outbox is a client's bounded queue of outbound messages. Senders never block on it,
+// only the client's WriteMessage takes messages out.
+type outbox struct {
+	clientID string
+	limits   QueueLimits
+	counters *queueCounters
+
+	mu          sync.Mutex
+	messages    []outMessage
+	behindSince time.Time // Zero while the queue is at most half full
+	closed      bool
+
+	ready chan struct{} // Holds a token while messages are waiting
+	done  chan struct{} // Closed with the outbox
+}
+
+type outMessage struct {
+	key  string // Waiting messages with the same non-empty key supersede each other
+	data []byte
+
+	broadcastAt time.Time // When a room broadcast the message, zero for messages to a single client
+}
+
+func newOutbox(clientID string, limits QueueLimits, counters *queueCounters) *outbox {
+	return &outbox{
+		clientID: clientID,
+		limits:   limits,
+		counters: counters,
+		ready:    make(chan struct{}, 1),
+		done:     make(chan struct{}),
+	}
+}
+
+// push queues a message. It reports whether the client has now been behind for too long.
+func (o *outbox) push(msg outMessage) (tooSlow bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.closed {
+		return false
+	}
+
+	if msg.key != "" {
+		for i := range o.messages {
+			if o.messages[i].key == msg.key {
+				o.messages[i] = msg
+				o.counters.coalesced.Add(1)
+				return false
+			}
+		}
+	}
+
+	if len(o.messages) >= o.limits.Size {
+		o.counters.dropped.Add(1)
+		slog.Warn("send queue full, dropped a message", "client_id", o.clientID)
+	} else {
+		o.messages = append(o.messages, msg)
+		select {
+		case o.ready <- struct{}{}:
+		default:
+		}
+	}
+
+	if len(o.messages) <= o.limits.Size/2 {
+		return false
+	}
+	now := time.Now()
+	if o.behindSince.IsZero() {
+		o.behindSince = now
+		return false
+	}
+	if o.limits.MaxBehind > 0 && now.Sub(o.behindSince) > o.limits.MaxBehind {
+		o.closeLocked()
+		o.counters.slowDisconnects.Add(1)
+		return true
+	}
+	return false
+}
+
+// next takes the oldest waiting message.
+func (o *outbox) next() (outMessage, bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.closed || len(o.messages) == 0 {
+		return outMessage{}, false
+	}
+	msg := o.messages[0]
+	o.messages[0] = outMessage{}
+	o.messages = o.messages[1:]
+	if len(o.messages) <= o.limits.Size/2 {
+		o.behindSince = time.Time{}
+	}
+	return msg, true
+}
+
+// close discards waiting messages and stops the client's WriteMessage.
+func (o *outbox) close() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.closeLocked()
+}
+
+func (o *outbox) closeLocked() {
+	if o.closed {
+		return
+	}
+	o.closed = true
+	o.messages = nil
+	close(o.done)
+}
+
+// queue sends a message to the client without blocking. A non-empty key marks a state message:
+// a newer one with the same key replaces it while it is still waiting, so the client only gets the latest.
+func (c *Client) queue(key string, data []byte) {
+	if c.out.push(outMessage{key: key, data: data}) {
+		go c.disconnectSlow()
+	}
+}
+
+// queueBroadcast queues a message the client's room sent to everyone at broadcastAt.
+func (c *Client) queueBroadcast(data []byte, broadcastAt time.Time) {
+	if c.out.push(outMessage{data: data, broadcastAt: broadcastAt}) {
+		go c.disconnectSlow()
+	}
+}
+
+// disconnectSlow drops a client that could not keep up with its messages.
+func (c *Client) disconnectSlow() {
+	c.logger().Warn("disconnected for falling behind")
+	closeMsg := websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "too slow to keep up")
+	if err := c.Conn.WriteControl(websocket.CloseMessage, closeMsg, time.Now().Add(time.Second)); err != nil {
+		c.logger().Info("failed to send close message", "error", err)
+	}
+	c.Conn.Close()
+}
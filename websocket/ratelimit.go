@@ -0,0 +1,163 @@
+package websocket
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// Limit is a token bucket: Rate events per second on average, up to Burst at once.
+// A zero Rate means no limit.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+func (l Limit) newLimiter() *rate.Limiter {
+	if l.Rate <= 0 {
+		return nil
+	}
+	burst := l.Burst
+	if burst < 1 {
+		burst = 1
+	}
+	return rate.NewLimiter(rate.Limit(l.Rate), burst)
+}
+
+// RateLimits protect the server from clients flooding it with events.
+type RateLimits struct {
+	Client Limit            // Every event of a single connection
+	IP     Limit            // Every event of all connections from one IP address
+	Events map[string]Limit // Budgets for single event types, per connection
+}
+
+// ParseEventBudgets reads per-event budgets written as "event=rate/burst" pairs separated by commas,
+// e.g. "create_room=0.2/2,submit_answer=2/3".
+func ParseEventBudgets(s string) (map[string]Limit, error) {
+	budgets := map[string]Limit{}
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		event, budget, ok := strings.Cut(pair, "=")
+		rateStr, burstStr, ok2 := strings.Cut(budget, "/")
+		if !ok || !ok2 {
+			return nil, fmt.Errorf("invalid event budget %q, want event=rate/burst", pair)
+		}
+		r, err := strconv.ParseFloat(rateStr, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rate in event budget %q: %w", pair, err)
+		}
+		burst, err := strconv.Atoi(burstStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid burst in event budget %q: %w", pair, err)
+		}
+		budgets[strings.TrimSpace(event)] = Limit{Rate: r, Burst: burst}
+	}
+	return budgets, nil
+}
+
+// clientLimiter holds the buckets of one connection. It is only used by the connection's ReadMessage.
+type clientLimiter struct {
+	limits *RateLimits
+	all    *rate.Limiter
+	events map[string]*rate.Limiter
+}
+
+func newClientLimiter(limits *RateLimits) *clientLimiter {
+	return &clientLimiter{
+		limits: limits,
+		all:    limits.Client.newLimiter(),
+		events: map[string]*rate.Limiter{},
+	}
+}
+
+// allowEvent spends a token from the budget of the event type, if it has one.
+// It returns how long to wait before retrying when the budget is exhausted.
+func (l *clientLimiter) allowEvent(eventType string) (bool, time.Duration) {
+	limiter, ok := l.events[eventType]
+	if !ok {
+		limiter = l.limits.Events[eventType].newLimiter()
+		l.events[eventType] = limiter
+	}
+	return allow(limiter)
+}
+
+// allow applies the server's rate limits to an event from the client.
+// It returns how long to wait before retrying when a limit is exceeded.
+func (c *Client) allow(eventType string) (bool, time.Duration) {
+	if c.limiter == nil {
+		return true, 0
+	}
+	if ok, wait := allow(c.limiter.all); !ok {
+		return false, wait
+	}
+	if ok, wait := c.Wssvr.ipLimits.allow(c.IP); !ok {
+		return false, wait
+	}
+	return c.limiter.allowEvent(eventType)
+}
+
+// ipLimiters share a bucket between all connections from the same address.
+type ipLimiters struct {
+	limit     Limit
+	mu        sync.Mutex
+	byIP      map[string]*ipLimiter
+	lastSweep time.Time
+}
+
+type ipLimiter struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// ipIdleTimeout is how long an address keeps its bucket after its last event.
+const ipIdleTimeout = 10 * time.Minute
+
+func newIPLimiters(limit Limit) *ipLimiters {
+	return &ipLimiters{limit: limit, byIP: map[string]*ipLimiter{}, lastSweep: time.Now()}
+}
+
+func (l *ipLimiters) allow(ip string) (bool, time.Duration) {
+	if l.limit.Rate <= 0 {
+		return true, 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(l.lastSweep) > ipIdleTimeout {
+		for addr, entry := range l.byIP {
+			if now.Sub(entry.lastSeen) > ipIdleTimeout {
+				delete(l.byIP, addr)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	entry, ok := l.byIP[ip]
+	if !ok {
+		entry = &ipLimiter{limiter: l.limit.newLimiter()}
+		l.byIP[ip] = entry
+	}
+	entry.lastSeen = now
+	return allow(entry.limiter)
+}
+
+// allow spends a token if one is available, otherwise it returns how long until the next one is.
+func allow(limiter *rate.Limiter) (bool, time.Duration) {
+	if limiter == nil {
+		return true, 0
+	}
+	reservation := limiter.Reserve()
+	if delay := reservation.Delay(); delay > 0 {
+		reservation.Cancel()
+		return false, delay
+	}
+	return true, 0
+}
@@ -0,0 +1,123 @@
+package websocket
+
+import (
+	"fmt"
+	"sync"
+)
+
+// ClientList holds the connected clients by ID. It is safe for concurrent use.
+type ClientList struct {
+	mu   sync.RWMutex
+	byID map[string]*Client
+}
+
+func NewClientList() *ClientList {
+	return &ClientList{byID: make(map[string]*Client)}
+}
+
+func (l *ClientList) Add(c *Client) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.byID[c.ID] = c
+}
+
+// Remove deletes the client and reports whether it was still registered,
+// so concurrent disconnects only clean up once.
+func (l *ClientList) Remove(c *Client) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.byID[c.ID] != c {
+		return false
+	}
+	delete(l.byID, c.ID)
+	return true
+}
+
+func (l *ClientList) String() string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return fmt.Sprint(l.byID)
+}
+
+func (l *ClientList) Get(id string) (*Client, bool) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	c, ok := l.byID[id]
+	return c, ok
+}
+
+func (l *ClientList) Len() int {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return len(l.byID)
+}
+
+// Range calls f for every client until it returns false. f must not add or remove clients.
+func (l *ClientList) Range(f func(c *Client) bool) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	for _, c := range l.byID {
+		if !f(c) {
+			return
+		}
+	}
+}
+
+// RoomList holds the open rooms by code. It is safe for concurrent use.
+type RoomList struct {
+	mu   sync.RWMutex
+	byID map[string]*Room
+}
+
+func NewRoomList() *RoomList {
+	return &RoomList{byID: make(map[string]*Room)}
+}
+
+// Add registers the room unless its code is already taken.
+func (l *RoomList) Add(r *Room) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, taken := l.byID[r.ID]; taken {
+		return false
+	}
+	l.byID[r.ID] = r
+	return true
+}
+
+func (l *RoomList) Remove(r *Room) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.byID[r.ID] == r {
+		delete(l.byID, r.ID)
+	}
+}
+
+func (l *RoomList) String() string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return fmt.Sprint(l.byID)
+}
+
+func (l *RoomList) Get(id string) (*Room, bool) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	r, ok := l.byID[id]
+	return r, ok
+}
+
+func (l *RoomList) Len() int {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return len(l.byID)
+}
+
+// Range calls f for every room until it returns false. f must not add or remove rooms.
+func (l *RoomList) Range(f func(r *Room) bool) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	for _, r := range l.byID {
+		if !f(r) {
+			return
+		}
+	}
+}
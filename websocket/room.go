@@ -1,13 +1,17 @@
 package websocket
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
-	"log"
-	"math/rand"
+	"log/slog"
 	"sort"
-	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/oblongtable/beanbag-backend/internal/game"
+	"github.com/oblongtable/beanbag-backend/internal/roomcode"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const MAX_ROOM_SIZE = 20
@@ -19,238 +23,397 @@ type ParticipantsDetail struct {
 	UserLobbyId int // Add UserLobbyId to ParticipantsDetail
 }
 
+// Room is an actor: its participants are only touched by the room's own goroutine.
+// Other goroutines hand it work through call, so a busy room only ever slows down itself.
 type Room struct {
-	ID           string // UUID
-	Name         string // Customised
-	Size            int    // Min: 1, Max: 20
-	IsAlive         bool
-	Creator         *Client
-	Host            *Client
-	Participants    map[string]ParticipantsDetail
-	Join            chan *Client
-	Leave           chan *Client
-	mu              sync.Mutex
-	nextUserLobbyId int // New field to assign unique sequential UserLobbyIds
+	ID      string // Room code
+	Name    string // Customised
+	Size    int    // Min: 1, Max: 20
+	Creator *Client
+	wssvr   *WebSocServer
+
+	commands chan func()
+	closed   chan struct{} // Closed once the room has shut down
+	logger   *slog.Logger  // Tags every line with the room ID
+
+	// Owned by the room goroutine
+	host            *Client
+	participants    map[string]ParticipantsDetail
+	nextUserLobbyId int // Assigns unique sequential UserLobbyIds
+	closing         bool
+	warnedFor       time.Time // Deadline room_expiring was last sent for
+
+	lastActive atomic.Int64 // Unix nanoseconds of the last event, join or leave, see touch
+
+	// Snapshot of the participants for broadcasts from game goroutines, replaced on every join and leave
+	members atomic.Pointer[[]*Client]
 }
 
 func (r *Room) String() string {
-	return fmt.Sprintf("Room {ID:\"%s\", Name:\"%s\", Size:%d, Creator:%s, Participants:%v}",
-		r.ID, r.Name, r.Size, r.Creator, r.Participants)
+	return fmt.Sprintf("Room {ID:\"%s\", Name:\"%s\", Size:%d, Creator:%s}", r.ID, r.Name, r.Size, r.Creator)
 }
 
-func NewRoom(name string, size int, creator *Client) (r *Room) {
-	r = &Room{
-		ID:              GenerateRandomCode(4),
+// NewRoom opens a room with the creator in it and registers it with the server.
+// The room gets the code if one is given, which must be reserved for the creator, or a new one.
+func NewRoom(ctx context.Context, name string, size int, creator *Client, code string) (*Room, error) {
+	codes := creator.Wssvr.Codes
+	if code == "" {
+		var err error
+		if code, err = codes.Allocate(ctx); err != nil {
+			return nil, err
+		}
+	} else {
+		code = roomcode.Normalize(code)
+		if err := codes.ClaimReserved(ctx, code, creator.UserID); err != nil {
+			return nil, err
+		}
+	}
+
+	r := &Room{
+		ID:              code,
 		Name:            name,
 		Size:            size,
 		Creator:         creator,
-		Participants:    make(map[string]ParticipantsDetail),
-		Join:            make(chan *Client),
-		Leave:           make(chan *Client),
-		mu:              sync.Mutex{},
+		wssvr:           creator.Wssvr,
+		commands:        make(chan func()),
+		closed:          make(chan struct{}),
+		participants:    make(map[string]ParticipantsDetail),
 		nextUserLobbyId: 1, // Initialize to 1, as creator gets 0
 	}
 
-	// Add the host to the clients list immediately
-	r.Participants[creator.ID] = ParticipantsDetail{
+	// Add the creator to the clients list immediately
+	r.participants[creator.ID] = ParticipantsDetail{
 		Client:      creator,
 		Role:        RoleCreator,
 		joinedAt:    time.Now(),
 		UserLobbyId: 0, // Assign UserLobbyId 0 to the creator
 	}
+	r.publishMembers()
+	r.touch()
 
-	// Re-generate room ID if already exist such ID for 5 times
-	exist := true
-	for range 5 {
-		if _, ok := creator.Wssvr.Rooms[r.ID]; !ok {
-			exist = false
-			break
+	// The store and the room list only disagree if another server took over the code of a room still open here
+	if !r.wssvr.Rooms.Add(r) {
+		if err := r.wssvr.Codes.Release(ctx, r.ID); err != nil {
+			slog.Error("failed to release room code", "room_id", r.ID, "error", err)
 		}
-		r.ID = GenerateRandomCode(4)
+		return nil, ErrServerBusy
 	}
 
-	// Return NULL pointer if it still fails to generate unique code
-	if exist {
-		return nil
+	r.logger = slog.Default().With("room_id", r.ID)
+	r.logger.Info("room created", "name", r.Name, "size", r.Size, "creator_id", creator.ID)
+	creator.setRoomID(r.ID)
+	go r.Run()
+	return r, nil
+}
+
+// Run executes the room's commands one at a time until the room shuts down,
+// checking in between whether the room has outlived its limits.
+func (r *Room) Run() {
+	limits := r.wssvr.roomLimits
+	var tick <-chan time.Time
+	if limits.enabled() {
+		ticker := time.NewTicker(limits.CheckInterval)
+		defer ticker.Stop()
+		tick = ticker.C
 	}
 
-	go r.Run()
-	return r
+	for !r.closing {
+		select {
+		case f := <-r.commands:
+			f()
+		case now := <-tick:
+			r.checkExpiry(now, limits)
+		}
+	}
+	close(r.closed)
+	r.wssvr.Rooms.Remove(r)
+	if err := r.wssvr.Codes.Release(context.Background(), r.ID); err != nil {
+		r.logger.Error("failed to release room code", "error", err)
+	}
+	r.logger.Info("room removed")
+}
+
+// call runs f on the room's goroutine and waits for it to finish.
+// It returns ErrRoomNotFound if the room has shut down.
+func (r *Room) call(f func()) error {
+	finished := make(chan struct{})
+	select {
+	case r.commands <- func() { f(); close(finished) }:
+	case <-r.closed:
+		return ErrRoomNotFound
+	}
+	<-finished
+	return nil
+}
+
+// Ping waits for the room's goroutine to take a command, returning ctx's error if it doesn't in time.
+func (r *Room) Ping(ctx context.Context) error {
+	select {
+	case r.commands <- func() {}:
+		return nil
+	case <-r.closed:
+		return ErrRoomNotFound
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
 
-func (r *Room) JoinRoom(c *Client) {
+// Join adds the client to the room and returns the room as the client sees it.
+func (r *Room) Join(c *Client) (roomInfo RoomInfo, err error) {
+	callErr := r.call(func() {
+		if len(r.participants) >= r.Size+1 {
+			err = ErrRoomFull
+			return
+		}
+		r.joinRoom(c)
+		roomInfo = r.roomInfo(c)
+	})
+	if callErr != nil {
+		return RoomInfo{}, callErr
+	}
+	return roomInfo, err
+}
 
-	r.mu.Lock()
+// Leave removes the client from the room.
+func (r *Room) Leave(c *Client) error {
+	return r.call(func() { r.leaveRoom(c) })
+}
 
-	c.RoomID = r.ID
+// IsHost reports whether the client is the room's host.
+func (r *Room) IsHost(c *Client) (isHost bool, err error) {
+	err = r.call(func() { isHost = r.host != nil && r.host.ID == c.ID })
+	return isHost, err
+}
 
-	// Only the creator is in the room so I am the host
-	userLobbyId := r.nextUserLobbyId // Assign UserLobbyId from the counter
-	r.nextUserLobbyId++              // Increment the counter for the next user
+// CreateGame sets up a game with everyone currently in the room, if c is the host.
+func (r *Room) CreateGame(ctx context.Context, c *Client, games *game.GameService, quizID int32) (g *game.Game, err error) {
+	callErr := r.call(func() {
+		if r.host == nil || r.host.ID != c.ID {
+			err = ErrNotRoomHost
+			return
+		}
 
-	// Only the creator is in the room so I am the host
-	if len(r.Participants) == 1 { // This means the creator is already there, and this is the second person
-
-		r.Host = c
-		r.Participants[c.ID] = ParticipantsDetail{
-			Client:      c,
-			Role:        RoleHost,
-			joinedAt:    time.Now(),
-			UserLobbyId: userLobbyId, // Assign the calculated UserLobbyId
+		// Prepare initial player info from room participants
+		initialPlayers := make([]game.InitialPlayerInfo, 0, len(r.participants))
+		for _, pDetail := range r.participants {
+			initialPlayers = append(initialPlayers, game.InitialPlayerInfo{
+				ID:       pDetail.Client.ID,
+				Username: pDetail.Client.Username,
+				UserID:   pDetail.Client.UserID,
+			})
 		}
+		g, err = games.CreateGame(ctx, r.ID, r.Creator.ID, r.host.ID, quizID, r.broadcastGameMessage, initialPlayers)
+	})
+	if callErr != nil {
+		return nil, callErr
+	}
+	return g, err
+}
 
-	} else {
+// ParticipantSnapshot describes someone in a room for the admin API.
+type ParticipantSnapshot struct {
+	ClientID    string
+	Username    string
+	UserID      int32
+	Role        Role
+	UserLobbyId int
+	JoinedAt    time.Time
+}
 
-		r.Participants[c.ID] = ParticipantsDetail{
-			Client:      c,
-			Role:        RolePlayer,
-			joinedAt:    time.Now(),
-			UserLobbyId: userLobbyId, // Assign the calculated UserLobbyId
+// RoomSnapshot describes a room for the admin API.
+type RoomSnapshot struct {
+	ID           string
+	Name         string
+	Size         int
+	CreatorID    string
+	HostID       string                // "" while there is no host
+	Participants []ParticipantSnapshot // In the order they joined
+}
+
+// Snapshot returns who is in the room and their roles.
+func (r *Room) Snapshot() (snap RoomSnapshot, err error) {
+	err = r.call(func() {
+		snap = RoomSnapshot{ID: r.ID, Name: r.Name, Size: r.Size, CreatorID: r.Creator.ID}
+		if r.host != nil {
+			snap.HostID = r.host.ID
+		}
+		for _, pd := range r.participants {
+			snap.Participants = append(snap.Participants, ParticipantSnapshot{
+				ClientID:    pd.Client.ID,
+				Username:    pd.Client.Username,
+				UserID:      pd.Client.UserID,
+				Role:        pd.Role,
+				UserLobbyId: pd.UserLobbyId,
+				JoinedAt:    pd.joinedAt,
+			})
 		}
+		sort.Slice(snap.Participants, func(i, j int) bool {
+			return snap.Participants[i].JoinedAt.Before(snap.Participants[j].JoinedAt)
+		})
+	})
+	return snap, err
+}
 
+// Close shuts the room down as if its creator had left.
+func (r *Room) Close() error {
+	return r.call(func() {
+		r.logger.Warn("room closed by an admin")
+		r.shutdown()
+	})
+}
+
+// ParticipantCount returns how many clients are in the room, including its creator.
+func (r *Room) ParticipantCount() (count int) {
+	r.call(func() { count = len(r.participants) })
+	return count
+}
+
+// broadcastGameMessage queues a game message for everyone in the room.
+// Games call it from their own goroutines, so it reads the members snapshot rather than the participants.
+func (r *Room) broadcastGameMessage(ctx context.Context, msgType string, payload interface{}) {
+	members := *r.members.Load()
+	ctx, span := tracer.Start(ctx, "room.broadcast", trace.WithAttributes(
+		attribute.String("ws.room.id", r.ID),
+		attribute.String("ws.message.type", msgType),
+		attribute.Int("ws.recipients", len(members)),
+	))
+	defer span.End()
+
+	strmsg, err := MarshalMessage(msgType, payload)
+	if err != nil {
+		r.logger.Error("failed to marshal broadcast", "type", msgType, "error", err)
+		failSpan(ctx, err)
+		return
 	}
 
-	r.mu.Unlock()
+	now := time.Now()
+	for _, c := range members {
+		c.queueBroadcast(strmsg, now)
+	}
+}
 
-	// Notify all clients in the room of the updated user list
-	log.Printf("Length of participants: %d", len(r.Participants))
-	for id, pd := range r.Participants {
-		log.Printf("Notify %s (%s) of room status update", pd.Client.Username, id)
-		NotifyUserRoomStatus(r, pd.Client, r.GetSortedUserInfo(), MessageRoomStatusUpdate)
+// publishMembers replaces the members snapshot after the participants changed.
+func (r *Room) publishMembers() {
+	members := make([]*Client, 0, len(r.participants))
+	for _, pd := range r.participants {
+		members = append(members, pd.Client)
 	}
+	r.members.Store(&members)
 }
 
-func (r *Room) LeaveRoom(c *Client) {
-	r.mu.Lock()
+func (r *Room) joinRoom(c *Client) {
+	c.setRoomID(r.ID)
+	r.touch()
+
+	userLobbyId := r.nextUserLobbyId // Assign UserLobbyId from the counter
+	r.nextUserLobbyId++              // Increment the counter for the next user
 
-	leavingParticipantDetail, exists := r.Participants[c.ID]
-	log.Printf(" %s", c.ID)
-	isLeavingHost := false
-	log.Printf("Participant %s (%s) is leaving room %s %s", c.Username, c.ID, r.ID, leavingParticipantDetail.Role)
-	if exists && leavingParticipantDetail.Role == RoleHost {
-		isLeavingHost = true
+	// Only the creator is in the room so I am the host
+	role := RolePlayer
+	if len(r.participants) == 1 { // This means the creator is already there, and this is the second person
+		r.host = c
+		role = RoleHost
+	}
+	r.participants[c.ID] = ParticipantsDetail{
+		Client:      c,
+		Role:        role,
+		joinedAt:    time.Now(),
+		UserLobbyId: userLobbyId, // Assign the calculated UserLobbyId
 	}
+	r.publishMembers()
 
-	c.RoomID = ""
-	delete(r.Participants, c.ID)
+	// Notify all clients in the room of the updated user list
+	r.logger.Info("participant joined", "client_id", c.ID, "role", role.String(), "participants", len(r.participants))
+	r.notifyRoomStatus()
+}
 
-	r.mu.Unlock()
+func (r *Room) leaveRoom(c *Client) {
+	leavingParticipantDetail, exists := r.participants[c.ID]
+	if !exists {
+		return
+	}
+	r.logger.Info("participant leaving", "client_id", c.ID, "role", leavingParticipantDetail.Role.String())
 
+	c.setRoomID("")
+	r.touch()
+	delete(r.participants, c.ID)
+	r.publishMembers()
+
+	switch {
 	// If the leaving participant is the creator, shut down the room
-	if c == r.Creator {
-		r.IsAlive = false
-		// Notify all clients in the room that the room is shutting down
-		shutdownMessage := BaseMessage{Type: MessageRoomShutdown}
-		message, _ := json.Marshal(shutdownMessage)
-		for _, pd := range r.Participants {
-			log.Printf("Notify %s (%s) of room closure", pd.Client.Username, pd.Client.ID)
-			select {
-			case pd.Client.Send <- message:
-			default:
-				close(pd.Client.Send)
-				// It's safe to delete here as the loop is finishing for this participant
-				delete(r.Participants, pd.Client.ID)
+	case c == r.Creator:
+		r.shutdown()
+
+	// If the leaving participant is the host (and not the creator), transfer the host role
+	case leavingParticipantDetail.Role == RoleHost:
+		var nextHostID string
+		var earliestJoinTime time.Time
+
+		for id, pd := range r.participants {
+			// Exclude the creator
+			if pd.Client.ID != r.Creator.ID && (nextHostID == "" || pd.joinedAt.Before(earliestJoinTime)) {
+				earliestJoinTime = pd.joinedAt
+				nextHostID = id
 			}
 		}
-	} else if isLeavingHost { // If the leaving participant is the host (and not the creator)
-		// If there are other participants (besides the creator), transfer host role
-		if len(r.Participants) > 0 {
-			var nextHostID string
-			var earliestJoinTime time.Time
-
-			// Initialize with a time far in the future
-			earliestJoinTime = time.Now().Add(24 * time.Hour)
-
-			for id, pd := range r.Participants {
-				// Exclude the leaving host and the creator
-				if pd.Client.ID != c.ID && pd.Client.ID != r.Creator.ID {
-					if pd.joinedAt.Before(earliestJoinTime) {
-						earliestJoinTime = pd.joinedAt
-						nextHostID = id
-					}
-				}
-			}
 
-			if nextHostID != "" {
-				// Assign the host role to the next participant
-				pd := r.Participants[nextHostID]
-				pd.Role = RoleHost
-				r.Participants[nextHostID] = pd
-				r.Host = pd.Client
-
-				log.Printf("Host role transferred to %s (%s) in room %s", pd.Client.Username, nextHostID, r.ID)
-
-				// Notify all remaining clients of the updated user list and role change
-				for _, pd := range r.Participants {
-					log.Printf("Notify %s (%s) of room status update with new host", pd.Client.Username, pd.Client.ID)
-					NotifyUserRoomStatus(r, pd.Client, r.GetSortedUserInfo(), MessageRoomStatusUpdate)
-				}
-			} else {
-				// No other participants besides the creator, room stays open, notify creator
-				log.Printf("Host left, no other participants to transfer role to. Notifying creator %s (%s) in room %s", r.Creator.Username, r.Creator.ID, r.ID)
-				NotifyUserRoomStatus(r, r.Creator, r.GetSortedUserInfo(), MessageRoomStatusUpdate)
-			}
+		if nextHostID != "" {
+			// Assign the host role to the next participant
+			pd := r.participants[nextHostID]
+			pd.Role = RoleHost
+			r.participants[nextHostID] = pd
+			r.host = pd.Client
+
+			r.logger.Info("host role transferred", "client_id", nextHostID)
 		} else {
-			// Should not happen if the creator is still in the room, but as a fallback
-			// No other participants, shut down the room
-			r.IsAlive = false
-			// Notify all clients in the room that the room is shutting down
-			shutdownMessage := BaseMessage{Type: MessageRoomShutdown}
-			message, _ := json.Marshal(shutdownMessage)
-			for _, pd := range r.Participants {
-				log.Printf("Notify %s (%s) of room closure", pd.Client.Username, pd.Client.ID)
-				select {
-				case pd.Client.Send <- message:
-				default:
-					close(pd.Client.Send)
-					// It's safe to delete here as the loop is finishing for this participant
-					delete(r.Participants, pd.Client.ID)
-				}
-			}
-		}
-	} else { // User is not the creator and not the host, just remove them and notify others of updated user list
-		// Notify all clients in the room of the updated user list
-		for _, pd := range r.Participants {
-			log.Printf("Notify %s (%s) of room status update", pd.Client.Username, pd.Client.ID)
-			NotifyUserRoomStatus(r, pd.Client, r.GetSortedUserInfo(), MessageRoomStatusUpdate)
+			r.logger.Info("host left, no one to transfer the role to")
 		}
+		// Notify all remaining clients of the updated user list and role change
+		r.notifyRoomStatus()
+
+	// User is not the creator and not the host, notify others of updated user list
+	default:
+		r.notifyRoomStatus()
 	}
 }
 
-func (r *Room) Run() {
-	defer func() {
-		r.Creator.Wssvr.UnregisterRoom <- r
-	}()
-
-	r.IsAlive = true
-
-	for r.IsAlive {
-		select {
-		case cli := <-r.Join:
-			r.JoinRoom(cli)
-
-		case cli := <-r.Leave:
-			r.LeaveRoom(cli)
+// shutdown ends the room's game, tells everyone left that the room is closing and stops the room goroutine.
+func (r *Room) shutdown() {
+	r.closing = true
+	r.endGame()
 
-		}
+	r.logger.Info("room shutting down", "participants", len(r.participants))
+	message, _ := MarshalMessage(MessageRoomShutdown, nil)
+	for _, pd := range r.participants {
+		pd.Client.setRoomID("")
+		pd.Client.queue("", message)
 	}
-	log.Printf("Room %s removed.", r.ID)
+	r.participants = make(map[string]ParticipantsDetail)
+	r.publishMembers()
 }
 
-func (r *Room) ParticipantCount() int {
-	r.mu.Lock()
-	defer r.mu.Unlock()
-
-	count := len(r.Participants)
-	return count
+func (r *Room) notifyRoomStatus() {
+	userInfo := r.sortedUserInfo()
+	for _, pd := range r.participants {
+		NotifyUserRoomStatus(r, pd.Client, userInfo, MessageRoomStatusUpdate)
+	}
 }
 
-func (r *Room) GetSortedUserInfo() []*UserInfo {
-	r.mu.Lock()
-	defer r.mu.Unlock()
+// roomInfo describes the room to the given client.
+func (r *Room) roomInfo(c *Client) RoomInfo {
+	return RoomInfo{
+		ID:        r.ID,
+		Name:      r.Name,
+		Size:      r.Size,
+		UsersInfo: r.sortedUserInfo(),
+		SenderID:  c.ID,
+		IsHost:    r.host != nil && r.host.ID == c.ID, // Set IsHost based on recipient client
+	}
+}
 
+func (r *Room) sortedUserInfo() []*UserInfo {
 	var clients []ParticipantsDetail
-	for _, pd := range r.Participants {
+	for _, pd := range r.participants {
 		clients = append(clients, pd)
 	}
 
@@ -269,23 +432,3 @@ func (r *Room) GetSortedUserInfo() []*UserInfo {
 
 	return userInfo
 }
-
-func GenerateRandomCode(length int) string {
-
-	const asciiBytes = "ABCDEFGHIJKLMNOPQRSTUVWXYZ1234567890"
-
-	source := rand.NewSource(time.Now().UnixNano())
-	r := rand.New(source)
-	b := make([]byte, length)
-
-	for i := range b {
-		randomIndex := r.Intn(len(asciiBytes))
-		b[i] = asciiBytes[randomIndex]
-	}
-
-	return string(b)
-}
-
-func Broadcast(message []byte) {
-
-}
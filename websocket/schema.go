@@ -0,0 +1,250 @@
+package websocket
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/oblongtable/beanbag-backend/internal/achievements"
+	"github.com/oblongtable/beanbag-backend/internal/game"
+)
+
+// messageSpec documents one message type of the protocol.
+type messageSpec struct {
+	Type    string
+	Summary string
+	Info    []interface{} // Zero values of the possible info structs, empty for messages without info
+}
+
+func info(payloads ...interface{}) []interface{} { return payloads }
+
+// clientMessages are the events clients send.
+var clientMessages = []messageSpec{
+	{EventCreateRoom, "Create a room and join it as its creator", info(CreateRoomEvent{})},
+	{EventJoinRoom, "Join a room as a player", info(JoinRoomEvent{})},
+	{EventLeaveRoom, "Leave the current room", info(LeaveRoomEvent{})},
+	{EventStartQuiz, "Start a live game in the current room, host only", info(StartQuizEvent{})},
+	{EventForwardQuiz, "Advance the live game to its next screen, host only", nil},
+	{EventSubmitAnswer, "Answer the current question of the live game", info(SubmitAnswerEvent{})},
+	{EventStartAssignment, "Start a self-paced attempt at an assignment", info(StartAssignmentEvent{})},
+	{EventAssignmentNext, "Advance the self-paced attempt to its next question", nil},
+	{EventAssignmentAnswer, "Answer the current question of the self-paced attempt", info(SubmitAnswerEvent{})},
+}
+
+// callbackMessages answer client events, in an EventCallbackMessage.
+var callbackMessages = []messageSpec{
+	{MessageCreateRoom, "Answers create_room", info(RoomInfo{})},
+	{MessageJoinRoom, "Answers join_room", info(RoomInfo{})},
+	{MessageLeaveRoom, "Answers leave_room", info(RoomInfo{})},
+	{MessageQuizStart, "Answers start_quiz", info(RoomInfo{})},
+	{MessageQuizForward, "Answers quiz_forward", info(NoInfo{})},
+	{MessageSubmitAnswer, "Answers submit_answer", info(NoInfo{})},
+	{MessageStartAssignment, "Answers start_assignment", info(NoInfo{})},
+	{MessageAssignmentNext, "Answers assignment_next", info(NoInfo{})},
+	{MessageAssignmentAnswer, "Answers assignment_answer", info(NoInfo{})},
+}
+
+// serverMessages are pushed by the server without being asked for.
+var serverMessages = []messageSpec{
+	{MessageRoomStatusUpdate, "The participants of the room changed", info(RoomInfo{})},
+	{MessageRoomShutdown, "The room was closed by its creator, an admin, or its lifecycle limits", nil},
+	{MessageRoomExpiring, "The room will close by itself after expires_in_ms, an event from anyone in an idle room keeps it open", info(RoomExpiringInfo{})},
+	{MessageError, "An event was rejected before reaching its handler, correlation_id is the event's ID", info(ErrorInfo{})},
+	{MessageRateLimited, "An event was dropped because the client sent too many, correlation_id is the event's ID", info(RateLimitedInfo{})},
+	{game.MessageShowTitle, "A game or attempt started", info(game.ShowTitlePayload{})},
+	{game.MessageShowSection, "A section of the live game starts", info(game.ShowSectionPayload{})},
+	{game.MessageNewQuestion, "A question is open for answers", info(game.NewQuestionPayload{})},
+	{game.MessageQuestionResult, "A question closed, live games and attempts send different results", info(game.QuestionResultPayload{}, game.AttemptResultPayload{})},
+	{game.MessageGameOver, "The game or attempt finished", info(game.GameOverPayload{}, game.AttemptOverPayload{})},
+	{achievements.MessageUnlocked, "The signed-in player unlocked an achievement", info(achievements.Achievement{})},
+}
+
+// AsyncAPI describes the websocket protocol as an AsyncAPI 2.6 document,
+// generated from the Go types of every message.
+func AsyncAPI() map[string]interface{} {
+	g := &schemaGenerator{schemas: map[string]interface{}{}}
+	messages := map[string]interface{}{}
+
+	refs := func(specs []messageSpec, callback bool) []interface{} {
+		oneOf := make([]interface{}, 0, len(specs))
+		for _, spec := range specs {
+			messages[spec.Type] = map[string]interface{}{
+				"name":    spec.Type,
+				"summary": spec.Summary,
+				"payload": g.envelope(spec, callback),
+			}
+			oneOf = append(oneOf, ref("messages", spec.Type))
+		}
+		return oneOf
+	}
+	publish := refs(clientMessages, false)
+	subscribe := append(refs(callbackMessages, true), refs(serverMessages, false)...)
+
+	return map[string]interface{}{
+		"asyncapi": "2.6.0",
+		"info": map[string]interface{}{
+			"title":   "Beanbag Websocket Protocol",
+			"version": ProtocolVersion,
+			"description": "Every message is a JSON envelope. Clients may set id on events, callbacks echo it back as correlation_id. " +
+				"Events without v are read as the current version. Version 2 breaks version 1 clients: " +
+				"room_status_update now carries the room under info instead of at the top level of the message.",
+		},
+		"channels": map[string]interface{}{
+			"/ws": map[string]interface{}{
+				"description": "Signed-in players connect with ?token=<access token>, everyone else joins as a guest.",
+				"publish":     map[string]interface{}{"message": map[string]interface{}{"oneOf": publish}},
+				"subscribe":   map[string]interface{}{"message": map[string]interface{}{"oneOf": subscribe}},
+			},
+		},
+		"components": map[string]interface{}{
+			"messages": messages,
+			"schemas":  g.schemas,
+		},
+	}
+}
+
+// ServeAsyncAPI godoc
+// @Summary Websocket protocol
+// @Description Returns the AsyncAPI document describing the messages of the /ws endpoint
+// @Tags websocket
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Router /asyncapi.json [get]
+func ServeAsyncAPI(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, AsyncAPI())
+}
+
+// errorCodeNames lists every code a failed callback can carry.
+func errorCodeNames() []string {
+	names := []string{}
+	seen := map[ErrorCode]bool{}
+	for _, e := range errorCodes {
+		if !seen[e.code] {
+			seen[e.code] = true
+			names = append(names, string(e.code))
+		}
+	}
+	return append(names, string(CodeInternal))
+}
+
+func ref(kind, name string) map[string]interface{} {
+	return map[string]interface{}{"$ref": "#/components/" + kind + "/" + name}
+}
+
+// schemaGenerator turns Go types into JSON Schemas, collecting named structs under components.
+type schemaGenerator struct {
+	schemas map[string]interface{}
+}
+
+// envelope is the schema of a whole message of the given type.
+func (g *schemaGenerator) envelope(spec messageSpec, callback bool) map[string]interface{} {
+	properties := map[string]interface{}{
+		"v":              map[string]interface{}{"type": "integer", "const": ProtocolVersion},
+		"id":             map[string]interface{}{"type": "string"},
+		"type":           map[string]interface{}{"type": "string", "const": spec.Type},
+		"correlation_id": map[string]interface{}{"type": "string"},
+	}
+	required := []string{"type"}
+	if callback {
+		properties["success"] = map[string]interface{}{"type": "boolean"}
+		properties["message"] = map[string]interface{}{"type": "string"}
+		properties["code"] = map[string]interface{}{"type": "string", "enum": errorCodeNames()}
+		required = append(required, "v", "id", "success", "message")
+	}
+
+	switch len(spec.Info) {
+	case 0:
+	case 1:
+		properties["info"] = g.schema(reflect.TypeOf(spec.Info[0]))
+	default:
+		oneOf := make([]interface{}, 0, len(spec.Info))
+		for _, payload := range spec.Info {
+			oneOf = append(oneOf, g.schema(reflect.TypeOf(payload)))
+		}
+		properties["info"] = map[string]interface{}{"oneOf": oneOf}
+	}
+	if callback {
+		// Failed callbacks carry no info
+		properties["info"] = map[string]interface{}{"oneOf": []interface{}{properties["info"], map[string]interface{}{"type": "null"}}}
+	}
+
+	return map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+		"required":   required,
+	}
+}
+
+var (
+	timeType = reflect.TypeOf(time.Time{})
+	rawType  = reflect.TypeOf(json.RawMessage{})
+)
+
+func (g *schemaGenerator) schema(t reflect.Type) map[string]interface{} {
+	switch {
+	case t == timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case t == rawType:
+		return map[string]interface{}{}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return g.schema(t.Elem())
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": g.schema(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": g.schema(t.Elem())}
+	case reflect.Struct:
+		if _, done := g.schemas[t.Name()]; !done {
+			g.schemas[t.Name()] = nil // Reserve the name so recursive types terminate
+			g.schemas[t.Name()] = g.structSchema(t)
+		}
+		return ref("schemas", t.Name())
+	default:
+		return map[string]interface{}{}
+	}
+}
+
+func (g *schemaGenerator) structSchema(t reflect.Type) map[string]interface{} {
+	properties := map[string]interface{}{}
+	required := []string{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		properties[name] = g.schema(field.Type)
+		if !strings.Contains(opts, "omitempty") && field.Type.Kind() != reflect.Ptr {
+			required = append(required, name)
+		}
+	}
+
+	schema := map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
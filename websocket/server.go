@@ -1,47 +1,52 @@
 package websocket
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
 	"net/http"
 
 	"github.com/gorilla/websocket"
 	"github.com/oblongtable/beanbag-backend/internal/game"
+	"github.com/oblongtable/beanbag-backend/internal/roomcode"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
-type ClientList map[*Client]bool
-
-type RoomList map[string]*Room
-
 type EventHandler func(cliEvt *ClientEvent) error
 
 type EventHandlerList map[string]EventHandler
 
+// Authenticator resolves the access token a client connects with to a user ID.
+type Authenticator interface {
+	Authenticate(ctx context.Context, token string) (int32, error)
+}
+
 type ClientEvent struct {
 	Requester *Client
 	EventInfo *Event
+	Ctx       context.Context // Carries the span of the event, pass it on to work done for the event
 }
 
+// WebSocServer has no central loop: each client's events are handled on the client's
+// own goroutine, rooms serialise their state on theirs, and games lock their own.
 type WebSocServer struct {
-	Clients  ClientList
-	Rooms    RoomList
+	Clients  *ClientList
+	Rooms    *RoomList
 	Handlers EventHandlerList
-	Games    *game.GameService // Add GameService
-
-	Register       chan *Client
-	Unregister     chan *Client
-	RegisterRoom   chan *ClientEvent
-	UnregisterRoom chan *Room
-	JoinRoom       chan *ClientEvent
-	LeaveRoom      chan *ClientEvent
-
-	StartQuiz    chan *ClientEvent
-	ForwardQuiz  chan *ClientEvent // New channel for advancing the quiz
-	SubmitAnswer chan *ClientEvent // New channel for submitting answers
-	// broadcast:  make(chan *Message, 5)
-	// Mu sync.RWMutex
+	Games    *game.GameService   // Add GameService
+	Codes    *roomcode.Allocator // Room codes, only unique within this server unless given a shared store
+	Auth     Authenticator       // Optional, without it every client is a guest
+
+	limits   *RateLimits // Optional, set with SetRateLimits before serving
+	ipLimits *ipLimiters
+
+	queueLimits   QueueLimits // Set with SetQueueLimits before serving
+	queueCounters queueCounters
+
+	roomLimits RoomLimits // Optional, set with SetRoomLimits before serving
 }
 
 // Upgrader is used to upgrade HTTP connections to WebSocket connections.
@@ -55,23 +60,15 @@ var upgrader = websocket.Upgrader{
 
 func NewWebSockServer() (wssvr *WebSocServer) {
 	wssvr = &WebSocServer{
-		Clients:        make(ClientList),
-		Rooms:          make(RoomList),
-		Handlers:       make(EventHandlerList),
-		Games:          game.NewService(), // Initialize GameService
-		Register:       make(chan *Client),
-		Unregister:     make(chan *Client),
-		RegisterRoom:   make(chan *ClientEvent),
-		UnregisterRoom: make(chan *Room),
-		JoinRoom:       make(chan *ClientEvent),
-		LeaveRoom:      make(chan *ClientEvent),
-
-		StartQuiz:    make(chan *ClientEvent),
-		ForwardQuiz:  make(chan *ClientEvent), // Initialize the new channel
-		SubmitAnswer: make(chan *ClientEvent), // Initialize the new channel
+		Clients:  NewClientList(),
+		Rooms:    NewRoomList(),
+		Handlers: make(EventHandlerList),
+		Games:    game.NewService(), // Initialize GameService
+		Codes:    roomcode.NewAllocator(roomcode.NewMemoryStore(), roomcode.Options{}),
+
+		queueLimits: defaultQueueLimits,
 	}
 	wssvr.SetupEventHandlers()
-	go wssvr.Run()
 	return wssvr
 }
 
@@ -82,68 +79,149 @@ func (wssvr *WebSocServer) SetupEventHandlers() {
 	wssvr.Handlers[EventStartQuiz] = StartQuizEventHandler
 	wssvr.Handlers[EventForwardQuiz] = ForwardQuizEventHandler   // Register the new handler
 	wssvr.Handlers[EventSubmitAnswer] = SubmitAnswerEventHandler // Register the new handler
+	wssvr.Handlers[EventStartAssignment] = StartAssignmentEventHandler
+	wssvr.Handlers[EventAssignmentNext] = AssignmentNextEventHandler
+	wssvr.Handlers[EventAssignmentAnswer] = AssignmentAnswerEventHandler
+}
+
+// SetRateLimits limits how fast clients connecting afterwards may send events.
+func (wssvr *WebSocServer) SetRateLimits(limits RateLimits) {
+	wssvr.limits = &limits
+	wssvr.ipLimits = newIPLimiters(limits.IP)
+}
+
+// SetQueueLimits bounds the queue of messages waiting to be sent to each client connecting afterwards.
+func (wssvr *WebSocServer) SetQueueLimits(limits QueueLimits) {
+	if limits.Size < 1 {
+		limits.Size = defaultQueueLimits.Size
+	}
+	wssvr.queueLimits = limits
 }
 
-func (wssvr *WebSocServer) RouteEvent(evt *Event, c *Client) error {
+// QueueStats reports what the outbound queues have dropped and coalesced so far.
+func (wssvr *WebSocServer) QueueStats() QueueStats {
+	return QueueStats{
+		Dropped:         wssvr.queueCounters.dropped.Load(),
+		Coalesced:       wssvr.queueCounters.coalesced.Load(),
+		SlowDisconnects: wssvr.queueCounters.slowDisconnects.Load(),
+	}
+}
+
+func (wssvr *WebSocServer) RouteEvent(evt *Event, c *Client) (err error) {
+	// Every event starts a trace of its own, the connection outlives any HTTP request span
+	spanName := "ws unknown"
+	if _, known := wssvr.Handlers[evt.Type]; known {
+		spanName = "ws " + evt.Type
+	}
+	// Anything a participant does keeps their room open
+	if room, ok := wssvr.Rooms.Get(c.RoomID()); ok {
+		room.touch()
+	}
+
+	ctx, span := tracer.Start(context.Background(), spanName,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			attribute.String("ws.event.type", evt.Type),
+			attribute.String("ws.correlation_id", evt.ID),
+			attribute.String("ws.client.id", c.ID),
+			attribute.String("ws.room.id", c.RoomID()),
+		))
+	defer func() {
+		if err != nil {
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}()
+
 	var cliEvt ClientEvent
 	cliEvt.Requester = c
 	cliEvt.EventInfo = evt
+	cliEvt.Ctx = ctx
 
+	if evt.Version > ProtocolVersion {
+		return fmt.Errorf("%w %d", ErrUnsupportedVersion, evt.Version)
+	}
+	if newPayload, ok := eventPayloads[evt.Type]; ok {
+		payload := newPayload()
+		if err := json.Unmarshal(evt.Payload, payload); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidPayload, err)
+		}
+		if err := payload.Validate(); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidPayload, err)
+		}
+	}
 	if handler, ok := wssvr.Handlers[evt.Type]; ok {
 		if err := handler(&cliEvt); err != nil {
 			return err
 		}
 		return nil
 	} else {
-		return errors.New("there is no such event")
+		return fmt.Errorf("%w: %s", ErrUnknownEvent, evt.Type)
 	}
 }
 
 func (wssvr *WebSocServer) AddClient(c *Client) {
+	wssvr.Clients.Add(c)
+}
 
-	wssvr.Clients[c] = true
+// NotifyPlayer sends a game message to the client with the given ID, if they are still connected.
+func (wssvr *WebSocServer) NotifyPlayer(playerID string, msgType string, payload interface{}) {
+	if c, ok := wssvr.Clients.Get(playerID); ok {
+		SendGameMessage(c, msgType, payload)
+	}
 }
 
+// RemoveClient takes a disconnected client out of its room and attempt.
+// Both of the client's goroutines call it, only the first one does anything.
 func (wssvr *WebSocServer) RemoveClient(c *Client) {
-	if _, ok := wssvr.Clients[c]; !ok {
+	if !wssvr.Clients.Remove(c) {
 		return
 	}
 
-	if room, ok := wssvr.Rooms[c.RoomID]; ok {
-		room.Leave <- c
+	if room, ok := wssvr.Rooms.Get(c.RoomID()); ok {
+		room.Leave(c)
 	}
+	wssvr.Games.AbandonAttempt(c.ID)
 
-	delete(wssvr.Clients, c)
+	c.out.close()
 	c.Conn.Close()
 }
 
+// CloseRoom ends the room's game, if it has one, and sends everyone in the room away.
+func (wssvr *WebSocServer) CloseRoom(ctx context.Context, roomID string) error {
+	room, ok := wssvr.Rooms.Get(roomcode.Normalize(roomID))
+	if !ok {
+		return ErrRoomNotFound
+	}
+	if err := wssvr.Games.ForceEnd(ctx, room.ID); err != nil && !errors.Is(err, game.ErrGameNotFound) && !errors.Is(err, game.ErrInvalidState) {
+		return err
+	}
+	return room.Close()
+}
+
 func (wssvr *WebSocServer) AddRoom(cliEvt *ClientEvent) {
 	var crevt CreateRoomEvent
 	var msg string
 	var roomInfo RoomInfo
+	var err error
 
-	isSuccess := false
 	cli := cliEvt.Requester
 	jsonRaw := cliEvt.EventInfo.Payload
-	if err := json.Unmarshal(jsonRaw, &crevt); err != nil {
-		msg = fmt.Sprintf("Create room failed: %v", err)
-		log.Println(msg)
+	if jsonErr := json.Unmarshal(jsonRaw, &crevt); jsonErr != nil {
+		err = fmt.Errorf("%w: %v", ErrInvalidPayload, jsonErr)
 
 	} else if crevt.RoomSize > MAX_ROOM_SIZE {
-		msg = fmt.Sprintf("Create room failed: Room size cannot be larger than %d", MAX_ROOM_SIZE)
-		log.Println(msg)
+		err = fmt.Errorf("%w: Room size cannot be larger than %d", ErrRoomTooLarge, MAX_ROOM_SIZE)
+
+	} else if len(cli.RoomID()) > 0 {
+		err = ErrAlreadyInRoom
 
 	} else {
 		cli.Username = crevt.UserName
-		room := NewRoom(crevt.RoomName, crevt.RoomSize, cli)
-		if room == nil {
-			msg = "Create room failed: Server overloaded, please try again later."
+		room, roomErr := NewRoom(cliEvt.Ctx, crevt.RoomName, crevt.RoomSize, cli, crevt.RoomCode)
+		if roomErr != nil {
+			err = roomErr
 		} else {
-			isSuccess = true
-
-			cli.RoomID = room.ID
-			cli.Wssvr.Rooms[room.ID] = room
-
 			roomInfo.ID = room.ID
 			roomInfo.Name = room.Name
 			roomInfo.Size = room.Size
@@ -156,299 +234,222 @@ func (wssvr *WebSocServer) AddRoom(cliEvt *ClientEvent) {
 			roomInfo.IsHost = true // Set IsHost to true for the creator
 
 			msg = "Create room Success"
-			log.Println(msg)
 		}
 	}
+	if err != nil {
+		msg = fmt.Sprintf("Create room failed: %v", err)
+		failSpan(cliEvt.Ctx, err)
+	}
 
 	// Message callback
-	SendEventCallback(cli, MessageCreateRoom, isSuccess, msg, &roomInfo)
-}
-
-func (wssvr *WebSocServer) RemoveRoom(room *Room) {
-	cli := room.Creator
-	cli.RoomID = ""
-	delete(cli.Wssvr.Rooms, room.ID)
+	SendEventCallback(cli, cliEvt.EventInfo.ID, MessageCreateRoom, err, msg, &roomInfo)
 }
 
 func (wssvr *WebSocServer) JoinRoomF(cliEvt *ClientEvent) {
 	var jrevt JoinRoomEvent
 	var msg string
 	var roomInfo RoomInfo
+	var err error
 
-	isSuccess := true
 	cli := cliEvt.Requester
 	jsonRaw := cliEvt.EventInfo.Payload
-	if err := json.Unmarshal(jsonRaw, &jrevt); err != nil {
-		isSuccess = false
-		msg = fmt.Sprintf("Join room failed: %v", err)
-		log.Println(msg)
-
-	} else if len(cli.RoomID) > 0 {
-		isSuccess = false
-		msg = "Join room failed: You have already joined a room"
-		log.Println(msg)
+	if jsonErr := json.Unmarshal(jsonRaw, &jrevt); jsonErr != nil {
+		err = fmt.Errorf("%w: %v", ErrInvalidPayload, jsonErr)
 
-	} else if room, ok := wssvr.Rooms[jrevt.RoomID]; !ok {
-		isSuccess = false
-		msg = "Join room failed: Room not found"
-		log.Println(msg)
+	} else if len(cli.RoomID()) > 0 {
+		err = ErrAlreadyInRoom
 
-	} else if len(room.Participants) >= room.Size+1 {
-		isSuccess = false
-		msg = "Join room failed: Room is full"
-		log.Println(msg)
+	} else if room, ok := wssvr.Rooms.Get(roomcode.Normalize(jrevt.RoomID)); !ok {
+		err = ErrRoomNotFound
 
 	} else {
 		cli.Username = jrevt.Name
-
-		msg = "Join room Success"
-		log.Println(msg)
-
-		roomInfo.ID = room.ID
-		roomInfo.Name = room.Name
-		roomInfo.Size = room.Size
-		roomInfo.UsersInfo = room.GetSortedUserInfo()
-		roomInfo.SenderID = cli.ID
-
-		// Add myself to user info
-		var clientRole Role
-		if (room.ParticipantCount()) == 1 {
-			clientRole = RoleHost
-		} else {
-			clientRole = RolePlayer
+		if roomInfo, err = room.Join(cli); err == nil {
+			msg = "Join room Success"
 		}
-
-		roomInfo.UsersInfo = append(roomInfo.UsersInfo, &UserInfo{
-			Username: cli.Username,
-			Role:     clientRole.String(),
-		})
-
-		room.Join <- cli
-
+	}
+	if err != nil {
+		msg = fmt.Sprintf("Join room failed: %v", err)
+		failSpan(cliEvt.Ctx, err)
 	}
 
 	// Message callback
-	SendEventCallback(cli, MessageJoinRoom, isSuccess, msg, &roomInfo)
+	SendEventCallback(cli, cliEvt.EventInfo.ID, MessageJoinRoom, err, msg, &roomInfo)
 }
 
 func (wssvr *WebSocServer) LeaveRoomF(cliEvt *ClientEvent) {
 	var jrevt LeaveRoomEvent
 	var msg string
 	var roomInfo RoomInfo
+	var err error
 
-	isSuccess := true
 	cli := cliEvt.Requester
 	jsonRaw := cliEvt.EventInfo.Payload
-	if err := json.Unmarshal(jsonRaw, &jrevt); err != nil {
-		isSuccess = false
-		msg = fmt.Sprintf("Leave room failed: %v", err)
-		log.Println(msg)
+	if jsonErr := json.Unmarshal(jsonRaw, &jrevt); jsonErr != nil {
+		err = fmt.Errorf("%w: %v", ErrInvalidPayload, jsonErr)
 
-	} else if len(cli.RoomID) < 1 {
-		isSuccess = false
-		msg = "Leave room failed: You haven't joined a room"
-		log.Println(msg)
+	} else if len(cli.RoomID()) < 1 {
+		err = ErrNotInRoom
 
-	} else if room, ok := wssvr.Rooms[jrevt.RoomID]; !ok {
-		isSuccess = false
-		msg = "Leave room failed: Room not found"
-		log.Println(msg)
+	} else if room, ok := wssvr.Rooms.Get(roomcode.Normalize(jrevt.RoomID)); !ok {
+		err = ErrRoomNotFound
 
-	} else {
+	} else if err = room.Leave(cli); err == nil {
 		msg = "Leave room Success"
-		log.Println(msg)
-
-		room.Leave <- cli
+	}
+	if err != nil {
+		msg = fmt.Sprintf("Leave room failed: %v", err)
+		failSpan(cliEvt.Ctx, err)
 	}
 
 	// Message callback
-	SendEventCallback(cli, MessageLeaveRoom, isSuccess, msg, &roomInfo)
+	SendEventCallback(cli, cliEvt.EventInfo.ID, MessageLeaveRoom, err, msg, &roomInfo)
 }
 
 func (wssvr *WebSocServer) StartQuizF(cliEvt *ClientEvent) {
 	var jrevt StartQuizEvent
-	var msg string
+	var err error
 
-	isSuccess := true
 	cli := cliEvt.Requester
 	jsonRaw := cliEvt.EventInfo.Payload
-	if err := json.Unmarshal(jsonRaw, &jrevt); err != nil {
-		isSuccess = false
-		msg = fmt.Sprintf("Start Quiz failed: %v", err)
-		log.Println(msg)
+	if jsonErr := json.Unmarshal(jsonRaw, &jrevt); jsonErr != nil {
+		err = fmt.Errorf("%w: %v", ErrInvalidPayload, jsonErr)
 
-	} else {
-		log.Println("Start Quiz Event received")
-		log.Println(msg)
-		room, ok := wssvr.Rooms[cli.RoomID]
-		if !ok {
-			isSuccess = false
-			msg = "Start Quiz failed: Room not found"
-			log.Println(msg)
-		}
-		if room.Host.ID != cli.ID {
-			isSuccess = false
-			msg = "Start Quiz failed: Only the room creator can start the quiz"
-			log.Println(msg)
-		} else {
-			// Define the broadcast function for this specific room
-			broadcastFunc := func(msgType string, payload interface{}) {
-				evt := &Event{
-					Type: msgType,
-				}
-
-				jsonPayload, err := json.Marshal(payload)
-				if err != nil {
-					log.Printf("Error marshaling broadcast payload for type %s: %v", msgType, err)
-					return
-				}
-				evt.Payload = jsonPayload
-
-				strmsg, err := json.Marshal(evt)
-				if err != nil {
-					log.Printf("Error marshaling broadcast event for type %s: %v", msgType, err)
-					return
-				}
-
-				for _, participant := range room.Participants {
-					select {
-					case participant.Client.Send <- strmsg:
-						// Message sent successfully
-					default:
-						log.Printf("Failed to send message to client %s in room %s", participant.Client.ID, room.ID)
-					}
-				}
-			}
+	} else if room, ok := wssvr.Rooms.Get(cli.RoomID()); !ok {
+		err = ErrRoomNotFound
 
-			// Prepare initial player info from room participants
-			initialPlayers := make([]game.InitialPlayerInfo, 0, len(room.Participants))
-			for _, pDetail := range room.Participants {
-				initialPlayers = append(initialPlayers, game.InitialPlayerInfo{
-					ID:       pDetail.Client.ID,
-					Username: pDetail.Client.Username,
-				})
-			}
+	} else if game, createErr := room.CreateGame(cliEvt.Ctx, cli, wssvr.Games, jrevt.QuizID); createErr != nil {
+		err = createErr
 
-			// Call CreateGame here, passing the broadcast function and initial players
-			game, err := wssvr.Games.CreateGame(room.ID, room.Creator.ID, room.Host.ID, broadcastFunc, initialPlayers)
-			if err != nil {
-				isSuccess = false
-				msg = fmt.Sprintf("Start Quiz failed: Failed to create game: %v", err)
-				log.Println(msg)
-			} else {
-
-				SendEventCallback(cli, MessageQuizStart, isSuccess, msg, &RoomInfo{})
-
-				// Call StartGame here
-				err = wssvr.Games.StartGame(game.ID, room.Host.ID)
-				if err != nil {
-					isSuccess = false
-					msg = fmt.Sprintf("Start Quiz failed: Failed to start game: %v", err)
-					log.Println(msg)
-				} else {
-					msg = "Start Quiz Success"
-					log.Println(msg)
-				}
-			}
-		}
+	} else {
+		// Send the callback first so it arrives ahead of the title screen
+		SendEventCallback(cli, cliEvt.EventInfo.ID, MessageQuizStart, nil, "Start Quiz Success", &RoomInfo{})
 
+		if err := wssvr.Games.StartGame(cliEvt.Ctx, game.ID, game.HostID); err != nil {
+			cli.logger().Error("failed to start game", "game_id", game.ID, "error", err)
+			failSpan(cliEvt.Ctx, err)
+		}
+		return
 	}
+
+	msg := fmt.Sprintf("Start Quiz failed: %v", err)
+	failSpan(cliEvt.Ctx, err)
+	SendEventCallback(cli, cliEvt.EventInfo.ID, MessageQuizStart, err, msg, &RoomInfo{})
 }
 
 func (wssvr *WebSocServer) ForwardQuizF(cliEvt *ClientEvent) {
 	var msg string
-	isSuccess := true
+	var err error
 	cli := cliEvt.Requester
 
-	room, ok := wssvr.Rooms[cli.RoomID]
+	room, ok := wssvr.Rooms.Get(cli.RoomID())
 	if !ok {
-		isSuccess = false
-		msg = "Forward Quiz failed: Room not found"
-		log.Println(msg)
-	} else if room.Host.ID != cli.ID {
-		isSuccess = false
-		msg = "Forward Quiz failed: Only the room host can advance the quiz"
-		log.Println(msg)
+		err = ErrRoomNotFound
+	} else if isHost, hostErr := room.IsHost(cli); hostErr != nil {
+		err = hostErr
+	} else if !isHost {
+		err = ErrNotRoomHost
 	} else {
-		log.Println("Forward Quiz Event received")
-		err := wssvr.Games.NextAction(room.ID, cli.ID) // Use the existing NextAction method
-		if err != nil {
-			isSuccess = false
-			msg = fmt.Sprintf("Forward Quiz failed: %v", err)
-			log.Println(msg)
-		} else {
-			msg = "Forward Quiz Success"
-			log.Println(msg)
-		}
+		err = wssvr.Games.NextAction(cliEvt.Ctx, room.ID, cli.ID) // Use the existing NextAction method
 	}
-	// Define MessageQuizForward in message.go and use it here
-	SendEventCallback(cli, MessageQuizForward, isSuccess, msg, &BaseMessage{})
+	if err != nil {
+		msg = fmt.Sprintf("Forward Quiz failed: %v", err)
+		failSpan(cliEvt.Ctx, err)
+	} else {
+		msg = "Forward Quiz Success"
+	}
+	SendEventCallback(cli, cliEvt.EventInfo.ID, MessageQuizForward, err, msg, &NoInfo{})
 
 }
 
 func (wssvr *WebSocServer) SubmitAnswerF(cliEvt *ClientEvent) {
 	var saEvt SubmitAnswerEvent
 	var msg string
-	isSuccess := true
+	var err error
 	cli := cliEvt.Requester
 	jsonRaw := cliEvt.EventInfo.Payload
 
-	if err := json.Unmarshal(jsonRaw, &saEvt); err != nil {
-		isSuccess = false
+	if jsonErr := json.Unmarshal(jsonRaw, &saEvt); jsonErr != nil {
+		err = fmt.Errorf("%w: %v", ErrInvalidPayload, jsonErr)
+	} else if roomID := cli.RoomID(); roomID == "" {
+		err = ErrNotInRoom
+	} else {
+		// Answers go straight to the game, which has its own lock
+		err = wssvr.Games.HandleAnswer(cliEvt.Ctx, roomID, cli.ID, saEvt.AnswerIndex)
+	}
+	if err != nil {
 		msg = fmt.Sprintf("Submit Answer failed: %v", err)
-		log.Println(msg)
+		failSpan(cliEvt.Ctx, err)
 	} else {
-		log.Printf("Submit Answer Event received for answer index: %d", saEvt.AnswerIndex)
-		room, ok := cli.Wssvr.Rooms[cli.RoomID]
-		if !ok {
-			isSuccess = false
-			msg = "Submit Answer failed: Room not found"
-			log.Println(msg)
-		} else {
-			err := wssvr.Games.HandleAnswer(room.ID, cli.ID, saEvt.AnswerIndex)
-			if err != nil {
-				isSuccess = false
-				msg = fmt.Sprintf("Submit Answer failed: %v", err)
-				log.Println(msg)
-			} else {
-				msg = "Submit Answer Success"
-				log.Println(msg)
-			}
-		}
+		msg = "Submit Answer Success"
 	}
-	SendEventCallback(cli, MessageSubmitAnswer, isSuccess, msg, &BaseMessage{})
+	SendEventCallback(cli, cliEvt.EventInfo.ID, MessageSubmitAnswer, err, msg, &NoInfo{})
 }
 
-func (wssvr *WebSocServer) Run() {
-	for {
-		select {
-
-		case cli := <-wssvr.Register:
-			wssvr.AddClient(cli)
-
-		case cli := <-wssvr.Unregister:
-			wssvr.RemoveClient(cli)
-
-		case cliEvt := <-wssvr.RegisterRoom:
-			wssvr.AddRoom(cliEvt)
+func (wssvr *WebSocServer) StartAssignmentF(cliEvt *ClientEvent) {
+	var saEvt StartAssignmentEvent
+	var err error
+	cli := cliEvt.Requester
+	jsonRaw := cliEvt.EventInfo.Payload
 
-		case room := <-wssvr.UnregisterRoom:
-			wssvr.RemoveRoom(room)
+	if jsonErr := json.Unmarshal(jsonRaw, &saEvt); jsonErr != nil {
+		err = fmt.Errorf("%w: %v", ErrInvalidPayload, jsonErr)
+	} else if len(cli.RoomID()) > 0 {
+		err = fmt.Errorf("%w: Leave your room first", ErrAlreadyInRoom)
+	} else {
+		if saEvt.Name != "" {
+			cli.Username = saEvt.Name
+		}
+		send := func(msgType string, payload interface{}) {
+			SendGameMessage(cli, msgType, payload)
+		}
+		player := game.InitialPlayerInfo{ID: cli.ID, Username: cli.Username, UserID: cli.UserID}
+		if _, err = wssvr.Games.CreateAttempt(cliEvt.Ctx, saEvt.AssignmentCode, player, send); err == nil {
+			// Send the callback first so it arrives ahead of the title screen
+			SendEventCallback(cli, cliEvt.EventInfo.ID, MessageStartAssignment, nil, "Start Assignment Success", &NoInfo{})
+			if err := wssvr.Games.StartAttempt(cli.ID); err != nil {
+				cli.logger().Error("failed to start attempt", "assignment_code", saEvt.AssignmentCode, "error", err)
+			}
+			return
+		}
+	}
 
-		case cliEvt := <-wssvr.JoinRoom:
-			wssvr.JoinRoomF(cliEvt)
+	msg := fmt.Sprintf("Start Assignment failed: %v", err)
+	failSpan(cliEvt.Ctx, err)
+	SendEventCallback(cli, cliEvt.EventInfo.ID, MessageStartAssignment, err, msg, &NoInfo{})
+}
 
-		case cliEvt := <-wssvr.LeaveRoom:
-			wssvr.LeaveRoomF(cliEvt)
+func (wssvr *WebSocServer) AssignmentNextF(cliEvt *ClientEvent) {
+	var msg string
+	cli := cliEvt.Requester
 
-		case cliEvt := <-wssvr.StartQuiz:
-			wssvr.StartQuizF(cliEvt)
+	err := wssvr.Games.AttemptNext(cli.ID)
+	if err != nil {
+		msg = fmt.Sprintf("Assignment Next failed: %v", err)
+		failSpan(cliEvt.Ctx, err)
+	} else {
+		msg = "Assignment Next Success"
+	}
+	SendEventCallback(cli, cliEvt.EventInfo.ID, MessageAssignmentNext, err, msg, &NoInfo{})
+}
 
-		case cliEvt := <-wssvr.ForwardQuiz: // Handle the new ForwardQuiz event
-			wssvr.ForwardQuizF(cliEvt)
+func (wssvr *WebSocServer) AssignmentAnswerF(cliEvt *ClientEvent) {
+	var saEvt SubmitAnswerEvent
+	var msg string
+	var err error
+	cli := cliEvt.Requester
+	jsonRaw := cliEvt.EventInfo.Payload
 
-		case cliEvt := <-wssvr.SubmitAnswer: // Handle the new SubmitAnswer event
-			wssvr.SubmitAnswerF(cliEvt)
-		}
+	if jsonErr := json.Unmarshal(jsonRaw, &saEvt); jsonErr != nil {
+		err = fmt.Errorf("%w: %v", ErrInvalidPayload, jsonErr)
+	} else {
+		err = wssvr.Games.AttemptAnswer(cli.ID, saEvt.AnswerIndex)
+	}
+	if err != nil {
+		msg = fmt.Sprintf("Assignment Answer failed: %v", err)
+		failSpan(cliEvt.Ctx, err)
+	} else {
+		msg = "Assignment Answer Success"
 	}
+	SendEventCallback(cli, cliEvt.EventInfo.ID, MessageAssignmentAnswer, err, msg, &NoInfo{})
 }
@@ -0,0 +1,19 @@
+package websocket
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/oblongtable/beanbag-backend/websocket")
+
+// failSpan marks the span in ctx as failed. Handlers report their errors in callbacks
+// rather than returning them, so they mark the event's span themselves.
+func failSpan(ctx context.Context, err error) {
+	span := trace.SpanFromContext(ctx)
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
@@ -1,21 +1,37 @@
 package websocket
 
 import (
-	"fmt"
+	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/oblongtable/beanbag-backend/internal/logging"
 )
 
 // Endpoint handler
+// Signed-in players pass their access token as ?token=, browsers can't set headers on websocket requests.
+// Without a token the client joins as a guest.
 func (wssvr *WebSocServer) ServeWs(ctx *gin.Context) {
+	var userID int32
+	if token := ctx.Query("token"); token != "" && wssvr.Auth != nil {
+		id, err := wssvr.Auth.Authenticate(ctx.Request.Context(), token)
+		if err != nil {
+			logging.FromGin(ctx).Warn("websocket authentication failed", "error", err)
+			ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid access token"})
+			return
+		}
+		userID = id
+	}
+
 	// Upgrade the HTTP connection to a WebSocket connection
 	conn, err := upgrader.Upgrade(ctx.Writer, ctx.Request, nil)
 	if err != nil {
-		fmt.Println("Error upgrading:", err)
+		logging.FromGin(ctx).Warn("websocket upgrade failed", "error", err)
 		return
 	}
 
-	c := NewClient(conn, wssvr)
+	c := NewClient(conn, wssvr, userID, ctx.ClientIP())
+	// Ties the client's log lines to the request it connected with
+	logging.FromGin(ctx).Info("client connected", "client_id", c.ID, "user_id", userID, "ip", c.IP)
 
 	go c.ReadMessage()
 	go c.WriteMessage()